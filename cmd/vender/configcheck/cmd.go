@@ -0,0 +1,83 @@
+// Offline config and scenario check, for CI of config repository.
+// Hardware is not touched: MDB uses dummy driver, drivers only register actions.
+package configcheck
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/AlexTransit/vender/cmd/vender/subcmd"
+	"github.com/AlexTransit/vender/hardware"
+	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/money"
+	"github.com/AlexTransit/vender/internal/sound"
+	"github.com/AlexTransit/vender/internal/state"
+	"github.com/AlexTransit/vender/log2"
+	tele_api "github.com/AlexTransit/vender/tele"
+	"github.com/juju/errors"
+)
+
+const usage = `usage: config-check [-v]`
+
+var Mod = subcmd.Mod{Name: "config-check", Main: Main}
+
+func Main(ctx context.Context, args ...[]string) error {
+	g := state.GetGlobal(ctx)
+	fs := flag.NewFlagSet("config-check", flag.ContinueOnError)
+	verbose := fs.Bool("v", false, "show driver log")
+	if len(args) != 0 && len(args[0]) > 1 {
+		if err := fs.Parse(args[0][1:]); err != nil {
+			return errors.Annotate(err, usage)
+		}
+	}
+	if !*verbose {
+		g.Log.SetLevel(log2.LOG_CRIT)
+	}
+
+	cfg, src, problems := config_global.ReadConfigCheck(g.Log, g.Config.ConfigFile)
+	g.Config = cfg
+	g.Inventory = &cfg.Inventory
+
+	cleanup, err := Offline(ctx)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	problems = append(problems, g.ConfigCheck(ctx, src)...)
+
+	for _, p := range problems {
+		fmt.Println(p.String())
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("config-check: %d problems", len(problems))
+	}
+	fmt.Printf("config-check: %s ok\n", cfg.ConfigFile)
+	return nil
+}
+
+// Offline init of drivers without hardware for commands working with scenarios (config-check, margin):
+// MDB dummy driver, devices not required, stock file in temp dir.
+func Offline(ctx context.Context) (cleanup func(), err error) {
+	g := state.GetGlobal(ctx)
+	cfg := g.Config
+	g.Tele = tele_api.Noop{}
+	// stock and money journal files go to temp dir
+	dir, err := os.MkdirTemp("", "vender-offline")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Inventory.File = filepath.Join(dir, "inventory")
+	cfg.Hardware.Mdb.UartDriver = "dummy"
+	for name, d := range cfg.Hardware.EvendDevices {
+		d.Required = false // probe fails without hardware, actions are registered before probe
+		cfg.Hardware.EvendDevices[name] = d
+	}
+
+	sound.Init(ctx, false)
+	_ = hardware.InitMDBDevices(ctx)
+	_ = (&money.MoneySystem{}).Start(ctx)
+	return func() { os.RemoveAll(dir) }, nil
+}
@@ -0,0 +1,38 @@
+package ledger
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/AlexTransit/vender/cmd/vender/subcmd"
+	"github.com/AlexTransit/vender/internal/ledger"
+	"github.com/AlexTransit/vender/internal/state"
+	"github.com/juju/errors"
+)
+
+const usage = `usage: ledger export [-since 2006-01-02|RFC3339] [-format csv|json]`
+
+var Mod = subcmd.Mod{Name: "ledger", Main: Main}
+
+func Main(ctx context.Context, args ...[]string) error {
+	g := state.GetGlobal(ctx)
+	var a []string
+	if len(args) != 0 && len(args[0]) > 1 {
+		a = args[0][1:]
+	}
+	if len(a) == 0 || a[0] != "export" {
+		return errors.New(usage)
+	}
+	fs := flag.NewFlagSet("ledger export", flag.ContinueOnError)
+	sinceStr := fs.String("since", "", "export sales since date (2006-01-02 or RFC3339)")
+	format := fs.String("format", "csv", "csv|json")
+	if err := fs.Parse(a[1:]); err != nil {
+		return err
+	}
+	since, err := ledger.ParseTime(*sinceStr)
+	if err != nil {
+		return errors.Annotate(err, usage)
+	}
+	return ledger.New(g.Config.Ledger).Export(os.Stdout, since, *format)
+}
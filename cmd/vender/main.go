@@ -8,12 +8,16 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/AlexTransit/vender/cmd/vender/configcheck"
 	cmd_engine "github.com/AlexTransit/vender/cmd/vender/engine"
+	cmd_ledger "github.com/AlexTransit/vender/cmd/vender/ledger"
+	cmd_margin "github.com/AlexTransit/vender/cmd/vender/margin"
 	"github.com/AlexTransit/vender/cmd/vender/mdb"
 	"github.com/AlexTransit/vender/cmd/vender/subcmd"
 	cmd_tele "github.com/AlexTransit/vender/cmd/vender/tele"
 	"github.com/AlexTransit/vender/cmd/vender/ui"
 	"github.com/AlexTransit/vender/cmd/vender/vmc"
+	cmd_voucher "github.com/AlexTransit/vender/cmd/vender/voucher"
 	config_global "github.com/AlexTransit/vender/internal/config"
 	state_new "github.com/AlexTransit/vender/internal/state/new"
 	"github.com/AlexTransit/vender/internal/tele"
@@ -24,11 +28,15 @@ var (
 	log     = log2.NewStderr(log2.LOG_DEBUG)
 	modules = []subcmd.Mod{
 		cmd_engine.Mod,
+		configcheck.Mod,
+		cmd_ledger.Mod,
+		cmd_margin.Mod,
 		mdb.Mod,
 		cmd_tele.Mod,
 		ui.Mod,
 		vmc.VmcMod,
 		vmc.CmdMod,
+		cmd_voucher.Mod,
 		{Name: "version", Main: versionMain},
 	}
 )
@@ -0,0 +1,66 @@
+// Cost and margin of menu items from sales ledger.
+// Scenarios are parsed without hardware, like config-check.
+package margin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AlexTransit/vender/cmd/vender/configcheck"
+	"github.com/AlexTransit/vender/cmd/vender/subcmd"
+	"github.com/AlexTransit/vender/internal/ledger"
+	"github.com/AlexTransit/vender/internal/margin"
+	"github.com/AlexTransit/vender/internal/state"
+	"github.com/AlexTransit/vender/log2"
+	"github.com/juju/errors"
+)
+
+const usage = `usage: margin ` + margin.Usage
+
+var Mod = subcmd.Mod{Name: "margin", Main: Main}
+
+func Main(ctx context.Context, args ...[]string) error {
+	g := state.GetGlobal(ctx)
+	var a []string
+	if len(args) != 0 && len(args[0]) > 1 {
+		a = args[0][1:]
+	}
+	q, err := margin.ParseQuery(a)
+	if err != nil {
+		return errors.Annotate(err, usage)
+	}
+	g.Log.SetLevel(log2.LOG_ERR)
+	g.Inventory = &g.Config.Inventory
+	g.Ledger = ledger.New(g.Config.Ledger)
+	cleanup, err := configcheck.Offline(ctx)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err = g.InitScenarios(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "scenario errors, cost of some items unknown (see config-check): %v\n", err)
+	}
+	periods, err := g.Margin(q)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, p := range periods {
+		fmt.Fprintf(w, "%s - %s\n", p.From.Format("2006-01-02 15:04"), p.To.Format("2006-01-02 15:04"))
+		fmt.Fprintln(w, "code\tprice\tcost\tmargin\tmargin%\tsold\trevenue\tspent\terrors\twaste\tprofit\tname\t")
+		for _, i := range p.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.0f\t%d\t%s\t%s\t%d\t%s\t%s\t%s\t\n",
+				i.Code, i.Price.Format100I(), i.Cost.Format100I(), rub(i.Margin()), i.MarginPercent(),
+				i.Sold, i.Revenue.Format100I(), i.Spent.Format100I(), i.Errors, i.Waste.Format100I(), rub(i.Profit()), i.Name)
+		}
+		t := p.Total()
+		fmt.Fprintf(w, "%s\t\t\t\t\t%d\t%s\t%s\t%d\t%s\t%s\t\t\n",
+			t.Code, t.Sold, t.Revenue.Format100I(), t.Spent.Format100I(), t.Errors, t.Waste.Format100I(), rub(t.Profit()))
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func rub(kop int64) string { return fmt.Sprintf("%.2f", float64(kop)/100) }
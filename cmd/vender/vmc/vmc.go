@@ -11,6 +11,7 @@ import (
 
 	"github.com/AlexTransit/vender/cmd/vender/subcmd"
 	"github.com/AlexTransit/vender/hardware"
+	config_global "github.com/AlexTransit/vender/internal/config"
 	"github.com/AlexTransit/vender/internal/money"
 	"github.com/AlexTransit/vender/internal/sound"
 	"github.com/AlexTransit/vender/internal/state"
@@ -38,6 +39,9 @@ func VmcMain(ctx context.Context, args ...[]string) error {
 		g.VmcStop(ctx)
 	}()
 	subcmd.SdNotify(daemon.SdNotifyReady)
+	if config_global.RemoteConfigBoot(g.Log, g.Config.RemoteConfig) { // previous boot with new remote config failed
+		g.Config = config_global.ReadConfig(g.Log, g.Config.ConfigFile)
+	}
 	err := g.Init(ctx, g.Config)
 	if err != nil {
 		g.Fatal(err)
@@ -70,6 +74,7 @@ func VmcMain(ctx context.Context, args ...[]string) error {
 		return errors.Annotate(err, "ui Init()")
 	}
 	g.CheckMenuExecution()
+	g.Metrics.Start()
 	g.Log.Debugf("VMC init complete")
 
 	ui.Loop(ctx)
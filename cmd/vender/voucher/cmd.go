@@ -0,0 +1,97 @@
+package voucher
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlexTransit/vender/cmd/vender/subcmd"
+	"github.com/AlexTransit/vender/internal/state"
+	"github.com/AlexTransit/vender/internal/voucher"
+	"github.com/juju/errors"
+)
+
+const usage = `usage: voucher sign (-credit N | -item CODE) -until 2006-01-02 -serial N [-ed25519-key file]
+       voucher keygen
+       voucher used`
+
+var Mod = subcmd.Mod{Name: "voucher", Main: Main}
+
+func Main(ctx context.Context, args ...[]string) error {
+	g := state.GetGlobal(ctx)
+	var a []string
+	if len(args) != 0 && len(args[0]) > 1 {
+		a = args[0][1:]
+	}
+	if len(a) == 0 {
+		return errors.New(usage)
+	}
+	switch a[0] {
+	case "sign":
+		return sign(g, a[1:])
+	case "keygen":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("private (keep secret, -ed25519-key file): %s\n", base64.StdEncoding.EncodeToString(priv))
+		fmt.Printf("voucher.ed25519_public_key = \"%s\"\n", base64.StdEncoding.EncodeToString(pub))
+		return nil
+	case "used":
+		records, err := voucher.ReadUsed(g.Config.Voucher.File)
+		for _, r := range records {
+			fmt.Printf("%s serial=%d %s=%d until=%s\n", r.Time.Format(time.RFC3339), r.Serial, r.Kind, r.Value, r.Until)
+		}
+		return err
+	}
+	return errors.New(usage)
+}
+
+func sign(g *state.Global, args []string) error {
+	fs := flag.NewFlagSet("voucher sign", flag.ContinueOnError)
+	credit := fs.Uint("credit", 0, "gift credit, as money.set_gift_credit")
+	item := fs.Uint("item", 0, "free menu code")
+	untilStr := fs.String("until", "", "last day 2006-01-02")
+	serial := fs.Uint("serial", 0, "voucher number 1-999999, accepted once")
+	keyFile := fs.String("ed25519-key", "", "file with base64 Ed25519 private key (voucher keygen), default config voucher.hmac_key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	v := voucher.Voucher{Kind: voucher.KindCredit, Value: uint32(*credit), Serial: uint32(*serial)}
+	if *item != 0 {
+		v.Kind, v.Value = voucher.KindItem, uint32(*item)
+	}
+	until, err := time.ParseInLocation("2006-01-02", *untilStr, time.Local)
+	if err != nil {
+		return errors.Annotate(err, usage)
+	}
+	v.Until = until
+	if (*credit == 0) == (*item == 0) || v.Value > 9999 || v.Serial == 0 || v.Serial > 999999 {
+		return errors.New(usage)
+	}
+
+	prefix := g.Config.Voucher.Prefix
+	if *keyFile != "" {
+		b, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil || len(key) != ed25519.PrivateKeySize {
+			return errors.NotValidf("ed25519 private key file=%s", *keyFile)
+		}
+		fmt.Println(prefix + voucher.SignEd25519(v, key))
+		return nil
+	}
+	if g.Config.Voucher.HmacKey == "" {
+		return errors.New("config voucher.hmac_key is empty, use -ed25519-key")
+	}
+	fmt.Println(prefix + voucher.SignHmac(v, []byte(g.Config.Voucher.HmacKey), g.Config.Voucher.HmacDigits))
+	return nil
+}
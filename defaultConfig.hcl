@@ -8,6 +8,11 @@ script_if_broken = ""
 error_folder     = "/home/vmc/vender-db/errors/"
 # EN: BrokenFile for broken state logging
 broken_file      = "/home/vmc/broken"
+# RU: файл конфигурации, присланный с сервера (tele set_config). подключается последним. пусто - отключено.
+# применяется при следующем StateFrontBegin, предыдущая версия хранится в .prev, при неудачной загрузке - откат.
+# EN: config fragment received from server (tele set_config). included last. empty = disabled.
+# applied at next StateFrontBegin, previous version kept in .prev, rolled back if boot fails.
+remote_config    = "/home/vmc/vender-db/remote-config.hcl"
 
 # EN: Inventory configuration for stock management
 inventory {
@@ -105,6 +110,31 @@ hardware {
 # RU: путь к фреймбуферу для графического дисплея.
 # EN: path to framebuffer for graphical display.
     framebuffer = "/dev/fb0"
+# RU: графический интерфейс на фреймбуфере: меню с ценами, кредит, шкалы сливок/сахара, приготовление, ошибки, QR оплата.
+# рисуется там же где пишется текстовый дисплей, строки текстового дисплея - заголовок и подвал экрана.
+# картинки действия picture(?) перерисовываются следующим экраном.
+# EN: graphic UI on framebuffer: menu with prices, credit, cream/sugar scales, cooking progress, errors, QR payment.
+# drawn where text display lines are set, text display lines are screen header and footer.
+# pictures of picture(?) action are replaced by next screen.
+    graphic {
+      enable = false
+# RU: TTF/OTF шрифт, для кириллицы нужен. пусто - встроенный растровый 7x13 (только латиница), font_scale - увеличение.
+# EN: TTF/OTF font, required for cyrillic. empty - builtin bitmap 7x13 (latin only), font_scale - magnification.
+      font       = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+      font_size  = 20
+      font_scale = 2
+# RU: раскладка меню: list - список, grid - таблица в menu_columns колонок.
+# EN: menu layout: list, grid - menu_columns columns.
+      menu_layout  = "list"
+      menu_columns = 2
+      background   = "#000000"
+      foreground   = "#ffffff"
+      accent       = "#ffc000"
+      error_color  = "#ff4040"
+# RU: ожидаемое время приготовления для шкалы прогресса, дальше берется время прошлого приготовления напитка.
+# EN: expected cooking time for progress bar, then last cooking time of the item is used.
+      progress_sec = 30
+    }
   }
 
 # RU: Конфигурация для LCD 16х2 дисплея HD44780
@@ -163,8 +193,25 @@ hardware {
       dispense_strategy = 0
     }
 
+# RU: Конфигурация для безналичного считывателя (MDB cashless level 1/3).
+    cashless {
+# RU: множитель суммы. по умолчанию 100 (как у купюроприемника).
+      scaling_factor   = 0
+# RU: сколько секунд ждать ответа банка на запрос продажи. по умолчанию 60.
+      vend_timeout_sec = 0
+    }
+
     log_debug   = false
     uart_device = ""
+# RU: драйвер MDB шины: mega, iodin, file, dummy (шина без устройств), sim (симулятор автомата для запуска без железа).
+# sim: модели устройств evend (клапан с нагревом, стаканы, конвейер, миксер, лифт, бункеры, эспрессо), монетоприемник с тубами
+# и купюроприемник с escrow. клавиатура и текстовый дисплей тоже симулируются, управление с консоли (stdin): help - список команд,
+# 12 / ok / c - кнопки, coin 5, bill 100 - вставить деньги, fail cup 0x15 - ошибка следующей команды устройства, status - состояние.
+# EN: MDB bus driver: mega, iodin, file, dummy (bus without devices), sim (machine simulator to run without hardware).
+# sim: models of evend devices (valve with heating, cup, conveyor, mixer, elevator, hoppers, espresso), coin changer with tubes
+# and bill validator with escrow. keyboard and text display are simulated too, operated from console (stdin): help - commands,
+# 12 / ok / c - keys, coin 5, bill 100 - insert money, fail cup 0x15 - next device command fails, status - devices state.
+# Example: vender vmc with uart_driver = "sim" on laptop to try config and recipes before deploy.
     uart_driver = "mega"
   }
 
@@ -193,8 +240,46 @@ tele {
   mqtt_log_debug              = false
   mqtt_password               = ""
   store_path                  = ""
+# RU: TLS для брокеров mqtts:// ssl:// wss:// (и https для transport = "http"). Файлы в формате PEM. срок действия клиентского сертификата передается в RoboHardware.
+# EN: TLS for mqtts:// ssl:// wss:// brokers (and https for transport = "http"). PEM files. client certificate expiry is reported in RoboHardware.
+  tls_ca_file                 = ""
+  tls_cert_file               = ""
+  tls_key_file                = ""
+  tls_server_name             = ""
+# RU: Сколько ждать окончания удаленного приготовления (tele cook). по истечении отвечает cookError. 0 = 300 сек.
+# EN: Remote cook (tele cook) result wait. cookError is replied when it expires. 0 = 300 sec.
+  cook_timeout_sec            = 0
   network_restart_timeout_sec = 0
   network_restart_script      = ""
+# RU: Транспорт телеметрии: mqtt (по умолчанию) или http. http - POST сообщений и long-poll команд на http_url.
+# EN: Telemetry transport: mqtt (default) or http. http - POST messages and long-poll commands at http_url.
+  transport                   = "mqtt"
+  http_url                    = ""
+  http_poll_timeout_sec       = 30
+# RU: Подписанные удаленные команды. Подпись HMAC-SHA256 или Ed25519 по (executer, timestamp, nonce, task) ключом этого executer. Повторы отклоняются. Отказ возвращается в Response.error.
+# EN: Signed remote commands. HMAC-SHA256 or Ed25519 signature over (executer, timestamp, nonce, task) with key of this executer. Replays are rejected. Denials are returned in Response.error.
+  command_auth {
+    enable             = false
+# RU: допустимое расхождение времени команды, секунд.
+# EN: allowed command timestamp skew, seconds.
+    max_age_sec        = 300
+# RU: отправитель, свой ключ и права. hmac_key или ed25519_public_key (base64) - ключ только этого executer, чужим ключом подписать от его имени нельзя.
+# RU:   exec - может выполнять сценарии, scenario_prefix - разрешенные префиксы каждого действия сценария (пусто = любые), bypass_lock - может использовать "_" для обхода блокировки,
+# RU:   config - может присылать конфигурацию, inventory - может менять остатки (set_inventory), cook - может запускать удаленное приготовление.
+# EN: executer, its own key and permissions. hmac_key or ed25519_public_key (base64) - key of this executer only, other key can not sign on its behalf.
+# EN:   exec - may run scenarios, scenario_prefix - allowed prefixes of every scenario action (empty = any), bypass_lock - may use "_" to bypass client lock,
+# EN:   config - may send remote config, inventory - may set stock levels (set_inventory), cook - may start remote cook.
+    executer "1" {
+      hmac_key           = ""
+      ed25519_public_key = ""
+      exec               = true
+      scenario_prefix    = []
+      bypass_lock        = false
+      config             = false
+      inventory          = false
+      cook               = false
+    }
+  }
 }
 
 # EN: UI_config for user interface settings
@@ -258,6 +343,18 @@ ui {
     pic_pay_reject                  = "/home/vmc/pic-pay-reject"
 # RU: Расписание включения витрины. Например, "(* 06:00-23:00)" - включать подсветку каждый день с 6 утра до 11 вечера.
     light_sheduler                  = "(* 06:00-23:00)"
+# RU: клавиша смены языка клиента (блоки locale) при пустом коде напитка. язык возвращается через reset_sec.
+# EN: key to switch customer language (locale blocks) when drink code is empty. language reverts after reset_sec.
+    locale_key                      = "."
+# RU: сообщения промокода (блок voucher). если voucher.prefix начинается с locale_key, язык переключается locale_key и Accept.
+# EN: voucher messages (voucher block). if voucher.prefix starts with locale_key, language is switched by locale_key and Accept.
+    msg_voucher_invalid             = "неверный промокод"
+    msg_voucher_expired             = "промокод истек"
+    msg_voucher_used                = "промокод уже был"
+    msg_voucher_locked              = "ждите %.0f сек"
+# RU: вместо msg_credit, если есть только подарок промокода.
+# EN: instead of msg_credit when there is only voucher gift.
+    msg_voucher_gift                = "подарок: "
   }
 
   service {
@@ -280,7 +377,79 @@ ui {
 
 # RU: Время в секундах, через которое будет сделан выход из сервисного меню.
     reset_sec = 1800
+
+# RU: техники с PIN кодом. если указан хотя бы один - вход в сервисное меню только по PIN с клавиатуры.
+# в конфиге только соль и хэш PBKDF2-HMAC-SHA256 (pin_iterations итераций):
+# python3 -c 'import hashlib,sys; print(hashlib.pbkdf2_hmac("sha256", sys.argv[2].encode(), sys.argv[1].encode(), 100000).hex())' "$salt" "$pin"
+# вход, выход и действия (инвентарь, загрузка монет, тесты, обнуление кассы, перезагрузка) пишутся в лог и в tele (ServiceAudit) с именем техника.
+# EN: technicians with PIN. if any - service menu only by PIN from keypad.
+# config keeps salt and PBKDF2-HMAC-SHA256 hash only (pin_iterations iterations), python3 command above.
+# session and actions (inventory, money load, tests, cashbox zero, reboot) are logged and sent to tele (ServiceAudit) with technician name.
+    # technician "alex" {
+    #   salt     = "k3J9xq"
+    #   pin_hash = "pbkdf2-sha256 hex"
+    # }
+# RU: неверных PIN подряд до блокировки (0 - без блокировки) и время блокировки в секундах.
+# EN: wrong PINs in a row before lockout (0 - no lockout) and lockout time in seconds.
+    auth_max_fail    = 3
+    auth_lockout_sec = 300
+# RU: Файл счетчика неверных PIN и времени блокировки. Без файла перезапуск сбрасывает блокировку.
+# EN: Wrong PIN counter and lockout time file. Without file restart resets lockout.
+    auth_file        = "/home/vmc/vender-db/service-auth.json"
+# RU: итераций PBKDF2 для pin_hash (не меньше 10000). больше - медленнее подбор PIN по конфигу и вход.
+# EN: PBKDF2 iterations for pin_hash (at least 10000). more - slower PIN brute force from config and login.
+    pin_iterations = 100000
+
+# RU: сообщения сервисного меню.
+# EN: service menu messages.
+    msg_menu           = "Menu"
+    msg_input_pin      = "PIN %s"
+    msg_pin_fail       = "wrong PIN"
+    msg_locked         = "service locked"
+    msg_inv_empty      = "inv empty"
+    msg_inv_invalid    = "inv invalid"
+    msg_set_or_clear   = "set or clear?"
+    msg_empty          = "empty"
+    msg_number_invalid = "number-invalid"
+    msg_no_tests       = "no tests"
+    msg_in_progress    = "in progress"
+    msg_ok             = "OK"
+    msg_error          = "error"
+    msg_press_1        = "press 1"
+    msg_reboot_ask     = "for reboot"
+    msg_reboot         = "reboot"
+    msg_network_ask    = "for select net 0"
+    msg_network        = "wifi restart"
+    msg_coin_offline   = "coin offline"
+    msg_report_ask     = "for tele report"
   }
+
+# RU: язык сообщений front и service: правила множественного числа и имя языка по умолчанию.
+# в сообщениях после %s/%d можно указать формы множественного числа: "цена: %s {рубль|рубля|рублей}"
+# (ru: one|few|many, en: one|other).
+# EN: language of front and service messages: plural rules and default locale name.
+# plural forms may follow %s/%d in messages: "price: %s {ruble|rubles}" (en: one|other, ru: one|few|many).
+  lang = "ru"
+
+# RU: языки клиента. msg - сообщения front по имени параметра и фразы для speech(имя),
+# не указанные сообщения берутся из front. каталог удобно держать в отдельном файле через include.
+# char_map - символы текстового дисплея (код знакогенератора HD44780), tts_exec - голос языка.
+# EN: customer languages. msg - front messages by parameter name and phrases for speech(name),
+# missing messages are taken from front. keep catalogue in separate file with include.
+# char_map - text display characters (HD44780 code), tts_exec - voice of language.
+  # locale "en" {
+  #   title = "English"
+  #   msg = {
+  #     msg_wait   = "please wait"
+  #     msg_cream  = "Cream"
+  #     msg_sugar  = "Sugar"
+  #     msg_credit = "Credit: %s {ruble|rubles}"
+  #     msg_price  = "price: %s"
+  #     thanks     = "thank you"
+  #   }
+  #   char_map = { "ä" = 225 }
+  #   tts_exec = ["/home/vmc/vender-db/audio/tts/piper", "--model", "/home/vmc/vender-db/audio/tts/en/voice.onnx"]
+  # }
 }
 
 # RU: Конфигурация для звука
@@ -321,6 +490,30 @@ watchdog {
 engine {
 # RU: список псевдонимов. псевдоним соджержит имя сценарий и может содержать сценарии действий при ошибках. при вызове псевдонима выполняется сценарий действий. если при выполнении возникает ошибка, то выполняется сценарий действий для этой ошибки. если сценария для этой ошибки нет, то выполнение псевдонима прерывается и ошибка возвращается в вызывающий код. сценарии действий если произошла ошибка. Ключ - регулярное выражение кода ошибки ( '\' надо экранировать ). на каждую ошибку свой сценарий. не допускается пересечение регулярных выражений для разных ошибок.
 # Example: onError "3[78]" { scenario = "error_scenario" } - при ошибках с кодами 37,38 будет выполняться сценарий "error_scenario". onError "\\d+" { scenario = "error_scenario" } - для любой ошибки.
+# RU: в сценарии можно задать параллельные ветки: "[ evend.cup.dispense(1) | conveyor.move(300) ] add.water_hot(100)". ветки выполняются одновременно, дальше сценарий идет после окончания всех веток. ошибка в одной ветке останавливает остальные.
+# EN: scenario may contain parallel branches: "[ evend.cup.dispense(1) | conveyor.move(300) ] add.water_hot(100)". branches run concurrently, scenario continues when all branches finish. error in one branch cancels the others.
+# RU: условие: "if(user.cream>0){ add.cream(?) }else if(stock.milk>10){ add.milk(5) }else{ add.water_hot(10) }". переменные только для чтения:
+# RU:   stock.<ингредиент или метка склада> - остаток, user.cream, user.sugar - выбор покупателя, payment.method - cash/cashless/gift..., valve.temp - температура воды.
+# RU:   операторы: == != < <= > >= && || ! и скобки. строки сравниваются без учета регистра.
+# EN: condition: "if(user.cream>0){ add.cream(?) }else if(stock.milk>10){ add.milk(5) }else{ add.water_hot(10) }". read-only variables:
+# EN:   stock.<ingredient or stock label> - stock value, user.cream, user.sugar - customer tuning, payment.method - cash/cashless/gift..., valve.temp - hot water temperature.
+# EN:   operators: == != < <= > >= && || ! and parentheses. strings compare case-insensitive.
+# RU: ограничение времени: "timeout(30s){ evend.cup.dispense(1) conveyor.move(300) }". по истечении действия внутри прерываются, ошибка с кодом 99 (ловится onError "99").
+# RU:   кнопка сервис, команда stop с сервера и выключение прерывают выполняемый сценарий.
+# EN: time limit: "timeout(30s){ evend.cup.dispense(1) conveyor.move(300) }". when expired inner actions are interrupted, error code 99 (match with onError "99").
+# EN:   service key, server stop command and shutdown interrupt running scenario.
+# RU: повтор при ошибке: "retry(3, 500ms){ conveyor.reset conveyor.move(300) }". до 3 попыток, пауза 500ms, затем 1s... если все попытки неудачны - ошибка последней попытки (код для onError).
+# RU:   коды ошибок устройств - hardware/mdb/evend/evend_hardware.md (1-89 прошивка, 90 неожиданный ответ, 91 потеря связи, 92 код не прочитан, 93 температура, 94 нет ответа, 99 таймаут).
+# EN: retry on error: "retry(3, 500ms){ conveyor.reset conveyor.move(300) }". up to 3 attempts, pause 500ms, then 1s... when all attempts fail - error of last attempt (code for onError).
+# EN:   device error codes - hardware/mdb/evend/evend_hardware.md (1-89 firmware, 90 unexpected response, 91 connection lost, 92 code not read, 93 temperature, 94 no response, 99 timeout).
+# RU: действия с несколькими аргументами: "evend.conveyor.move(300)", "evend.conveyor.move(300, 80)", "evend.conveyor.move(pos=300, speed=80)", "evend.multihopper.run(hopper=3, time=20)".
+# RU:   типы аргументов проверяются при загрузке сценария. не указанный аргумент берет значение по умолчанию (evend.conveyor.move speed=-1 - не менять скорость). "?" - подставляется из вызова, "pos=?" - по имени.
+# EN: multi argument actions: "evend.conveyor.move(300)", "evend.conveyor.move(300, 80)", "evend.conveyor.move(pos=300, speed=80)", "evend.multihopper.run(hopper=3, time=20)".
+# EN:   argument types are checked at scenario load. omitted argument takes default (evend.conveyor.move speed=-1 - keep current speed). "?" - taken from call, "pos=?" - by name.
+# RU: проверка конфигурации без оборудования (для CI): vender -config config.hcl config-check. сценарии псевдонимов, меню, on_*, сервисных тестов проверяются на неизвестные действия и аргументы,
+# RU:   также пересечение onError, склады без ингредиента и повтор кодов. ошибки выводятся как файл:строка, код выхода 1.
+# EN: offline config check (for CI): vender -config config.hcl config-check. scenarios of aliases, menu, on_*, service tests are checked for unknown actions and arguments,
+# EN:   also overlapping onError, stocks without ingredient and duplicate codes. problems are printed as file:line, exit code 1.
   alias "example" {
     scenario = "example_scenario"
     onError "3[78]" { // this will match error codes 37 and 38
@@ -341,8 +534,21 @@ engine {
       scenario = "error_scenario2"
     }
   }
+# RU: псевдоним с параметрами. в сценарии параметр подставляется как $имя. вызов в меню: make_coffee(150,8,4) или make_coffee(water=150, coffee=8, sugar=4).
+# RU:   все параметры обязательны, количество и имена проверяются при загрузке конфигурации. onError работает как у обычного псевдонима.
+# EN: alias with params. param is substituted in scenario as $name. call from menu: make_coffee(150,8,4) or make_coffee(water=150, coffee=8, sugar=4).
+# EN:   every param is required, count and names are checked when config is loaded. onError works as for plain alias.
+  alias "make_coffee" {
+    params   = ["water", "coffee", "sugar"]
+    scenario = "add.water_hot($water) add.coffee($coffee) add.sugar($sugar) cup_serve"
+  }
 # RU: список меню.
   menu {
+# RU: стоимость стакана. добавляется к себестоимости каждого напитка.
+# RU: себестоимость и маржа: vender margin -since 2024-05-01 -period day|week|month (по журналу продаж ledger), menu.cost в лог, tele reportMargin.
+# EN: cup cost, added to cost of every item.
+# EN: cost and margin: vender margin -since 2024-05-01 -period day|week|month (from sales ledger), menu.cost to log, tele reportMargin.
+    cup_cost = 2.5
 # RU: код напитка. должен быть уникальным для каждого напитка.
     item "43." {
 # RU: имя напитка.
@@ -369,7 +575,27 @@ engine {
       price = 60 
 # RU: сценарий приготовления напитка. может содержать псевдонимы.
       scenario = " preset add.coffee(5) add.chocolate(30) mix_midle w_hot85 add.peanut(7) cup_serve_p "
+# RU: категория напитка для правил цены (price_rule categories).
+# EN: item category for price rules (price_rule categories).
+      category = "coffee"
     }
+# RU: правило цены (happy hours): скидка в дни и время schedule (синтаксис как ui.front.light_sheduler, 0=воскресенье,
+# RU:   начало больше конца - до полуночи и после полуночи того же дня). percent - скидка в процентах, discount - суммой в единицах price.
+# RU:   items и categories - к каким напиткам, если оба пустые - ко всем. из подходящих правил берется самая низкая цена.
+# RU:   цена со скидкой показывается на экране, в QR оплате, ответе validateCode и в Order.Amount. прием денег ограничен полной ценой.
+# RU:   скидка не должна делать платный напиток бесплатным (ошибка config-check, при работе такое правило для напитка не применяется).
+# EN: price rule (happy hours): discount on days and time of schedule (ui.front.light_sheduler syntax, 0=sunday,
+# EN:   begin after end - till midnight and after midnight of same day). percent - discount percent, discount - amount in price units.
+# EN:   items and categories - which items, both empty - all items. the lowest price of matching rules is used.
+# EN:   discounted price is shown on display, in QR payment, validateCode reply and Order.Amount. money accept is limited by full price.
+# EN:   discount must not make paid item free (config-check error, at runtime such rule is not applied to the item).
+    # price_rule "happy" {
+    #   schedule   = "(1-5 15:00-17:00) (6 10:00-12:00)"
+    #   percent    = 20
+    #   discount   = 0
+    #   items      = ["43."]
+    #   categories = ["coffee"]
+    # }
   }
 # RU: список действий при загрузке системы.
 # Example: on_boot = ["text_boot sleep(2s)", "evend.cup.ensure", "evend.valve.set_temp_hot_config " ]
@@ -400,4 +626,69 @@ engine {
     min_us     = 0
     log_format = ""
   }
+# RU: сколько последних заказов хранить в трассировке: каждое действие с вложенностью, аргумент, ошибка, код ошибки и обмен MDB. 0 - выключено.
+# Выгрузка через HTTP сервер metrics: /trace - список заказов, /trace?n=0 - последний заказ в формате Chrome trace (открыть в chrome://tracing или ui.perfetto.dev).
+# EN: how many last orders keep in trace: every action with nesting, argument, error, error code and MDB transactions. 0 = disabled.
+# Export on metrics HTTP server: /trace - list of orders, /trace?n=0 - newest order as Chrome trace (open in chrome://tracing or ui.perfetto.dev).
+  trace_orders = 10
+}
+
+# RU: Локальный журнал продаж (для сверки инкассации, если автомат долго без связи). Выгрузка: vender ledger export -since 2024-05-01 -format csv
+# EN: Local sales ledger (to reconcile cash collections when the machine is offline). Export: vender ledger export -since 2024-05-01 -format csv
+ledger {
+# RU: Если true, то журнал продаж не ведется.
+# EN: If true, the sales ledger is disabled.
+  disabled    = false
+# RU: Файл журнала продаж. Одна строка JSON на продажу. Старые файлы переименовываются в file.1, file.2 и т.д.
+# EN: Sales ledger file. One JSON line per sale. Old files are renamed to file.1, file.2 etc.
+  file        = "/home/vmc/vender-db/ledger/sales.jsonl"
+# RU: Максимальный размер файла в килобайтах, после которого файл ротируется.
+# EN: Maximum file size in kilobytes before rotation.
+  max_size_kb = 1024
+# RU: Сколько старых файлов хранить.
+# EN: How many rotated files to keep.
+  keep        = 5
+}
+
+# RU: HTTP точка /metrics для Prometheus: счетчики MDB по адресам, ошибки mega SPI, состояние UI, продажи по кодам, кредит, склад, температура воды.
+# EN: Prometheus HTTP endpoint /metrics: MDB counters per address, mega SPI errors, UI state, sales per code, credit, stock, water temperature.
+# RU: там же /trace - трассировка последних заказов, см. engine.trace_orders
+# EN: also /trace - trace of last orders, see engine.trace_orders
+metrics {
+# RU: Если true, то HTTP точка включена.
+# EN: If true, the HTTP endpoint is enabled.
+  enabled = false
+# RU: Адрес для HTTP сервера, например ":9110" или "127.0.0.1:9110".
+# EN: HTTP listen address, e.g. ":9110" or "127.0.0.1:9110".
+  listen  = ":9110"
+}
+
+# RU: Промокоды с клавиатуры: префикс, затем только цифры K VVVV YYMMDD SSSSSS и подпись, Accept.
+# RU: K=1 подарок VVVV (как money.set_gift_credit), K=2 бесплатный напиток с кодом VVVV. YYMMDD последний день, SSSSSS номер (принимается один раз).
+# RU: проверка без связи, использованные номера пишутся в file и отправляются в tele (Voucher). без ключа промокоды выключены.
+# RU: выпуск: vender voucher sign -credit 50 -until 2024-12-31 -serial 1 ; ключи Ed25519: vender voucher keygen ; использованные: vender voucher used
+# EN: Keypad voucher codes: prefix, then digits only K VVVV YYMMDD SSSSSS and signature, Accept.
+# EN: K=1 gift VVVV (as money.set_gift_credit), K=2 free drink with menu code VVVV. YYMMDD last day, SSSSSS number (accepted once).
+# EN: verified offline, used numbers are written to file and sent to tele (Voucher). without key vouchers are disabled.
+# EN: issue: vender voucher sign -credit 50 -until 2024-12-31 -serial 1 ; Ed25519 keys: vender voucher keygen ; used: vender voucher used
+voucher {
+# RU: Префикс промокода на клавиатуре, отличает промокод от кода напитка. Без цифр.
+# EN: Voucher prefix on keypad, distinguishes voucher from menu code. No digits.
+  prefix             = "."
+# RU: Открытый ключ Ed25519 (base64). Секрета в автомате нет, но весь код 172 цифры, набирать на клавиатуре непрактично.
+# EN: Ed25519 public key (base64). No secret on machine, but whole code is 172 digits, impractical to type on keypad.
+  # ed25519_public_key = ""
+# RU: Секрет HMAC-SHA256 для коротких кодов (подпись hmac_digits цифр). Это НЕ открытый ключ: секрет хранится в конфиге автомата,
+# RU: любой с доступом к конфигу (автомат, бэкап, remote config) может выпускать промокоды. Свой ключ на каждый автомат.
+# EN: HMAC-SHA256 secret for short codes (signature of hmac_digits digits). This is NOT a public key: the secret is stored in machine config,
+# EN: anyone with config access (machine, backup, remote config) can issue vouchers. Use own key per machine.
+  # hmac_key         = ""
+  hmac_digits        = 10
+# RU: Файл использованных промокодов. Одна строка JSON на промокод.
+# EN: Used vouchers file. One JSON line per voucher.
+  file               = "/home/vmc/vender-db/voucher/used.jsonl"
+# RU: Неверных промокодов подряд до блокировки ввода и время блокировки в секундах.
+# EN: Wrong codes in a row before lockout and lockout time in seconds.
+  max_fail           = 5
+  lockout_sec        = 300
 }
@@ -3,6 +3,8 @@ package display
 import (
 	"image"
 	"image/color"
+	"image/png"
+	"io"
 	"os"
 	"strings"
 
@@ -84,6 +86,28 @@ func (d *Display) QR(text string, border bool, level qrcode.RecoveryLevel) error
 	return d.Flush()
 }
 
+// Image draw img over display and flush
+func (d *Display) Image(img image.Image) error {
+	b := img.Bounds().Intersect(image.Rectangle{Max: d.size})
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			d.set(x, y, toRGBA(img.At(x, y)))
+		}
+	}
+	return d.Flush()
+}
+
+// PNG current picture, tests and screenshots
+func (d *Display) PNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rectangle{Max: d.size})
+	for y := 0; y < d.size.Y; y++ {
+		for x := 0; x < d.size.X; x++ {
+			img.SetRGBA(x, y, d.get(x, y))
+		}
+	}
+	return png.Encode(w, img)
+}
+
 func (d *Display) CopyFile2FB(f string) error {
 	p, _ := os.ReadFile(f)
 	if len(p) == 0 {
@@ -0,0 +1,369 @@
+package display
+
+// графический интерфейс на фреймбуфере. экран строится из состояния UI (Screen) и раскладки (GraphicConfig).
+// graphic UI on framebuffer: menu with prices, credit, cream/sugar scale, cooking progress, errors, QR payment.
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/juju/errors"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+type GraphicConfig struct {
+	// RU: включить графический интерфейс. экран рисуется там же где пишется текстовый дисплей.
+	// EN: enable graphic UI. screen is drawn where text display lines are set.
+	Enable bool `hcl:"enable,optional"`
+	// RU: путь к TTF/OTF шрифту. пусто - встроенный растровый 7x13 (только латиница).
+	// EN: path to TTF/OTF font. empty - builtin bitmap 7x13 (latin only).
+	Font string `hcl:"font,optional"`
+	// RU: размер TTF шрифта в пикселях.
+	FontSize float64 `hcl:"font_size,optional"`
+	// RU: увеличение растрового шрифта (1,2,3).
+	FontScale int `hcl:"font_scale,optional"`
+	// RU: раскладка меню: "list" - список, "grid" - таблица в menu_columns колонок.
+	MenuLayout  string `hcl:"menu_layout,optional"`
+	MenuColumns int    `hcl:"menu_columns,optional"`
+	// RU: цвета "#rrggbb".
+	Background string `hcl:"background,optional"`
+	Foreground string `hcl:"foreground,optional"`
+	Accent     string `hcl:"accent,optional"`
+	ErrorColor string `hcl:"error_color,optional"`
+	// RU: ожидаемое время приготовления в секундах для шкалы прогресса. дальше берется время прошлого приготовления напитка.
+	// EN: expected cooking time for progress bar until the item was cooked once.
+	ProgressSec int `hcl:"progress_sec,optional"`
+}
+
+const (
+	MenuLayoutList = "list"
+	MenuLayoutGrid = "grid"
+)
+
+type ScreenKind uint8
+
+const (
+	ScreenText     ScreenKind = iota // lines of text display (service menu, messages)
+	ScreenMenu                       // menu items, credit, typed code
+	ScreenTune                       // cream/sugar scale
+	ScreenProgress                   // cooking
+	ScreenError
+	ScreenQR
+)
+
+type MenuItem struct {
+	Code      string
+	Name      string
+	Price     currency.Amount
+	Available bool
+}
+
+type Scale struct {
+	Name    string
+	Value   uint8
+	Max     uint8
+	Default uint8
+}
+
+// Screen state of UI to draw. Lines - same lines as on text display
+type Screen struct {
+	Kind     ScreenKind
+	Lines    [2]string
+	Credit   currency.Amount
+	Input    string
+	Menu     []MenuItem
+	Scale    Scale
+	Progress float64 // 0..1
+	QR       string
+}
+
+type Renderer struct {
+	mu     sync.Mutex
+	d      *Display
+	cfg    GraphicConfig
+	canvas *image.RGBA
+	face   font.Face
+	scale  int // bitmap font
+	bg     color.RGBA
+	fg     color.RGBA
+	dim    color.RGBA
+	accent color.RGBA
+	errc   color.RGBA
+	last   *Screen
+}
+
+func NewRenderer(d *Display, cfg GraphicConfig) (*Renderer, error) {
+	r := &Renderer{
+		d:      d,
+		cfg:    cfg,
+		canvas: image.NewRGBA(image.Rectangle{Max: d.size}),
+		face:   basicfont.Face7x13,
+		scale:  cfg.FontScale,
+	}
+	if r.scale <= 0 {
+		r.scale = 2
+	}
+	if cfg.Font != "" {
+		b, err := os.ReadFile(cfg.Font)
+		if err != nil {
+			return nil, errors.Annotate(err, "graphic font")
+		}
+		f, err := opentype.Parse(b)
+		if err != nil {
+			return nil, errors.Annotatef(err, "graphic font=%s", cfg.Font)
+		}
+		size := cfg.FontSize
+		if size <= 0 {
+			size = 20
+		}
+		if r.face, err = opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull}); err != nil {
+			return nil, errors.Annotatef(err, "graphic font=%s", cfg.Font)
+		}
+		r.scale = 1
+	}
+	var err error
+	for _, c := range []struct {
+		dst *color.RGBA
+		s   string
+		def color.RGBA
+	}{
+		{&r.bg, cfg.Background, color.RGBA{0, 0, 0, 0xff}},
+		{&r.fg, cfg.Foreground, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{&r.accent, cfg.Accent, color.RGBA{0xff, 0xc0, 0, 0xff}},
+		{&r.errc, cfg.ErrorColor, color.RGBA{0xff, 0x40, 0x40, 0xff}},
+	} {
+		if *c.dst, err = parseColor(c.s, c.def); err != nil {
+			return nil, err
+		}
+	}
+	r.dim = color.RGBA{(r.fg.R + r.bg.R) / 2, (r.fg.G + r.bg.G) / 2, (r.fg.B + r.bg.B) / 2, 0xff}
+	switch cfg.MenuLayout {
+	case "", MenuLayoutList, MenuLayoutGrid:
+	default:
+		return nil, errors.NotValidf("graphic menu_layout=%s (list|grid)", cfg.MenuLayout)
+	}
+	return r, nil
+}
+
+// Draw screen and flush to framebuffer. same screen is not redrawn
+func (r *Renderer) Draw(s Screen) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last != nil && reflect.DeepEqual(*r.last, s) {
+		return nil
+	}
+	if err := r.render(s); err != nil {
+		return err
+	}
+	r.last = &s
+	return r.d.Image(r.canvas)
+}
+
+func (r *Renderer) render(s Screen) error {
+	draw.Draw(r.canvas, r.canvas.Bounds(), image.NewUniform(r.bg), image.Point{}, draw.Src)
+	b := r.canvas.Bounds()
+	lh := r.lineHeight()
+	pad := lh / 4
+	if s.Kind == ScreenQR {
+		return r.qr(s, b, lh)
+	}
+	header := image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+lh+2*pad)
+	footer := image.Rect(b.Min.X, b.Max.Y-lh-2*pad, b.Max.X, b.Max.Y)
+	body := image.Rect(b.Min.X+pad, header.Max.Y+pad, b.Max.X-pad, footer.Min.Y-pad)
+	headerColor := r.fg
+	if s.Kind == ScreenError {
+		headerColor = r.errc
+	}
+	r.fill(image.Rect(header.Min.X, header.Max.Y-1, header.Max.X, header.Max.Y), r.dim)
+	credit := ""
+	if s.Credit != 0 {
+		credit = s.Credit.Format100I()
+		r.text(header.Max.X-pad-r.width(credit), header.Min.Y+pad, credit, r.accent)
+	}
+	r.text(header.Min.X+pad, header.Min.Y+pad, r.fit(s.Lines[0], header.Dx()-3*pad-r.width(credit)), headerColor)
+	r.text(footer.Min.X+pad, footer.Min.Y+pad, r.fit(s.Lines[1], footer.Dx()-2*pad), r.fg)
+
+	switch s.Kind {
+	case ScreenMenu:
+		r.menu(s, body, lh)
+	case ScreenTune:
+		r.scaleBar(s.Scale, body, lh)
+	case ScreenProgress:
+		r.progress(s.Progress, body, lh)
+	case ScreenError:
+		frame := body.Inset(pad)
+		r.frame(frame, r.errc, 2)
+		r.text(frame.Min.X+(frame.Dx()-r.width("!"))/2, frame.Min.Y+(frame.Dy()-lh)/2, "!", r.errc)
+	}
+	return nil
+}
+
+func (r *Renderer) menu(s Screen, body image.Rectangle, lh int) {
+	columns := 1
+	if r.cfg.MenuLayout == MenuLayoutGrid && r.cfg.MenuColumns > 1 {
+		columns = r.cfg.MenuColumns
+	}
+	rows := body.Dy() / lh
+	if rows == 0 {
+		return
+	}
+	items := s.Menu
+	if s.Input != "" { // typed code first
+		items = make([]MenuItem, 0, len(s.Menu))
+		for _, i := range s.Menu {
+			if strings.HasPrefix(i.Code, s.Input) {
+				items = append(items, i)
+			}
+		}
+	}
+	if len(items) > rows*columns {
+		items = items[:rows*columns]
+	}
+	cw := body.Dx() / columns
+	gap := lh / 2
+	for n, i := range items {
+		x := body.Min.X + (n/rows)*cw
+		y := body.Min.Y + (n%rows)*lh
+		c := r.fg
+		switch {
+		case !i.Available:
+			c = r.dim
+		case s.Input != "" && i.Code == s.Input:
+			c = r.accent
+		}
+		price := i.Price.Format100I()
+		right := x + cw - gap
+		r.text(right-r.width(price), y, price, c)
+		r.text(x, y, r.fit(i.Code+" "+i.Name, right-x-r.width(price)-gap), c)
+	}
+}
+
+// scale like createScale: cells 0..max, current filled, default marked
+func (r *Renderer) scaleBar(sc Scale, body image.Rectangle, lh int) {
+	value := fmt.Sprintf("%s %d", sc.Name, sc.Value)
+	r.text(body.Min.X+(body.Dx()-r.width(value))/2, body.Min.Y, value, r.fg)
+	cells := int(sc.Max) + 1
+	gap := 4
+	cw := (body.Dx() - gap*(cells-1)) / cells
+	top := body.Min.Y + lh + lh/2
+	bottom := minInt(body.Max.Y-lh/2, top+2*lh)
+	for i := 0; i < cells; i++ {
+		cell := image.Rect(body.Min.X+i*(cw+gap), top, body.Min.X+i*(cw+gap)+cw, bottom)
+		if i <= int(sc.Value) {
+			r.fill(cell, r.accent)
+		} else {
+			r.frame(cell, r.dim, 1)
+		}
+		if i == int(sc.Default) {
+			r.fill(image.Rect(cell.Min.X, bottom+2, cell.Max.X, bottom+5), r.fg)
+		}
+	}
+}
+
+func (r *Renderer) progress(p float64, body image.Rectangle, lh int) {
+	p = clamp01(p)
+	bar := image.Rect(body.Min.X, body.Min.Y+(body.Dy()-lh)/2-lh/2, body.Max.X, body.Min.Y+(body.Dy()+lh)/2+lh/2)
+	r.frame(bar, r.fg, 2)
+	inner := bar.Inset(4)
+	inner.Max.X = inner.Min.X + int(float64(inner.Dx())*p)
+	r.fill(inner, r.accent)
+	percent := fmt.Sprintf("%d%%", int(p*100))
+	r.text(body.Min.X+(body.Dx()-r.width(percent))/2, bar.Max.Y+lh/4, percent, r.fg)
+}
+
+// QR centered, caption under it
+func (r *Renderer) qr(s Screen, b image.Rectangle, lh int) error {
+	qr, err := qrcode.New(s.QR, qrcode.Medium)
+	if err != nil {
+		return errors.Annotate(err, "QR")
+	}
+	size := minInt(b.Dx(), b.Dy()-lh-lh/2)
+	img := qr.Image(size)
+	at := image.Pt(b.Min.X+(b.Dx()-img.Bounds().Dx())/2, b.Min.Y)
+	draw.Draw(r.canvas, img.Bounds().Add(at), img, image.Point{}, draw.Src)
+	caption := strings.TrimSpace(strings.Join(s.Lines[:], " "))
+	caption = r.fit(caption, b.Dx())
+	r.text(b.Min.X+(b.Dx()-r.width(caption))/2, b.Max.Y-lh-lh/4, caption, r.fg)
+	return nil
+}
+
+func (r *Renderer) lineHeight() int {
+	m := r.face.Metrics()
+	return (m.Ascent + m.Descent).Ceil() * r.scale
+}
+
+func (r *Renderer) width(s string) int {
+	return font.MeasureString(r.face, s).Ceil() * r.scale
+}
+
+// cut text to width
+func (r *Renderer) fit(s string, width int) string {
+	for s != "" && r.width(s) > width {
+		rs := []rune(s)
+		s = string(rs[:len(rs)-1])
+	}
+	return s
+}
+
+// text with top left corner x,y
+func (r *Renderer) text(x, y int, s string, c color.RGBA) {
+	if s == "" {
+		return
+	}
+	ascent := r.face.Metrics().Ascent.Ceil()
+	if r.scale == 1 {
+		d := font.Drawer{Dst: r.canvas, Src: image.NewUniform(c), Face: r.face, Dot: fixed.P(x, y+ascent)}
+		d.DrawString(s)
+		return
+	}
+	// bitmap font drawn 1:1 and scaled
+	w := font.MeasureString(r.face, s).Ceil()
+	h := r.lineHeight() / r.scale
+	small := image.NewRGBA(image.Rect(0, 0, w, h))
+	d := font.Drawer{Dst: small, Src: image.NewUniform(c), Face: r.face, Dot: fixed.P(0, ascent)}
+	d.DrawString(s)
+	draw.NearestNeighbor.Scale(r.canvas, image.Rect(x, y, x+w*r.scale, y+h*r.scale), small, small.Bounds(), draw.Over, nil)
+}
+
+func (r *Renderer) fill(rect image.Rectangle, c color.RGBA) {
+	draw.Draw(r.canvas, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func (r *Renderer) frame(rect image.Rectangle, c color.RGBA, w int) {
+	r.fill(image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+w), c)
+	r.fill(image.Rect(rect.Min.X, rect.Max.Y-w, rect.Max.X, rect.Max.Y), c)
+	r.fill(image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+w, rect.Max.Y), c)
+	r.fill(image.Rect(rect.Max.X-w, rect.Min.Y, rect.Max.X, rect.Max.Y), c)
+}
+
+func parseColor(s string, def color.RGBA) (color.RGBA, error) {
+	if s == "" {
+		return def, nil
+	}
+	c := color.RGBA{A: 0xff}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil || len(s) != 7 {
+		return def, errors.NotValidf("graphic color=%s (#rrggbb)", s)
+	}
+	return c, nil
+}
+
+func clamp01(f float64) float64 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
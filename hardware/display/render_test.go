@@ -0,0 +1,127 @@
+package display
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+var testSize = image.Point{X: 320, Y: 240}
+
+// draw screen and save PNG, picture is read back from file
+func renderPNG(t *testing.T, r *Renderer, s Screen) image.Image {
+	t.Helper()
+	require.NoError(t, r.Draw(s))
+	fn := filepath.Join(t.TempDir(), "screen.png")
+	f, err := os.Create(fn)
+	require.NoError(t, err)
+	require.NoError(t, r.d.PNG(f))
+	require.NoError(t, f.Close())
+	f, err = os.Open(fn)
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	require.NoError(t, err)
+	assert.Equal(t, testSize, img.Bounds().Max)
+	return img
+}
+
+func count(img image.Image, rect image.Rectangle, c color.RGBA) (n int) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if toRGBA(img.At(x, y)) == c {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRenderer(NewMock(testSize), GraphicConfig{Accent: "#00ff00"})
+	require.NoError(t, err)
+	accent := color.RGBA{0, 0xff, 0, 0xff}
+	all := image.Rectangle{Max: testSize}
+
+	menu := []MenuItem{
+		{Code: "1", Name: "espresso", Price: 5000, Available: true},
+		{Code: "2", Name: "cappuccino", Price: 8000, Available: true},
+		{Code: "3", Name: "tea", Price: 3000},
+	}
+	img := renderPNG(t, r, Screen{Kind: ScreenMenu, Lines: [2]string{"Credit", ""}, Menu: menu})
+	assert.Equal(t, 0, count(img, all, accent))
+	assert.NotEqual(t, 0, count(img, all, r.fg))
+	assert.NotEqual(t, 0, count(img, all, r.dim)) // not available
+
+	// credit and typed code in accent
+	img = renderPNG(t, r, Screen{Kind: ScreenMenu, Credit: 10000, Input: "2", Menu: menu})
+	header := image.Rect(testSize.X/2, 0, testSize.X, r.lineHeight())
+	assert.NotEqual(t, 0, count(img, header, accent))
+	assert.NotEqual(t, 0, count(img, image.Rect(0, header.Max.Y, testSize.X, testSize.Y), accent))
+
+	// scale: 4 of 7 cells filled
+	img = renderPNG(t, r, Screen{Kind: ScreenTune, Scale: Scale{Name: "sugar", Value: 3, Max: 6, Default: 4}})
+	filled := count(img, all, accent)
+	assert.NotEqual(t, 0, filled)
+	img = renderPNG(t, r, Screen{Kind: ScreenTune, Scale: Scale{Name: "sugar", Value: 6, Max: 6, Default: 4}})
+	assert.InDelta(t, float64(filled)*7/4, float64(count(img, all, accent)), float64(filled)/4)
+
+	img = renderPNG(t, r, Screen{Kind: ScreenProgress, Progress: 0.25})
+	quarter := count(img, all, accent)
+	img = renderPNG(t, r, Screen{Kind: ScreenProgress, Progress: 0.5})
+	assert.InDelta(t, float64(quarter*2), float64(count(img, all, accent)), float64(quarter)/10)
+
+	img = renderPNG(t, r, Screen{Kind: ScreenError, Lines: [2]string{"error", "not available"}})
+	assert.NotEqual(t, 0, count(img, all, r.errc))
+
+	img = renderPNG(t, r, Screen{Kind: ScreenQR, QR: "t=20200211T1825&s=23.00", Lines: [2]string{"QR 23"}})
+	assert.NotEqual(t, 0, count(img, image.Rect(0, 0, testSize.X, testSize.Y-r.lineHeight()*3/2), color.RGBA{0xff, 0xff, 0xff, 0xff}))
+}
+
+func TestRenderSkipSame(t *testing.T) {
+	t.Parallel()
+
+	d := NewMock(testSize)
+	r, err := NewRenderer(d, GraphicConfig{})
+	require.NoError(t, err)
+	s := Screen{Kind: ScreenText, Lines: [2]string{"line 1", "line 2"}}
+	require.NoError(t, r.Draw(s))
+	require.NoError(t, d.Clear())
+	require.NoError(t, r.Draw(s)) // not redrawn
+	assert.Equal(t, strings.Repeat(strings.Repeat("  ", d.size.X)+"\n", d.size.Y), d.String2())
+	s.Lines[1] = ""
+	require.NoError(t, r.Draw(s))
+	assert.NotEqual(t, strings.Repeat(strings.Repeat("  ", d.size.X)+"\n", d.size.Y), d.String2())
+}
+
+func TestRenderFont(t *testing.T) {
+	t.Parallel()
+
+	fn := filepath.Join(t.TempDir(), "goregular.ttf")
+	require.NoError(t, os.WriteFile(fn, goregular.TTF, 0o600))
+	r, err := NewRenderer(NewMock(testSize), GraphicConfig{Font: fn, FontSize: 24})
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.scale)
+	img := renderPNG(t, r, Screen{Kind: ScreenText, Lines: [2]string{"Кредит: 50", "капучино"}})
+	assert.NotEqual(t, 0, count(img, image.Rect(0, 0, testSize.X, r.lineHeight()), r.fg))
+	assert.Equal(t, "капучи", r.fit("капучино", r.width("капучи")))
+
+	for _, cfg := range []GraphicConfig{
+		{Font: filepath.Join(t.TempDir(), "none.ttf")},
+		{Background: "black"},
+		{MenuLayout: "circle"},
+	} {
+		_, err := NewRenderer(NewMock(testSize), cfg)
+		assert.Error(t, err)
+	}
+}
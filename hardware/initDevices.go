@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/AlexTransit/vender/hardware/mdb/bill"
+	"github.com/AlexTransit/vender/hardware/mdb/cashless"
 	"github.com/AlexTransit/vender/hardware/mdb/coin"
 	"github.com/AlexTransit/vender/hardware/mdb/evend"
 	"github.com/AlexTransit/vender/helpers"
@@ -24,6 +25,8 @@ func InitMDBDevices(ctx context.Context) error {
 		switch rd.Name {
 		case "bill":
 			go helpers.WrapErrChan(&wg, errch, func() error { return bill.Enum(ctx) })
+		case "cashless":
+			go helpers.WrapErrChan(&wg, errch, func() error { return cashless.Enum(ctx) })
 		case "coin":
 			go coin.InitDevice(ctx)
 			wg.Done()
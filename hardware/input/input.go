@@ -20,6 +20,8 @@ type EventFunc func(types.InputEvent)
 type Dispatch struct {
 	Log *log2.Log
 	Bus chan types.InputEvent
+	// called on service key release before event delivery. used to interrupt running scenario
+	OnService EventFunc
 }
 
 func (d *Dispatch) InputChain() chan types.InputEvent {
@@ -57,6 +59,9 @@ func (d *Dispatch) ReadEvendKeyboard(s Source) {
 			fmt.Printf(" key event (%v)", event)
 		}
 
+		if event.Source == DevInputEventTag && event.Up && d.OnService != nil {
+			d.OnService(event)
+		}
 		if event.Source == DevInputEventTag || config_global.VMC.User.KeyboardReadEnable {
 			d.Log.Infof("key press (%s) ", kn)
 			d.Bus <- event
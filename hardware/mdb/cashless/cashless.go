@@ -0,0 +1,558 @@
+// Package cashless incapsulates work with MDB cashless devices (card readers, level 1 and 3).
+package cashless
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/mdb"
+	mdb_config "github.com/AlexTransit/vender/hardware/mdb/config"
+	"github.com/AlexTransit/vender/hardware/money"
+	"github.com/AlexTransit/vender/internal/state"
+
+	oerr "github.com/juju/errors"
+	"github.com/temoto/alive/v2"
+)
+
+const (
+	deviceAddress      = 0x10
+	defaultVendTimeout = 60 * time.Second
+	// funds 0xffff = reader does not know card balance (level 1 readers)
+	fundsUnknown uint16 = 0xffff
+)
+
+type CashlessDevice struct { //nolint:maligned
+	mdb.Device
+	pollmu        sync.Mutex // isolate active/idle polling
+	configScaling uint16
+	vendTimeout   time.Duration
+	reseted       bool
+
+	state CashlessState
+
+	// parsed from SETUP
+	featureLevel    uint8
+	countryCode     uint16
+	scalingFinal    currency.Nominal // mdb value * scalingFinal = currency.Amount
+	decimalPlaces   uint8
+	maxResponseTime time.Duration
+	miscOptions     uint8
+	manafacturer    string
+
+	// session
+	sessionFunds currency.Amount
+	vendApproved currency.Amount
+	teleError    func(error)
+}
+
+type CashlessState byte
+
+const (
+	noState CashlessState = iota
+	Broken
+	Disabled // reader inited, waiting reader enable
+	Enabled  // reader enabled, waiting card
+	SessionIdle
+	Vending
+)
+
+func (s CashlessState) String() string {
+	return [...]string{"noState", "Broken", "Disabled", "Enabled", "SessionIdle", "Vending"}[s]
+}
+
+// cashless poll response
+const (
+	StatusJustReset        byte = 0x00
+	StatusReaderConfig     byte = 0x01
+	StatusDisplayRequest   byte = 0x02
+	StatusBeginSession     byte = 0x03
+	StatusSessionCancel    byte = 0x04
+	StatusVendApproved     byte = 0x05
+	StatusVendDenied       byte = 0x06
+	StatusEndSession       byte = 0x07
+	StatusCancelled        byte = 0x08
+	StatusPeripheralID     byte = 0x09
+	StatusMalfunction      byte = 0x0a
+	StatusOutOfSequence    byte = 0x0b
+	StatusRevalueApproved  byte = 0x0d
+	StatusRevalueDenied    byte = 0x0e
+	StatusRevalueLimit     byte = 0x0f
+	StatusTimeDateRequest  byte = 0x11
+	StatusDataEntryRequest byte = 0x12
+)
+
+func (c *CashlessDevice) init(ctx context.Context) error {
+	const tag = deviceName + ".init"
+	g := state.GetGlobal(ctx)
+	mdbus, err := g.Mdb()
+	if err != nil {
+		return oerr.Annotate(err, tag)
+	}
+	c.teleError = func(err error) { g.Tele.Error(err) }
+	c.initDevice(mdbus, g.Config.Hardware.Mdb.Cashless)
+	g.Engine.RegisterNewFunc(
+		"cashless.reset",
+		func(ctx context.Context) error {
+			return c.CashlessReset()
+		},
+	)
+	return c.CashlessReset()
+}
+
+func (c *CashlessDevice) initDevice(mdbus *mdb.Bus, config mdb_config.CashlessStruct) {
+	c.Device.Init(mdbus, deviceAddress, deviceName, binary.BigEndian)
+	// cashless POLL is 0x12, not address+3
+	c.Device.PacketPoll = mdb.MustPacketFromBytes([]byte{deviceAddress + 2}, true)
+	c.configScaling = 100
+	if config.ScalingFactor != 0 {
+		c.configScaling = uint16(config.ScalingFactor)
+	}
+	c.vendTimeout = defaultVendTimeout
+	if config.VendTimeoutSec != 0 {
+		c.vendTimeout = time.Duration(config.VendTimeoutSec) * time.Second
+	}
+	if c.teleError == nil {
+		c.teleError = c.Device.TeleError
+	}
+}
+
+func (c *CashlessDevice) GetState() CashlessState { return c.state }
+
+func (c *CashlessDevice) SessionFunds() currency.Amount { return c.sessionFunds }
+
+func (c *CashlessDevice) CashlessReset() (err error) {
+	c.pollmu.Lock()
+	defer c.pollmu.Unlock()
+	c.reseted = false
+	c.setState(Broken)
+	if err = c.Device.Tx(c.Device.PacketReset, nil); err != nil {
+		return err
+	}
+	if err = c.pollF(nil); err != nil {
+		return err
+	}
+	if !c.reseted {
+		return errors.New("cashless. no complete reset response")
+	}
+	if err = c.setupConfig(); err != nil {
+		return err
+	}
+	if err = c.setupPrices(); err != nil {
+		return err
+	}
+	if err = c.expansionIdentification(); err != nil {
+		return err
+	}
+	c.Log.Info("cashless reset complete")
+	c.setState(Disabled)
+	return nil
+}
+
+// SETUP config data. VMC level 3, no display.
+func (c *CashlessDevice) setupConfig() error {
+	const tag = deviceName + ".setup"
+	const expectLength = 8
+	request := mdb.MustPacketFromHex("1100030000", true)
+	response := mdb.Packet{}
+	if err := c.Device.Tx(request, &response); err != nil {
+		return oerr.Annotate(err, tag)
+	}
+	bs := response.Bytes()
+	if len(bs) < expectLength || bs[0] != StatusReaderConfig {
+		return fmt.Errorf("%s response=%x expected %d bytes", tag, bs, expectLength)
+	}
+	c.parseReaderConfig(bs)
+	return nil
+}
+
+func (c *CashlessDevice) parseReaderConfig(bs []byte) {
+	c.featureLevel = bs[1]
+	c.countryCode = c.Device.ByteOrder.Uint16(bs[2:4])
+	scaleFactor := bs[4]
+	c.decimalPlaces = bs[5]
+	c.maxResponseTime = time.Duration(bs[6]) * time.Second
+	c.miscOptions = bs[7]
+	scalingFinal := currency.Nominal(scaleFactor) * currency.Nominal(c.configScaling)
+	for i := c.decimalPlaces; i > 0 && scalingFinal%10 == 0; i-- {
+		scalingFinal /= 10
+	}
+	c.scalingFinal = scalingFinal
+	c.Log.Debugf("cashless Feature Level: %d", c.featureLevel)
+	c.Log.Debugf("cashless Country / Currency Code: %x", c.countryCode)
+	c.Log.Debugf("cashless Scale Factor: %d Decimal Places: %d final scaling: %d", scaleFactor, c.decimalPlaces, scalingFinal)
+	c.Log.Debugf("cashless Max Response Time: %v Misc Options: %08b", c.maxResponseTime, c.miscOptions)
+}
+
+// SETUP max/min prices. price unknown.
+func (c *CashlessDevice) setupPrices() error {
+	request := mdb.MustPacketFromHex("1101ffff0000", true)
+	return oerr.Annotate(c.Device.Tx(request, nil), deviceName+".setup-prices")
+}
+
+func (c *CashlessDevice) expansionIdentification() error {
+	const tag = deviceName + ".ExpId"
+	const expectLength = 30
+	// VMC manufacturer(3) serial(12) model(12) software version(2)
+	buf := append([]byte{0x17, 0x00}, "VND000000000001vender      "...)
+	buf = append(buf, 0x01, 0x00)
+	request := mdb.MustPacketFromBytes(buf, true)
+	response := mdb.Packet{}
+	if err := c.Device.Tx(request, &response); err != nil {
+		return oerr.Annotate(err, tag)
+	}
+	bs := response.Bytes()
+	if len(bs) < expectLength || bs[0] != StatusPeripheralID {
+		c.Log.WarningF("%s response=%x length=%d expected=%d", tag, bs, len(bs), expectLength)
+		return nil
+	}
+	c.manafacturer = string(bs[1 : 1+3])
+	c.Log.Infof("%s Manufacturer Code: '%s'", tag, c.manafacturer)
+	c.Log.Debugf("%s Serial Number: '%s'", tag, string(bs[4:4+12]))
+	c.Log.Debugf("%s Model Number: '%s'", tag, string(bs[16:16+12]))
+	c.Log.Debugf("%s Software Version: %x", tag, bs[28:28+2])
+	return nil
+}
+
+func (c *CashlessDevice) readerEnable() error {
+	return c.Device.Tx(mdb.MustPacketFromHex("1401", true), nil)
+}
+
+func (c *CashlessDevice) readerDisable() error {
+	return c.Device.Tx(mdb.MustPacketFromHex("1400", true), nil)
+}
+
+// CashlessRun enable reader and poll it until alive stopped.
+// reader stays enabled if the session is open (vend will be requested later).
+func (c *CashlessDevice) CashlessRun(alive *alive.Alive, returnEvent func(money.ValidatorEvent)) {
+	if alive == nil {
+		returnEvent(money.ValidatorEvent{Err: errors.New("cashless run with nil alive")})
+		return
+	}
+	c.pollmu.Lock()
+	defer func() {
+		c.pollmu.Unlock()
+		alive.Done()
+	}()
+	switch c.state {
+	case Disabled:
+		if err := c.readerEnable(); err != nil {
+			returnEvent(money.ValidatorEvent{Err: err})
+			return
+		}
+		c.setState(Enabled)
+	case Enabled, SessionIdle:
+	default:
+		returnEvent(money.ValidatorEvent{Err: errors.New("cashless state not valid:" + c.state.String() + " need reset")})
+		return
+	}
+	stopRun := alive.StopChan()
+	refreshTime := time.Duration(200 * time.Millisecond)
+	refreshTimer := time.NewTimer(refreshTime)
+	again := true
+	for again {
+		select {
+		case <-stopRun:
+			again = false
+		case <-refreshTimer.C:
+			if err := c.pollF(returnEvent); err != nil {
+				returnEvent(money.ValidatorEvent{Err: err})
+				again = false
+			}
+			refreshTimer.Reset(refreshTime)
+		}
+	}
+	refreshTimer.Stop()
+	if c.state == Enabled {
+		if err := c.readerDisable(); err != nil {
+			c.Log.WarningF("cashless disable reader error:%v", err)
+		}
+		c.setState(Disabled)
+	}
+}
+
+// VendRequest ask the reader to approve the amount and wait for the bank answer.
+func (c *CashlessDevice) VendRequest(amount currency.Amount, item uint16) error {
+	c.pollmu.Lock()
+	defer c.pollmu.Unlock()
+	if c.state != SessionIdle {
+		return ErrNoSession
+	}
+	buf := [6]byte{0x13, 0x00}
+	c.Device.ByteOrder.PutUint16(buf[2:], c.amountToMdb(amount))
+	c.Device.ByteOrder.PutUint16(buf[4:], item)
+	if err := c.Device.Tx(mdb.MustPacketFromBytes(buf[:], true), nil); err != nil {
+		return err
+	}
+	c.vendApproved = 0
+	c.setState(Vending)
+	result := c.waitResult(c.vendTimeout, func(e money.ValidatorEvent) (error, bool) {
+		switch e.Event {
+		case money.CashlessVendApproved:
+			return nil, true
+		case money.CashlessVendDenied:
+			return ErrVendDenied, true
+		case money.CashlessSessionCancel, money.CashlessSessionEnd:
+			return ErrSessionCanceled, true
+		}
+		return nil, false
+	})
+	if errors.Is(result, ErrVendTimeout) {
+		// VEND CANCEL. reader must answer vend denied
+		_ = c.Device.Tx(mdb.MustPacketFromHex("1301", true), nil)
+		_ = c.pollF(nil)
+	}
+	if result != nil && c.state == Vending {
+		c.setState(SessionIdle)
+	}
+	return result
+}
+
+func (c *CashlessDevice) VendSuccess(item uint16) error {
+	c.pollmu.Lock()
+	defer c.pollmu.Unlock()
+	if c.state != Vending {
+		return ErrNoSession
+	}
+	buf := [4]byte{0x13, 0x02}
+	c.Device.ByteOrder.PutUint16(buf[2:], item)
+	err := c.Device.Tx(mdb.MustPacketFromBytes(buf[:], true), nil)
+	c.setState(SessionIdle)
+	return oerr.Annotate(err, deviceName+".vend-success")
+}
+
+// VendFailure reader must refund the approved amount.
+func (c *CashlessDevice) VendFailure() error {
+	c.pollmu.Lock()
+	defer c.pollmu.Unlock()
+	if c.state != Vending {
+		return ErrNoSession
+	}
+	err := c.Device.Tx(mdb.MustPacketFromHex("1303", true), nil)
+	c.setState(SessionIdle)
+	return oerr.Annotate(err, deviceName+".vend-failure")
+}
+
+// SessionComplete close session and wait reader end session.
+func (c *CashlessDevice) SessionComplete() error {
+	c.pollmu.Lock()
+	defer c.pollmu.Unlock()
+	switch c.state {
+	case SessionIdle, Vending:
+	default:
+		return nil
+	}
+	if err := c.Device.Tx(mdb.MustPacketFromHex("1304", true), nil); err != nil {
+		return oerr.Annotate(err, deviceName+".session-complete")
+	}
+	err := c.waitResult(c.responseTimeout(), func(e money.ValidatorEvent) (error, bool) {
+		return nil, e.Event == money.CashlessSessionEnd
+	})
+	c.sessionFunds = 0
+	c.vendApproved = 0
+	if c.state != Broken {
+		if e := c.readerDisable(); e != nil {
+			c.Log.WarningF("cashless disable reader error:%v", e)
+		}
+		c.setState(Disabled)
+	}
+	return err
+}
+
+// Revalue return money to the card (level 2+).
+func (c *CashlessDevice) Revalue(amount currency.Amount) error {
+	const tag = deviceName + ".revalue"
+	c.pollmu.Lock()
+	defer c.pollmu.Unlock()
+	if c.featureLevel < 2 {
+		return mdb.FeatureNotSupported(tag + " is level 1")
+	}
+	if c.state != SessionIdle {
+		return ErrNoSession
+	}
+	buf := [4]byte{0x15, 0x00}
+	c.Device.ByteOrder.PutUint16(buf[2:], c.amountToMdb(amount))
+	if err := c.Device.Tx(mdb.MustPacketFromBytes(buf[:], true), nil); err != nil {
+		return oerr.Annotate(err, tag)
+	}
+	return c.waitResult(c.responseTimeout(), func(e money.ValidatorEvent) (error, bool) {
+		switch e.Event {
+		case money.CashlessRevalueApproved:
+			return nil, true
+		case money.CashlessRevalueDenied:
+			return ErrRevalueDenied, true
+		}
+		return nil, false
+	})
+}
+
+func (c *CashlessDevice) responseTimeout() time.Duration {
+	if c.maxResponseTime > 0 {
+		return c.maxResponseTime
+	}
+	return 5 * time.Second
+}
+
+// poll reader until check returns done or timeout
+func (c *CashlessDevice) waitResult(timeout time.Duration, check func(money.ValidatorEvent) (error, bool)) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		var result error
+		done := false
+		err := c.pollF(func(e money.ValidatorEvent) {
+			if !done {
+				result, done = check(e)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if done {
+			return result
+		}
+		time.Sleep(c.Device.DelayNext)
+	}
+	return ErrVendTimeout
+}
+
+// poll function.
+// возвращает события и объедененную ошибку
+func (c *CashlessDevice) pollF(returnEvent func(money.ValidatorEvent)) (err error) {
+	var response mdb.Packet
+	if err = c.Device.Tx(c.Device.PacketPoll, &response); err != nil {
+		c.Log.WarningF("cashless poll TX error:%v", err)
+		return err
+	}
+	rb := response.Bytes()
+	for len(rb) > 0 {
+		e, n := c.decode(rb)
+		rb = rb[n:]
+		if returnEvent != nil && (e.Event != money.NoEvent || e.Err != nil) {
+			returnEvent(e)
+		}
+		if e.Err != nil {
+			err = errors.Join(err, e.Err)
+		}
+	}
+	return err
+}
+
+// decode one poll event. returns event and consumed bytes count.
+func (c *CashlessDevice) decode(rb []byte) (e money.ValidatorEvent, n int) {
+	switch rb[0] {
+	case StatusJustReset:
+		c.reseted = true
+		return e, 1
+	case StatusReaderConfig:
+		if len(rb) < 8 {
+			return c.badLength(rb)
+		}
+		c.parseReaderConfig(rb[:8])
+		return e, 8
+	case StatusDisplayRequest:
+		if len(rb) < 2 {
+			return c.badLength(rb)
+		}
+		return e, len(rb) // display data fills the rest of response
+	case StatusBeginSession:
+		n = 3
+		if c.featureLevel >= 3 {
+			n = 10
+		}
+		if len(rb) < 3 {
+			return c.badLength(rb)
+		}
+		if len(rb) < n {
+			n = len(rb)
+		}
+		c.sessionFunds = c.amountFromMdb(c.Device.ByteOrder.Uint16(rb[1:3]))
+		c.setState(SessionIdle)
+		c.Log.Infof("cashless begin session funds:%s", c.sessionFunds.Format100I())
+		return money.ValidatorEvent{Event: money.CashlessSessionBegin, Nominal: currency.Nominal(c.sessionFunds)}, n
+	case StatusSessionCancel:
+		c.Log.Info("cashless session cancel request")
+		return money.ValidatorEvent{Event: money.CashlessSessionCancel}, 1
+	case StatusVendApproved:
+		if len(rb) < 3 {
+			return c.badLength(rb)
+		}
+		c.vendApproved = c.amountFromMdb(c.Device.ByteOrder.Uint16(rb[1:3]))
+		c.Log.Infof("cashless vend approved (%s)", c.vendApproved.Format100I())
+		return money.ValidatorEvent{Event: money.CashlessVendApproved, Nominal: currency.Nominal(c.vendApproved)}, 3
+	case StatusVendDenied:
+		c.Log.Info("cashless vend denied")
+		return money.ValidatorEvent{Event: money.CashlessVendDenied}, 1
+	case StatusEndSession:
+		c.sessionFunds = 0
+		if c.state == SessionIdle || c.state == Vending {
+			c.setState(Enabled)
+		}
+		return money.ValidatorEvent{Event: money.CashlessSessionEnd}, 1
+	case StatusCancelled:
+		return e, 1
+	case StatusPeripheralID:
+		return e, len(rb)
+	case StatusMalfunction:
+		if len(rb) < 2 {
+			return c.badLength(rb)
+		}
+		return c.setBroken(fmt.Errorf("malfunction code:%02x", rb[1])), 2
+	case StatusOutOfSequence:
+		c.Log.WarningF("cashless command out of sequence (%x)", rb)
+		if c.featureLevel >= 2 && len(rb) >= 2 {
+			return e, 2
+		}
+		return e, 1
+	case StatusRevalueApproved:
+		return money.ValidatorEvent{Event: money.CashlessRevalueApproved}, 1
+	case StatusRevalueDenied:
+		return money.ValidatorEvent{Event: money.CashlessRevalueDenied}, 1
+	case StatusRevalueLimit:
+		if len(rb) < 3 {
+			return c.badLength(rb)
+		}
+		return e, 3
+	case StatusTimeDateRequest, StatusDataEntryRequest:
+		c.Log.Debugf("cashless ignore request (%x)", rb)
+		return e, len(rb)
+	}
+	c.Log.Errorf("cashless unknown poll response (%x)", rb)
+	return e, len(rb)
+}
+
+func (c *CashlessDevice) badLength(rb []byte) (money.ValidatorEvent, int) {
+	return money.ValidatorEvent{Err: fmt.Errorf("cashless poll response=%x too short", rb)}, len(rb)
+}
+
+func (c *CashlessDevice) setBroken(err error) money.ValidatorEvent {
+	err = errors.Join(errors.New("cashless broken - "), err)
+	c.teleError(err)
+	c.setState(Broken)
+	return money.ValidatorEvent{Err: err}
+}
+
+func (c *CashlessDevice) setState(s CashlessState) {
+	c.state = s
+}
+
+func (c *CashlessDevice) scaling() currency.Nominal {
+	if c.scalingFinal == 0 {
+		return 1
+	}
+	return c.scalingFinal
+}
+
+func (c *CashlessDevice) amountFromMdb(v uint16) currency.Amount {
+	if v == fundsUnknown {
+		return currency.MaxAmount
+	}
+	return currency.Amount(v) * currency.Amount(c.scaling())
+}
+
+func (c *CashlessDevice) amountToMdb(a currency.Amount) uint16 {
+	return uint16(a / currency.Amount(c.scaling()))
+}
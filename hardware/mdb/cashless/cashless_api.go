@@ -0,0 +1,66 @@
+package cashless
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/money"
+	"github.com/AlexTransit/vender/internal/state"
+	"github.com/temoto/alive/v2"
+)
+
+const deviceName = "cashless"
+
+var (
+	ErrNoSession       = errors.New("cashless. no session")
+	ErrVendDenied      = errors.New("cashless. vend denied")
+	ErrVendTimeout     = errors.New("cashless. vend timeout")
+	ErrSessionCanceled = errors.New("cashless. session canceled")
+	ErrRevalueDenied   = errors.New("cashless. revalue denied")
+)
+
+func Enum(ctx context.Context) error {
+	g := state.GetGlobal(ctx)
+	dev := &CashlessDevice{}
+	return g.RegisterDevice(deviceName, dev, func() error { return dev.init(ctx) })
+}
+
+type Cashless interface {
+	CashlessRun(*alive.Alive, func(money.ValidatorEvent))
+	CashlessReset() error
+	GetState() CashlessState
+	SessionFunds() currency.Amount
+	VendRequest(amount currency.Amount, item uint16) error
+	VendSuccess(item uint16) error
+	VendFailure() error
+	SessionComplete() error
+	Revalue(amount currency.Amount) error
+}
+
+var _ Cashless = &CashlessDevice{}
+var _ Cashless = Stub{}
+
+type Stub struct{}
+
+func (Stub) CashlessRun(alive *alive.Alive, _ func(money.ValidatorEvent)) {
+	if alive != nil {
+		alive.Done()
+	}
+}
+
+func (Stub) CashlessReset() error { return nil }
+
+func (Stub) GetState() CashlessState { return noState }
+
+func (Stub) SessionFunds() currency.Amount { return 0 }
+
+func (Stub) VendRequest(currency.Amount, uint16) error { return ErrNoSession }
+
+func (Stub) VendSuccess(uint16) error { return nil }
+
+func (Stub) VendFailure() error { return nil }
+
+func (Stub) SessionComplete() error { return nil }
+
+func (Stub) Revalue(currency.Amount) error { return ErrNoSession }
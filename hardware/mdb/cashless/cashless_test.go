@@ -0,0 +1,146 @@
+package cashless
+
+import (
+	"testing"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/mdb"
+	mdb_config "github.com/AlexTransit/vender/hardware/mdb/config"
+	"github.com/AlexTransit/vender/hardware/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temoto/alive/v2"
+)
+
+func newTestCashless(t *testing.T) (*CashlessDevice, *mdb.MockUart) {
+	bus, mock := mdb.NewMockBus(t)
+	c := &CashlessDevice{}
+	c.initDevice(bus, mdb_config.CashlessStruct{})
+	go mock.Expect([]mdb.MockR{
+		{"10", ""},
+		{"12", "00"},
+		{"1100030000", "0103164301020500"},
+		{"1101ffff0000", ""},
+		{"1700564e4430303030303030303030303176656e6465722020202020200100", ""},
+	})
+	require.NoError(t, c.CashlessReset())
+	return c, mock
+}
+
+// enable reader, wait card
+func beginSession(t *testing.T, c *CashlessDevice, mock *mdb.MockUart) {
+	go mock.Expect([]mdb.MockR{
+		{"1401", ""},
+		{"12", ""},
+		{"12", "0300c8ffffffff000000"},
+	})
+	a := alive.NewAlive()
+	a.Add(1)
+	var events []money.ValidatorEvent
+	c.CashlessRun(a, func(e money.ValidatorEvent) {
+		events = append(events, e)
+		if e.Event == money.CashlessSessionBegin {
+			a.Stop()
+		}
+	})
+	a.Wait()
+	require.Len(t, events, 1)
+	assert.Equal(t, currency.Nominal(200), events[0].Nominal)
+	assert.Equal(t, SessionIdle, c.GetState())
+}
+
+func TestCashlessReset(t *testing.T) {
+	t.Parallel()
+	c, mock := newTestCashless(t)
+	defer mock.Close()
+	assert.Equal(t, Disabled, c.GetState())
+	assert.Equal(t, uint8(3), c.featureLevel)
+	assert.Equal(t, currency.Nominal(1), c.scalingFinal)
+}
+
+func TestCashlessVendApproved(t *testing.T) {
+	t.Parallel()
+	c, mock := newTestCashless(t)
+	defer mock.Close()
+	beginSession(t, c, mock)
+	assert.Equal(t, currency.Amount(200), c.SessionFunds())
+
+	go mock.Expect([]mdb.MockR{
+		{"130000960003", ""},
+		{"12", ""},
+		{"12", "050096"},
+	})
+	require.NoError(t, c.VendRequest(150, 3))
+	assert.Equal(t, Vending, c.GetState())
+	assert.Equal(t, currency.Amount(150), c.vendApproved)
+
+	go mock.Expect([]mdb.MockR{
+		{"13020003", ""},
+		{"1304", ""},
+		{"12", "07"},
+		{"1400", ""},
+	})
+	require.NoError(t, c.VendSuccess(3))
+	require.NoError(t, c.SessionComplete())
+	assert.Equal(t, Disabled, c.GetState())
+	assert.Equal(t, currency.Amount(0), c.SessionFunds())
+}
+
+func TestCashlessVendDenied(t *testing.T) {
+	t.Parallel()
+	c, mock := newTestCashless(t)
+	defer mock.Close()
+	beginSession(t, c, mock)
+
+	go mock.Expect([]mdb.MockR{
+		{"130000960003", ""},
+		{"12", "06"},
+	})
+	require.Equal(t, ErrVendDenied, c.VendRequest(150, 3))
+	assert.Equal(t, SessionIdle, c.GetState())
+
+	go mock.Expect([]mdb.MockR{
+		{"1304", ""},
+		{"12", "07"},
+		{"1400", ""},
+	})
+	require.NoError(t, c.SessionComplete())
+}
+
+func TestCashlessRevalue(t *testing.T) {
+	t.Parallel()
+	c, mock := newTestCashless(t)
+	defer mock.Close()
+	beginSession(t, c, mock)
+
+	go mock.Expect([]mdb.MockR{
+		{"15000032", ""},
+		{"12", "0d"},
+	})
+	require.NoError(t, c.Revalue(50))
+
+	go mock.Expect([]mdb.MockR{
+		{"15000032", ""},
+		{"12", "0e"},
+	})
+	require.Equal(t, ErrRevalueDenied, c.Revalue(50))
+
+	go mock.Expect([]mdb.MockR{
+		{"1304", ""},
+		{"12", "07"},
+		{"1400", ""},
+	})
+	require.NoError(t, c.SessionComplete())
+}
+
+func TestCashlessMalfunction(t *testing.T) {
+	t.Parallel()
+	c, mock := newTestCashless(t)
+	defer mock.Close()
+
+	go mock.Expect([]mdb.MockR{
+		{"12", "0a12"},
+	})
+	require.Error(t, c.pollF(nil))
+	assert.Equal(t, Broken, c.GetState())
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/AlexTransit/vender/currency"
 	"github.com/AlexTransit/vender/hardware/mdb"
 	"github.com/AlexTransit/vender/hardware/money"
+	"github.com/AlexTransit/vender/helpers"
 	"github.com/AlexTransit/vender/internal/engine"
 	"github.com/AlexTransit/vender/internal/state"
 	"github.com/temoto/alive/v2"
@@ -130,7 +131,7 @@ func InitDevice(ctx context.Context) error {
 	)
 	g.Engine.Register("coin.dispence(?)",
 		engine.FuncArg{Name: "coin.dispence", F: func(ctx context.Context, arg engine.Arg) (err error) {
-			err = ca.Dispense(currency.Amount(arg.(int16)))
+			err = ca.Dispense(ctx, currency.Amount(arg.(int16)))
 			return err
 		}})
 	err = ca.CoinReset()
@@ -162,22 +163,22 @@ func fullOrNo(tubefull bool) string {
 }
 
 // test dispense. dispence all nominals
-func (ca *CoinAcceptor) TestingDispense() {
+func (ca *CoinAcceptor) TestingDispense(ctx context.Context) {
 	for _, n := range ca.Tub {
 		// fmt.Printf("\033[41m %v %v \033[0m\n", i, n.nominal)
-		_, _ = ca.DispenceCoin(n.Nominal)
+		_, _ = ca.DispenceCoin(ctx, n.Nominal)
 	}
 }
 
-func (ca *CoinAcceptor) ReturnMoney(amount currency.Amount) (err error) {
+func (ca *CoinAcceptor) ReturnMoney(ctx context.Context, amount currency.Amount) (err error) {
 	strategy := ca.dispenseStrategy
 	ca.dispenseStrategy = maximumAvailable
-	err = ca.Dispense(amount)
+	err = ca.Dispense(ctx, amount)
 	ca.dispenseStrategy = strategy
 	return err
 }
 
-func (ca *CoinAcceptor) Dispense(amount currency.Amount) (err error) {
+func (ca *CoinAcceptor) Dispense(ctx context.Context, amount currency.Amount) (err error) {
 	if amount == 0 {
 		return nil
 	}
@@ -196,9 +197,12 @@ func (ca *CoinAcceptor) Dispense(amount currency.Amount) (err error) {
 			dispenseNominal, e = ca.maximumAvailableNominal(dispenceAmount)
 		}
 		err = errors.Join(err, e)
-		_, e = ca.DispenceCoin(dispenseNominal)
+		_, e = ca.DispenceCoin(ctx, dispenseNominal)
 		m = m + dispenseNominal.Format100I() + " "
 		err = errors.Join(err, e)
+		if ctx.Err() != nil {
+			break
+		}
 		if dispenceAmount <= currency.Amount(dispenseNominal) {
 			break
 		}
@@ -248,7 +252,7 @@ func (ca *CoinAcceptor) maximumAvailableNominal(notMore currency.Amount) (n curr
 	return n, fmt.Errorf("return bigged need:%s returned:%s", notMore.Format100I(), n.Format100I())
 }
 
-func (ca *CoinAcceptor) DispenceCoin(nominal currency.Nominal) (complete bool, err error) {
+func (ca *CoinAcceptor) DispenceCoin(ctx context.Context, nominal currency.Nominal) (complete bool, err error) {
 	if err = ca.ReadTubeStatus(); err != nil {
 		return false, err
 	}
@@ -267,7 +271,9 @@ func (ca *CoinAcceptor) DispenceCoin(nominal currency.Nominal) (complete bool, e
 	// timeout poll dispense 1 coin
 	var errp error
 	for i := 0; i < 50; i++ {
-		time.Sleep(500 * time.Millisecond)
+		if e := helpers.SleepCtx(ctx, 500*time.Millisecond); e != nil {
+			return false, errors.Join(err, fmt.Errorf("coin dispense %s interrupted: %w", nominal.Format100I(), e))
+		}
 		var emptyResponse bool
 		emptyResponse, errp = ca.pollF(nil)
 		err = errors.Join(err, errp)
@@ -6,10 +6,12 @@ type Config struct { //nolint:maligned
 	// RU: Конфигурация для купюроприемника.
 	Bill BillStruct `hcl:"bill,block"`
 	// RU: Конфигурация для монетоприемника.
-	Coin       CoinStruct `hcl:"coin,block"`
-	LogDebug   bool       `hcl:"log_debug,optional"`
-	UartDevice string     `hcl:"uart_device,optional"`
-	UartDriver string     `hcl:"uart_driver"` // file|mega|iodin|dummy
+	Coin CoinStruct `hcl:"coin,block"`
+	// RU: Конфигурация для безналичного считывателя (MDB cashless level 1/3).
+	Cashless   CashlessStruct `hcl:"cashless,block"`
+	LogDebug   bool           `hcl:"log_debug,optional"`
+	UartDevice string         `hcl:"uart_device,optional"`
+	UartDriver string         `hcl:"uart_driver"` // file|mega|iodin|dummy|sim
 }
 
 type BillStruct struct {
@@ -20,3 +22,10 @@ type CoinStruct struct {
 	// RU: Стратегия выдачи сдачи. 0 = равномерная выдача (стараемся держать одинаковое количество монет в каждой тубе), 1 = сначала полная трубка (если туба полная то выдаем из нее. далее выдаем минимальным количеством монет), 2 = минимальное количество монет (выдаем минимальным количеством монет).
 	DispenseStrategy int `hcl:"dispense_strategy,optional"` // 0 = uniform dispensing, 1 = first ful tube, 2 = minimal coins
 }
+
+type CashlessStruct struct {
+	// RU: множитель суммы. по умолчанию 100 (как у купюроприемника).
+	ScalingFactor int `hcl:"scaling_factor,optional"`
+	// RU: сколько секунд ждать ответа банка на запрос продажи. по умолчанию 60.
+	VendTimeoutSec int `hcl:"vend_timeout_sec,optional"`
+}
@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/AlexTransit/vender/helpers"
 	"github.com/AlexTransit/vender/internal/engine"
 	"github.com/AlexTransit/vender/internal/types"
 	"github.com/AlexTransit/vender/log2"
@@ -275,9 +276,11 @@ func (dev *Device) NewFunLoop(tag string, fun PollFunc, timeout time.Duration) e
 			if timeout == 0 {
 				return errors.Errorf("tag=%s timeout=0 invalid", tag)
 			}
-			time.Sleep(dev.DelayNext)
+			if err = helpers.SleepCtx(ctx, dev.DelayNext); err != nil {
+				return errors.Annotate(err, tag)
+			}
 			if time.Since(tbegin) > timeout {
-				err = errors.Timeoutf(tag)
+				err = &helpers.AppError{ErrorCode: engine.ErrCodeTimeout, Err: errors.Timeoutf(tag)}
 				dev.SetError(err)
 				return err
 			}
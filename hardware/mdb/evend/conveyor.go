@@ -38,49 +38,60 @@ func (c *DeviceConveyor) init(ctx context.Context) error {
 	c.Generic.Init(ctx, 0xd8, "conveyor", proto2)
 	g.Engine.RegisterNewFuncAgr(c.name+".set_speed(?)", func(ctx context.Context, speed engine.Arg) error { return c.setSpeed(uint8(speed.(int16))) })
 	g.Engine.RegisterNewFuncAgr("pc(?)", func(ctx context.Context, poolCount engine.Arg) error {
-		return c.WaitSuccess(uint16(poolCount.(int16)), false)
+		return c.WaitSuccess(ctx, uint16(poolCount.(int16)), false)
 	})
 
 	g.Engine.RegisterNewFuncAgr(c.name+".moveNoWait(?)", func(ctx context.Context, position engine.Arg) error {
-		return c.moveNoWait(position.(int16))
+		return c.moveNoWait(ctx, position.(int16))
 	})
-	g.Engine.RegisterNewFunc(c.name+".movingDone", func(ctx context.Context) error { return c.movingDone() })
+	g.Engine.RegisterNewFunc(c.name+".movingDone", func(ctx context.Context) error { return c.movingDone(ctx) })
 	g.Engine.RegisterNewFunc(c.name+".status", func(ctx context.Context) error {
 		g.Log.Infof("%s.position:%d speed:%d", c.name, c.position, c.speed)
 		return nil
 	})
 	g.Engine.RegisterNewFunc(c.name+".reset", func(ctx context.Context) error { return c.reset() })
-	g.Engine.RegisterNewFuncAgr(c.name+".move(?)", func(ctx context.Context, position engine.Arg) error { return c.move(int16(position.(int16))) })
+	// conveyor.move(300) conveyor.move(pos=300, speed=80). speed -1 = keep current
+	g.Engine.RegisterNewFuncArgs(c.name+".move", []engine.Param{
+		{Name: "pos", Type: engine.ArgInt},
+		{Name: "speed", Type: engine.ArgInt, Default: int16(-1)},
+	}, func(ctx context.Context, args engine.Args) error {
+		if speed := args.Int("speed"); speed >= 0 && int8(speed) != c.speed {
+			if err := c.setSpeed(uint8(speed)); err != nil {
+				return err
+			}
+		}
+		return c.move(ctx, args.Int("pos"))
+	})
 	g.Engine.RegisterNewFuncAgr(c.name+".shake(?)", func(ctx context.Context, cnt engine.Arg) error {
-		return c.CommandWaitSuccess(uint16(cnt.(int16))*2*5, commandWaitAndShake, byte(cnt.(int16)), 0)
+		return c.CommandWaitSuccess(ctx, uint16(cnt.(int16))*2*5, commandWaitAndShake, byte(cnt.(int16)), 0)
 	})
 	g.Engine.RegisterNewFuncAgr(c.name+".vibrate(?)", func(ctx context.Context, cnt engine.Arg) error {
-		return c.CommandWaitSuccess(uint16(cnt.(int16))*2*5, commandShake, byte(cnt.(int16)), 0)
+		return c.CommandWaitSuccess(ctx, uint16(cnt.(int16))*2*5, commandShake, byte(cnt.(int16)), 0)
 	})
 
 	return c.reset()
 }
 
-func (c *DeviceConveyor) moveNoWait(position int16) error {
+func (c *DeviceConveyor) moveNoWait(ctx context.Context, position int16) error {
 	c.newPosition = position
 	c.position = -1
-	return c.CommandNoWait(commandMove, byte(position&0xff), byte(position>>8))
+	return c.CommandNoWait(ctx, commandMove, byte(position&0xff), byte(position>>8))
 }
 
-func (c *DeviceConveyor) move(position int16) (err error) {
+func (c *DeviceConveyor) move(ctx context.Context, position int16) (err error) {
 	if c.position == -1 {
-		c.CommandWaitSuccess(c.timeout, commandMove, byte(0x00), byte(0x00))
+		c.CommandWaitSuccess(ctx, c.timeout, commandMove, byte(0x00), byte(0x00))
 		c.position = 0
 	}
 	if c.position == position {
 		return nil
 	}
-	c.moveNoWait(position)
-	return c.movingDone()
+	c.moveNoWait(ctx, position)
+	return c.movingDone(ctx)
 }
 
-func (c *DeviceConveyor) movingDone() (err error) {
-	if err = c.WaitSuccess(c.timeout, true); err != nil {
+func (c *DeviceConveyor) movingDone(ctx context.Context) (err error) {
+	if err = c.WaitSuccess(ctx, c.timeout, true); err != nil {
 		return err
 	}
 	c.position = c.newPosition
@@ -2,10 +2,9 @@ package evend
 
 import (
 	"context"
-	"regexp"
-	"strconv"
 	"time"
 
+	"github.com/AlexTransit/vender/helpers"
 	"github.com/AlexTransit/vender/internal/engine"
 	"github.com/AlexTransit/vender/internal/state"
 )
@@ -32,48 +31,48 @@ func (c *DeviceCup) init(ctx context.Context) error {
 	g := state.GetGlobal(ctx)
 	c.initLightSheduler(g.Config.UI_config.Front.LightShedule)
 	c.timeout = 50 * 5 // 40 second max time for cup dispensing
-	g.Engine.RegisterNewFunc(c.name+".ensure", func(ctx context.Context) error { return c.CommandWaitSuccess(c.timeout, 0x04) })
-	g.Engine.RegisterNewFunc(c.name+".ensureNoWait", func(ctx context.Context) error { return c.CommandNoWait(0x04) })
-	g.Engine.RegisterNewFuncAgr(c.name+".dispense(?)", func(ctx context.Context, _ engine.Arg) error { return c.CommandWaitSuccess(c.timeout, 0x01) })
-	g.Engine.RegisterNewFuncAgr(c.name+".dispenseNoWait(?)", func(ctx context.Context, _ engine.Arg) error { return c.CommandNoWait(0x01) })
-	g.Engine.RegisterNewFunc(c.name+".wait_complete", func(ctx context.Context) error { return c.WaitSuccess(c.timeout, true) })
-	g.Engine.RegisterNewFunc(c.name+".wait_completeNoWait", func(ctx context.Context) error { return c.Proto2PollWaitSuccess(5, true) })
-	g.Engine.RegisterNewFunc(c.name+".light_on", func(ctx context.Context) error { return c.LightOn() })
-	g.Engine.RegisterNewFunc(c.name+".light_off", func(ctx context.Context) error { return c.LightOff() })
+	g.Engine.RegisterNewFunc(c.name+".ensure", func(ctx context.Context) error { return c.CommandWaitSuccess(ctx, c.timeout, 0x04) })
+	g.Engine.RegisterNewFunc(c.name+".ensureNoWait", func(ctx context.Context) error { return c.CommandNoWait(ctx, 0x04) })
+	g.Engine.RegisterNewFuncAgr(c.name+".dispense(?)", func(ctx context.Context, _ engine.Arg) error { return c.CommandWaitSuccess(ctx, c.timeout, 0x01) })
+	g.Engine.RegisterNewFuncAgr(c.name+".dispenseNoWait(?)", func(ctx context.Context, _ engine.Arg) error { return c.CommandNoWait(ctx, 0x01) })
+	g.Engine.RegisterNewFunc(c.name+".wait_complete", func(ctx context.Context) error { return c.WaitSuccess(ctx, c.timeout, true) })
+	g.Engine.RegisterNewFunc(c.name+".wait_completeNoWait", func(ctx context.Context) error { return c.Proto2PollWaitSuccess(ctx, 5, true) })
+	g.Engine.RegisterNewFunc(c.name+".light_on", func(ctx context.Context) error { return c.LightOn(ctx) })
+	g.Engine.RegisterNewFunc(c.name+".light_off", func(ctx context.Context) error { return c.LightOff(ctx) })
 	g.Engine.RegisterNewFunc(c.name+".reset", func(ctx context.Context) error { return c.dev.Rst() })
-	g.Engine.RegisterNewFunc(c.name+".light_on_schedule", func(ctx context.Context) error { return c.lightOnSchedule() })
+	g.Engine.RegisterNewFunc(c.name+".light_on_schedule", func(ctx context.Context) error { return c.lightOnSchedule(ctx) })
 
 	return c.dev.Rst()
 }
 
-func (c *DeviceCup) lightOnSchedule() error {
+func (c *DeviceCup) lightOnSchedule(ctx context.Context) error {
 	if !c.lightShouldWork() {
 		if c.light {
-			return c.LightOff()
+			return c.LightOff(ctx)
 		}
 		return nil
 	}
-	return c.LightOn()
+	return c.LightOn(ctx)
 }
 
-func (c *DeviceCup) LightOn() error {
+func (c *DeviceCup) LightOn(ctx context.Context) error {
 	if c.light {
 		return nil
 	}
 	c.dev.Log.Info("light on")
 	c.Command(0x02)
 	c.light = true
-	return c.WaitSuccess(c.timeout, false)
+	return c.WaitSuccess(ctx, c.timeout, false)
 }
 
-func (c *DeviceCup) LightOff() error {
+func (c *DeviceCup) LightOff(ctx context.Context) error {
 	if !c.light {
 		return nil
 	}
 	c.dev.Log.Info("light off")
 	c.light = false
 	c.Command(0x03)
-	return c.WaitSuccess(c.timeout, false)
+	return c.WaitSuccess(ctx, c.timeout, false)
 }
 
 // sheduler front light
@@ -87,46 +86,19 @@ func (c *DeviceCup) LightOff() error {
 // 3,4 - begin hours, minutes
 // 5,6 - end hours, minutes
 func (c *DeviceCup) initLightSheduler(sh string) {
-	wd := `([0-6]|\*)[-]?([0-6])? ([01]?[0-9]|2[0-3]):([0-5][0-9])-([01]?[0-9]|2[0-3]):([0-5][0-9])`
-	cmd := regexp.MustCompile(wd)
-
-	parts := cmd.FindAllStringSubmatch(sh, 7)
-	if (sh != "") && (len(parts) == 0) {
+	ws, err := helpers.ParseWeekSchedule(sh)
+	if err != nil {
 		c.dev.Log.WarningF("light shedule string error: %s", sh)
 		return
 	}
-	for _, v := range parts {
-		c.dev.Log.Infof("add light shedule %v", v[0])
-		switch v[1] {
-		case "*":
-			for i := 0; i < 7; i++ {
-				c.writeShedule(i, v)
-			}
-		default:
-			w, _ := strconv.Atoi(v[1])
-			if v[2] == "" {
-				c.writeShedule(w, v)
-			} else {
-				e, _ := strconv.Atoi(v[2])
-				for i := w; i <= e; i++ {
-					c.writeShedule(i, v)
-				}
-			}
+	c.dev.Log.Infof("add light shedule %s", sh)
+	for i, w := range ws {
+		if w.Set {
+			c.lightShedule.weekDay[i] = worktime{BeginOfWork: w.Begin, EndOfWork: w.End}
 		}
 	}
 }
 
-func (s *DeviceCup) writeShedule(w int, v []string) {
-	s.lightShedule.weekDay[w].BeginOfWork = textTimeToDudation(v[3], v[4])
-	s.lightShedule.weekDay[w].EndOfWork = textTimeToDudation(v[5], v[6])
-}
-
-func textTimeToDudation(hours string, minutes string) time.Duration {
-	h, _ := strconv.Atoi(hours)
-	m, _ := strconv.Atoi(minutes)
-	return time.Hour*time.Duration(h) + time.Minute*time.Duration(m)
-}
-
 func (s *DeviceCup) lightShouldWork() bool {
 	t := time.Now()
 	w := t.Weekday()
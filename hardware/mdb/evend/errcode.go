@@ -0,0 +1,103 @@
+package evend
+
+import (
+	"fmt"
+
+	"github.com/AlexTransit/vender/helpers"
+	"github.com/AlexTransit/vender/internal/engine"
+)
+
+// device error codes. AppError.ErrorCode, match in alias onError "code regexp" { scenario = "..." }
+// 1..89 firmware codes (evend_hardware.md), read with base+4 02 when POLL has problem bit 08
+// 90..99 detected by vender
+const (
+	ErrCodeNoCups               int32 = 21
+	ErrCodeNoWater              int32 = 22
+	ErrCodeStepperDontMove      int32 = 23
+	ErrCodeMotorDisconnected    int32 = 31
+	ErrCodeMotorHighLoad        int32 = 32
+	ErrCodeShakerDisconnected   int32 = 33
+	ErrCodeShakerHighLoad       int32 = 34
+	ErrCodeReverseDisconnected  int32 = 35
+	ErrCodeReverseHighLoad      int32 = 36
+	ErrCodeReverseTopSensor     int32 = 37
+	ErrCodeReverseBottomSensor  int32 = 38
+	ErrCodeReverseNotInTop      int32 = 39
+	ErrCodeCupDropperDisconnect int32 = 51
+	ErrCodeCupDropperHighLoad   int32 = 52
+	ErrCodeCupDropperSensor     int32 = 53
+	ErrCodeCupLoaderDisconnect  int32 = 54
+	ErrCodeCupLoaderHighLoad    int32 = 55
+	ErrCodeCupLoaderSensor      int32 = 56
+	ErrCodeTabletPressedSensor  int32 = 60
+	ErrCodeTabletThrowedSensor  int32 = 61
+	ErrCodeNoCoffee             int32 = 62
+	ErrCodeDoserSensorOn        int32 = 63
+	ErrCodeDoserDisconnected    int32 = 64
+	ErrCodeDoserHighLoad        int32 = 65
+	ErrCodeHotTempSensorMissing int32 = 71
+	ErrCodeTempSensorCRC        int32 = 72
+	ErrCodeTempSensorNotStart   int32 = 73
+	ErrCodeCupNotDetected       int32 = 80
+
+	ErrCodeUnexpected     int32 = 90 // unexpected POLL response
+	ErrCodeConnectionLost int32 = 91 // device restarted while executing command (POLL bit 04)
+	ErrCodeProblemUnknown int32 = 92 // problem bit set, but error code not read
+	ErrCodeInvalidTemp    int32 = 93 // valve reports impossible temperature
+	ErrCodeNoResponse     int32 = 94 // transmit failed after resend
+	ErrCodeTimeout              = engine.ErrCodeTimeout
+)
+
+var errCodeNames = map[int32]string{
+	ErrCodeNoCups:               "no cups",
+	ErrCodeNoWater:              "no water",
+	ErrCodeStepperDontMove:      "stepper don't move",
+	ErrCodeMotorDisconnected:    "motor disconnected",
+	ErrCodeMotorHighLoad:        "motor high load",
+	ErrCodeShakerDisconnected:   "shaker disconnected",
+	ErrCodeShakerHighLoad:       "shaker high load",
+	ErrCodeReverseDisconnected:  "reverse disconnected",
+	ErrCodeReverseHighLoad:      "reverse high load",
+	ErrCodeReverseTopSensor:     "reverse top sensor",
+	ErrCodeReverseBottomSensor:  "reverse bottom sensor",
+	ErrCodeReverseNotInTop:      "reverse not in top position",
+	ErrCodeCupDropperDisconnect: "cup dropper disconnected",
+	ErrCodeCupDropperHighLoad:   "cup dropper high load",
+	ErrCodeCupDropperSensor:     "cup dropper sensor",
+	ErrCodeCupLoaderDisconnect:  "cup loader disconnected",
+	ErrCodeCupLoaderHighLoad:    "cup loader high load",
+	ErrCodeCupLoaderSensor:      "cup loader sensor",
+	ErrCodeTabletPressedSensor:  "tablet pressed sensor",
+	ErrCodeTabletThrowedSensor:  "tablet throwed out sensor",
+	ErrCodeNoCoffee:             "no coffee",
+	ErrCodeDoserSensorOn:        "doser sensor on",
+	ErrCodeDoserDisconnected:    "doser disconnected",
+	ErrCodeDoserHighLoad:        "doser high load",
+	ErrCodeHotTempSensorMissing: "hot temperature sensor not found",
+	ErrCodeTempSensorCRC:        "temperature sensor crc",
+	ErrCodeTempSensorNotStart:   "temperature sensor not start",
+	ErrCodeCupNotDetected:       "cup not detected",
+	ErrCodeUnexpected:           "unexpected response",
+	ErrCodeConnectionLost:       "connection lost",
+	ErrCodeProblemUnknown:       "problem code unknown",
+	ErrCodeInvalidTemp:          "invalid temperature",
+	ErrCodeNoResponse:           "no response",
+	ErrCodeTimeout:              "timeout",
+}
+
+// ErrCodeName description of device error code
+func ErrCodeName(code int32) string {
+	if s, ok := errCodeNames[code]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// NewDeviceError AppError with catalogue code, message contains device name and code description
+func NewDeviceError(device string, code int32, format string, args ...interface{}) error {
+	err := fmt.Errorf("%s error:%d (%s)", device, code, ErrCodeName(code))
+	if format != "" {
+		err = fmt.Errorf("%v %s", err, fmt.Sprintf(format, args...))
+	}
+	return &helpers.AppError{ErrorCode: code, Err: err}
+}
@@ -0,0 +1,52 @@
+package evend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AlexTransit/vender/hardware/mdb"
+	"github.com/AlexTransit/vender/helpers"
+	state_new "github.com/AlexTransit/vender/internal/state/new"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceError(t *testing.T) {
+	t.Parallel()
+
+	err := NewDeviceError("evend.cup", ErrCodeNoCups, "command(%s)", "dispense")
+	var appErr *helpers.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, int32(21), appErr.Code())
+	assert.Equal(t, "evend.cup error:21 (no cups) command(dispense)", err.Error())
+	assert.Equal(t, "evend.cup error:99 (timeout)", NewDeviceError("evend.cup", ErrCodeTimeout, "").Error())
+	assert.Equal(t, "unknown", ErrCodeName(200))
+}
+
+func TestProto2ProblemCode(t *testing.T) {
+	t.Parallel()
+
+	ctx, _ := state_new.NewTestContext(t, "", ``)
+	mock := mdb.MockFromContext(ctx)
+	defer mock.Close()
+	go mock.Expect([]mdb.MockR{
+		{"e3", "08"},   // POLL -> problem bit
+		{"e402", "15"}, // error code 21
+		{"e3", ""},
+		{"e3", "08"},
+		{"e402", "00"}, // problem bit, but no code
+		{"e3", ""},
+	})
+	dev := &Generic{}
+	dev.Init(ctx, 0xe0, "cup", proto2)
+
+	var appErr *helpers.AppError
+	err := dev.WaitSuccess(context.Background(), 5, true)
+	require.True(t, errors.As(err, &appErr), err)
+	assert.Equal(t, ErrCodeNoCups, appErr.Code())
+
+	err = dev.WaitSuccess(context.Background(), 5, true)
+	require.True(t, errors.As(err, &appErr), err)
+	assert.Equal(t, ErrCodeProblemUnknown, appErr.Code())
+}
@@ -84,11 +84,11 @@ func (gen *Generic) Init(ctx context.Context, address uint8, name string, proto
 func (gen *Generic) Name() string { return gen.name }
 
 func (gen *Generic) NewErrPollProblem(p mdb.Packet) error {
-	return fmt.Errorf("%s POLL=%x -> need to ask problem code", gen.name, p.Bytes())
+	return NewDeviceError(gen.name, ErrCodeProblemUnknown, "POLL=%x -> need to ask problem code", p.Bytes())
 }
 
 func (gen *Generic) NewErrPollUnexpected(p mdb.Packet) error {
-	return fmt.Errorf("%s POLL=%x unexpected", gen.name, p.Bytes())
+	return NewDeviceError(gen.name, ErrCodeUnexpected, "POLL=%x unexpected", p.Bytes())
 }
 
 func (gen *Generic) NewAction(tag string, args ...byte) engine.Doer {
@@ -151,12 +151,12 @@ func (gen *Generic) NewWaitReady(tag string) engine.Doer {
 				if code == 0 {
 					return true, nil
 				}
-				err := fmt.Errorf("%s response=%x errorcode=%d", tag, bs, code)
+				err := NewDeviceError(tag, int32(code), "response=%x", bs)
 				gen.dev.Log.Error(err)
-				return true, &helpers.AppError{ErrorCode: int32(code), Err: err}
+				return true, err
 
 			default:
-				err := fmt.Errorf("%s unknown response=%x", tag, bs)
+				err := NewDeviceError(tag, ErrCodeUnexpected, "unknown response=%x", bs)
 				gen.dev.Log.Error(err)
 				return false, err
 			}
@@ -224,7 +224,7 @@ func (gen *Generic) NewWaitDone(tag string, timeout time.Duration) engine.Doer {
 			// 04 during WaitDone is "oops, device reboot in operation"
 			if value&genericPollMiss != 0 {
 				gen.dev.SetState(mdb.DeviceOnline)
-				return true, fmt.Errorf("%s POLL=%x ignore=%02x continous connection lost, (TODO decide reset?)", tag, bs, gen.proto2IgnoreMask)
+				return true, NewDeviceError(tag, ErrCodeConnectionLost, "POLL=%x ignore=%02x continous connection lost, (TODO decide reset?)", bs, gen.proto2IgnoreMask)
 			}
 
 			// busy during WaitDone is correct path
@@ -259,9 +259,7 @@ func (gen *Generic) newProto1PollWaitSuccess(tag string, timeout time.Duration)
 			return true, nil
 		}
 		if bs[0] == 0x04 {
-			code := bs[1]
-			err := fmt.Errorf("%s errorcode=%d", tag, code)
-			return true, &helpers.AppError{ErrorCode: int32(code), Err: err}
+			return true, NewDeviceError(tag, int32(bs[1]), "POLL=%x", bs)
 		}
 		return true, gen.NewErrPollUnexpected(p)
 	}
@@ -273,7 +271,7 @@ func (gen *Generic) proto2PollCommon(tag string, bs []byte) (bool, error) {
 		return true, nil
 	}
 	if len(bs) > 1 {
-		return true, fmt.Errorf("%s POLL=%x -> too long", tag, bs)
+		return true, NewDeviceError(tag, ErrCodeUnexpected, "POLL=%x -> too long", bs)
 	}
 	value := bs[0]
 	value &^= gen.proto2IgnoreMask
@@ -284,11 +282,9 @@ func (gen *Generic) proto2PollCommon(tag string, bs []byte) (bool, error) {
 	if value&genericPollProblem != 0 {
 		code, err := gen.Diagnostic()
 		if err != nil {
-			err = fmt.Errorf("%s %v", tag, err)
-			return true, err
+			return true, NewDeviceError(tag, ErrCodeProblemUnknown, "diagnostic (%v)", err)
 		}
-		// return true, DeviceErrorCode(code)
-		return true, &helpers.AppError{ErrorCode: int32(code), Err: err}
+		return true, NewDeviceError(tag, int32(code), "POLL=%x", bs)
 	}
 	return false, nil
 }
@@ -296,13 +292,15 @@ func (gen *Generic) proto2PollCommon(tag string, bs []byte) (bool, error) {
 //-------------------------------------------------------------------------
 
 // timeout count every 200 ms
-func (gen *Generic) Proto1PollWaitSuccess(count uint16, timeOut bool) (err error) {
+func (gen *Generic) Proto1PollWaitSuccess(ctx context.Context, count uint16, timeOut bool) (err error) {
 	response := mdb.Packet{}
 	for count > 0 {
 		count--
-		time.Sleep(200 * time.Millisecond)
+		if err = helpers.SleepCtx(ctx, 200*time.Millisecond); err != nil {
+			return fmt.Errorf("command(%s) %w", gen.dev.Action, err)
+		}
 		if err = gen.dev.Tx(gen.dev.PacketPoll, &response); err != nil {
-			return err
+			return NewDeviceError(gen.name, ErrCodeNoResponse, "poll (%v)", err)
 		}
 		rb := response.Bytes()
 		if len(rb) == 0 {
@@ -313,65 +311,68 @@ func (gen *Generic) Proto1PollWaitSuccess(count uint16, timeOut bool) (err error
 			gen.dev.Action = ""
 			return
 		case 0x04:
-			errCode := rb[1]
-			e := fmt.Errorf("execute command(%s) error(%v)", gen.dev.Action, errCode)
-			return &helpers.AppError{ErrorCode: int32(errCode), Err: e}
+			return NewDeviceError(gen.name, int32(rb[1]), "execute command(%s)", gen.dev.Action)
 		case 0x05:
 		default:
-			err = fmt.Errorf("unknow answer(%v) on command(%s)", rb, gen.dev.Action)
-			return
+			return NewDeviceError(gen.name, ErrCodeUnexpected, "unknow answer(%v) on command(%s)", rb, gen.dev.Action)
 		}
 	}
 	if timeOut {
 		if gen.dev.Action != "" {
-			err = fmt.Errorf("execute command (%v) timeout", gen.dev.Action)
+			err = NewDeviceError(gen.name, ErrCodeTimeout, "execute command (%v) timeout", gen.dev.Action)
 		}
 	}
 	return err
 }
 
-func (gen *Generic) Proto2PollWaitSuccess(count uint16, timeOut bool) (err error) {
+func (gen *Generic) Proto2PollWaitSuccess(ctx context.Context, count uint16, timeOut bool) (err error) {
 	response := mdb.Packet{}
 	for count > 0 {
 		count--
-		time.Sleep(200 * time.Millisecond)
+		if err = helpers.SleepCtx(ctx, 200*time.Millisecond); err != nil {
+			return fmt.Errorf("%s wait %w", gen.name, err)
+		}
 		if err = gen.dev.Tx(gen.dev.PacketPoll, &response); err != nil {
-			return err
+			return NewDeviceError(gen.name, ErrCodeNoResponse, "poll (%v)", err)
 		}
 		rb := response.Byte() << 2
 		rb = rb >> 2
 		if rb&(1<<3) != 0 { // error bit
-			return gen.ReadError()
+			if err = gen.ReadError(); err == nil {
+				err = gen.NewErrPollProblem(response)
+			}
+			return err
 		}
 		if response.Len() == 0 || rb == 0 { // complete
 			return nil
 		}
 	}
 	if timeOut {
-		return errors.New("time out pool")
+		return NewDeviceError(gen.name, ErrCodeTimeout, "command(%s) time out pool", gen.dev.Action)
 	}
 	return nil
 }
 
-func (gen *Generic) WaitSuccess(count uint16, timeOut bool) error {
+// WaitSuccess poll every 200 ms until complete, count expired or ctx done
+func (gen *Generic) WaitSuccess(ctx context.Context, count uint16, timeOut bool) error {
 	if gen.proto == proto1 {
-		return gen.Proto1PollWaitSuccess(count, timeOut)
+		return gen.Proto1PollWaitSuccess(ctx, count, timeOut)
 	}
-	return gen.Proto2PollWaitSuccess(count, timeOut)
+	return gen.Proto2PollWaitSuccess(ctx, count, timeOut)
 }
 
-func (gen *Generic) CommandNoWait(cmd ...byte) (err error) {
+func (gen *Generic) CommandNoWait(ctx context.Context, cmd ...byte) (err error) {
 	if err = gen.Command(cmd...); err != nil {
 		return
 	}
-	return gen.WaitSuccess(1, false)
+	return gen.WaitSuccess(ctx, 1, false)
 }
 
-func (gen *Generic) CommandWaitSuccess(count uint16, cmd ...byte) (err error) {
+func (gen *Generic) CommandWaitSuccess(ctx context.Context, count uint16, cmd ...byte) (err error) {
 	if err = gen.Command(cmd...); err != nil {
 		return
 	}
-	return gen.WaitSuccess(count, true)
+	return gen.WaitSuccess(ctx, count, true)
 }
 
 func (gen *Generic) Command(args ...byte) (err error) {
@@ -407,12 +408,16 @@ func (gen *Generic) ReadError_proto2() (errb byte) {
 	return response[0]
 }
 
+// ReadError device problem code as AppError, nil if no problem
 func (gen *Generic) ReadError() (err error) {
-	if errb := gen.ReadError_proto2(); errb != 0 {
-		// gen.dev.Rst()
-		return &helpers.AppError{ErrorCode: int32(errb), Err: fmt.Errorf("device:%s error:%v", gen.name, errb)}
+	switch errb := gen.ReadError_proto2(); errb {
+	case 0:
+		return nil
+	case 255: // read failed
+		return NewDeviceError(gen.name, ErrCodeProblemUnknown, "read error code")
+	default:
+		return NewDeviceError(gen.name, int32(errb), "")
 	}
-	return nil
 }
 
 // read device data
@@ -429,7 +434,7 @@ func (gen *Generic) ReadData(readCommand byte) (rp []byte, err error) {
 			if err != nil {
 				gen.log.Errorf("%s read data (%v)", gen.name, err)
 			}
-			if err = gen.WaitSuccess(1, false); err != nil {
+			if err = gen.WaitSuccess(context.Background(), 1, false); err != nil {
 				return nil, err
 			}
 			return
@@ -451,5 +456,5 @@ func (gen *Generic) tx(bs *[]byte, response ...*mdb.Packet) (err error) {
 		}
 		err = errors.Join(err, fmt.Errorf("(%d) transmit %s (%v)", i, gen.name, e))
 	}
-	return
+	return NewDeviceError(gen.name, ErrCodeNoResponse, "%v", err)
 }
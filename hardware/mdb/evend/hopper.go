@@ -16,7 +16,7 @@ type DeviceMultiHopper struct {
 	Generic
 }
 type BunkerDevice interface {
-	run(byte, byte) error
+	run(context.Context, byte, byte) error
 	reset() error
 	logError(error)
 }
@@ -31,7 +31,7 @@ func (h *DeviceHopper) init(ctx context.Context, addr uint8, nameSuffix string)
 	g := state.GetGlobal(ctx)
 	h.Generic.Init(ctx, addr, name, proto2)
 	g.Engine.RegisterNewFuncAgr(h.name+".run(?)", func(ctx context.Context, spinTime engine.Arg) (err error) {
-		return runWitchControl(h, byte(spinTime.(int16)), 0)
+		return runWitchControl(ctx, h, byte(spinTime.(int16)), 0)
 	})
 	g.Engine.RegisterNewFunc(h.name+".reset", func(ctx context.Context) error { return h.reset() })
 	return h.dev.Rst()
@@ -43,35 +43,46 @@ func (mh *DeviceMultiHopper) init(ctx context.Context) error {
 	mh.Generic.Init(ctx, addr, "multihopper", proto1)
 
 	g.Engine.RegisterNewFunc(mh.name+".run", func(ctx context.Context) error { return mh.reset() })
+	// multihopper.run(hopper=3, time=20)
+	g.Engine.RegisterNewFuncArgs(mh.name+".run", []engine.Param{
+		{Name: "hopper", Type: engine.ArgInt},
+		{Name: "time", Type: engine.ArgInt},
+	}, func(ctx context.Context, args engine.Args) error {
+		hopper := args.Int("hopper")
+		if hopper < 1 || hopper > 8 {
+			return fmt.Errorf("%s.run hopper=%d out of range 1..8", mh.name, hopper)
+		}
+		return runWitchControl(ctx, mh, byte(args.Int("time")), byte(hopper))
+	})
 	for i := uint8(1); i <= 8; i++ {
 		hopperNumber := i
 		g.Engine.RegisterNewFuncAgr(fmt.Sprintf("%s%d.run(?)", mh.name, hopperNumber), func(ctx context.Context, spinTime engine.Arg) (err error) {
-			return runWitchControl(mh, byte(spinTime.(int16)), hopperNumber)
+			return runWitchControl(ctx, mh, byte(spinTime.(int16)), hopperNumber)
 		})
 	}
 	return mh.dev.Rst()
 }
 
-func runWitchControl(b BunkerDevice, spinTime byte, hopperNumber byte) (err error) {
+func runWitchControl(ctx context.Context, b BunkerDevice, spinTime byte, hopperNumber byte) (err error) {
 	if spinTime == 0 {
 		return
 	}
-	err = b.run(spinTime, hopperNumber)
+	err = b.run(ctx, spinTime, hopperNumber)
 	return err
 }
 
-func (h *DeviceHopper) run(spinTime byte, _tmp byte) error {
+func (h *DeviceHopper) run(ctx context.Context, spinTime byte, _tmp byte) error {
 	h.dev.Action = fmt.Sprintf("hopper %s run(%v)", h.name, spinTime)
 	timeout := uint16(spinTime) + 5
 	h.log.Infof("%s start (%d)", h.name, spinTime)
 	defer h.log.Infof("%s stop", h.name)
-	return h.CommandWaitSuccess(timeout, spinTime)
+	return h.CommandWaitSuccess(ctx, timeout, spinTime)
 }
 
-func (mh *DeviceMultiHopper) run(spinTime byte, hopperNumber byte) error {
+func (mh *DeviceMultiHopper) run(ctx context.Context, spinTime byte, hopperNumber byte) error {
 	mh.dev.Action = fmt.Sprintf("multihopper%v run(%v)", hopperNumber, spinTime)
 	timeout := uint16(spinTime) + 5
 	mh.log.Infof("%s%d start (%d)", mh.name, hopperNumber, spinTime)
 	defer mh.log.Infof("%s%d stop", mh.name, hopperNumber)
-	return mh.CommandWaitSuccess(timeout, hopperNumber, spinTime)
+	return mh.CommandWaitSuccess(ctx, timeout, hopperNumber, spinTime)
 }
@@ -37,9 +37,11 @@ func (me *MiherElevator) InitMiherElevator(ctx context.Context, address uint8, n
 	me.currentPos = -1
 	g.Engine.RegisterNewFunc(me.name+".reset", func(ctx context.Context) error { return me.reset() })
 	g.Engine.RegisterNewFuncAgr(me.name+".moveNoWait(?)", func(ctx context.Context, arg engine.Arg) error { return me.moveNoWait(int8(arg.(int16))) })
-	g.Engine.RegisterNewFuncAgr(me.name+".WaitSuccess(?)", func(ctx context.Context, arg engine.Arg) error { return me.WaitSuccess(uint16(arg.(int16)*5+5), true) })
-	g.Engine.RegisterNewFunc(me.name+".movingComplete", func(ctx context.Context) error { return me.mvComplete() })
-	g.Engine.RegisterNewFuncAgr(me.name+".move(?)", func(ctx context.Context, arg engine.Arg) error { return me.move(int8(arg.(int16))) })
+	g.Engine.RegisterNewFuncAgr(me.name+".WaitSuccess(?)", func(ctx context.Context, arg engine.Arg) error {
+		return me.WaitSuccess(ctx, uint16(arg.(int16)*5+5), true)
+	})
+	g.Engine.RegisterNewFunc(me.name+".movingComplete", func(ctx context.Context) error { return me.mvComplete(ctx) })
+	g.Engine.RegisterNewFuncAgr(me.name+".move(?)", func(ctx context.Context, arg engine.Arg) error { return me.move(ctx, int8(arg.(int16))) })
 
 	g.Engine.RegisterNewFunc(me.name+".status", func(ctx context.Context) error {
 		g.Log.Infof("%s.position:%d shake speed:%d", me.name, me.currentPos, me.shakeSpeed)
@@ -49,12 +51,12 @@ func (me *MiherElevator) InitMiherElevator(ctx context.Context, address uint8, n
 	return me.dev.Rst()
 }
 
-func (me *MiherElevator) move(position int8) error {
+func (me *MiherElevator) move(ctx context.Context, position int8) error {
 	me.dev.Action = fmt.Sprintf("%s move %d=>%d", me.name, me.currentPos, position)
 	if err := me.moveNoWait(position); err != nil {
-		return fmt.Errorf("send command(%v) error(%v)", me.dev.Action, err)
+		return fmt.Errorf("send command(%v) error(%w)", me.dev.Action, err)
 	}
-	return me.mvComplete()
+	return me.mvComplete(ctx)
 }
 
 func (me *MiherElevator) moveNoWait(position int8) error {
@@ -63,8 +65,8 @@ func (me *MiherElevator) moveNoWait(position int8) error {
 	return me.Command(0x03, byte(position), 0x64)
 }
 
-func (me *MiherElevator) mvComplete() error {
-	err := me.WaitSuccess(100, true) // FIXME timeout to config
+func (me *MiherElevator) mvComplete(ctx context.Context) error {
+	err := me.WaitSuccess(ctx, 100, true) // FIXME timeout to config
 	if err == nil {
 		me.currentPos = me.newPos
 		me.dev.Action = ""
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/AlexTransit/vender/helpers"
 	"github.com/AlexTransit/vender/internal/engine"
 	"github.com/AlexTransit/vender/internal/state"
 )
@@ -33,9 +34,9 @@ func (m *DeviceMixer) init(ctx context.Context) error {
 	g.Engine.Register(m.name+".shake(?)",
 		engine.FuncArg{Name: m.name + ".shake", F: func(ctx context.Context, arg engine.Arg) (err error) {
 			m.dev.Action = fmt.Sprintf("miher shake(%d)", arg)
-			return m.shake(uint8(arg.(int16)))
+			return m.shake(ctx, uint8(arg.(int16)))
 		}})
-	g.Engine.RegisterNewFuncAgr(m.name+".shakeNoWait(?)", func(ctx context.Context, arg engine.Arg) error { return m.shakeNoWait(uint8(arg.(int16))) })
+	g.Engine.RegisterNewFuncAgr(m.name+".shakeNoWait(?)", func(ctx context.Context, arg engine.Arg) error { return m.shakeNoWait(ctx, uint8(arg.(int16))) })
 	g.Engine.Register(m.name+".fan_on", m.NewFan(true))
 	g.Engine.Register(m.name+".fan_off", m.NewFan(false))
 	g.Engine.Register(m.name+".shake_set_speed(?)",
@@ -46,22 +47,24 @@ func (m *DeviceMixer) init(ctx context.Context) error {
 	return err
 }
 
-func (m *DeviceMixer) shakeNoWait(steps uint8) (err error) {
+func (m *DeviceMixer) shakeNoWait(ctx context.Context, steps uint8) (err error) {
 	if err = m.Command(0x01, byte(steps), m.shakeSpeed); err != nil {
 		return err
 	}
-	return m.WaitSuccess(1, false)
+	return m.WaitSuccess(ctx, 1, false)
 }
 
 // 1step = 100ms
-func (m *DeviceMixer) shake(steps uint8) (err error) {
-	if err = m.shakeNoWait(steps); err != nil {
+func (m *DeviceMixer) shake(ctx context.Context, steps uint8) (err error) {
+	if err = m.shakeNoWait(ctx, steps); err != nil {
 		return
 	}
 	if steps > 4 {
-		time.Sleep(time.Duration(steps-4) * 100 * time.Millisecond)
+		if err = helpers.SleepCtx(ctx, time.Duration(steps-4)*100*time.Millisecond); err != nil {
+			return err
+		}
 	}
-	return m.WaitSuccess(20, false)
+	return m.WaitSuccess(ctx, 20, false)
 }
 
 // --------------------------------------------------------
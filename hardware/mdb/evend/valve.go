@@ -53,17 +53,22 @@ func (dv *DeviceValve) init(ctx context.Context) (err error) {
 		}
 	}
 	dv.waterStock = waterStock
-	g.Engine.RegisterNewFuncAgr("add.water_hot(?)", func(ctx context.Context, arg engine.Arg) error { return dv.waterRun(waterHot, uint8(arg.(int16))) })
+	g.Engine.RegisterNewFuncAgr("add.water_hot(?)", func(ctx context.Context, arg engine.Arg) error { return dv.waterRun(ctx, waterHot, uint8(arg.(int16))) })
 	g.Engine.RegisterNewFuncAgr("add.water_hot_NoWait(?)", func(ctx context.Context, arg engine.Arg) error {
-		return dv.waterRunNoWait(waterHot, uint8(arg.(int16)))
+		return dv.waterRunNoWait(ctx, waterHot, uint8(arg.(int16)))
+	})
+	g.Engine.RegisterNewFuncAgr("add.water_cold(?)", func(ctx context.Context, arg engine.Arg) error {
+		return dv.waterRun(ctx, waterCold, uint8(arg.(int16)))
+	})
+	g.Engine.RegisterNewFuncAgr("add.water_espresso(?)", func(ctx context.Context, arg engine.Arg) error {
+		return dv.waterRun(ctx, waterEspresso, uint8(arg.(int16)))
 	})
-	g.Engine.RegisterNewFuncAgr("add.water_cold(?)", func(ctx context.Context, arg engine.Arg) error { return dv.waterRun(waterCold, uint8(arg.(int16))) })
-	g.Engine.RegisterNewFuncAgr("add.water_espresso(?)", func(ctx context.Context, arg engine.Arg) error { return dv.waterRun(waterEspresso, uint8(arg.(int16))) })
 	g.Engine.RegisterNewFunc("evend.valve.get_temp_hot", func(ctx context.Context) error { return dv.readTemp() })
+	g.Engine.RegisterVar("valve.temp", func(context.Context, string) (interface{}, error) { return dv.GetTemperature() })
 	g.Engine.RegisterNewFunc("evend.valve.reset", func(ctx context.Context) error { return dv.Reset() })
 	g.Engine.RegisterNewFuncAgr("evend.valve.set_temp_hot(?)", func(ctx context.Context, arg engine.Arg) error { return dv.SetTemp(uint8(arg.(int16))) })
 	g.Engine.RegisterNewFunc("evend.valve.set_temp_hot_config", func(ctx context.Context) error { return dv.SetTemp(uint8(valveConfig.TemperatureHot)) })
-	g.Engine.RegisterNewFunc(dv.name+".flowDone", func(ctx context.Context) error { return dv.flowDone() })
+	g.Engine.RegisterNewFunc(dv.name+".flowDone", func(ctx context.Context) error { return dv.flowDone(ctx) })
 	g.Engine.RegisterNewFunc("evend.valve.status", func(ctx context.Context) error {
 		ct, err := dv.GetTemperature()
 		g.Log.Infof("current temp=%v target temp=%v", ct, EValve.tempHotTarget)
@@ -112,12 +117,12 @@ func (dv *DeviceValve) Reset() (err error) {
 	return dv.dev.Rst()
 }
 
-func (dv *DeviceValve) flowDone() (err error) {
+func (dv *DeviceValve) flowDone(ctx context.Context) (err error) {
 	timeuot := uint16(dv.pourMilliliters) * 10
 	if timeuot == 0 {
 		timeuot = 5
 	}
-	err = dv.WaitSuccess(timeuot, true)
+	err = dv.WaitSuccess(ctx, timeuot, true)
 	if err != nil {
 		return err
 	}
@@ -126,17 +131,17 @@ func (dv *DeviceValve) flowDone() (err error) {
 	return nil
 }
 
-func (dv *DeviceValve) waterRunNoWait(waterType byte, milliliters uint8) (err error) {
+func (dv *DeviceValve) waterRunNoWait(ctx context.Context, waterType byte, milliliters uint8) (err error) {
 	wts := waterTypeString(waterType)
 	dv.log.Infof("start %s %d ml.", wts, milliliters)
 	dv.pourMilliliters = dv.milliliters(milliliters)
-	err = dv.CommandNoWait(waterType, dv.pourMilliliters)
+	err = dv.CommandNoWait(ctx, waterType, dv.pourMilliliters)
 	return err
 }
 
-func (dv *DeviceValve) waterRun(waterType byte, milliliters uint8) (err error) {
-	dv.waterRunNoWait(waterType, milliliters)
-	return dv.flowDone()
+func (dv *DeviceValve) waterRun(ctx context.Context, waterType byte, milliliters uint8) (err error) {
+	dv.waterRunNoWait(ctx, waterType, milliliters)
+	return dv.flowDone(ctx)
 }
 
 func (dv *DeviceValve) milliliters(milliliters uint8) (hwValue byte) {
@@ -151,6 +156,9 @@ func (dv *DeviceValve) GetTemperature() (int32, error) {
 	return dv.tempHot, nil
 }
 
+// last read hot water temperature without bus request. 0 = not read yet, -1 = read error
+func (dv *DeviceValve) LastTemperature() int32 { return dv.tempHot }
+
 func (dv *DeviceValve) readTemp() error {
 	r, err := dv.ReadData(0x11)
 	if err != nil || len(r) == 0 {
@@ -159,7 +167,10 @@ func (dv *DeviceValve) readTemp() error {
 	}
 	dv.tempHot = int32(r[0])
 	if dv.tempHot == 0 || dv.tempHot > 100 {
-		return errors.Join(fmt.Errorf("invalid temp=%v", dv.tempHot), dv.ReadError())
+		if err = dv.ReadError(); err != nil {
+			return err
+		}
+		return NewDeviceError(dv.name, ErrCodeInvalidTemp, "temp=%v", dv.tempHot)
 	}
 	return nil
 }
@@ -2,6 +2,7 @@ package mdb
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/AlexTransit/vender/log2"
@@ -33,7 +34,18 @@ func (fns FeatureNotSupported) Error() string { return string(fns) }
 type Bus struct {
 	Error func(error)
 	Log   *log2.Log
+	OnTx  func(request, response []byte, begin time.Time, err error) // order trace
 	u     Uarter
+
+	statMu sync.Mutex
+	stat   map[uint8]BusStat
+}
+
+// transactions counters per device address
+type BusStat struct {
+	Tx      uint32
+	Error   uint32
+	Timeout uint32
 }
 
 func NewBus(u Uarter, log *log2.Log, errfun func(error)) *Bus {
@@ -66,7 +78,12 @@ func (b *Bus) Tx(request Packet, response *Packet) (err error) {
 	}
 
 	reqBs := request.Bytes()
+	tbegin := time.Now()
 	rp.l, err = b.u.Tx(reqBs, rp.b[:])
+	b.count(reqBs[0]&0xf8, err)
+	if b.OnTx != nil {
+		b.OnTx(reqBs, rp.Bytes(), tbegin, err)
+	}
 	if err != nil {
 		err = fmt.Errorf("error=%v mdb.Tx send=%x recv=%x", err, reqBs, rp.Bytes())
 	}
@@ -76,6 +93,34 @@ func (b *Bus) Tx(request Packet, response *Packet) (err error) {
 	return err
 }
 
+func (b *Bus) count(addr uint8, err error) {
+	b.statMu.Lock()
+	defer b.statMu.Unlock()
+	if b.stat == nil {
+		b.stat = make(map[uint8]BusStat)
+	}
+	st := b.stat[addr]
+	st.Tx++
+	if err != nil {
+		st.Error++
+		if oerr.Cause(err) == ErrTimeoutMDB {
+			st.Timeout++
+		}
+	}
+	b.stat[addr] = st
+}
+
+// Stat copy of counters by device address
+func (b *Bus) Stat() map[uint8]BusStat {
+	b.statMu.Lock()
+	defer b.statMu.Unlock()
+	r := make(map[uint8]BusStat, len(b.stat))
+	for k, v := range b.stat {
+		r[k] = v
+	}
+	return r
+}
+
 func IsResponseTimeout(e error) bool {
 	return e != nil && oerr.Cause(e) == ErrTimeoutMDB
 }
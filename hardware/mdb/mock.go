@@ -29,12 +29,15 @@ type MockUart struct {
 	mu sync.Mutex
 	m  map[string]string
 	q  chan MockR
+	// closed instead of q, so leftover Expect goroutine stops rather than panics on send
+	closed chan struct{}
 }
 
 func NewMockUart(t testing.TB) *MockUart {
 	self := &MockUart{
-		t: t,
-		q: make(chan MockR),
+		t:      t,
+		q:      make(chan MockR),
+		closed: make(chan struct{}),
 	}
 	return self
 }
@@ -44,17 +47,21 @@ func (mu *MockUart) Close() error {
 	mu.mu.Lock()
 	defer mu.mu.Unlock()
 	select {
-	case _, ok := <-mu.q:
+	case <-mu.closed:
+		err := errors.Errorf("code error mdb-mock already closed")
+		mu.t.Fatal(err)
+		return err
+	default:
+	}
+	defer close(mu.closed)
+	select {
+	case <-mu.q:
 		err := errors.Errorf("mdb-mock: Close() with non-empty queue")
-		if !ok {
-			err = errors.Errorf("code error mdb-mock already closed")
-		}
 		// panic(err)
 		// self.t.Log(err)
 		mu.t.Fatal(err)
 		return err
 	default:
-		close(mu.q)
 		return nil
 	}
 }
@@ -96,14 +103,12 @@ func (mu *MockUart) txMap(request, response []byte) (int, error) {
 // Expect() requests in defined order
 func (mu *MockUart) txQueue(request, response []byte) (n int, err error) {
 	var rr MockR
-	var ok bool
 	select {
-	case rr, ok = <-mu.q:
-		if !ok {
-			err = errors.Errorf("mdb-mock: queue ended, received=%x", request)
-			mu.t.Error(err)
-			return 0, err
-		}
+	case rr = <-mu.q:
+	case <-mu.closed:
+		err = errors.Errorf("mdb-mock: queue ended, received=%x", request)
+		mu.t.Error(err)
+		return 0, err
 	case <-time.After(MockTimeout):
 		err = errors.Errorf("mdb-mock: queue timeout, received=%x", request)
 		mu.t.Error(err)
@@ -142,6 +147,8 @@ func (mu *MockUart) Expect(rrs []MockR) {
 	for _, rr := range rrs {
 		select {
 		case mu.q <- rr:
+		case <-mu.closed: // test ended, its failure is already reported
+			return
 		case <-time.After(MockTimeout):
 			err := errors.Errorf("mdb-mock: background processing is too slow, timeout sending into mock queue rr=%s", rr)
 			mu.t.Fatal(err)
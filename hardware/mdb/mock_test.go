@@ -39,3 +39,17 @@ func TestTestMdberExpectBg(t *testing.T) {
 	require.Nil(t, mdbus.Tx(PH("30", true), new(Packet)))
 	// require.Nil(t, mdbus.Tx(PH("0b", true), new(Packet)))
 }
+
+func TestBusStat(t *testing.T) {
+	t.Parallel()
+
+	mdbus, mock := NewMockBus(t)
+	defer mock.Close()
+	mock.ExpectMap(map[string]string{"30": "", "c1": "01", "c2": ""})
+	require.Nil(t, mdbus.Tx(PH("30", true), new(Packet)))
+	require.Nil(t, mdbus.Tx(PH("c1", true), new(Packet)))
+	require.Error(t, mdbus.Tx(PH("c3", true), new(Packet)))
+	stat := mdbus.Stat()
+	require.Equal(t, BusStat{Tx: 1}, stat[0x30])
+	require.Equal(t, BusStat{Tx: 2, Error: 1, Timeout: 1}, stat[0xc0])
+}
@@ -41,6 +41,13 @@ const (
 	Stacked
 	CoinRejectKey
 	CoinCredit
+	CashlessSessionBegin
+	CashlessSessionCancel
+	CashlessVendApproved
+	CashlessVendDenied
+	CashlessSessionEnd
+	CashlessRevalueApproved
+	CashlessRevalueDenied
 )
 
 type PollItem struct {
@@ -0,0 +1,202 @@
+package sim
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexTransit/vender/hardware/input"
+	"github.com/AlexTransit/vender/hardware/text_display"
+	"github.com/AlexTransit/vender/internal/types"
+)
+
+const consoleHelp = `simulator console:
+  12 .          keys, every character is key press
+  ok | c        accept | reject key
+  cream+ cream- sugar+ sugar-
+  service       service key
+  coin N        insert coin N rub
+  bill N        insert bill N rub
+  cups N        load N cups
+  fail DEV CODE next command of evend device DEV (cup, valve, hopper1...) ends with error CODE (0x15 or 21)
+  status        devices state
+  display       show display
+  help`
+
+// Keyboard evend keyboard and service key, pressed in console
+func (m *Machine) Keyboard() input.Source { return keyboard(m.keys) }
+
+type keyboard chan types.InputEvent
+
+func (k keyboard) String() string { return input.EvendKeyboardSourceTag }
+
+func (k keyboard) Read() (types.InputEvent, error) {
+	e, ok := <-k
+	if !ok {
+		return types.InputEvent{}, io.EOF
+	}
+	return e, nil
+}
+
+func (m *Machine) key(key types.InputKey) {
+	m.keys <- types.InputEvent{Source: input.EvendKeyboardSourceTag, Key: key}
+}
+
+// Display hd44780 text display, printed to console on change
+func (m *Machine) Display() text_display.Devicer { return m.display }
+
+const lcdPrintDelay = 300 * time.Millisecond // scrolling text changes display often
+
+type lcd struct {
+	m       *Machine
+	mu      sync.Mutex
+	rows    [2][16]byte
+	y, x    int
+	printed string
+	timer   *time.Timer
+}
+
+func (d *lcd) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rows = [2][16]byte{}
+	d.y, d.x = 0, 0
+	d.changed()
+}
+
+func (d *lcd) CursorYX(y, x uint8) bool {
+	if y < 1 || y > 2 || x < 1 || x > 16 {
+		return false
+	}
+	d.mu.Lock()
+	d.y, d.x = int(y-1), int(x-1)
+	d.mu.Unlock()
+	return true
+}
+
+func (d *lcd) Write(b []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, c := range b {
+		if d.x < len(d.rows[d.y]) {
+			d.rows[d.y][d.x] = c
+			d.x++
+		}
+	}
+	d.changed()
+}
+
+func (d *lcd) changed() {
+	if d.timer == nil {
+		d.timer = time.AfterFunc(lcdPrintDelay, d.print)
+	}
+}
+
+func (d *lcd) String() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return "+----------------+\n|" + text_display.Decode(d.rows[0][:]) + "|\n|" + text_display.Decode(d.rows[1][:]) + "|\n+----------------+"
+}
+
+func (d *lcd) print() {
+	s := d.String()
+	d.mu.Lock()
+	d.timer = nil
+	same := s == d.printed
+	d.printed = s
+	d.mu.Unlock()
+	if !same {
+		fmt.Fprintln(d.m.out, s)
+	}
+}
+
+// RunConsole reads operator commands until EOF
+func (m *Machine) RunConsole(r io.Reader) {
+	fmt.Fprintln(m.out, "simulator: type help")
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if err := m.Command(scanner.Text()); err != nil {
+			fmt.Fprintf(m.out, "simulator: %v\n", err)
+		}
+	}
+}
+
+// Command one console line
+func (m *Machine) Command(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	arg := func(i int) (int, error) {
+		if len(fields) <= i {
+			return 0, fmt.Errorf("%s: argument required, see help", fields[0])
+		}
+		n, err := strconv.ParseInt(fields[i], 0, 32)
+		return int(n), err
+	}
+	switch fields[0] {
+	case "help":
+		fmt.Fprintln(m.out, consoleHelp)
+	case "ok":
+		m.key(input.EvendKeyAccept)
+	case "c":
+		m.key(input.EvendKeyReject)
+	case "cream-":
+		m.key(input.EvendKeyCreamLess)
+	case "cream+":
+		m.key(input.EvendKeyCreamMore)
+	case "sugar-":
+		m.key(input.EvendKeySugarLess)
+	case "sugar+":
+		m.key(input.EvendKeySugarMore)
+	case "service":
+		m.keys <- types.InputEvent{Source: input.DevInputEventTag, Up: true}
+	case "coin", "bill", "cups":
+		n, err := arg(1)
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		switch fields[0] {
+		case "coin":
+			return m.coin.insert(n)
+		case "bill":
+			return m.bill.insert(n)
+		default:
+			m.cup.cups = n
+		}
+	case "fail":
+		code, err := arg(2)
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		e, ok := m.evends[strings.TrimPrefix(fields[1], "evend.")]
+		if !ok {
+			return fmt.Errorf("fail: unknown device %s", fields[1])
+		}
+		e.fail = byte(code)
+	case "status":
+		fmt.Fprintln(m.out, m.Status())
+	case "display":
+		fmt.Fprintln(m.out, m.display.String())
+	default:
+		for _, c := range line {
+			if (c < '0' || c > '9') && c != '.' && c != ' ' {
+				return fmt.Errorf("unknown command %q, type help", line)
+			}
+		}
+		for _, c := range line {
+			if c != ' ' {
+				m.key(types.InputKey(c))
+			}
+		}
+	}
+	return nil
+}
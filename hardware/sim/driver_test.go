@@ -0,0 +1,36 @@
+package sim_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/AlexTransit/vender/hardware/mdb"
+	"github.com/AlexTransit/vender/hardware/mdb/evend"
+	"github.com/AlexTransit/vender/hardware/sim"
+	"github.com/AlexTransit/vender/helpers"
+	config_global "github.com/AlexTransit/vender/internal/config"
+	state_new "github.com/AlexTransit/vender/internal/state/new"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// real driver on simulated bus
+func TestCupDriver(t *testing.T) {
+	t.Parallel()
+
+	ctx, g := state_new.NewTestContext(t, "", "")
+	g.Config.Hardware.EvendDevices["evend.cup"] = config_global.DeviceConfig{Name: "evend.cup"}
+	m := sim.New(g.Log, io.Discard)
+	g.Hardware.Mdb.Bus = mdb.NewBus(m.Uart(), g.Log, func(error) {})
+	require.NoError(t, evend.EnumCup(ctx))
+
+	g.Engine.TestDo(t, ctx, "evend.cup.dispense(1)")
+	assert.Contains(t, m.Status(), "cups=99")
+
+	require.NoError(t, m.Command("fail cup 0x21"))
+	err := g.Engine.Exec(ctx, g.Engine.Resolve("evend.cup.dispense(1)"))
+	var appErr *helpers.AppError
+	require.True(t, errors.As(err, &appErr), err)
+	assert.Equal(t, int32(0x21), appErr.Code())
+}
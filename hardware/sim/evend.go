@@ -0,0 +1,333 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// see hardware/mdb/evend/evend-devices-doc.txt
+const (
+	evendPollProblem = 0x08
+	evendPollBusy    = 0x50
+	valvePollBusy    = 0x10
+	valvePollNotHot  = 0x40
+
+	errCodeOutOfCups = 0x15
+)
+
+// device specific part of evend device
+type evendModel interface {
+	// command base+2, returns operation time and error code at the end (0 = success)
+	command(args []byte, now time.Time) (time.Duration, byte)
+	// extra POLL bits while idle (valve: water not hot)
+	poll(now time.Time) byte
+	// read data base+4, except 02 error code
+	read(cmd byte, now time.Time) []byte
+	// configuration base+5
+	config(args []byte, now time.Time)
+	status(now time.Time) string
+}
+
+// evend protocol common for all devices, proto1 or proto2
+type evend struct {
+	name  string
+	proto int
+	busy  byte // proto2 POLL while operation runs
+	model evendModel
+
+	until    time.Time // operation end
+	code     byte      // error code of operation, reported after until
+	reported bool      // proto1 reports result once
+	fail     byte      // console: next operation ends with this error code
+}
+
+func (e *evend) tx(sub byte, data []byte, now time.Time) []byte {
+	switch sub {
+	case 0: // reset
+		e.until, e.code, e.reported = time.Time{}, 0, true
+		return nil
+	case 1: // setup
+		return []byte{0x01, 'S', 'I', 'M', byte(e.proto)}
+	case 2: // command
+		if now.Before(e.until) {
+			return nil // device ignores command while busy
+		}
+		d, code := e.model.command(data, now)
+		if e.fail != 0 {
+			code, e.fail = e.fail, 0
+		}
+		e.until, e.code, e.reported = now.Add(d), code, false
+		return nil
+	case 3:
+		return e.poll(now)
+	case 4: // read data
+		if len(data) == 0 {
+			return nil
+		}
+		if data[0] == 0x02 {
+			code := e.code
+			e.code = 0
+			return []byte{code}
+		}
+		return e.model.read(data[0], now)
+	case 5:
+		e.model.config(data, now)
+		return nil
+	}
+	return nil
+}
+
+func (e *evend) poll(now time.Time) []byte {
+	extra := e.model.poll(now)
+	if e.proto == 1 {
+		// empty while idle or busy, then once 0d 00 success or 04 XX error
+		if now.Before(e.until) || e.reported {
+			return nil
+		}
+		e.reported = true
+		if e.code != 0 {
+			code := e.code
+			e.code = 0
+			return []byte{0x04, code}
+		}
+		return []byte{0x0d, 0x00}
+	}
+	switch {
+	case now.Before(e.until):
+		return []byte{e.busy | extra}
+	case e.code != 0: // until base+4 02 read
+		return []byte{evendPollProblem | extra}
+	case extra != 0:
+		return []byte{extra}
+	}
+	return nil
+}
+
+func (e *evend) status(now time.Time) string {
+	s := e.model.status(now)
+	if now.Before(e.until) {
+		s += " busy"
+	}
+	if e.code != 0 {
+		s += fmt.Sprintf(" error=%02x", e.code)
+	}
+	if e.fail != 0 {
+		s += fmt.Sprintf(" fail_next=%02x", e.fail)
+	}
+	return s
+}
+
+type noModel struct{}
+
+func (noModel) poll(time.Time) byte                             { return 0 }
+func (noModel) read(byte, time.Time) []byte                     { return nil }
+func (noModel) config([]byte, time.Time)                        {}
+func (noModel) status(time.Time) string                         { return "" }
+func (noModel) command([]byte, time.Time) (time.Duration, byte) { return 0, 0 }
+
+// valve: boiler heats with constant power to target, cools to ambient, cold water of hot pour lowers temperature
+const (
+	valveAmbient     = 20.0
+	valveHeatPerSec  = 1.5
+	valveCoolPerSec  = 0.05
+	valveDropPerUnit = 0.08
+	valveUnitTime    = 100 * time.Millisecond // 1 unit = 1.538 ml
+	valveHotRange    = 5                      // POLL not hot when colder than target-range
+)
+
+type valve struct {
+	noModel
+	temp   float64
+	target float64
+	at     time.Time
+}
+
+func (v *valve) update(now time.Time) {
+	if !v.at.IsZero() {
+		dt := now.Sub(v.at).Seconds()
+		if v.temp < v.target {
+			v.temp = math.Min(v.target, v.temp+valveHeatPerSec*dt)
+		} else {
+			v.temp = math.Max(math.Max(v.target, valveAmbient), v.temp-valveCoolPerSec*dt)
+		}
+	}
+	v.at = now
+}
+
+func (v *valve) command(args []byte, now time.Time) (time.Duration, byte) {
+	v.update(now)
+	if len(args) < 2 {
+		return 0, 0
+	}
+	switch args[0] {
+	case 0x01, 0x02, 0x03: // pour hot, cold, espresso
+		if args[0] != 0x02 {
+			v.temp = math.Max(valveAmbient, v.temp-valveDropPerUnit*float64(args[1]))
+		}
+		return time.Duration(args[1]) * valveUnitTime, 0
+	}
+	return 0, 0 // valves and pumps open/close
+}
+
+func (v *valve) poll(now time.Time) byte {
+	v.update(now)
+	if v.target != 0 && v.temp < v.target-valveHotRange {
+		return valvePollNotHot
+	}
+	return 0
+}
+
+func (v *valve) read(cmd byte, now time.Time) []byte {
+	v.update(now)
+	if cmd == 0x11 {
+		return []byte{byte(math.Round(v.temp))}
+	}
+	return nil
+}
+
+func (v *valve) config(args []byte, now time.Time) {
+	v.update(now)
+	if len(args) >= 2 && args[0] == 0x10 {
+		v.target = float64(args[1])
+	}
+}
+
+func (v *valve) status(now time.Time) string {
+	v.update(now)
+	return fmt.Sprintf("temp=%.1f target=%.0f", v.temp, v.target)
+}
+
+// cup: ensure/dispense rotate tubes, without cups error 15 after ~5s
+type cup struct {
+	noModel
+	cups  int
+	light bool
+}
+
+func (c *cup) command(args []byte, now time.Time) (time.Duration, byte) {
+	if len(args) == 0 {
+		return 0, 0
+	}
+	switch args[0] {
+	case 0x01, 0x04: // dispense, ensure
+		if c.cups <= 0 {
+			return 5 * time.Second, errCodeOutOfCups
+		}
+		if args[0] == 0x01 {
+			c.cups--
+			return 1500 * time.Millisecond, 0
+		}
+		return 500 * time.Millisecond, 0
+	case 0x02, 0x03:
+		c.light = args[0] == 0x02
+	}
+	return 0, 0
+}
+
+func (c *cup) status(time.Time) string { return fmt.Sprintf("cups=%d light=%t", c.cups, c.light) }
+
+// conveyor: move time by distance, after reset position unknown until move to 0
+type conveyor struct {
+	noModel
+	position int
+	speed    byte
+}
+
+func (c *conveyor) command(args []byte, now time.Time) (time.Duration, byte) {
+	if len(args) < 2 {
+		return 0, 0
+	}
+	switch args[0] {
+	case 0x01:
+		pos := int(args[1])
+		if len(args) >= 3 {
+			pos |= int(args[2]) << 8
+		}
+		d := pos - c.position
+		c.position = pos
+		return 300*time.Millisecond + time.Duration(abs(d))*2*time.Millisecond, 0
+	case 0x02, 0x03: // vibrate, shake
+		return time.Duration(args[1]) * 300 * time.Millisecond, 0
+	}
+	return 0, 0
+}
+
+func (c *conveyor) config(args []byte, _ time.Time) {
+	if len(args) >= 2 && args[0] == 0x10 {
+		c.speed = args[1]
+	}
+}
+
+func (c *conveyor) status(time.Time) string {
+	return fmt.Sprintf("position=%d speed=%d", c.position, c.speed)
+}
+
+// mixer and elevator: same board, position 0-100%
+type motor struct {
+	noModel
+	position int
+	fan      bool
+}
+
+func (mo *motor) command(args []byte, now time.Time) (time.Duration, byte) {
+	if len(args) < 2 {
+		return 0, 0
+	}
+	switch args[0] {
+	case 0x01: // shake XX*0.1s
+		return time.Duration(args[1]) * 100 * time.Millisecond, 0
+	case 0x02:
+		mo.fan = args[1] == 1
+	case 0x03: // move
+		d := int(args[1]) - mo.position
+		mo.position = int(args[1])
+		return 200*time.Millisecond + time.Duration(abs(d))*30*time.Millisecond, 0
+	}
+	return 0, 0
+}
+
+func (mo *motor) status(time.Time) string {
+	return fmt.Sprintf("position=%d fan=%t", mo.position, mo.fan)
+}
+
+// hopper: run motor XX*0.1s, multihopper: hopper number and time
+type hopper struct {
+	noModel
+	multi bool
+}
+
+func (h hopper) command(args []byte, now time.Time) (time.Duration, byte) {
+	if h.multi {
+		if len(args) < 2 {
+			return 0, 0
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return 0, 0
+	}
+	return time.Duration(args[0]) * 100 * time.Millisecond, 0
+}
+
+type espresso struct{ noModel }
+
+func (espresso) command(args []byte, now time.Time) (time.Duration, byte) {
+	if len(args) == 0 {
+		return 0, 0
+	}
+	switch args[0] {
+	case 0x01: // grind
+		return 2 * time.Second, 0
+	case 0x02, 0x03: // press, release
+		return 2180 * time.Millisecond, 0
+	}
+	return 0, 0 // heat on/off
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,228 @@
+package sim
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MDB currency code RUB, BCD 0643 with 1 prefix (ISO 4217)
+var simCurrency = []byte{0x16, 0x43}
+
+// coin changer level 3: coin types 1,2,5,10 rub (scaling 100 kop, 2 decimals), all routed to tubes
+const (
+	coinScaling  = 100
+	coinTubeFull = 50
+	coinPayTime  = 500 * time.Millisecond // per coin
+)
+
+type coinChanger struct {
+	credit [16]byte // coin type -> rub
+	tubes  [16]byte
+	accept uint16
+	events []byte
+	payout time.Time // busy paying until
+}
+
+func newCoinChanger() *coinChanger {
+	c := &coinChanger{}
+	copy(c.credit[:], []byte{1, 2, 5, 10})
+	copy(c.tubes[:], []byte{20, 20, 20, 20})
+	return c
+}
+
+func (c *coinChanger) tx(sub byte, data []byte, now time.Time) []byte {
+	switch sub {
+	case 0: // reset
+		c.accept = 0
+		c.events = []byte{0x0b} // changer was reset
+	case 1: // setup
+		bs := []byte{3, simCurrency[0], simCurrency[1], coinScaling, 2, 0, 0}
+		binary.BigEndian.PutUint16(bs[5:], c.routing())
+		return append(bs, c.credit[:]...)
+	case 2: // tube status
+		bs := make([]byte, 2, 18)
+		var full uint16
+		for i, n := range c.tubes {
+			if n >= coinTubeFull {
+				full |= 1 << uint(i)
+			}
+		}
+		binary.BigEndian.PutUint16(bs, full)
+		return append(bs, c.tubes[:]...)
+	case 3: // poll
+		if now.Before(c.payout) {
+			return []byte{0x02} // payout busy
+		}
+		events := c.events
+		c.events = nil
+		return events
+	case 4: // coin type: accept mask, dispense mask
+		if len(data) >= 2 {
+			c.accept = binary.BigEndian.Uint16(data)
+		}
+	case 5: // dispense: count<<4 | type
+		if len(data) >= 1 {
+			count, typ := data[0]>>4, data[0]&0xf
+			if c.tubes[typ] < count {
+				count = c.tubes[typ]
+			}
+			c.tubes[typ] -= count
+			c.payout = now.Add(time.Duration(count) * coinPayTime)
+		}
+	case 7: // expansion
+		if len(data) == 0 {
+			return nil
+		}
+		switch data[0] {
+		case 0x00: // identification, features: extended diagnostic
+			return expansionID(0x00000002)
+		case 0x05: // diagnostic status: OK
+			return []byte{0x03, 0x00}
+		}
+	}
+	return nil
+}
+
+func (c *coinChanger) routing() (r uint16) {
+	for i, n := range c.credit {
+		if n != 0 {
+			r |= 1 << uint(i)
+		}
+	}
+	return r
+}
+
+// insert coin, rub
+func (c *coinChanger) insert(rub int) error {
+	for i, n := range c.credit {
+		if n == 0 || int(n) != rub {
+			continue
+		}
+		typ := byte(i)
+		switch {
+		case c.accept&(1<<uint(i)) == 0:
+			c.events = append(c.events, 0x70|typ, c.tubes[i]) // rejected
+			return fmt.Errorf("coin %d not accepted now", rub)
+		case c.tubes[i] < coinTubeFull:
+			c.tubes[i]++
+			c.events = append(c.events, 0x50|typ, c.tubes[i]) // to tube
+		default:
+			c.events = append(c.events, 0x40|typ, c.tubes[i]) // to cashbox
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown coin %d, valid: %s", rub, nominals(c.credit[:], 1))
+}
+
+func (c *coinChanger) status(time.Time) string {
+	ss := make([]string, 0, 4)
+	for i, n := range c.credit {
+		if n != 0 {
+			ss = append(ss, fmt.Sprintf("%d:%d", n, c.tubes[i]))
+		}
+	}
+	return fmt.Sprintf("tubes=%s accept=%016b", strings.Join(ss, ","), c.accept)
+}
+
+// bill validator level 1 with escrow: bill types 50..2000 rub (scaling 10 rub with default mdb.bill.scaling_factor=100)
+const (
+	billScaling  = 10
+	billStackCap = 500
+)
+
+type billValidator struct {
+	credit  [16]byte // bill type -> rub/billScaling
+	accept  uint16
+	escrow  int // bill type in escrow, -1 none
+	stacker uint16
+	events  []byte
+}
+
+func newBillValidator() *billValidator {
+	b := &billValidator{escrow: -1}
+	copy(b.credit[:], []byte{5, 10, 20, 50, 100, 200})
+	return b
+}
+
+func (b *billValidator) tx(sub byte, data []byte, now time.Time) []byte {
+	switch sub {
+	case 0: // reset
+		b.accept, b.escrow = 0, -1
+		b.events = []byte{0x06} // validator was reset
+	case 1: // setup
+		bs := []byte{1, simCurrency[0], simCurrency[1], 0, billScaling, 0, 0, 0, 0, 0, 0xff}
+		binary.BigEndian.PutUint16(bs[6:], billStackCap)
+		return append(bs, b.credit[:]...)
+	case 3: // poll
+		events := b.events
+		b.events = nil
+		return events
+	case 4: // bill type: accept mask, escrow mask
+		if len(data) >= 2 {
+			b.accept = binary.BigEndian.Uint16(data)
+		}
+	case 5: // escrow 01 stack, 00 return
+		if len(data) == 0 || b.escrow < 0 {
+			b.events = append(b.events, 0x0a) // invalid escrow request
+			return nil
+		}
+		if data[0] == 1 {
+			b.stacker++
+			b.events = append(b.events, 0x80|byte(b.escrow))
+		} else {
+			b.events = append(b.events, 0xa0|byte(b.escrow))
+		}
+		b.escrow = -1
+	case 6: // stacker
+		bs := make([]byte, 2)
+		binary.BigEndian.PutUint16(bs, b.stacker)
+		return bs
+	case 7: // expansion identification
+		if len(data) != 0 && data[0] == 0x00 {
+			return expansionID(0)[:29]
+		}
+	}
+	return nil
+}
+
+// insert bill, rub
+func (b *billValidator) insert(rub int) error {
+	for i, n := range b.credit {
+		if n == 0 || int(n)*billScaling != rub {
+			continue
+		}
+		switch {
+		case b.escrow >= 0:
+			return fmt.Errorf("bill in escrow already")
+		case b.accept&(1<<uint(i)) == 0:
+			b.events = append(b.events, 0xc0|byte(i)) // disabled bill rejected
+			return fmt.Errorf("bill %d not accepted now", rub)
+		}
+		b.escrow = i
+		b.events = append(b.events, 0x90|byte(i)) // escrow position
+		return nil
+	}
+	return fmt.Errorf("unknown bill %d, valid: %s", rub, nominals(b.credit[:], billScaling))
+}
+
+func (b *billValidator) status(time.Time) string {
+	return fmt.Sprintf("stacker=%d escrow=%d accept=%016b", b.stacker, b.escrow, b.accept)
+}
+
+// manufacturer, serial, model, version, features
+func expansionID(features uint32) []byte {
+	bs := []byte("SIM000000000001vender-sim  \x00\x01")
+	return binary.BigEndian.AppendUint32(bs, features)
+}
+
+func nominals(credit []byte, scaling int) string {
+	ss := make([]string, 0, len(credit))
+	for _, n := range credit {
+		if n != 0 {
+			ss = append(ss, fmt.Sprint(int(n)*scaling))
+		}
+	}
+	return strings.Join(ss, ",")
+}
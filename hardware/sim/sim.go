@@ -0,0 +1,114 @@
+// Package sim simulates vending machine hardware to run vender without a machine (mdb.uart_driver = "sim"):
+// MDB bus with evend devices, coin changer and bill validator, evend keyboard and text display.
+// Real drivers talk to models through mdb.Uarter, so config and recipes run exactly as on the machine.
+// Operator drives simulator from console (stdin): keys, coins, bills, device faults. Type "help".
+package sim
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexTransit/vender/hardware/mdb"
+	"github.com/AlexTransit/vender/internal/types"
+	"github.com/AlexTransit/vender/log2"
+)
+
+// MDB device model. sub = command offset from device address (0 reset, 1 setup, 3 poll...)
+type device interface {
+	tx(sub byte, data []byte, now time.Time) []byte
+	status(now time.Time) string
+}
+
+type Machine struct {
+	log *log2.Log
+	out io.Writer
+	now func() time.Time // tests
+
+	mu      sync.Mutex
+	devs    map[byte]device // by MDB address
+	evends  map[string]*evend
+	coin    *coinChanger
+	bill    *billValidator
+	cup     *cup
+	keys    chan types.InputEvent
+	display *lcd
+}
+
+func New(log *log2.Log, out io.Writer) *Machine {
+	m := &Machine{
+		log:    log,
+		out:    out,
+		now:    time.Now,
+		devs:   make(map[byte]device),
+		evends: make(map[string]*evend),
+		coin:   newCoinChanger(),
+		bill:   newBillValidator(),
+		cup:    &cup{cups: 100},
+		keys:   make(chan types.InputEvent, 32),
+	}
+	m.display = &lcd{m: m}
+	m.devs[0x08] = m.coin
+	m.devs[0x30] = m.bill
+	m.addEvend(0xc0, "valve", 2, valvePollBusy, &valve{temp: valveAmbient})
+	m.addEvend(0xc8, "mixer", 1, 0, &motor{})
+	m.addEvend(0xd0, "elevator", 1, 0, &motor{})
+	m.addEvend(0xd8, "conveyor", 2, evendPollBusy, &conveyor{})
+	m.addEvend(0xe0, "cup", 2, evendPollBusy, m.cup)
+	m.addEvend(0xe8, "espresso", 2, evendPollBusy, espresso{})
+	m.addEvend(0xb8, "multihopper", 1, 0, hopper{multi: true})
+	for i := 1; i <= 8; i++ {
+		m.addEvend(byte(0x40+(i-1)*8), fmt.Sprintf("hopper%d", i), 2, evendPollBusy, hopper{})
+	}
+	return m
+}
+
+func (m *Machine) addEvend(addr byte, name string, proto int, busy byte, model evendModel) {
+	e := &evend{name: name, proto: proto, busy: busy, model: model}
+	m.devs[addr] = e
+	m.evends[name] = e
+}
+
+// Uart MDB bus of simulator
+func (m *Machine) Uart() mdb.Uarter { return (*uart)(m) }
+
+type uart Machine
+
+func (u *uart) Open(string) error                  { return nil }
+func (u *uart) Close() error                       { return nil }
+func (u *uart) Break(_, sleep time.Duration) error { time.Sleep(sleep); return nil }
+
+// Tx device at request address answers, absent device - MDB timeout like on real bus
+func (u *uart) Tx(request, response []byte) (int, error) {
+	m := (*Machine)(u)
+	if len(request) == 0 {
+		return 0, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.devs[request[0]&0xf8]
+	if !ok {
+		return 0, mdb.ErrTimeoutMDB
+	}
+	return copy(response, d.tx(request[0]&7, request[1:], m.now())), nil
+}
+
+// Status of all models, console "status"
+func (m *Machine) Status() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	names := make([]string, 0, len(m.evends))
+	for name := range m.evends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{"coin " + m.coin.status(now), "bill " + m.bill.status(now)}
+	for _, name := range names {
+		lines = append(lines, name+" "+m.evends[name].status(now))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,164 @@
+package sim
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/hardware/input"
+	"github.com/AlexTransit/vender/hardware/mdb"
+	"github.com/AlexTransit/vender/log2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMachine struct {
+	*Machine
+	t   *testing.T
+	now time.Time
+}
+
+func newTestMachine(t *testing.T) *testMachine {
+	tm := &testMachine{Machine: New(log2.NewTest(t, log2.LOG_DEBUG), io.Discard), t: t, now: time.Unix(1e9, 0)}
+	tm.Machine.now = func() time.Time { return tm.now }
+	return tm
+}
+
+// tx hex request, hex response
+func (tm *testMachine) tx(request string) string {
+	tm.t.Helper()
+	bs, err := hex.DecodeString(request)
+	require.NoError(tm.t, err)
+	var response [mdb.PacketMaxLength]byte
+	n, err := tm.Uart().Tx(bs, response[:])
+	require.NoError(tm.t, err, request)
+	return hex.EncodeToString(response[:n])
+}
+
+func (tm *testMachine) sleep(d time.Duration) { tm.now = tm.now.Add(d) }
+
+func TestAbsentDevice(t *testing.T) {
+	t.Parallel()
+
+	tm := newTestMachine(t)
+	_, err := tm.Uart().Tx([]byte{0x10}, make([]byte, 40)) // cashless is not simulated
+	assert.True(t, mdb.IsResponseTimeout(err), err)
+}
+
+func TestValve(t *testing.T) {
+	t.Parallel()
+
+	tm := newTestMachine(t)
+	tm.tx("c0")
+	assert.NotEqual(t, "", tm.tx("c1"))
+	assert.Equal(t, "", tm.tx("c3")) // no target - no "not hot"
+	tm.tx("c51055")                  // target 85
+	assert.Equal(t, "40", tm.tx("c3"))
+	assert.Equal(t, "14", tm.tx("c411")) // 20C
+	tm.sleep(20 * time.Second)
+	assert.Equal(t, "32", tm.tx("c411")) // 20+1.5*20
+	tm.sleep(time.Minute)
+	assert.Equal(t, "55", tm.tx("c411"))
+	assert.Equal(t, "", tm.tx("c3"))
+
+	tm.tx("c2014e") // pour hot 78 units
+	assert.Equal(t, "50", tm.tx("c3"), "busy and colder after cold water inflow")
+	tm.sleep(8 * time.Second)
+	assert.Equal(t, "", tm.tx("c3"))
+}
+
+func TestEvendError(t *testing.T) {
+	t.Parallel()
+
+	tm := newTestMachine(t)
+	tm.cup.cups = 1
+	tm.tx("e201")
+	assert.Equal(t, "50", tm.tx("e3"))
+	tm.sleep(2 * time.Second)
+	assert.Equal(t, "", tm.tx("e3"))
+
+	tm.tx("e201") // out of cups
+	tm.sleep(6 * time.Second)
+	assert.Equal(t, "08", tm.tx("e3"))
+	assert.Equal(t, "15", tm.tx("e402"))
+	assert.Equal(t, "", tm.tx("e3"))
+
+	// proto1 reports result once
+	require.NoError(t, tm.Command("fail mixer 0x24"))
+	tm.tx("ca030a64")
+	assert.Equal(t, "", tm.tx("cb"))
+	tm.sleep(time.Second)
+	assert.Equal(t, "0424", tm.tx("cb"))
+	assert.Equal(t, "", tm.tx("cb"))
+	tm.tx("ca030064")
+	tm.sleep(time.Second)
+	assert.Equal(t, "0d00", tm.tx("cb"))
+}
+
+func TestCoin(t *testing.T) {
+	t.Parallel()
+
+	tm := newTestMachine(t)
+	tm.tx("08")
+	assert.Equal(t, "0b", tm.tx("0b"))
+	assert.Equal(t, "0316436402000f"+"0102050a"+strings.Repeat("00", 12), tm.tx("09"))
+	assert.Error(t, tm.Command("coin 5"), "disabled")
+	assert.Equal(t, "7214", tm.tx("0b"))
+	tm.tx("0c000f000f")
+	require.NoError(t, tm.Command("coin 5"))
+	assert.Equal(t, "5215", tm.tx("0b"))
+	assert.Error(t, tm.Command("coin 3"))
+
+	tm.tx("0d12") // pay one 5 rub
+	assert.Equal(t, "02", tm.tx("0b"))
+	tm.sleep(time.Second)
+	assert.Equal(t, "", tm.tx("0b"))
+	assert.Equal(t, "0000141414", tm.tx("0a")[:10])
+}
+
+func TestBill(t *testing.T) {
+	t.Parallel()
+
+	tm := newTestMachine(t)
+	tm.tx("30")
+	assert.Equal(t, "06", tm.tx("33"))
+	assert.Equal(t, 27, len(tm.tx("31"))/2)
+	assert.Equal(t, 29, len(tm.tx("3700"))/2)
+	tm.tx("34ffffffff")
+	require.NoError(t, tm.Command("bill 100"))
+	assert.Error(t, tm.Command("bill 100"), "escrow busy")
+	assert.Equal(t, "91", tm.tx("33"))
+	tm.tx("3501")
+	assert.Equal(t, "81", tm.tx("33"))
+	assert.Equal(t, "0001", tm.tx("36"))
+
+	require.NoError(t, tm.Command("bill 500"))
+	tm.tx("3500")
+	assert.Equal(t, "93a3", tm.tx("33"))
+}
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+
+	tm := newTestMachine(t)
+	kb := tm.Keyboard()
+	require.NoError(t, tm.Command("1."))
+	require.NoError(t, tm.Command("ok"))
+	require.NoError(t, tm.Command("service"))
+	assert.Error(t, tm.Command("1x"))
+	for _, expect := range []rune{'1', '.', rune(input.EvendKeyAccept)} {
+		e, err := kb.Read()
+		require.NoError(t, err)
+		assert.True(t, input.IsAccept(&e) == (expect == rune(input.EvendKeyAccept)))
+		assert.Equal(t, expect, rune(e.Key))
+	}
+	e, _ := kb.Read()
+	assert.Equal(t, input.DevInputEventTag, e.Source)
+
+	d := tm.Display()
+	d.CursorYX(2, 1)
+	d.Write([]byte("Hello"))
+	assert.Contains(t, tm.display.String(), "|Hello           |")
+}
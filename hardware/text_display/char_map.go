@@ -81,3 +81,33 @@ var lcdCharMap = map[rune]byte{
 	'.': 0x2e, '>': 0x3e,
 	'/': 0x2f, '?': 0x3f,
 }
+
+// lcd code -> rune, for simulator. ascii codes are ascii, russian letters share some of them
+var lcdCharDecode = func() map[byte]rune {
+	m := make(map[byte]rune, len(lcdCharMap))
+	for r, b := range lcdCharMap {
+		if b < 0x20 || b > 0x7e {
+			m[b] = r
+		}
+	}
+	for b := byte(0x20); b <= 0x7e; b++ {
+		m[b] = rune(b)
+	}
+	return m
+}()
+
+// Decode display bytes back to text, unknown codes as '?'
+func Decode(b []byte) string {
+	rs := make([]rune, len(b))
+	for i, c := range b {
+		switch r, ok := lcdCharDecode[c]; {
+		case ok:
+			rs[i] = r
+		case c == 0:
+			rs[i] = ' '
+		default:
+			rs[i] = '?'
+		}
+	}
+	return string(rs)
+}
@@ -24,6 +24,8 @@ type TextDisplay struct { //nolint:maligned
 	width uint32
 	state State
 
+	charMap map[rune]byte // language characters over lcdCharMap
+
 	line []string
 
 	tickd time.Duration
@@ -80,6 +82,14 @@ func (td *TextDisplay) SetScrollDelay(d time.Duration) {
 	td.tickd = d
 }
 
+// SetCharMap characters of current language, used before default map
+func (td *TextDisplay) SetCharMap(m map[rune]byte) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	td.charMap = m
+}
+
 func (td *TextDisplay) Clear() {
 	td.mu.Lock()
 	defer td.mu.Unlock()
@@ -204,7 +214,10 @@ func (td *TextDisplay) Translate(s string) []byte {
 	result := make([]byte, len([]rune(s)))
 	i := 0
 	for _, v := range s {
-		ch, ok := lcdCharMap[v]
+		ch, ok := td.charMap[v]
+		if !ok {
+			ch, ok = lcdCharMap[v]
+		}
 		if !ok {
 			td.log.Errf("the character %c is missing from the symbol map", v)
 			ch = lcdCharMap['?']
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/AlexTransit/vender/log2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,3 +77,25 @@ func TestJustCenter(t *testing.T) {
 	assert.Equal(t, []byte("  long  "), d.JustCenter([]byte("long")))
 	assert.Equal(t, []byte("   1    "), d.JustCenter([]byte("1")))
 }
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	d := NewMockTextDisplay(&TextDisplayConfig{Width: 16})
+	assert.Equal(t, "Price:5.00 Цбдж ", Decode(d.Translate("Price:5.00 Цбдж")))
+	// russian letters same as latin decode as latin, display looks the same
+	assert.Equal(t, "Koд", Decode(d.Translate("Код\x00")))
+	assert.Equal(t, "ab  ", Decode([]byte{'a', 'b', 0x20, 0}))
+}
+
+func TestCharMap(t *testing.T) {
+	t.Parallel()
+
+	d := NewMockTextDisplay(&TextDisplayConfig{Width: 4})
+	d.SetLogger(log2.NewTest(t, log2.LOG_DEBUG))
+	assert.Equal(t, []byte("?a? "), d.Translate("äaü"))
+	d.SetCharMap(map[rune]byte{'ä': 0xe1, 'ü': 0xf5, 'a': 'A'})
+	assert.Equal(t, []byte{0xe1, 'A', 0xf5, ' '}, d.Translate("äaü"))
+	d.SetCharMap(nil)
+	assert.Equal(t, []byte("?a? "), d.Translate("äaü"))
+}
@@ -1,6 +1,12 @@
 package helpers
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
 
 func intDurationDefault(x int, scale time.Duration, def time.Duration) time.Duration {
 	if x == 0 {
@@ -20,3 +26,71 @@ func IntSecondConfigDefault(x int, def int) time.Duration {
 	}
 	return time.Duration(x) * time.Second
 }
+
+// SleepCtx like time.Sleep but returns ctx.Err() when context is done before duration elapsed
+func SleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WeekSchedule day/time windows, syntax of ui.front.light_sheduler:
+// "(1-5 08:00-20:00) (6 10:00-13:00) (0 11:00-12:00)" or "(* 11:00-18:21)"
+// day 0=sunday, * - all days, range of days 1-5
+type WeekSchedule [7]DayWindow
+
+type DayWindow struct {
+	Set   bool // day is in schedule
+	Begin time.Duration
+	End   time.Duration
+}
+
+var reWeekSchedule = regexp.MustCompile(`([0-6]|\*)[-]?([0-6])? ([01]?[0-9]|2[0-3]):([0-5][0-9])-([01]?[0-9]|2[0-3]):([0-5][0-9])`)
+
+// ParseWeekSchedule later parts overwrite days of previous
+func ParseWeekSchedule(s string) (ws WeekSchedule, err error) {
+	parts := reWeekSchedule.FindAllStringSubmatch(s, 7)
+	if s != "" && len(parts) == 0 {
+		return ws, fmt.Errorf("schedule string error: %s", s)
+	}
+	for _, v := range parts {
+		w := DayWindow{Set: true, Begin: clockDuration(v[3], v[4]), End: clockDuration(v[5], v[6])}
+		first, last := 0, 6
+		if v[1] != "*" {
+			first, _ = strconv.Atoi(v[1])
+			last = first
+			if v[2] != "" {
+				last, _ = strconv.Atoi(v[2])
+			}
+		}
+		for i := first; i <= last; i++ {
+			ws[i] = w
+		}
+	}
+	return ws, nil
+}
+
+func clockDuration(hours string, minutes string) time.Duration {
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	return time.Hour*time.Duration(h) + time.Minute*time.Duration(m)
+}
+
+// Contains t in window of its day, Begin > End - window till next midnight and from midnight till End
+func (ws *WeekSchedule) Contains(t time.Time) bool {
+	w := ws[t.Weekday()]
+	if !w.Set {
+		return false
+	}
+	h, m, s := t.Clock()
+	clock := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	if w.Begin <= w.End {
+		return clock >= w.Begin && clock < w.End
+	}
+	return clock >= w.Begin || clock < w.End
+}
@@ -0,0 +1,209 @@
+package config_global
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlexTransit/vender/log2"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// offline config check (vender config-check).
+// Sources keeps position of every block, attribute and list element by key - block types and labels joined by ".":
+// engine.alias.make_coffee.scenario, engine.alias.make_coffee.onError.3[78], engine.menu.item.11, engine.on_boot.0,
+// ui.service.test.cup, inventory.stock.sugar. later file overrides position, like value.
+type Sources struct {
+	ranges map[string]hcl.Range
+}
+
+// Problem config error with position "file:line"
+type Problem struct {
+	Pos string
+	Key string
+	Msg string
+}
+
+func (p Problem) String() string {
+	s := p.Msg
+	if p.Key != "" {
+		s = p.Key + ": " + s
+	}
+	if p.Pos != "" {
+		s = p.Pos + ": " + s
+	}
+	return s
+}
+
+// Pos "file:line" of key or nearest parent
+func (s *Sources) Pos(key string) string {
+	for key != "" {
+		if r, ok := s.ranges[key]; ok {
+			return fmt.Sprintf("%s:%d", r.Filename, r.Start.Line)
+		}
+		i := strings.LastIndexByte(key, '.')
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return ""
+}
+
+func (s *Sources) Problem(key string, err error) Problem {
+	return Problem{Pos: s.Pos(key), Key: key, Msg: err.Error()}
+}
+
+// ReadConfigCheck reads config like ReadConfig, but returns errors instead of exit
+// and also reports what ReadConfig ignores: decode errors, duplicates in one file, broken stocks and onError.
+func ReadConfigCheck(log *log2.Log, fn string) (*Config, *Sources, []Problem) {
+	cfg := newDefaultConfig()
+	cfg.ConfigFile = fn
+	src := &Sources{ranges: make(map[string]hcl.Range)}
+	var problems []Problem
+	cc := configLoadStruct{log: log}
+	cc.readConfig(fn)
+	merge := func(bodies []hcl.Body) {
+		for _, body := range bodies {
+			problems = append(problems, src.index(body)...)
+			for _, d := range cfg.mergeBody(body) {
+				if d.Severity != hcl.DiagError || missingBlock(d) || overrideDiag(d) {
+					continue
+				}
+				p := Problem{Msg: d.Summary}
+				if d.Detail != "" {
+					p.Msg += ". " + d.Detail
+				}
+				if d.Subject != nil {
+					p.Pos = fmt.Sprintf("%s:%d", d.Subject.Filename, d.Subject.Start.Line)
+				}
+				problems = append(problems, p)
+			}
+		}
+	}
+	merge(cc.bodies)
+	if cfg.RemoteConfig != "" {
+		if _, err := os.Stat(cfg.RemoteConfig); err == nil {
+			n := len(cc.bodies)
+			cc.readConfig(cfg.RemoteConfig)
+			merge(cc.bodies[n:])
+		}
+	}
+	for _, err := range cc.errs {
+		problems = append(problems, Problem{Msg: err.Error()})
+	}
+	problems = append(problems, cfg.checkStocks(src)...)
+	problems = append(problems, cfg.checkOnError(src)...)
+	VMC = cfg
+	return cfg, src, problems
+}
+
+// accepted by ReadConfig: include is read before decode, file may override part of block
+func overrideDiag(d *hcl.Diagnostic) bool {
+	return d.Summary == "Missing required argument" ||
+		(d.Summary == "Unsupported block type" && strings.Contains(d.Detail, `"include"`))
+}
+
+func (s *Sources) index(body hcl.Body) (problems []Problem) {
+	sb, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	s.indexBody("", sb, make(map[string]hcl.Range), &problems)
+	return problems
+}
+
+func (s *Sources) indexBody(prefix string, body *hclsyntax.Body, seen map[string]hcl.Range, problems *[]Problem) {
+	for name, attr := range body.Attributes {
+		key := prefix + name
+		s.ranges[key] = attr.SrcRange
+		if tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr); ok {
+			for i, e := range tuple.Exprs {
+				s.ranges[key+"."+strconv.Itoa(i)] = e.Range()
+			}
+		}
+	}
+	for _, block := range body.Blocks {
+		key := prefix + strings.Join(append([]string{block.Type}, block.Labels...), ".")
+		r := block.DefRange()
+		if first, ok := seen[key]; ok && len(block.Labels) != 0 {
+			*problems = append(*problems, Problem{
+				Pos: fmt.Sprintf("%s:%d", r.Filename, r.Start.Line),
+				Key: key,
+				Msg: fmt.Sprintf("duplicate %s, first at %s:%d", block.Type, first.Filename, first.Start.Line),
+			})
+		}
+		seen[key] = r
+		s.ranges[key] = r
+		s.indexBody(key+".", block.Body, seen, problems)
+	}
+}
+
+func (cfg *Config) checkStocks(src *Sources) (problems []Problem) {
+	labels := make([]string, 0, len(cfg.Inventory.XXX_Stocks))
+	for label := range cfg.Inventory.XXX_Stocks {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	codes := make(map[int]string, len(labels))
+	for _, label := range labels {
+		s := cfg.Inventory.XXX_Stocks[label]
+		key := "inventory.stock." + label
+		if _, ok := cfg.Inventory.XXX_Ingredient[s.XXX_Ingredient]; !ok {
+			problems = append(problems, src.Problem(key+".ingredient", fmt.Errorf("ingredient=%q not defined", s.XXX_Ingredient)))
+		}
+		switch prev, dup := codes[s.Code]; {
+		case s.Code <= 0 || s.Code > len(labels):
+			problems = append(problems, src.Problem(key+".code", fmt.Errorf("code=%d out of range 1..%d", s.Code, len(labels))))
+		case dup:
+			problems = append(problems, src.Problem(key+".code", fmt.Errorf("duplicate code=%d, same as stock=%s (%s)", s.Code, prev, src.Pos("inventory.stock."+prev+".code"))))
+		default:
+			codes[s.Code] = label
+		}
+	}
+	return problems
+}
+
+// onError keys are regexp matched with error code, more than one match - undefined which one runs
+const checkOnErrorMaxCode = 999
+
+func (cfg *Config) checkOnError(src *Sources) (problems []Problem) {
+	names := make([]string, 0, len(cfg.Engine.Aliases))
+	for name := range cfg.Engine.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		alias := cfg.Engine.Aliases[name]
+		patterns := make([]string, 0, len(alias.OnError))
+		res := make(map[string]*regexp.Regexp, len(alias.OnError))
+		for pattern := range alias.OnError {
+			key := "engine.alias." + name + ".onError." + pattern
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				problems = append(problems, src.Problem(key, err))
+				continue
+			}
+			patterns = append(patterns, pattern)
+			res[pattern] = re
+		}
+		sort.Strings(patterns)
+		for i, a := range patterns {
+			for _, b := range patterns[i+1:] {
+				for code := 0; code <= checkOnErrorMaxCode; code++ {
+					c := strconv.Itoa(code)
+					if res[a].MatchString(c) && res[b].MatchString(c) {
+						problems = append(problems, src.Problem("engine.alias."+name+".onError."+b,
+							fmt.Errorf("overlaps onError %q (%s), both match code=%d", a, src.Pos("engine.alias."+name+".onError."+a), code)))
+						break
+					}
+				}
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,68 @@
+package config_global
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexTransit/vender/log2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfigCheck(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "vender.hcl")
+	extraFile := filepath.Join(dir, "extra.hcl")
+	require.NoError(t, os.WriteFile(mainFile, []byte(`include "`+extraFile+`" {}
+inventory {
+  stock "sugar" {
+    code = 1
+    ingredient = "sugar"
+  }
+  stock "milk" {
+    code = 1
+    ingredient = "milk"
+  }
+  ingredient "sugar" {}
+}
+engine {
+  alias "make" {
+    scenario = "cup.dispense"
+    onError "3[78]" { scenario = "cup.reset" }
+    onError "\\d+" { scenario = "cup.reset" }
+    onError "(" { scenario = "cup.reset" }
+  }
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(extraFile, []byte(`engine {
+  menu {
+    item "1" { scenario = "make" }
+    item "1" { scenario = "make" }
+  }
+}
+`), 0o644))
+
+	cfg, src, problems := ReadConfigCheck(log, mainFile)
+	require.NotNil(t, cfg)
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = strings.TrimPrefix(p.String(), dir+"/")
+	}
+	assert.ElementsMatch(t, []string{
+		`extra.hcl:4: engine.menu.item.1: duplicate item, first at ` + extraFile + `:3`,
+		`vender.hcl:9: inventory.stock.milk.ingredient: ingredient="milk" not defined`,
+		`vender.hcl:4: inventory.stock.sugar.code: duplicate code=1, same as stock=milk (` + mainFile + `:8)`,
+		"vender.hcl:18: engine.alias.make.onError.(: error parsing regexp: missing closing ): `(`",
+		`vender.hcl:17: engine.alias.make.onError.\d+: overlaps onError "3[78]" (` + mainFile + `:16), both match code=37`,
+	}, lines)
+
+	assert.Equal(t, extraFile+":4", src.Pos("engine.menu.item.1"), "later block overrides position")
+	assert.Equal(t, mainFile+":15", src.Pos("engine.alias.make.scenario"))
+	assert.Equal(t, mainFile+":14", src.Pos("engine.alias.make.unknown"), "parent position")
+	assert.Equal(t, "", src.Pos("tele"))
+}
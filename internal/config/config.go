@@ -1,17 +1,22 @@
 package config_global
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/AlexTransit/vender/currency"
 	"github.com/AlexTransit/vender/hardware/hd44780"
 	mdb_config "github.com/AlexTransit/vender/hardware/mdb/config"
 	evend_config "github.com/AlexTransit/vender/hardware/mdb/evend/config"
+	"github.com/AlexTransit/vender/helpers"
 	engine_config "github.com/AlexTransit/vender/internal/engine/config"
 	"github.com/AlexTransit/vender/internal/engine/inventory"
+	ledger_config "github.com/AlexTransit/vender/internal/ledger/config"
 	menu_config "github.com/AlexTransit/vender/internal/menu/menu_config"
+	metrics_config "github.com/AlexTransit/vender/internal/metrics/config"
 	sound_config "github.com/AlexTransit/vender/internal/sound/config"
 	ui_config "github.com/AlexTransit/vender/internal/ui/config"
+	voucher_config "github.com/AlexTransit/vender/internal/voucher/config"
 	watchdog_config "github.com/AlexTransit/vender/internal/watchdog/config"
 	"github.com/AlexTransit/vender/log2"
 	tele_config "github.com/AlexTransit/vender/tele/config"
@@ -53,6 +58,7 @@ type configLoadStruct struct {
 	log      *log2.Log
 	includes []string
 	bodies   []hcl.Body
+	errs     []error
 }
 
 var includeFile = &hcl.BodySchema{
@@ -75,7 +81,8 @@ func (c *configLoadStruct) readConfig(fileName string) {
 	}
 	file, diags := hclsyntax.ParseConfig(src, fileName, hcl.Pos{Line: 1, Column: 1})
 	if diags.HasErrors() {
-		c.log.Fatalf("parse config file(%v) error(%v)", fileName, diags)
+		c.errs = append(c.errs, fmt.Errorf("parse config file(%v) error(%v)", fileName, diags))
+		return
 	}
 	bc, _ := file.Body.Content(includeFile)
 	c.bodies = append(c.bodies, file.Body)
@@ -91,111 +98,199 @@ func (c *configLoadStruct) readConfig(fileName string) {
 // в инвенторе есть списоки ингридиентов и складов ( бункеров)
 // в складе указывается ссылка на ингредиент
 // ключи ингредиента - название, для склада - код
+// последним подключается файл удаленной конфигурации (remote_config)
 func ReadConfig(log *log2.Log, fn string) *Config {
 	cfg := newDefaultConfig()
+	cfg.ConfigFile = fn
 	cc := configLoadStruct{log: log}
 	cc.readConfig(fn) // read all config files
 	// overwrite duplacates values
 	for i := range cc.bodies {
-		_ = gohcl.DecodeBody(cc.bodies[i], nil, cfg)
-		for _, v := range cfg.Hardware.XXX_Devices {
-			devConf := cfg.Hardware.EvendDevices[v.Name]
-			devConf.Name = v.Name
-			if v.Required {
-				devConf.Required = true
-			}
-			if v.Disabled {
-				devConf.Disabled = true
-			}
-			cfg.Hardware.EvendDevices[v.Name] = devConf
-		}
-		cfg.Hardware.XXX_Devices = nil
-		for _, v := range cfg.UI_config.Service.XXX_Tests {
-			uiTest := ui_config.TestsStruct{
-				Name:     v.Name,
-				Scenario: v.Scenario,
-			}
-			cfg.UI_config.Service.Tests[v.Name] = uiTest
-		}
-		cfg.UI_config.Service.XXX_Tests = nil
-		for _, v := range cfg.Inventory.Stocks {
-			confStock := cfg.Inventory.XXX_Stocks[v.Label]
-			confStock.Label = v.Label
-			if v.Code != 0 {
-				confStock.Code = v.Code
-			}
-			if v.RegisterAdd != "" {
-				confStock.RegisterAdd = v.RegisterAdd
-			}
-			if v.XXX_Ingredient != "" {
-				confStock.XXX_Ingredient = v.XXX_Ingredient
-			}
-			cfg.Inventory.XXX_Stocks[v.Label] = confStock
-		}
-		cfg.Inventory.Stocks = nil
-		for _, v := range cfg.Inventory.Ingredient {
-			ing := cfg.Inventory.XXX_Ingredient[v.Name]
-			ing.Name = v.Name
-			if v.SpendRate != 0 {
-				ing.SpendRate = v.SpendRate
-			}
-			if v.Level != "" {
-				ing.Level = v.Level
-			}
-			if v.Min != 0 {
-				ing.Min = v.Min
-			}
-			if v.Cost != 0 {
-				ing.Cost = v.Cost
-			}
-			if v.TuneKey != "" {
-				ing.TuneKey = v.TuneKey
-			}
-			cfg.Inventory.XXX_Ingredient[v.Name] = ing
-		}
-		cfg.Inventory.Ingredient = nil
-		for _, v := range cfg.Engine.XXX_Aliases {
-			errActions := map[string]engine_config.ErrorAction{}
-			for _, ea := range v.XXX_OnError {
-				errActions[ea.ErrCode] = engine_config.ErrorAction{Scenario: ea.Scenario, SkipMain: ea.SkipMain}
-			}
-			s := engine_config.Alias{
-				Name:     v.Name,
-				Scenario: v.Scenario,
-				OnError:  errActions,
-			}
-			cfg.Engine.Aliases[v.Name] = s
-		}
-		cfg.Engine.XXX_Aliases = nil
-		for _, v := range cfg.Engine.XXX_Menu.XXX_Items {
-			mi := cfg.Engine.Menu.Items[v.Code]
-			mi.Code = v.Code
-			if v.Disabled {
-				mi.Disabled = true
-			}
-			if v.Name != "" {
-				mi.Name = v.Name
-			}
-			if v.Scenario != "" {
-				mi.Scenario = v.Scenario
-			}
-			if v.CreamMax != 0 {
-				mi.CreamMax = v.CreamMax
-			}
-			if v.SugarMax != 0 {
-				mi.SugarMax = v.SugarMax
+		_ = cfg.mergeBody(cc.bodies[i])
+	}
+	if cfg.RemoteConfig != "" {
+		if _, err := os.Stat(cfg.RemoteConfig); err == nil {
+			n, nerr := len(cc.bodies), len(cc.errs)
+			cc.readConfig(cfg.RemoteConfig)
+			remoteErr := len(cc.errs) != nerr || len(cc.bodies) == n
+			for i := n; i < len(cc.bodies); i++ {
+				if err := decodeErrors(cfg.mergeBody(cc.bodies[i])); err != nil {
+					remoteErr = true
+				}
 			}
-			if v.XXX_Price != 0 {
-				mi.Price = cfg.ScaleI(v.XXX_Price)
+			// new remote config is broken (main config changed after check), restore previous instead of crash loop
+			if remoteErr && RemoteConfigTrial(cfg.RemoteConfig) {
+				log.Errorf("remote config (%s) read failed, rollback to previous", cfg.RemoteConfig)
+				remoteConfigRollback(log, cfg.RemoteConfig)
+				return ReadConfig(log, fn)
 			}
-			cfg.Engine.Menu.Items[v.Code] = mi
 		}
-		cfg.Engine.XXX_Menu.XXX_Items = nil
+	}
+	for _, err := range cc.errs {
+		log.Fatal(err)
 	}
 	VMC = cfg
 	return cfg
 }
 
+// decode one config file over cfg. maps are updated, not replaced
+func (cfg *Config) mergeBody(body hcl.Body) hcl.Diagnostics {
+	diags := gohcl.DecodeBody(body, nil, cfg)
+	for _, v := range cfg.Hardware.XXX_Devices {
+		devConf := cfg.Hardware.EvendDevices[v.Name]
+		devConf.Name = v.Name
+		if v.Required {
+			devConf.Required = true
+		}
+		if v.Disabled {
+			devConf.Disabled = true
+		}
+		cfg.Hardware.EvendDevices[v.Name] = devConf
+	}
+	cfg.Hardware.XXX_Devices = nil
+	for _, v := range cfg.UI_config.Service.XXX_Tests {
+		uiTest := ui_config.TestsStruct{
+			Name:     v.Name,
+			Scenario: v.Scenario,
+		}
+		cfg.UI_config.Service.Tests[v.Name] = uiTest
+	}
+	cfg.UI_config.Service.XXX_Tests = nil
+	for _, v := range cfg.UI_config.Service.XXX_Technicians {
+		cfg.UI_config.Service.Technicians[v.Name] = v
+	}
+	cfg.UI_config.Service.XXX_Technicians = nil
+	for _, v := range cfg.UI_config.XXX_Locales {
+		loc := cfg.UI_config.Locales[v.Name]
+		loc.Name = v.Name
+		if v.Title != "" {
+			loc.Title = v.Title
+		}
+		if loc.Msg == nil {
+			loc.Msg = map[string]string{}
+		}
+		for k, msg := range v.Msg {
+			loc.Msg[k] = msg
+		}
+		if loc.CharMap == nil {
+			loc.CharMap = map[string]int{}
+		}
+		for k, b := range v.CharMap {
+			loc.CharMap[k] = b
+		}
+		if len(v.TTSExec) != 0 {
+			loc.TTSExec = v.TTSExec
+		}
+		cfg.UI_config.Locales[v.Name] = loc
+	}
+	cfg.UI_config.XXX_Locales = nil
+	for _, v := range cfg.Inventory.Stocks {
+		confStock := cfg.Inventory.XXX_Stocks[v.Label]
+		confStock.Label = v.Label
+		if v.Code != 0 {
+			confStock.Code = v.Code
+		}
+		if v.RegisterAdd != "" {
+			confStock.RegisterAdd = v.RegisterAdd
+		}
+		if v.XXX_Ingredient != "" {
+			confStock.XXX_Ingredient = v.XXX_Ingredient
+		}
+		cfg.Inventory.XXX_Stocks[v.Label] = confStock
+	}
+	cfg.Inventory.Stocks = nil
+	for _, v := range cfg.Inventory.Ingredient {
+		ing := cfg.Inventory.XXX_Ingredient[v.Name]
+		ing.Name = v.Name
+		if v.SpendRate != 0 {
+			ing.SpendRate = v.SpendRate
+		}
+		if v.Level != "" {
+			ing.Level = v.Level
+		}
+		if v.Min != 0 {
+			ing.Min = v.Min
+		}
+		if v.Cost != 0 {
+			ing.Cost = v.Cost
+		}
+		if v.TuneKey != "" {
+			ing.TuneKey = v.TuneKey
+		}
+		cfg.Inventory.XXX_Ingredient[v.Name] = ing
+	}
+	cfg.Inventory.Ingredient = nil
+	for _, v := range cfg.Engine.XXX_Aliases {
+		errActions := map[string]engine_config.ErrorAction{}
+		for _, ea := range v.XXX_OnError {
+			errActions[ea.ErrCode] = engine_config.ErrorAction{Scenario: ea.Scenario, SkipMain: ea.SkipMain}
+		}
+		s := engine_config.Alias{
+			Name:     v.Name,
+			Params:   v.Params,
+			Scenario: v.Scenario,
+			OnError:  errActions,
+		}
+		cfg.Engine.Aliases[v.Name] = s
+	}
+	cfg.Engine.XXX_Aliases = nil
+	for _, v := range cfg.Engine.XXX_Menu.XXX_Items {
+		mi := cfg.Engine.Menu.Items[v.Code]
+		mi.Code = v.Code
+		if v.Disabled {
+			mi.Disabled = true
+		}
+		if v.Name != "" {
+			mi.Name = v.Name
+		}
+		if v.Scenario != "" {
+			mi.Scenario = v.Scenario
+		}
+		if v.CreamMax != 0 {
+			mi.CreamMax = v.CreamMax
+		}
+		if v.SugarMax != 0 {
+			mi.SugarMax = v.SugarMax
+		}
+		if v.Category != "" {
+			mi.Category = v.Category
+		}
+		if v.XXX_Price != 0 {
+			mi.Price = cfg.ScaleI(v.XXX_Price)
+		}
+		cfg.Engine.Menu.Items[v.Code] = mi
+	}
+	cfg.Engine.XXX_Menu.XXX_Items = nil
+	for _, v := range cfg.Engine.XXX_Menu.XXX_PriceRules {
+		r := cfg.Engine.Menu.PriceRules[v.Name]
+		r.Name = v.Name
+		if v.Schedule != "" {
+			r.Schedule = v.Schedule
+			r.Week, _ = helpers.ParseWeekSchedule(v.Schedule) // error in config-check, invalid schedule never matches
+		}
+		if v.Percent != 0 {
+			r.Percent = v.Percent
+		}
+		if v.XXX_Discount != 0 {
+			r.XXX_Discount = v.XXX_Discount // negative is config-check error
+			r.Discount = cfg.ScaleI(max(v.XXX_Discount, 0))
+		}
+		if v.Items != nil {
+			r.Items = v.Items
+		}
+		if v.Categories != nil {
+			r.Categories = v.Categories
+		}
+		cfg.Engine.Menu.PriceRules[v.Name] = r
+	}
+	cfg.Engine.XXX_Menu.XXX_PriceRules = nil
+	if cfg.Engine.XXX_Menu.XXX_CupCost != 0 {
+		cfg.Engine.Menu.CupCost = cfg.Engine.XXX_Menu.XXX_CupCost
+		cfg.Engine.XXX_Menu.XXX_CupCost = 0
+	}
+	return diags
+}
+
 func (u *Config) KeyboardReader(v ...bool) bool {
 	if len(v) > 0 {
 		u.User.KeyboardReadEnable = v[0]
@@ -215,8 +310,9 @@ func NewConfig() *Config {
 
 func newDefaultConfig() *Config {
 	return &Config{
-		ErrorFolder: "/home/vmc/vender-db/errors/",
-		BrokenFile:  "/home/vmc/broken",
+		ErrorFolder:  "/home/vmc/vender-db/errors/",
+		BrokenFile:   "/home/vmc/broken",
+		RemoteConfig: "/home/vmc/vender-db/remote-config.hcl",
 		Inventory: inventory.Inventory{
 			File:           "/home/vmc/vender-db/inventory/store.file",
 			XXX_Stocks:     map[string]inventory.Stock{},
@@ -280,11 +376,43 @@ func newDefaultConfig() *Config {
 				PicQRPayError:               "/home/vmc/pic-qrerror",
 				PicPayReject:                "/home/vmc/pic-pay-reject",
 				LightShedule:                "(* 06:00-23:00)",
+				LocaleKey:                   ".",
+				MsgVoucherInvalid:           "неверный промокод",
+				MsgVoucherExpired:           "промокод истек",
+				MsgVoucherUsed:              "промокод уже был",
+				MsgVoucherLocked:            "ждите %.0f сек",
+				MsgVoucherGift:              "подарок: ",
 			},
 			Service: ui_config.ServiceStruct{
-				ResetTimeoutSec: 1800,
-				Tests:           map[string]ui_config.TestsStruct{},
+				ResetTimeoutSec:  1800,
+				Tests:            map[string]ui_config.TestsStruct{},
+				Technicians:      map[string]ui_config.TechnicianStruct{},
+				AuthMaxFail:      3,
+				AuthLockoutSec:   300,
+				PinIterations:    100000,
+				MsgMenu:          "Menu",
+				MsgInputPin:      "PIN %s",
+				MsgPinFail:       "wrong PIN",
+				MsgLocked:        "service locked",
+				MsgInvEmpty:      "inv empty",
+				MsgInvInvalid:    "inv invalid",
+				MsgSetOrClear:    "set or clear?",
+				MsgEmpty:         "empty",
+				MsgNumberInvalid: "number-invalid",
+				MsgNoTests:       "no tests",
+				MsgInProgress:    "in progress",
+				MsgOK:            "OK",
+				MsgError:         "error",
+				MsgPress1:        "press 1",
+				MsgRebootAsk:     "for reboot",
+				MsgReboot:        "reboot",
+				MsgNetworkAsk:    "for select net 0",
+				MsgNetwork:       "wifi restart",
+				MsgCoinOffline:   "coin offline",
+				MsgReportAsk:     "for tele report",
 			},
+			Lang:    "ru",
+			Locales: map[string]ui_config.LocaleStruct{},
 		},
 		Sound: sound_config.Config{
 			DefaultVolume: 100,
@@ -298,14 +426,29 @@ func newDefaultConfig() *Config {
 			},
 		},
 		Watchdog: watchdog_config.Config{Folder: "/run/user/1000/vender/"},
+		Ledger: ledger_config.Config{
+			File:      "/home/vmc/vender-db/ledger/sales.jsonl",
+			MaxSizeKB: 1024,
+			Keep:      5,
+		},
+		Metrics: metrics_config.Config{Listen: ":9110"},
+		Voucher: voucher_config.Config{
+			Prefix:     ".",
+			HmacDigits: 10,
+			File:       "/home/vmc/vender-db/voucher/used.jsonl",
+			MaxFail:    5,
+			LockoutSec: 300,
+		},
 		Engine: engine_config.Config{
-			Aliases: map[string]engine_config.Alias{},
+			Aliases:     map[string]engine_config.Alias{},
+			TraceOrders: 10,
 			Menu: menu_config.MenuStruct{
 				DefaultCream:    4,
 				DefaultCreamMax: 6,
 				DefaultSugar:    4,
 				DefaultSugarMax: 8,
 				Items:           map[string]menu_config.MenuItem{},
+				PriceRules:      map[string]menu_config.PriceRule{},
 			},
 		},
 	}
@@ -1,22 +1,30 @@
 package config_global
 
 import (
+	"time"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/display"
 	"github.com/AlexTransit/vender/hardware/hd44780"
 	mdb_config "github.com/AlexTransit/vender/hardware/mdb/config"
 	evend_config "github.com/AlexTransit/vender/hardware/mdb/evend/config"
 	engine_config "github.com/AlexTransit/vender/internal/engine/config"
 	"github.com/AlexTransit/vender/internal/engine/inventory"
+	ledger_config "github.com/AlexTransit/vender/internal/ledger/config"
 	menu_config "github.com/AlexTransit/vender/internal/menu/menu_config"
+	metrics_config "github.com/AlexTransit/vender/internal/metrics/config"
 	sound_config "github.com/AlexTransit/vender/internal/sound/config"
 	ui_config "github.com/AlexTransit/vender/internal/ui/config"
+	voucher_config "github.com/AlexTransit/vender/internal/voucher/config"
 	watchdog_config "github.com/AlexTransit/vender/internal/watchdog/config"
 	tele_api "github.com/AlexTransit/vender/tele"
 	tele_config "github.com/AlexTransit/vender/tele/config"
 )
 
 type Config struct {
-	Version string
-	TeleN   tele_api.Teler
+	Version    string
+	ConfigFile string // main config file, for remote config check
+	TeleN      tele_api.Teler
 	// RU: UpgradeScript - скрипт, запускаемый для обновления системы после команды vmc.upgrade!.
 	// EN: UpgradeScript - a script run to update the system after the vmc.upgrade command!
 	// Example: sudo -u vmc git -C /home/vmc/vender-distr/ pull && sudo -u vmc -i /home/vmc/vender-distr/script/build && rsync -av /home/vmc/vender-distr/build/vender /home/vmc/ && logger upgrade complete. run reload triger
@@ -27,6 +35,9 @@ type Config struct {
 	ErrorFolder string `hcl:"error_folder,optional"`
 	// BrokenFile for broken state logging
 	BrokenFile string `hcl:"broken_file,optional"`
+	// RU: файл конфигурации, присланный с сервера (tele set_config). подключается последним. пусто - отключено.
+	// EN: config fragment received from server (tele set_config). included last. empty = disabled.
+	RemoteConfig string `hcl:"remote_config,optional"`
 	// Inventory configuration for stock management
 	Inventory inventory.Inventory `hcl:"inventory,block"`
 	// RU: настройки для валидаторов
@@ -47,6 +58,15 @@ type Config struct {
 	Watchdog watchdog_config.Config `hcl:"watchdog,block"`
 	// RU: Конфигурация для движка. В ней описано как готовить напитки, какие вложенные сценарии использовать и т.д.
 	Engine engine_config.Config `hcl:"engine,block"`
+	// RU: Локальный журнал продаж (для сверки инкассации, если автомат долго без связи).
+	// EN: Local sales ledger (to reconcile cash collections when the machine is offline).
+	Ledger ledger_config.Config `hcl:"ledger,block"`
+	// RU: HTTP точка для Prometheus (счетчики MDB, продажи, склад, температура).
+	// EN: Prometheus HTTP endpoint (MDB counters, sales, stock, temperature).
+	Metrics metrics_config.Config `hcl:"metrics,block"`
+	// RU: Промокоды с клавиатуры (подписаны, проверяются без связи, одноразовые).
+	// EN: Keypad voucher codes (signed, verified offline, single use).
+	Voucher voucher_config.Config `hcl:"voucher,block"`
 	// Remains   hcl.Body               `hcl:",remain"`
 	User ui_config.UIUser
 }
@@ -85,6 +105,9 @@ type DisplayStruct struct {
 	// RU: путь к фреймбуферу для графического дисплея.
 	// EN: path to framebuffer for graphical display.
 	Framebuffer string `hcl:"framebuffer"`
+	// RU: графический интерфейс: меню с ценами, кредит, шкалы сливок/сахара, приготовление, ошибки, QR.
+	// EN: graphic UI: menu with prices, credit, cream/sugar scales, cooking progress, errors, QR.
+	Graphic display.GraphicConfig `hcl:"graphic,block"`
 }
 
 type HD44780Struct struct {
@@ -154,7 +177,15 @@ func CreamMax() uint8 {
 	return VMC.Engine.Menu.DefaultCreamMax
 }
 
+// GetMenuItem with current price of price rules
 func GetMenuItem(menuCode string) (mi menu_config.MenuItem, ok bool) {
 	mi, ok = VMC.Engine.Menu.Items[menuCode]
+	mi.Price = MenuPrice(&mi)
 	return
 }
+
+// MenuPrice current price of item, price rules applied
+func MenuPrice(mi *menu_config.MenuItem) currency.Amount {
+	price, _ := VMC.Engine.Menu.Price(mi, time.Now())
+	return price
+}
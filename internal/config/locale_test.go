@@ -0,0 +1,86 @@
+package config_global
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexTransit/vender/log2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocale(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "vender.hcl")
+	enFile := filepath.Join(dir, "en.hcl")
+	require.NoError(t, os.WriteFile(mainFile, []byte(`include "`+enFile+`" {}
+ui {
+  lang = "ru"
+  front {
+    msg_price = "цена: %s {рубль|рубля|рублей}"
+  }
+  locale "ru" {
+    msg = { hello = "привет" }
+  }
+  locale "de" {
+    title = "Deutsch"
+    msg = { msg_cream = "Sahne", msg_wait = "bitte warten" }
+    char_map = { "ä" = 225 }
+  }
+  locale "en" {
+    title = "English"
+    msg = { msg_sugar = "sugar" }
+  }
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(enFile, []byte(`ui {
+  locale "en" {
+    msg = {
+      msg_cream = "cream"
+      msg_price = "price: %s {ruble|rubles}"
+      hello = "hello"
+      pic_pay_reject = "/en"
+    }
+    tts_exec = ["/tts/en"]
+  }
+}
+`), 0o644))
+
+	cfg, _, problems := ReadConfigCheck(log, mainFile)
+	require.Empty(t, problems)
+	ui := &cfg.UI_config
+	assert.Equal(t, []string{"ru", "de", "en"}, ui.LocaleNames())
+	assert.Equal(t, "de", ui.NextLocale(""))
+	assert.Equal(t, "en", ui.NextLocale("de"))
+	assert.Equal(t, "ru", ui.NextLocale("en"))
+	assert.Equal(t, "de", ui.NextLocale("unknown"))
+	assert.Equal(t, "English", ui.LocaleTitle("en"))
+	assert.Equal(t, "ru", ui.LocaleTitle(""))
+
+	front := ui.LocaleFront("")
+	assert.Equal(t, "Сливки", front.MsgCream)
+	assert.Equal(t, "цена: 22 рубля", ui.Sprintf("", front.MsgPrice, "22"))
+	front = ui.LocaleFront("en")
+	assert.Equal(t, "cream", front.MsgCream)
+	assert.Equal(t, "sugar", front.MsgSugar, "locale blocks of files merged")
+	assert.Equal(t, "Мало денег", front.MsgMenuInsufficientCreditL1, "not translated")
+	assert.Equal(t, "/home/vmc/pic-pay-reject", front.PicPayReject, "only messages")
+	assert.Equal(t, "price: 22 rubles", ui.Sprintf("en", front.MsgPrice, "22"))
+	assert.Equal(t, "цена: 1 рубль", ui.Sprintf("de", ui.LocaleFront("de").MsgPrice, "1"), "base message of lang")
+
+	text, tts := ui.Speech("en", "hello")
+	assert.Equal(t, "hello", text)
+	assert.Equal(t, []string{"/tts/en"}, tts)
+	text, tts = ui.Speech("de", "hello")
+	assert.Equal(t, "привет", text)
+	assert.Nil(t, tts)
+	text, _ = ui.Speech("", "спасибо")
+	assert.Equal(t, "спасибо", text)
+
+	assert.Equal(t, map[rune]byte{'ä': 225}, ui.CharMap("de"))
+	assert.Empty(t, ui.CharMap("en"))
+}
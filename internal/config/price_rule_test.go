@@ -0,0 +1,101 @@
+package config_global
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/currency"
+	menu_config "github.com/AlexTransit/vender/internal/menu/menu_config"
+	"github.com/AlexTransit/vender/log2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceRule(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	mainFile := filepath.Join(t.TempDir(), "vender.hcl")
+	require.NoError(t, os.WriteFile(mainFile, []byte(`money { scale = 100 }
+engine {
+  menu {
+    item "1" {
+      price    = 50
+      scenario = ""
+      category = "coffee"
+    }
+    item "2" {
+      price    = 40
+      scenario = ""
+    }
+    price_rule "happy" {
+      schedule   = "(1-5 15:00-17:00)"
+      percent    = 20
+      categories = ["coffee"]
+    }
+    price_rule "morning" {
+      schedule = "(* 06:00-09:00)"
+      discount = 5
+    }
+    price_rule "night" {
+      schedule = "(6 22:00-02:00)"
+      discount = 100
+      items    = ["2"]
+    }
+  }
+}
+`), 0o644))
+	cfg, _, problems := ReadConfigCheck(log, mainFile)
+	require.Empty(t, problems)
+	menu := &cfg.Engine.Menu
+	coffee, tea := menu.Items["1"], menu.Items["2"]
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		require.NoError(t, err)
+		return tm
+	}
+
+	cases := []struct {
+		time  string
+		price currency.Amount
+		rule  string
+	}{
+		{"2024-05-06 12:00", 5000, ""},        // monday
+		{"2024-05-06 15:00", 4000, "happy"},   // monday
+		{"2024-05-06 17:00", 5000, ""},        // end is not included
+		{"2024-05-11 15:30", 5000, ""},        // saturday
+		{"2024-05-07 07:00", 4500, "morning"}, // tuesday
+	}
+	for _, c := range cases {
+		price, rule := menu.Price(&coffee, at(c.time))
+		assert.Equal(t, c.price, price, c.time)
+		assert.Equal(t, c.rule, rule, c.time)
+	}
+
+	price, rule := menu.Price(&tea, at("2024-05-06 15:00"))
+	assert.Equal(t, currency.Amount(4000), price, "not coffee")
+	assert.Equal(t, "", rule)
+	price, _ = menu.Price(&tea, at("2024-05-06 08:00"))
+	assert.Equal(t, currency.Amount(3500), price, "all items")
+	price, rule = menu.Price(&tea, at("2024-05-11 23:00"))
+	assert.Equal(t, currency.Amount(4000), price, "paid item never free")
+	assert.Equal(t, "", rule)
+	price, rule = menu.Price(&tea, at("2024-05-11 07:00"))
+	assert.Equal(t, currency.Amount(3500), price, "other rule still applies")
+	assert.Equal(t, "morning", rule)
+
+	menu.PriceRules["night"] = menu_config.PriceRule{Week: menu.PriceRules["night"].Week, Discount: 1000, Items: []string{"2"}}
+	price, rule = menu.Price(&tea, at("2024-05-11 23:00"))
+	assert.Equal(t, currency.Amount(3000), price)
+	assert.Equal(t, "night", rule)
+	price, _ = menu.Price(&tea, at("2024-05-11 01:00"))
+	assert.Equal(t, currency.Amount(3000), price, "window over midnight, same day")
+	price, _ = menu.Price(&tea, at("2024-05-12 01:00"))
+	assert.Equal(t, currency.Amount(4000), price, "sunday")
+
+	free := menu_config.MenuItem{Code: "3", Price: 0}
+	price, _ = menu.Price(&free, at("2024-05-11 23:00"))
+	assert.Equal(t, currency.Amount(0), price, "free item not below zero")
+}
@@ -0,0 +1,188 @@
+package config_global
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlexTransit/vender/log2"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/juju/errors"
+)
+
+// удаленная конфигурация (tele set_config)
+// remote_config      - действующий фрагмент, подключается последним
+// remote_config.new  - проверенный фрагмент, применяется в StateFrontBegin
+// remote_config.prev - предыдущая версия, для отката
+// remote_config.trial - маркер пробной загрузки: "new" -> "booting" -> удален после успешного старта.
+// если при старте маркер уже "booting" - прошлая загрузка не удалась, возвращаем prev.
+// если во время пробы remote_config не читается (ReadConfig) - тоже возвращаем prev.
+const (
+	remoteNewSuffix   = ".new"
+	remotePrevSuffix  = ".prev"
+	remoteTrialSuffix = ".trial"
+
+	remoteTrialNew     = "new"
+	remoteTrialBooting = "booting"
+)
+
+// CheckRemoteConfig reads main config with fragment instead of current remote config.
+// fragment may not include other files.
+func CheckRemoteConfig(log *log2.Log, fn string, name string, fragment []byte) (*Config, error) {
+	cfg := newDefaultConfig()
+	cfg.ConfigFile = fn
+	cc := configLoadStruct{log: log}
+	cc.readConfig(fn)
+	for i := range cc.bodies {
+		_ = cfg.mergeBody(cc.bodies[i])
+	}
+	if len(cc.errs) != 0 {
+		return nil, errors.Annotate(cc.errs[0], "main config")
+	}
+	remoteFile := cfg.RemoteConfig
+	if remoteFile == "" {
+		return nil, errors.NotSupportedf("remote_config")
+	}
+	file, diags := hclsyntax.ParseConfig(fragment, name, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, errors.NotValidf("parse config(%s) error(%v)", name, diags)
+	}
+	if bc, _ := file.Body.Content(includeFile); len(bc.Blocks) != 0 {
+		return nil, errors.NotValidf("config(%s) include", name)
+	}
+	if err := decodeErrors(cfg.mergeBody(file.Body)); err != nil {
+		return nil, errors.Annotatef(err, "config(%s)", name)
+	}
+	cfg.RemoteConfig = remoteFile // fragment can not move itself
+	return cfg, nil
+}
+
+// fragment contains only part of blocks. missing blocks is not error
+func decodeErrors(diags hcl.Diagnostics) error {
+	var errs hcl.Diagnostics
+	for _, d := range diags {
+		if d.Severity != hcl.DiagError {
+			continue
+		}
+		if missingBlock(d) {
+			continue
+		}
+		errs = append(errs, d)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.NotValidf("%v", errs)
+}
+
+func missingBlock(d *hcl.Diagnostic) bool {
+	return strings.HasPrefix(d.Summary, "Missing ") && strings.HasSuffix(d.Summary, " block")
+}
+
+// WriteRemoteConfig atomically stores checked fragment for apply at next StateFrontBegin
+func WriteRemoteConfig(file string, fragment []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return errors.Trace(err)
+	}
+	return writeFileAtomic(file+remoteNewSuffix, fragment)
+}
+
+func writeFileAtomic(file string, b []byte) error {
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = f.Write(b); err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp, file))
+}
+
+// ApplyRemoteConfig makes pending fragment active, previous is kept for rollback.
+// returns true if restart required.
+func ApplyRemoteConfig(log *log2.Log, file string) bool {
+	if file == "" {
+		return false
+	}
+	if _, err := os.Stat(file + remoteNewSuffix); err != nil {
+		return false
+	}
+	prev, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("remote config read current(%s) error(%v)", file, err)
+		return false
+	}
+	// no current file - prev is empty, rollback disables remote config
+	if err = writeFileAtomic(file+remotePrevSuffix, prev); err == nil {
+		err = writeFileAtomic(file+remoteTrialSuffix, []byte(remoteTrialNew))
+	}
+	if err == nil {
+		err = os.Rename(file+remoteNewSuffix, file)
+	}
+	if err != nil {
+		log.Errorf("remote config apply(%s) error(%v)", file, err)
+		_ = os.Remove(file + remoteTrialSuffix)
+		return false
+	}
+	log.Infof("remote config applied (%s), restart", file)
+	return true
+}
+
+// RemoteConfigBoot is called once at vmc start.
+// returns true if previous config restored and config must be read again.
+func RemoteConfigBoot(log *log2.Log, file string) bool {
+	if file == "" {
+		return false
+	}
+	trial, err := os.ReadFile(file + remoteTrialSuffix)
+	if err != nil {
+		return false
+	}
+	if string(trial) != remoteTrialBooting {
+		if err = writeFileAtomic(file+remoteTrialSuffix, []byte(remoteTrialBooting)); err != nil {
+			log.Errorf("remote config trial(%s) error(%v)", file, err)
+		}
+		return false
+	}
+	log.Errorf("remote config (%s) boot failed, rollback to previous", file)
+	remoteConfigRollback(log, file)
+	return true
+}
+
+// restore previous fragment and end trial
+func remoteConfigRollback(log *log2.Log, file string) {
+	if err := os.Rename(file+remotePrevSuffix, file); err != nil {
+		log.Errorf("remote config rollback(%s) error(%v)", file, err)
+	}
+	_ = os.Remove(file + remoteTrialSuffix)
+}
+
+// RemoteConfigCommit boot with new config complete. returns true if was trial boot
+func RemoteConfigCommit(log *log2.Log, file string) bool {
+	if file == "" {
+		return false
+	}
+	if err := os.Remove(file + remoteTrialSuffix); err != nil {
+		return false
+	}
+	log.Infof("remote config (%s) boot success", file)
+	return true
+}
+
+// RemoteConfigTrial true while booting with not yet committed remote config
+func RemoteConfigTrial(file string) bool {
+	if file == "" {
+		return false
+	}
+	_, err := os.Stat(file + remoteTrialSuffix)
+	return err == nil
+}
@@ -0,0 +1,137 @@
+package config_global
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexTransit/vender/log2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestConfig(t *testing.T) (mainFile, remoteFile string) {
+	dir := t.TempDir()
+	mainFile = filepath.Join(dir, "vender.hcl")
+	remoteFile = filepath.Join(dir, "remote.hcl")
+	require.NoError(t, os.WriteFile(mainFile, []byte(`
+remote_config = "`+remoteFile+`"
+engine {
+  menu {
+    item "1" {
+      name = "espresso"
+      price = 30
+      scenario = "sleep(1ms)"
+    }
+  }
+}
+`), 0o644))
+	return mainFile, remoteFile
+}
+
+func menuFragment(price string) []byte {
+	return []byte("engine {\n menu {\n item \"1\" {\n " + price + "\n scenario = \"sleep(1ms)\"\n }\n }\n}\n")
+}
+
+func TestCheckRemoteConfig(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	mainFile, remoteFile := writeTestConfig(t)
+
+	cfg, err := CheckRemoteConfig(log, mainFile, "price", []byte(`
+engine {
+  menu {
+    item "1" {
+      price = 40
+      scenario = "sleep(1ms)"
+    }
+    item "2" {
+      name = "latte"
+      price = 50
+      scenario = "sleep(2ms)"
+    }
+  }
+}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, remoteFile, cfg.RemoteConfig)
+	assert.Equal(t, "espresso", cfg.Engine.Menu.Items["1"].Name)
+	assert.Equal(t, 4000, int(cfg.Engine.Menu.Items["1"].Price))
+	assert.Equal(t, 2, len(cfg.Engine.Menu.Items))
+
+	for name, bad := range map[string]string{
+		"parse":   `engine { menu {`,
+		"include": `include "/etc/passwd" {}`,
+		"unknown": string(menuFragment(`prise = 40`)),
+		"missing": "engine {\n menu {\n item \"1\" {\n price = 40\n }\n }\n}\n",
+		"move":    `remote_config = "/tmp/x"`,
+	} {
+		cfg, err = CheckRemoteConfig(log, mainFile, name, []byte(bad))
+		if name == "move" { // allowed, but file stays
+			require.NoError(t, err)
+			assert.Equal(t, remoteFile, cfg.RemoteConfig)
+			continue
+		}
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRemoteConfigApplyRollback(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	mainFile, remoteFile := writeTestConfig(t)
+	v1 := menuFragment(`price = 40`)
+	v2 := menuFragment(`price = 50`)
+
+	assert.False(t, ApplyRemoteConfig(log, remoteFile)) // nothing pending
+
+	// first remote config, boot success
+	require.NoError(t, WriteRemoteConfig(remoteFile, v1))
+	assert.True(t, ApplyRemoteConfig(log, remoteFile))
+	assert.False(t, RemoteConfigBoot(log, remoteFile))
+	assert.True(t, RemoteConfigTrial(remoteFile))
+	assert.Equal(t, 4000, int(ReadConfig(log, mainFile).Engine.Menu.Items["1"].Price))
+	assert.True(t, RemoteConfigCommit(log, remoteFile))
+	assert.False(t, RemoteConfigCommit(log, remoteFile))
+
+	// second, boot failed twice -> rollback to v1
+	require.NoError(t, WriteRemoteConfig(remoteFile, v2))
+	assert.True(t, ApplyRemoteConfig(log, remoteFile))
+	assert.False(t, RemoteConfigBoot(log, remoteFile))
+	assert.Equal(t, 5000, int(ReadConfig(log, mainFile).Engine.Menu.Items["1"].Price))
+	assert.True(t, RemoteConfigBoot(log, remoteFile))
+	assert.False(t, RemoteConfigTrial(remoteFile))
+	b, err := os.ReadFile(remoteFile)
+	require.NoError(t, err)
+	assert.Equal(t, v1, b)
+	assert.Equal(t, 4000, int(ReadConfig(log, mainFile).Engine.Menu.Items["1"].Price))
+	assert.False(t, RemoteConfigBoot(log, remoteFile))
+}
+
+// broken remote config must not crash loop at first ReadConfig, previous is restored
+func TestRemoteConfigReadRollback(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	mainFile, remoteFile := writeTestConfig(t)
+	v1 := menuFragment(`price = 40`)
+
+	require.NoError(t, WriteRemoteConfig(remoteFile, v1))
+	assert.True(t, ApplyRemoteConfig(log, remoteFile))
+	assert.True(t, RemoteConfigCommit(log, remoteFile))
+
+	for name, bad := range map[string][]byte{
+		"parse":   []byte(`engine { menu {`),
+		"unknown": menuFragment(`prise = 50`),
+	} {
+		require.NoError(t, WriteRemoteConfig(remoteFile, bad))
+		assert.True(t, ApplyRemoteConfig(log, remoteFile))
+		assert.Equal(t, 4000, int(ReadConfig(log, mainFile).Engine.Menu.Items["1"].Price), name)
+		assert.False(t, RemoteConfigTrial(remoteFile), name)
+		b, err := os.ReadFile(remoteFile)
+		require.NoError(t, err)
+		assert.Equal(t, v1, b, name)
+	}
+}
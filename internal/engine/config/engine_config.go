@@ -34,6 +34,9 @@ type Config struct {
 	// Example: on_shutdown = [ "text_poweroff picture(/home/vmc/pic-broken) money.abort evend.cup.light_off evend.valve.set_temp_hot(0) " ]
 	OnShutdown []string      `hcl:"on_shutdown,optional"`
 	Profile    ProfileStruct `hcl:"profile,block"`
+	// RU: сколько последних заказов хранить в трассировке (действия, аргументы, ошибки, обмен MDB). 0 - выключено.
+	// Выгрузка через metrics: /trace - список, /trace?n=0 - последний заказ в формате Chrome trace.
+	TraceOrders int `hcl:"trace_orders,optional"`
 	// RU: список меню.
 	XXX_Menu    menu_config.XXX_MenuStruct `hcl:"menu,block"`
 	Menu        menu_config.MenuStruct
@@ -47,8 +50,11 @@ type ProfileStruct struct {
 }
 
 type Alias struct {
-	Name     string `hcl:"name,label"`
-	Scenario string `hcl:"scenario"`
+	Name string `hcl:"name,label"`
+	// RU: параметры псевдонима. в сценарии подставляются как $имя, вызов: name(1,2) или name(water=1, coffee=2). все параметры обязательны.
+	// Example: params = ["water", "coffee"] scenario = "add.water_hot($water) add.coffee($coffee)"
+	Params   []string `hcl:"params,optional"`
+	Scenario string   `hcl:"scenario"`
 
 	XXX_OnError []XXXErrorAction `hcl:"onError,block"`
 	OnError     map[string]ErrorAction
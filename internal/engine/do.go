@@ -63,11 +63,21 @@ type Sleep struct{ time.Duration }
 
 func (s Sleep) Validate() error                                   { return nil }
 func (s Sleep) Calculation() float64                              { return 0 }
-func (s Sleep) Do(ctx context.Context) error                      { time.Sleep(s.Duration); return nil }
 func (s Sleep) String() string                                    { return fmt.Sprintf("Sleep(%v)", s.Duration) }
 func (s Sleep) AddErrorAction(code string, d Doer, skipMain bool) {}
 func (s Sleep) FixErrorAction(code string) Doer                   { return Doer(nil) }
 
+func (s Sleep) Do(ctx context.Context) error {
+	t := time.NewTimer(s.Duration)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 type RepeatN struct {
 	N uint
 	D Doer
@@ -83,6 +93,9 @@ func (r RepeatN) Do(ctx context.Context) error {
 	log := log2.ContextValueLogger(ctx)
 	var err error
 	for i := uint(1); i <= r.N && err == nil; i++ {
+		if err = ctx.Err(); err != nil {
+			break
+		}
 		log.Debugf("engine loop %d/%d", i, r.N)
 		err = GetGlobal(ctx).ExecPart(ctx, r.D)
 	}
@@ -0,0 +1,280 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// typed multi argument actions.
+// scenario syntax: name(300) name(300, 80) name(pos=300, speed=80) name(pos=?, speed=80)
+// positional arguments go to params in order, named by name. "?" is placeholder filled by ArgApply.
+
+type ArgType uint8
+
+const (
+	ArgInt ArgType = iota + 1 // int16, like FuncArg numeric argument
+	ArgString
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case ArgInt:
+		return "int"
+	case ArgString:
+		return "string"
+	}
+	return "invalid"
+}
+
+// Param argument schema. Default nil = required
+type Param struct {
+	Name    string
+	Type    ArgType
+	Default Arg
+}
+
+// Args argument values by param name
+type Args map[string]Arg
+
+func (a Args) Int(name string) int16 {
+	v, _ := a[name].(int16)
+	return v
+}
+
+func (a Args) String(name string) string {
+	v, _ := a[name].(string)
+	return v
+}
+
+// NamedArg applies to placeholder with same param name
+type NamedArg struct {
+	Name  string
+	Value Arg
+}
+
+type FuncArgs struct {
+	Name   string
+	Params []Param
+	F      func(context.Context, Args) error
+	V      ValidateFunc
+	C      CalculationFunc
+	args   Args
+	places []string // pending placeholders, in text order
+}
+
+func (e *Engine) RegisterNewFuncArgs(name string, params []Param, fun func(context.Context, Args) error) {
+	e.Register(name+"(?)", FuncArgs{Name: name, Params: params, F: fun})
+}
+
+func (fa FuncArgs) param(name string) (Param, bool) {
+	for _, p := range fa.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+// convert text or applied value to param type
+func (p Param) value(v Arg) (Arg, error) {
+	switch p.Type {
+	case ArgInt:
+		switch x := v.(type) {
+		case int16:
+			return x, nil
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("param=%s value=%s expected %s", p.Name, x, p.Type)
+			}
+			return int16(n), nil
+		}
+	case ArgString:
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case int16:
+			return strconv.Itoa(int(x)), nil
+		}
+	}
+	return nil, fmt.Errorf("param=%s value=%v expected %s", p.Name, v, p.Type)
+}
+
+// split "300, speed=80" into positional and named values
+func splitCallArgs(text string) (positional []string, named [][2]string, err error) {
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, nil, fmt.Errorf("args=(%s) empty argument", text)
+		}
+		if name, value, ok := strings.Cut(part, "="); ok {
+			named = append(named, [2]string{strings.TrimSpace(name), strings.TrimSpace(value)})
+			continue
+		}
+		if len(named) != 0 {
+			return nil, nil, fmt.Errorf("args=(%s) positional argument after named", text)
+		}
+		positional = append(positional, part)
+	}
+	return positional, named, nil
+}
+
+// Bind copy of FuncArgs with arguments from scenario text, type checked.
+func (fa FuncArgs) Bind(text string) (FuncArgs, error) {
+	positional, named, err := splitCallArgs(text)
+	if err != nil {
+		return fa, errors.Annotatef(err, FmtErrContext, fa.Name)
+	}
+	if len(positional) > len(fa.Params) {
+		return fa, errors.Errorf("%s takes %d arguments, given %d", fa.Name, len(fa.Params), len(positional))
+	}
+	result := fa
+	result.args = make(Args, len(fa.Params))
+	result.places = nil
+	seen := make(map[string]bool, len(fa.Params))
+	set := func(p Param, text string) error {
+		if seen[p.Name] {
+			return errors.Errorf("%s param=%s repeated", fa.Name, p.Name)
+		}
+		seen[p.Name] = true
+		if text == "?" {
+			result.places = append(result.places, p.Name)
+			return nil
+		}
+		v, err := p.value(text)
+		if err != nil {
+			return errors.Annotatef(err, FmtErrContext, fa.Name)
+		}
+		result.args[p.Name] = v
+		return nil
+	}
+	for i, text := range positional {
+		if err := set(fa.Params[i], text); err != nil {
+			return fa, err
+		}
+	}
+	for _, nv := range named {
+		p, ok := fa.param(nv[0])
+		if !ok {
+			return fa, errors.Errorf("%s unknown param=%s", fa.Name, nv[0])
+		}
+		if err := set(p, nv[1]); err != nil {
+			return fa, err
+		}
+	}
+	for _, p := range fa.Params {
+		if seen[p.Name] {
+			continue
+		}
+		if p.Default == nil {
+			return fa, errors.Errorf("%s param=%s required", fa.Name, p.Name)
+		}
+		result.args[p.Name] = p.Default
+	}
+	return result, nil
+}
+
+// unbound "name(?)" - every required param is placeholder
+func (fa FuncArgs) pending() []string {
+	if fa.args != nil {
+		return fa.places
+	}
+	places := make([]string, 0, len(fa.Params))
+	for _, p := range fa.Params {
+		if p.Default == nil {
+			places = append(places, p.Name)
+		}
+	}
+	return places
+}
+
+// Apply fills first placeholder, NamedArg fills placeholder with the same name.
+func (fa FuncArgs) Apply(a Arg) (Doer, bool, error) {
+	places := fa.pending()
+	name := ""
+	if na, ok := a.(NamedArg); ok {
+		for _, pn := range places {
+			if pn == na.Name {
+				name = pn
+			}
+		}
+		a = na.Value
+	} else if len(places) != 0 {
+		name = places[0]
+	}
+	if name == "" {
+		return nil, false, errors.Annotatef(ErrArgOverwrite, FmtErrContext, fa.Name)
+	}
+	p, _ := fa.param(name)
+	v, err := p.value(a)
+	if err != nil {
+		return nil, false, errors.Annotatef(err, FmtErrContext, fa.Name)
+	}
+	result := fa
+	result.args = make(Args, len(fa.Params))
+	for k, v := range fa.args {
+		result.args[k] = v
+	}
+	if fa.args == nil {
+		for _, p := range fa.Params {
+			if p.Default != nil {
+				result.args[p.Name] = p.Default
+			}
+		}
+	}
+	result.args[name] = v
+	result.places = make([]string, 0, len(places))
+	for _, pn := range places {
+		if pn != name {
+			result.places = append(result.places, pn)
+		}
+	}
+	return result, true, nil
+}
+
+func (fa FuncArgs) Validate() error {
+	if places := fa.pending(); len(places) != 0 {
+		return errors.Annotatef(ErrArgNotApplied, "%s params=%s", fa.String(), strings.Join(places, ","))
+	}
+	return useValidator(fa.V)
+}
+
+func (fa FuncArgs) Calculation() float64 {
+	if len(fa.pending()) != 0 {
+		return 0
+	}
+	return useCalculation(fa.C)
+}
+
+func (fa FuncArgs) Do(ctx context.Context) error {
+	if err := fa.Validate(); err != nil {
+		return err
+	}
+	args := make(Args, len(fa.args))
+	for k, v := range fa.args {
+		args[k] = v
+	}
+	return fa.F(ctx, args)
+}
+
+func (fa FuncArgs) String() string {
+	parts := make([]string, len(fa.Params))
+	for i, p := range fa.Params {
+		v, ok := fa.args[p.Name]
+		if !ok {
+			parts[i] = p.Name + "=?"
+			continue
+		}
+		parts[i] = fmt.Sprintf("%s=%v", p.Name, v)
+	}
+	return fa.Name + "(" + strings.Join(parts, ",") + ")"
+}
+
+func (fa FuncArgs) AddErrorAction(code string, d Doer, skipMain bool) {}
+func (fa FuncArgs) FixErrorAction(code string) Doer                   { return Doer(nil) }
+
+var _ ArgApplier = FuncArgs{}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncArgs(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	var got Args
+	e.RegisterNewFuncArgs("conveyor.move", []Param{
+		{Name: "pos", Type: ArgInt},
+		{Name: "speed", Type: ArgInt, Default: int16(-1)},
+		{Name: "mode", Type: ArgString, Default: "fast"},
+	}, func(_ context.Context, args Args) error {
+		got = args
+		return nil
+	})
+
+	cases := []struct {
+		text  string
+		pos   int16
+		speed int16
+		mode  string
+	}{
+		{"conveyor.move(300)", 300, -1, "fast"},
+		{"conveyor.move(300, 80)", 300, 80, "fast"},
+		{"conveyor.move(pos=300, speed=80)", 300, 80, "fast"},
+		{"conveyor.move(speed=80, pos=300)", 300, 80, "fast"},
+		{"conveyor.move(300, mode=slow)", 300, -1, "slow"},
+	}
+	for _, c := range cases {
+		d, err := e.ParseText("t", c.text)
+		require.NoError(t, err, c.text)
+		require.NoError(t, e.Exec(ctx, d), c.text)
+		assert.Equal(t, c.pos, got.Int("pos"), c.text)
+		assert.Equal(t, c.speed, got.Int("speed"), c.text)
+		assert.Equal(t, c.mode, got.String("mode"), c.text)
+	}
+
+	// errors at parse time, not at execution
+	for _, text := range []string{
+		"conveyor.move(pos=abc)",
+		"conveyor.move(speed=80)",
+		"conveyor.move(300, 80, slow, 1)",
+		"conveyor.move(300, height=5)",
+		"conveyor.move(300, pos=400)",
+		"conveyor.move(pos=300, 80)",
+		"conveyor.move(300,)",
+	} {
+		_, err := e.ParseText("t", text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestFuncArgsPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	var got Args
+	e.RegisterNewFuncArgs("conveyor.move", []Param{
+		{Name: "pos", Type: ArgInt},
+		{Name: "speed", Type: ArgInt, Default: int16(-1)},
+	}, func(_ context.Context, args Args) error {
+		got = args
+		return nil
+	})
+
+	d, err := e.ResolveOrLazy("conveyor.move(pos=?, speed=?)")
+	require.NoError(t, err)
+	err = d.Validate()
+	assert.True(t, errors.Is(err, ErrArgNotApplied), err)
+
+	// by name, in any order
+	d2, applied, err := ArgApply(d, NamedArg{Name: "speed", Value: int16(80)})
+	require.NoError(t, err)
+	require.True(t, applied)
+	assert.Error(t, d2.Validate())
+	d2, applied, err = ArgApply(d2, NamedArg{Name: "pos", Value: "300"})
+	require.NoError(t, err)
+	require.True(t, applied)
+	require.NoError(t, d2.Validate())
+	assert.Equal(t, "conveyor.move(pos=300,speed=80)", d2.String())
+	require.NoError(t, e.Exec(ctx, d2))
+	assert.Equal(t, int16(300), got.Int("pos"))
+	assert.Equal(t, int16(80), got.Int("speed"))
+
+	// type checked, filled placeholder not overwritten
+	_, _, err = ArgApply(d, NamedArg{Name: "pos", Value: "abc"})
+	assert.Error(t, err)
+	_, _, err = ArgApply(d2, int16(1))
+	assert.True(t, errors.Is(err, ErrArgOverwrite), err)
+
+	// old single argument call: positional fills required params
+	d3, applied, err := ArgApply(e.Resolve("conveyor.move(?)"), int16(150))
+	require.NoError(t, err)
+	require.True(t, applied)
+	require.NoError(t, e.Exec(ctx, d3))
+	assert.Equal(t, int16(150), got.Int("pos"))
+	assert.Equal(t, int16(-1), got.Int("speed"))
+}
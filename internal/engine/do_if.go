@@ -0,0 +1,457 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	oerr "github.com/juju/errors"
+)
+
+// Conditional executor. scenario syntax: if(cond){ a b }else{ c }
+// cond evaluated at Do time, Validate checks both branches.
+type If struct {
+	name string
+	e    *Engine
+	cond cond
+	then Doer
+	els  Doer // nil = no else
+}
+
+func (d *If) Validate() (err error) {
+	if e := d.cond.validate(d.e); e != nil {
+		err = errors.Join(err, fmt.Errorf("if=%s condition (%v)", d.String(), e))
+	}
+	for _, b := range []Doer{d.then, d.els} {
+		if b == nil {
+			continue
+		}
+		if e := b.Validate(); e != nil {
+			err = errors.Join(err, fmt.Errorf("if=%s branch=%s validate (%v)", d.String(), b.String(), e))
+		}
+	}
+	return err
+}
+
+// worst case of branches
+func (d *If) Calculation() float64 {
+	c := d.then.Calculation()
+	if d.els != nil {
+		c = math.Max(c, d.els.Calculation())
+	}
+	return c
+}
+
+func (d *If) Do(ctx context.Context) error {
+	e := GetGlobal(ctx)
+	v, err := d.cond.eval(ctx, e)
+	if err != nil {
+		return oerr.Annotatef(err, FmtErrContext, d.String())
+	}
+	if truth(v) {
+		return e.Exec(ctx, d.then)
+	}
+	if d.els != nil {
+		return e.Exec(ctx, d.els)
+	}
+	return nil
+}
+
+func (d *If) String() string { return d.name }
+
+func (d *If) AddErrorAction(code string, ed Doer, skipMain bool) {}
+func (d *If) FixErrorAction(code string) Doer                    { return Doer(nil) }
+
+// Apply makes copy of If, applying `arg` to first placeholder of then and of else.
+// only one branch runs, so the argument is bound in both.
+func (d *If) Apply(arg Arg) (Doer, bool, error) {
+	result := *d
+	found := false
+	for _, b := range []*Doer{&result.then, &result.els} {
+		if *b == nil {
+			continue
+		}
+		new, applied, err := ArgApply(*b, arg)
+		switch oerr.Cause(err) {
+		case nil:
+			if applied {
+				*b = new
+				found = true
+			}
+		case ErrArgOverwrite, ErrArgNotApplied:
+		default:
+			return nil, false, oerr.Annotatef(err, FmtErrContext, d.String())
+		}
+	}
+	if !found {
+		return nil, false, oerr.Annotatef(ErrArgNotApplied, FmtErrContext, d.String())
+	}
+	return &result, true, nil
+}
+
+func (d *If) Force() (Doer, bool, error) {
+	result := *d
+	var forced, f bool
+	var err error
+	if result.then, forced, err = Force(d.then); err != nil {
+		return nil, forced, err
+	}
+	if d.els != nil {
+		if result.els, f, err = Force(d.els); err != nil {
+			return nil, f, err
+		}
+		forced = forced || f
+	}
+	if !forced {
+		return d, false, nil
+	}
+	return &result, true, nil
+}
+
+// compile-time interface checks
+var (
+	_ ArgApplier = &If{}
+	_ Forcer     = &If{}
+)
+
+// read-only scenario variables, value is float64 or string
+type VarFunc func(ctx context.Context, name string) (interface{}, error)
+
+type scenarioVar struct {
+	has func(name string) bool // prefix variables only
+	get VarFunc
+}
+
+// RegisterVar name="user.cream" exact variable
+func (e *Engine) RegisterVar(name string, get VarFunc) {
+	e.lk.Lock()
+	e.vars[name] = scenarioVar{get: get}
+	e.lk.Unlock()
+}
+
+// RegisterVarPrefix prefix="stock." variables family, has() checks name exists
+func (e *Engine) RegisterVarPrefix(prefix string, has func(name string) bool, get VarFunc) {
+	e.lk.Lock()
+	e.vars[prefix] = scenarioVar{has: has, get: get}
+	e.lk.Unlock()
+}
+
+func (e *Engine) lookupVar(name string) (VarFunc, bool) {
+	e.lk.RLock()
+	defer e.lk.RUnlock()
+	if v, ok := e.vars[name]; ok && v.has == nil {
+		return v.get, true
+	}
+	for i := len(name) - 1; i > 0; i-- {
+		if name[i] != '.' {
+			continue
+		}
+		if v, ok := e.vars[name[:i+1]]; ok && v.has != nil && v.has(name) {
+			return v.get, true
+		}
+	}
+	return nil, false
+}
+
+func truth(v interface{}) bool {
+	switch x := v.(type) {
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return v != nil
+}
+
+func normValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case fmt.Stringer:
+		return x.String()
+	}
+	return v
+}
+
+type cond interface {
+	eval(ctx context.Context, e *Engine) (interface{}, error)
+	validate(e *Engine) error
+	String() string
+}
+
+type condLit struct{ v interface{} }
+
+func (c condLit) eval(context.Context, *Engine) (interface{}, error) { return c.v, nil }
+func (c condLit) validate(*Engine) error                             { return nil }
+func (c condLit) String() string {
+	if s, ok := c.v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(c.v)
+}
+
+type condVar struct{ name string }
+
+func (c condVar) eval(ctx context.Context, e *Engine) (interface{}, error) {
+	get, ok := e.lookupVar(c.name)
+	if !ok {
+		return nil, fmt.Errorf("variable=%s not found", c.name)
+	}
+	v, err := get(ctx, c.name)
+	if err != nil {
+		return nil, oerr.Annotatef(err, "variable=%s", c.name)
+	}
+	return normValue(v), nil
+}
+
+func (c condVar) validate(e *Engine) error {
+	if _, ok := e.lookupVar(c.name); !ok {
+		return fmt.Errorf("variable=%s not found", c.name)
+	}
+	return nil
+}
+func (c condVar) String() string { return c.name }
+
+type condNot struct{ x cond }
+
+func (c condNot) eval(ctx context.Context, e *Engine) (interface{}, error) {
+	v, err := c.x.eval(ctx, e)
+	return !truth(v), err
+}
+func (c condNot) validate(e *Engine) error { return c.x.validate(e) }
+func (c condNot) String() string           { return "!" + c.x.String() }
+
+type condBin struct {
+	op   string
+	l, r cond
+}
+
+func (c condBin) validate(e *Engine) error {
+	return errors.Join(c.l.validate(e), c.r.validate(e))
+}
+func (c condBin) String() string { return c.l.String() + c.op + c.r.String() }
+
+func (c condBin) eval(ctx context.Context, e *Engine) (interface{}, error) {
+	l, err := c.l.eval(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	switch c.op { // short circuit
+	case "&&":
+		if !truth(l) {
+			return false, nil
+		}
+	case "||":
+		if truth(l) {
+			return true, nil
+		}
+	}
+	r, err := c.r.eval(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	if c.op == "&&" || c.op == "||" {
+		return truth(r), nil
+	}
+	lf, lnum := l.(float64)
+	rf, rnum := r.(float64)
+	if lnum && rnum {
+		switch c.op {
+		case "==":
+			return lf == rf, nil
+		case "!=":
+			return lf != rf, nil
+		case "<":
+			return lf < rf, nil
+		case "<=":
+			return lf <= rf, nil
+		case ">":
+			return lf > rf, nil
+		case ">=":
+			return lf >= rf, nil
+		}
+	}
+	ls, rs := fmt.Sprint(l), fmt.Sprint(r)
+	switch c.op {
+	case "==":
+		return strings.EqualFold(ls, rs), nil
+	case "!=":
+		return !strings.EqualFold(ls, rs), nil
+	}
+	return nil, fmt.Errorf("condition=%s compare %v %s %v not numbers", c.String(), l, c.op, r)
+}
+
+// cond grammar:
+// or    = and { "||" and }
+// and   = unary { "&&" unary }
+// unary = "!" unary | cmp
+// cmp   = operand [ ("=="|"!="|"<"|"<="|">"|">=") operand ]
+// operand = "(" or ")" | number | "string" | name
+// name with dot is variable (stock.sugar), without dot - string literal (cash)
+type condParser struct {
+	text   string
+	tokens []string
+	pos    int
+}
+
+func parseCond(text string) (cond, error) {
+	tokens, err := condTokens(text)
+	if err != nil {
+		return nil, err
+	}
+	cp := condParser{text: text, tokens: tokens}
+	c, err := cp.or()
+	if err != nil {
+		return nil, err
+	}
+	if cp.pos != len(cp.tokens) {
+		return nil, fmt.Errorf("condition=%s unexpected %s", text, cp.tokens[cp.pos])
+	}
+	return c, nil
+}
+
+func (cp *condParser) peek() string {
+	if cp.pos < len(cp.tokens) {
+		return cp.tokens[cp.pos]
+	}
+	return ""
+}
+
+func (cp *condParser) or() (cond, error) {
+	l, err := cp.and()
+	for err == nil && cp.peek() == "||" {
+		cp.pos++
+		var r cond
+		if r, err = cp.and(); err == nil {
+			l = condBin{op: "||", l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (cp *condParser) and() (cond, error) {
+	l, err := cp.unary()
+	for err == nil && cp.peek() == "&&" {
+		cp.pos++
+		var r cond
+		if r, err = cp.unary(); err == nil {
+			l = condBin{op: "&&", l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (cp *condParser) unary() (cond, error) {
+	if cp.peek() == "!" {
+		cp.pos++
+		x, err := cp.unary()
+		return condNot{x: x}, err
+	}
+	l, err := cp.operand()
+	if err != nil {
+		return nil, err
+	}
+	switch op := cp.peek(); op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		cp.pos++
+		r, err := cp.operand()
+		if err != nil {
+			return nil, err
+		}
+		return condBin{op: op, l: l, r: r}, nil
+	}
+	return l, nil
+}
+
+func (cp *condParser) operand() (cond, error) {
+	t := cp.peek()
+	cp.pos++
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("condition=%s unexpected end", cp.text)
+	case t == "(":
+		c, err := cp.or()
+		if err != nil {
+			return nil, err
+		}
+		if cp.peek() != ")" {
+			return nil, fmt.Errorf("condition=%s ) expected", cp.text)
+		}
+		cp.pos++
+		return c, nil
+	case t[0] == '"' || t[0] == '\'':
+		return condLit{v: t[1 : len(t)-1]}, nil
+	case t[0] == '-' || unicode.IsDigit(rune(t[0])):
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("condition=%s invalid number %s", cp.text, t)
+		}
+		return condLit{v: f}, nil
+	case isNameRune(rune(t[0])):
+		if strings.Contains(t, ".") {
+			return condVar{name: t}, nil
+		}
+		return condLit{v: t}, nil
+	}
+	return nil, fmt.Errorf("condition=%s unexpected %s", cp.text, t)
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func condTokens(text string) ([]string, error) {
+	tokens := make([]string, 0, 4)
+	rs := []rune(text)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(rs) && rs[j] != r {
+				j++
+			}
+			if j == len(rs) {
+				return nil, fmt.Errorf("condition=%s string not closed", text)
+			}
+			tokens = append(tokens, string(rs[i:j+1]))
+			i = j + 1
+		case i+1 < len(rs) && strings.Contains("== != <= >= && ||", string(rs[i:i+2])):
+			tokens = append(tokens, string(rs[i:i+2]))
+			i += 2
+		case strings.ContainsRune("<>!()", r):
+			tokens = append(tokens, string(r))
+			i++
+		case r == '-' || isNameRune(r):
+			j := i + 1
+			for j < len(rs) && isNameRune(rs[j]) {
+				j++
+			}
+			tokens = append(tokens, string(rs[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("condition=%s unexpected %c", text, r)
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCond(t *testing.T) {
+	t.Parallel()
+
+	_, e := newParTestEngine(t)
+	vars := map[string]interface{}{"user.cream": uint8(0), "payment.method": "Cash", "valve.temp": int32(80)}
+	for name := range vars {
+		e.RegisterVar(name, func(_ context.Context, name string) (interface{}, error) { return vars[name], nil })
+	}
+	ctx := context.WithValue(context.Background(), ContextKey, e)
+	cases := map[string]bool{
+		"user.cream>0":                                  false,
+		"user.cream == 0":                               true,
+		"payment.method==cash":                          true,
+		"payment.method != 'Gift'":                      true,
+		"valve.temp>=75 && !(user.cream>0)":             true,
+		"valve.temp<75 || payment.method==\"cashless\"": false,
+		"user.cream":                                    false,
+		"valve.temp > -1":                               true,
+	}
+	for text, expect := range cases {
+		c, err := parseCond(text)
+		require.NoError(t, err, text)
+		require.NoError(t, c.validate(e), text)
+		v, err := c.eval(ctx, e)
+		require.NoError(t, err, text)
+		assert.Equal(t, expect, truth(v), text)
+	}
+	for _, bad := range []string{"", "user.cream >", "(user.cream", "user.cream = 1", "'open"} {
+		_, err := parseCond(bad)
+		assert.Error(t, err, bad)
+	}
+	c, err := parseCond("stock.cram > 0")
+	require.NoError(t, err)
+	assert.Error(t, c.validate(e))
+	c, err = parseCond("payment.method > 1")
+	require.NoError(t, err)
+	_, err = c.eval(ctx, e)
+	assert.Error(t, err)
+}
+
+func TestParseIf(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	cream := 0
+	e.RegisterVar("user.cream", func(context.Context, string) (interface{}, error) { return cream, nil })
+	e.RegisterVarPrefix("stock.", func(name string) bool { return name == "stock.milk" },
+		func(context.Context, string) (interface{}, error) { return float32(10), nil })
+	var log []string
+	step := FuncArg{Name: "step(?)", F: func(ctx context.Context, arg Arg) error {
+		log = append(log, fmt.Sprint(arg))
+		return nil
+	}, C: func() float64 { return 1 }}
+	e.Register("step(?)", step)
+	e.Register("mix(?)", mustParse(t, e, "mix", "step(mix) step(?)"))
+
+	d, err := e.ParseText("drink", "step(cup) if(user.cream>0){ step(cream) mix(3) }else if(stock.milk>5){step(milk)}else{step(water)} step(done)")
+	require.NoError(t, err)
+	require.NoError(t, d.Validate())
+	assert.Equal(t, float64(2+3), d.Calculation()) // worst branch
+
+	require.NoError(t, e.Exec(ctx, d))
+	assert.Equal(t, "cup milk done", strings.Join(log, " "))
+	log, cream = nil, 2
+	require.NoError(t, e.Exec(ctx, d))
+	assert.Equal(t, "cup cream mix 3 done", strings.Join(log, " "))
+
+	// argument applied inside branch
+	e.Register("opt(?)", mustParse(t, e, "opt", "if(user.cream>0){step(?)}"))
+	applied, err := e.ParseText("apply", "opt(x)")
+	require.NoError(t, err)
+	require.NoError(t, applied.Validate())
+
+	// placeholder only in else, and in both branches
+	e.Register("alt(?)", mustParse(t, e, "alt", "if(user.cream>0){step(none)}else{step(?)}"))
+	e.Register("both(?)", mustParse(t, e, "both", "if(user.cream>0){step(?)}else{step(?)}"))
+	d, err = e.ParseText("apply", "alt(x) both(y)")
+	require.NoError(t, err)
+	require.NoError(t, d.Validate())
+	log, cream = nil, 0
+	require.NoError(t, e.Exec(ctx, d))
+	assert.Equal(t, "x y", strings.Join(log, " "))
+	log, cream = nil, 1
+	require.NoError(t, e.Exec(ctx, d))
+	assert.Equal(t, "none y", strings.Join(log, " "))
+
+	// every branch validated
+	d, err = e.ParseText("drink", "if(user.cream>0){ step(a) }else{ unknown.action }")
+	require.NoError(t, err)
+	assert.Error(t, d.Validate())
+	d, err = e.ParseText("drink", "if(stock.sugar>0){ step(a) }")
+	require.NoError(t, err)
+	assert.Error(t, d.Validate())
+
+	for _, bad := range []string{"if(user.cream>0) step(a)", "if(user.cream>0){ step(a)", "step(a) }", "else{ step(a) }", "if(user.cream>){step(a)}"} {
+		_, err = e.ParseText("bad", bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// alias with declared params, expanded when scenario is parsed.
+// alias "make_coffee" { params = ["water", "coffee"] scenario = "add.water_hot($water) add.coffee($coffee)" }
+// call: make_coffee(150, 8) or make_coffee(water=150, coffee=8). every param is required.
+
+const macroMaxDepth = 16
+
+var (
+	reMacroParam     = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+	reMacroParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+type Macro struct {
+	Name     string
+	Params   []string
+	Scenario string
+	errors   []macroErrorAction
+}
+
+type macroErrorAction struct {
+	code     string
+	d        Doer
+	skipMain bool
+}
+
+// NewMacro checks params: unique, every param used in scenario, every $name declared
+func NewMacro(name string, params []string, scenario string) (*Macro, error) {
+	declared := make(map[string]bool, len(params))
+	for _, p := range params {
+		if !reMacroParamName.MatchString(p) {
+			return nil, errors.Errorf("alias=%s invalid param=%s", name, p)
+		}
+		if declared[p] {
+			return nil, errors.Errorf("alias=%s param=%s repeated", name, p)
+		}
+		declared[p] = true
+	}
+	used := make(map[string]bool, len(params))
+	for _, m := range reMacroParam.FindAllStringSubmatch(scenario, -1) {
+		if !declared[m[1]] {
+			return nil, errors.Errorf("alias=%s scenario uses undeclared $%s", name, m[1])
+		}
+		used[m[1]] = true
+	}
+	for _, p := range params {
+		if !used[p] {
+			return nil, errors.Errorf("alias=%s param=%s not used in scenario", name, p)
+		}
+	}
+	return &Macro{Name: name, Params: params, Scenario: scenario}, nil
+}
+
+func (e *Engine) RegisterMacro(m *Macro) { e.Register(m.Name+"(?)", m) }
+
+// Text scenario with arguments of call text "150, water=8"
+func (m *Macro) Text(callArgs string) (string, error) {
+	positional, named, err := splitCallArgs(callArgs)
+	if err != nil {
+		return "", errors.Annotatef(err, FmtErrContext, m.Name)
+	}
+	if len(positional) > len(m.Params) {
+		return "", errors.Errorf("%s takes %d arguments, given %d", m.Name, len(m.Params), len(positional))
+	}
+	values := make(map[string]string, len(m.Params))
+	for i, v := range positional {
+		values[m.Params[i]] = v
+	}
+	for _, nv := range named {
+		if !m.param(nv[0]) {
+			return "", errors.Errorf("%s unknown param=%s", m.Name, nv[0])
+		}
+		if _, ok := values[nv[0]]; ok {
+			return "", errors.Errorf("%s param=%s repeated", m.Name, nv[0])
+		}
+		values[nv[0]] = nv[1]
+	}
+	for _, p := range m.Params {
+		v, ok := values[p]
+		if !ok {
+			return "", errors.Errorf("%s param=%s required", m.Name, p)
+		}
+		if v == "?" || strings.ContainsAny(v, " ()[]{}|$") {
+			return "", errors.Errorf("%s param=%s invalid value=%s", m.Name, p, v)
+		}
+	}
+	return reMacroParam.ReplaceAllStringFunc(m.Scenario, func(s string) string { return values[s[1:]] }), nil
+}
+
+func (m *Macro) param(name string) bool {
+	for _, p := range m.Params {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expand call "make_coffee(150,8)" to parsed scenario, error actions of alias are added
+func (m *Macro) expand(e *Engine, call, callArgs string, depth int) (Doer, error) {
+	if depth >= macroMaxDepth {
+		return nil, errors.Errorf("%s alias expansion too deep, recursive alias?", call)
+	}
+	text, err := m.Text(callArgs)
+	if err != nil {
+		return nil, err
+	}
+	sp := scenarioParser{e: e, text: text, tokens: scenarioTokens(text), depth: depth + 1}
+	tx, err := sp.seq(call, "")
+	if err != nil {
+		return nil, errors.Annotatef(err, FmtErrContext, call)
+	}
+	for _, ea := range m.errors {
+		tx.AddErrorAction(ea.code, ea.d, ea.skipMain)
+	}
+	return tx, nil
+}
+
+// unexpanded macro, "name(?)" without arguments
+func (m *Macro) Validate() error {
+	return errors.Annotatef(ErrArgNotApplied, "%s params=%s", m.String(), strings.Join(m.Params, ","))
+}
+func (m *Macro) Calculation() float64 { return 0 }
+func (m *Macro) Do(ctx context.Context) error {
+	return m.Validate()
+}
+func (m *Macro) String() string { return fmt.Sprintf("%s(%s)", m.Name, strings.Join(m.Params, ",")) }
+
+// AddErrorAction onError of alias, added to every expansion
+func (m *Macro) AddErrorAction(code string, d Doer, skipMain bool) {
+	m.errors = append(m.errors, macroErrorAction{code: code, d: d, skipMain: skipMain})
+}
+func (m *Macro) FixErrorAction(code string) Doer { return nil }
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacro(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	var log []string
+	for _, name := range []string{"water", "coffee", "sugar"} {
+		name := name
+		e.RegisterNewFuncAgr("add."+name+"(?)", func(_ context.Context, arg Arg) error {
+			log = append(log, fmt.Sprintf("%s=%d", name, arg))
+			return nil
+		})
+	}
+	m, err := NewMacro("make_coffee", []string{"water", "coffee", "sugar"}, "add.water($water) add.coffee($coffee) add.sugar($sugar)")
+	require.NoError(t, err)
+	e.RegisterMacro(m)
+	m, err = NewMacro("big", []string{"sugar"}, "make_coffee(300, 16, $sugar)")
+	require.NoError(t, err)
+	e.RegisterMacro(m)
+
+	for _, c := range []struct{ text, expect string }{
+		{"make_coffee(150,8,4)", "water=150 coffee=8 sugar=4"},
+		{"make_coffee(150, sugar=0, coffee=8)", "water=150 coffee=8 sugar=0"},
+		{"big(2)", "water=300 coffee=16 sugar=2"},
+	} {
+		log = nil
+		d, err := e.ParseText("menu", c.text)
+		require.NoError(t, err, c.text)
+		require.NoError(t, e.Exec(ctx, d), c.text)
+		assert.Equal(t, c.expect, strings.Join(log, " "), c.text)
+	}
+
+	// defined after scenario parsed, resolved by Lazy
+	d, err := e.ParseText("menu", "late(5)")
+	require.NoError(t, err)
+	m, err = NewMacro("late", []string{"n"}, "add.sugar($n)")
+	require.NoError(t, err)
+	e.RegisterMacro(m)
+	log = nil
+	require.NoError(t, e.Exec(ctx, d))
+	assert.Equal(t, []string{"sugar=5"}, log)
+
+	// argument count and names checked at parse time
+	for _, text := range []string{
+		"make_coffee(150,8)",
+		"make_coffee(150,8,4,1)",
+		"make_coffee(150,8,salt=4)",
+		"make_coffee(150,8,water=4)",
+		"make_coffee(150,8,?)",
+		"make_coffee(?)",
+	} {
+		_, err := e.ParseText("menu", text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestMacroDefinition(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []struct {
+		params   []string
+		scenario string
+	}{
+		{[]string{"a"}, "x($b)"},
+		{[]string{"a", "b"}, "x($a)"},
+		{[]string{"a", "a"}, "x($a)"},
+		{[]string{"1a"}, "x($1a)"},
+	} {
+		_, err := NewMacro("m", c.params, c.scenario)
+		assert.Error(t, err, c.scenario)
+	}
+
+	// recursive alias
+	_, e := newParTestEngine(t)
+	m, err := NewMacro("loop", []string{"n"}, "loop($n)")
+	require.NoError(t, err)
+	e.RegisterMacro(m)
+	_, err = e.ParseText("t", "loop(1)")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too deep")
+}
+
+func TestMacroErrorAction(t *testing.T) {
+	t.Parallel()
+
+	_, e := newParTestEngine(t)
+	e.RegisterNewFuncAgr("x(?)", func(context.Context, Arg) error { return nil })
+	m, err := NewMacro("m", []string{"n"}, "x($n)")
+	require.NoError(t, err)
+	m.AddErrorAction("15", Nothing{}, true)
+	e.RegisterMacro(m)
+	d, err := e.ParseText("t", "m(1)")
+	require.NoError(t, err)
+	seq := d.(*Seq).items[0].(*Seq)
+	assert.Equal(t, "m(1)", seq.String())
+	assert.NotNil(t, seq.FixErrorAction("15"))
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	oerr "github.com/juju/errors"
+)
+
+// Parallel executor. scenario syntax: [ a b | c ]
+// Branches run concurrently, each branch is Seq. First error cancels siblings context.
+type Par struct {
+	name     string
+	branches []Doer
+}
+
+func NewPar(name string, branches ...Doer) *Par {
+	return &Par{name: name, branches: branches}
+}
+
+func (p *Par) Validate() (err error) {
+	for _, d := range p.branches {
+		if e := d.Validate(); e != nil {
+			err = errors.Join(err, fmt.Errorf("par=%s branch=%s validate (%v)", p.String(), d.String(), e))
+		}
+	}
+	return err
+}
+
+func (p *Par) Calculation() (summ float64) {
+	for _, d := range p.branches {
+		summ += d.Calculation()
+	}
+	return summ
+}
+
+func (p *Par) Do(ctx context.Context) error {
+	e := GetGlobal(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first error
+	for _, d := range p.branches {
+		wg.Add(1)
+		go func(d Doer) {
+			defer wg.Done()
+			if err := e.Exec(ctx, d); err != nil {
+				once.Do(func() {
+					first = err
+					cancel()
+				})
+			}
+		}(d)
+	}
+	wg.Wait()
+	if first != nil {
+		e.Log.Errorf("error par:%s branch error:%v", p.String(), first)
+	}
+	return first
+}
+
+func (p *Par) String() string { return p.name }
+
+func (p *Par) AddErrorAction(code string, d Doer, skipMain bool) {}
+func (p *Par) FixErrorAction(code string) Doer                   { return Doer(nil) }
+
+// Apply makes copy of Par, applying `arg` to exactly one (first) placeholder.
+func (p *Par) Apply(arg Arg) (Doer, bool, error) {
+	result := NewPar(p.name)
+	found := false
+	places := uint(0)
+	for _, child := range p.branches {
+		if found {
+			result.branches = append(result.branches, child)
+			continue
+		}
+		new, applied, err := ArgApply(child, arg)
+		switch oerr.Cause(err) {
+		case nil: // success path
+			places++
+			found = applied
+			result.branches = append(result.branches, new)
+
+		case ErrArgOverwrite, ErrArgNotApplied:
+			places++
+			result.branches = append(result.branches, child)
+
+		default:
+			return nil, false, oerr.Annotatef(err, FmtErrContext, p.String())
+		}
+	}
+	if !found && places > 0 {
+		return nil, false, oerr.Annotatef(ErrArgNotApplied, FmtErrContext, p.String())
+	}
+	return result, true, nil
+}
+
+func (p *Par) Force() (Doer, bool, error) {
+	result := NewPar(p.name)
+	forcedAny := false
+	for _, child := range p.branches {
+		new, forced, err := Force(child)
+		if err != nil {
+			return nil, forced, oerr.Annotatef(err, FmtErrContext, child.String())
+		}
+		forcedAny = forcedAny || forced
+		result.branches = append(result.branches, new)
+	}
+	if !forcedAny {
+		return p, false, nil
+	}
+	return result, true, nil
+}
+
+// compile-time interface checks
+var (
+	_ ArgApplier = &Par{}
+	_ Forcer     = &Par{}
+)
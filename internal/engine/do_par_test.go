@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/log2"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newParTestEngine(t *testing.T) (context.Context, *Engine) {
+	e := NewEngine(log2.NewTest(t, log2.LOG_DEBUG))
+	ctx := context.WithValue(context.Background(), ContextKey, e)
+	return ctx, e
+}
+
+func TestScenarioTokens(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"a", "[", "b(1)", "c", "|", "d(x|y)", "]", "e"}, scenarioTokens("a [b(1) c| d(x|y)]  e"))
+}
+
+func TestScenarioActions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"a", "b(1)", "c", "d(x|y)", "e", "f", "g", "h", "timeout(1s)"},
+		ScenarioActions("a [b(1) c| d(x|y)] if(x>1){ e }else{ retry(2, 1s){ f } } timeout(5s){ g } h timeout(1s)"))
+}
+
+func TestParseParallel(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	var running, maxRunning, done atomic.Int32
+	step := FuncArg{Name: "step(?)", F: func(ctx context.Context, arg Arg) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() {
+		}
+		time.Sleep(time.Duration(arg.(int16)) * time.Millisecond)
+		done.Add(1)
+		return nil
+	}, C: func() float64 { return 1 }}
+	e.Register("step(?)", step)
+
+	d, err := e.ParseText("par", "step(1) [ step(30) step(1) | step(30) ] step(1)")
+	require.NoError(t, err)
+	require.NoError(t, d.Validate())
+	assert.Equal(t, float64(5), d.Calculation())
+	require.NoError(t, e.Exec(ctx, d))
+	assert.Equal(t, int32(5), done.Load())
+	assert.Equal(t, int32(2), maxRunning.Load())
+
+	// argument goes into first free placeholder inside group
+	e.Register("pair(?)", mustParse(t, e, "pair", "[ step(?) | step(1) ]"))
+	applied, err := e.ParseText("apply", "pair(2)")
+	require.NoError(t, err)
+	require.NoError(t, applied.Validate())
+	require.NoError(t, e.Exec(ctx, applied))
+
+	for _, bad := range []string{"[ step(1) | step(1)", "step(1) ]", "[ step(1) | ]", "a | b"} {
+		_, err = e.ParseText("bad", bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	var after atomic.Bool
+	e.RegisterNewFunc("fail", func(context.Context) error { return errors.New("jam") })
+	e.RegisterNewFunc("after", func(context.Context) error { after.Store(true); return nil })
+
+	d, err := e.ParseText("par", "[ sleep(10ms) fail | sleep(5s) after ]")
+	require.NoError(t, err)
+	start := time.Now()
+	err = e.Exec(ctx, d)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jam")
+	assert.True(t, time.Since(start) < time.Second)
+	assert.False(t, after.Load())
+}
+
+func mustParse(t *testing.T, e *Engine, tag, text string) Doer {
+	d, err := e.ParseText(tag, text)
+	require.NoError(t, err)
+	return d
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTransit/vender/helpers"
+	oerr "github.com/juju/errors"
+)
+
+// Retry executor. scenario syntax: retry(3, 500ms){ a b }
+// Runs block up to n times. Pause before next attempt starts with backoff and doubles.
+// Last error returned as is, so AppError code still reaches onError of enclosing alias.
+type Retry struct {
+	name    string
+	n       int
+	backoff time.Duration
+	d       Doer
+}
+
+func NewRetry(n int, backoff time.Duration, d Doer) *Retry {
+	return &Retry{name: fmt.Sprintf("retry(%d,%v){%s}", n, backoff, d.String()), n: n, backoff: backoff, d: d}
+}
+
+// parse "retry(n, backoff)" or "retry(n)"
+func parseRetry(word string) (n int, backoff time.Duration, err error) {
+	args := strings.Split(strings.TrimSuffix(strings.TrimPrefix(word, "retry("), ")"), ",")
+	if len(args) > 2 {
+		return 0, 0, fmt.Errorf("%s too many arguments", word)
+	}
+	if n, err = strconv.Atoi(strings.TrimSpace(args[0])); err != nil || n < 1 {
+		return 0, 0, fmt.Errorf("%s invalid attempts count", word)
+	}
+	if len(args) == 2 {
+		if backoff, err = time.ParseDuration(strings.TrimSpace(args[1])); err != nil || backoff < 0 {
+			return 0, 0, fmt.Errorf("%s invalid backoff", word)
+		}
+	}
+	return n, backoff, nil
+}
+
+func (r *Retry) Validate() error { return r.d.Validate() }
+
+// successful run consumes block once, same cost as without retry
+func (r *Retry) Calculation() float64 { return r.d.Calculation() }
+
+func (r *Retry) Do(ctx context.Context) (err error) {
+	e := GetGlobal(ctx)
+	pause := r.backoff
+	for attempt := 1; ; attempt++ {
+		if err = e.Exec(ctx, r.d); err == nil || attempt >= r.n || ctx.Err() != nil {
+			return err
+		}
+		e.Log.Errorf("%s attempt %d/%d error:%v next after %v", r.String(), attempt, r.n, err, pause)
+		if helpers.SleepCtx(ctx, pause) != nil {
+			return err
+		}
+		pause *= 2
+	}
+}
+
+func (r *Retry) String() string { return r.name }
+
+func (r *Retry) AddErrorAction(code string, d Doer, skipMain bool) {}
+func (r *Retry) FixErrorAction(code string) Doer                   { return Doer(nil) }
+
+func (r *Retry) Apply(arg Arg) (Doer, bool, error) {
+	d, applied, err := ArgApply(r.d, arg)
+	if err != nil {
+		return nil, false, oerr.Annotatef(err, FmtErrContext, r.String())
+	}
+	result := *r
+	result.d = d
+	return &result, applied, nil
+}
+
+func (r *Retry) Force() (Doer, bool, error) {
+	d, forced, err := Force(r.d)
+	if err != nil || !forced {
+		return r, forced, err
+	}
+	result := *r
+	result.d = d
+	return &result, true, nil
+}
+
+// compile-time interface checks
+var (
+	_ ArgApplier = &Retry{}
+	_ Forcer     = &Retry{}
+)
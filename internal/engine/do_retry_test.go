@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	calls, failUntil := 0, 2
+	e.RegisterNewFunc("jam", func(context.Context) error {
+		calls++
+		if calls <= failUntil {
+			return &helpers.AppError{ErrorCode: 17, Err: errors.New("conveyor jam")}
+		}
+		return nil
+	})
+	e.Register("unjam", Func{Name: "unjam", F: func(context.Context) error { return nil }, C: func() float64 { return 1 }})
+
+	d, err := e.ParseText("move", "retry(3, 5ms){ unjam jam }")
+	require.NoError(t, err)
+	require.NoError(t, d.Validate())
+	plain, err := e.ParseText("move", "unjam jam")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), d.Calculation())
+	assert.Equal(t, plain.Calculation(), d.Calculation(), "retry costs the same as plain block")
+	tbegin := time.Now()
+	require.NoError(t, e.Exec(ctx, d))
+	assert.Equal(t, 3, calls)
+	assert.True(t, time.Since(tbegin) >= 15*time.Millisecond, "backoff 5ms+10ms")
+
+	// attempts exhausted, last error code kept for onError
+	calls, failUntil = 0, 10
+	d, err = e.ParseText("move", "retry(2){ jam }")
+	require.NoError(t, err)
+	err = e.Exec(ctx, d)
+	var appErr *helpers.AppError
+	require.True(t, errors.As(err, &appErr), err)
+	assert.Equal(t, int32(17), appErr.Code())
+	assert.Equal(t, 2, calls)
+
+	// cancel stops retry pause
+	calls = 0
+	d, err = e.ParseText("move", "retry(5, 10s){ jam }")
+	require.NoError(t, err)
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, e.Exec(cctx, d))
+	assert.Equal(t, 1, calls)
+
+	for _, bad := range []string{"retry(0){ jam }", "retry(x, 1s){ jam }", "retry(2, 1x){ jam }", "retry(2, 1s, 3){ jam }"} {
+		_, err = e.ParseText("bad", bad)
+		assert.Error(t, err, bad)
+	}
+	// without block it is plain action name
+	d, err = e.ParseText("bad", "retry(2) jam")
+	require.NoError(t, err)
+	assert.Error(t, d.Validate())
+}
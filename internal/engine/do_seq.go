@@ -93,6 +93,9 @@ func (seq *Seq) Do(ctx context.Context) error {
 	// itemsList = append(itemsList, time.Now().Format("2006-01-02_15-04-05.00000"))
 	// itemsList = append(itemsList, seq.name)
 	for _, d := range seq.items {
+		if err := ctx.Err(); err != nil { // parallel sibling failed
+			return err
+		}
 		// itemsList = append(itemsList, time.Now().Format("-> 15:04:05.00000 ")+d.String())
 		err := e.Exec(ctx, d)
 		// itemsList = append(itemsList, time.Now().Format("<- 15:04:05.00000 ")+d.String())
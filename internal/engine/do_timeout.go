@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AlexTransit/vender/helpers"
+	oerr "github.com/juju/errors"
+)
+
+// error code of expired timeout block, match it in onError "99" { ... }
+const ErrCodeTimeout int32 = 99
+
+// Deadline executor. scenario syntax: timeout(5s){ a b }
+// When duration expires, context of inner actions is cancelled (device wait loops stop)
+// and Do returns AppError with ErrCodeTimeout.
+type Timeout struct {
+	name     string
+	duration time.Duration
+	d        Doer
+}
+
+func NewTimeout(duration time.Duration, d Doer) *Timeout {
+	return &Timeout{name: fmt.Sprintf("timeout(%v){%s}", duration, d.String()), duration: duration, d: d}
+}
+
+func (t *Timeout) Validate() error {
+	if t.duration <= 0 {
+		return fmt.Errorf("%s invalid duration", t.String())
+	}
+	return t.d.Validate()
+}
+
+func (t *Timeout) Calculation() float64 { return t.d.Calculation() }
+
+func (t *Timeout) Do(ctx context.Context) error {
+	e := GetGlobal(ctx)
+	tctx, cancel := context.WithTimeout(ctx, t.duration)
+	defer cancel()
+	err := e.Exec(tctx, t.d)
+	// parent cancel (shutdown, abort) is not a timeout
+	if ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return &helpers.AppError{ErrorCode: ErrCodeTimeout, Err: fmt.Errorf("%s expired (%v)", t.String(), err)}
+	}
+	return err
+}
+
+func (t *Timeout) String() string { return t.name }
+
+func (t *Timeout) AddErrorAction(code string, d Doer, skipMain bool) {}
+func (t *Timeout) FixErrorAction(code string) Doer                   { return Doer(nil) }
+
+func (t *Timeout) Apply(arg Arg) (Doer, bool, error) {
+	d, applied, err := ArgApply(t.d, arg)
+	if err != nil {
+		return nil, false, oerr.Annotatef(err, FmtErrContext, t.String())
+	}
+	result := *t
+	result.d = d
+	return &result, applied, nil
+}
+
+func (t *Timeout) Force() (Doer, bool, error) {
+	d, forced, err := Force(t.d)
+	if err != nil || !forced {
+		return t, forced, err
+	}
+	result := *t
+	result.d = d
+	return &result, true, nil
+}
+
+// compile-time interface checks
+var (
+	_ ArgApplier = &Timeout{}
+	_ Forcer     = &Timeout{}
+)
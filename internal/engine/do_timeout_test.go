@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	var fixed bool
+	e.RegisterNewFunc("fix", func(context.Context) error { fixed = true; return nil })
+
+	d, err := e.ParseText("slow", "timeout(20ms){ sleep(10ms) sleep(5s) }")
+	require.NoError(t, err)
+	require.NoError(t, d.Validate())
+	tbegin := time.Now()
+	err = e.Exec(ctx, d)
+	assert.True(t, time.Since(tbegin) < time.Second, "inner sleep not interrupted")
+	var appErr *helpers.AppError
+	require.True(t, errors.As(err, &appErr), err)
+	assert.Equal(t, ErrCodeTimeout, appErr.Code())
+
+	// onError "99" handler of enclosing sequence
+	seq := d.(*Seq)
+	seq.AddErrorAction("99", mustParse(t, e, "fix", "fix"), true)
+	require.NoError(t, e.Exec(ctx, seq))
+	assert.True(t, fixed)
+
+	d, err = e.ParseText("fast", "timeout(1s){ sleep(1ms) }")
+	require.NoError(t, err)
+	require.NoError(t, e.Exec(ctx, d))
+
+	// parent cancel is not a timeout
+	d, err = e.ParseText("abort", "timeout(1s){ sleep(5s) }")
+	require.NoError(t, err)
+	pctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = e.Exec(pctx, d)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.False(t, errors.As(err, &appErr))
+
+	for _, bad := range []string{"timeout(5x){ sleep(1ms) }", "timeout(0s){ sleep(1ms) }", "timeout(1s){ sleep(1ms)"} {
+		_, err = e.ParseText("bad", bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/AlexTransit/vender/helpers"
+	"github.com/AlexTransit/vender/internal/trace"
 	"github.com/AlexTransit/vender/log2"
 	"github.com/juju/errors"
 )
@@ -24,6 +25,7 @@ type Engine struct {
 	Log     *log2.Log
 	lk      sync.RWMutex
 	actions map[string]Doer
+	vars    map[string]scenarioVar
 	profile struct {
 		// optimistic field access guard; fastpath=0 -> profiling disabled, don't touch mutex
 		fastpath uint32
@@ -40,6 +42,7 @@ func NewEngine(log *log2.Log) *Engine {
 	e := &Engine{
 		Log:     log,
 		actions: make(map[string]Doer, 128),
+		vars:    make(map[string]scenarioVar),
 	}
 	e.actions["ignore(?)"] = FuncArg{
 		Name: "ignore(?)",
@@ -49,6 +52,24 @@ func NewEngine(log *log2.Log) *Engine {
 	return e
 }
 
+// Clone copy of registered actions, for config check without touching working engine
+func (e *Engine) Clone() *Engine {
+	e.lk.RLock()
+	defer e.lk.RUnlock()
+	c := &Engine{
+		Log:     e.Log,
+		actions: make(map[string]Doer, len(e.actions)),
+		vars:    make(map[string]scenarioVar, len(e.vars)),
+	}
+	for k, v := range e.actions {
+		c.actions[k] = v
+	}
+	for k, v := range e.vars {
+		c.vars[k] = v
+	}
+	return c
+}
+
 func (e *Engine) CheckAction(action string) (ok bool, doer Doer) {
 	doer, ok = e.actions[action]
 	return ok, doer
@@ -96,6 +117,9 @@ var reActionArg = regexp.MustCompile(`^(.+)\((.+)\)$`)
 
 func (e *Engine) resolve(action string) (Doer, error) {
 	// e.Log.Debugf("engine.resolve action=%s", action)
+	if m, tok := e.macroCall(action); m != nil {
+		return m.expand(e, action, tok.arg, 0)
+	}
 	e.lk.RLock()
 	defer e.lk.RUnlock()
 	return e.locked_resolve(action)
@@ -146,6 +170,9 @@ func (e *Engine) locked_resolve(action string) (d Doer, err error) {
 	if tok.arg == "?" {
 		return nil, nil
 	}
+	if fa, ok := d.(FuncArgs); ok {
+		return fa.Bind(tok.arg)
+	}
 	argn, err := strconv.Atoi(tok.arg)
 	if err == nil {
 		d, ok, err = ArgApply(d, Arg(int16(argn)).(int16))
@@ -177,9 +204,28 @@ func (e *Engine) List() []string {
 	return r
 }
 
+// call of alias with params
+func (e *Engine) macroCall(action string) (*Macro, token) {
+	tok := parseArg(action)
+	if !tok.ok {
+		return nil, tok
+	}
+	e.lk.RLock()
+	m, _ := e.actions[tok.norm].(*Macro)
+	e.lk.RUnlock()
+	return m, tok
+}
+
 var reSleep = regexp.MustCompile(`sleep\((\d+m?s)\)`)
 
-func (e *Engine) ResolveOrLazy(action string) (Doer, error) {
+func (e *Engine) ResolveOrLazy(action string) (Doer, error) { return e.resolveOrLazy(action, 0) }
+
+// depth - nesting of alias with params expansion
+func (e *Engine) resolveOrLazy(action string, depth int) (Doer, error) {
+	// expansion parses scenario, engine lock is not held
+	if m, tok := e.macroCall(action); m != nil {
+		return m.expand(e, action, tok.arg, depth)
+	}
 	e.lk.RLock()
 	defer e.lk.RUnlock()
 	d, ok := e.actions[action]
@@ -188,6 +234,13 @@ func (e *Engine) ResolveOrLazy(action string) (Doer, error) {
 		return d, nil
 	}
 
+	// typed arguments checked at parse time
+	if tok := parseArg(action); tok.ok {
+		if fa, ok := e.actions[tok.norm].(FuncArgs); ok {
+			return fa.Bind(tok.arg)
+		}
+	}
+
 	if m := reSleep.FindStringSubmatch(action); len(m) == 2 {
 		duration, err := time.ParseDuration(m[1])
 		if err != nil {
@@ -198,25 +251,16 @@ func (e *Engine) ResolveOrLazy(action string) (Doer, error) {
 	return &Lazy{Name: action, r: e.resolve}, nil
 }
 
-var reNotSpace = regexp.MustCompile(`\S+`)
-
+// scenario syntax in parse.go
 func (e *Engine) ParseText(tag, text string) (Doer, error) {
 	// TODO cache with github.com/hashicorp/golang-lru
 
-	errs := make([]error, 0)
-	words := reNotSpace.FindAllString(text, -1)
-	itemsCount := len(words)
-
-	tx := NewSeq(tag, itemsCount)
-	for _, word := range words {
-		d, err := e.ResolveOrLazy(word)
-		if err != nil {
-			return nil, errors.Annotatef(err, "scenario=%s unparsed=%s", text, word)
-		}
-
-		tx.Append(d)
+	sp := scenarioParser{e: e, text: text, tokens: scenarioTokens(text)}
+	tx, err := sp.seq(tag, "")
+	if err != nil {
+		return nil, err
 	}
-	return tx, helpers.FoldErrors(errs)
+	return tx, nil
 }
 
 func (e *Engine) Exec(ctx context.Context, d Doer) error     { return e.exec(ctx, d, false, true) }
@@ -247,10 +291,19 @@ func (e *Engine) exec(ctx context.Context, d Doer, validate, enableProfile bool)
 		err = fmt.Errorf("doer nil")
 		return
 	}
+	if err = ctx.Err(); err != nil { // cancelled or deadline of timeout block
+		return err
+	}
 	if validate {
 		err = d.Validate()
 	}
 	if err == nil {
+		if _, lazy := d.(*Lazy); !lazy && trace.Active(ctx) { // resolved doer gets span
+			var end func(error)
+			tok := parseArg(d.String())
+			ctx, end = trace.Start(ctx, strings.TrimSuffix(tok.tag, "(?)"), tok.arg)
+			defer func() { end(err) }()
+		}
 		if enableProfile {
 			tag := d.String() // FIXME faster .Tag() or cache result
 			if profFun, profMin := e.matchProfile(tag); profFun != nil {
@@ -29,6 +29,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/AlexTransit/vender/internal/engine"
@@ -175,10 +176,29 @@ func (inv *Inventory) Init(ctx context.Context, e *engine.Engine, log *log2.Log)
 			}
 		}
 	}
+	e.RegisterVarPrefix("stock.",
+		func(name string) bool { return inv.varStock(name) != nil },
+		func(ctx context.Context, name string) (interface{}, error) {
+			if s := inv.varStock(name); s != nil {
+				return s.Value(), nil
+			}
+			return nil, fmt.Errorf("stock=%s not found", name)
+		})
 	inv.InventoryLoad()
 	return errs
 }
 
+// scenario variable stock.<ingredient name or stock label>
+func (inv *Inventory) varStock(name string) *Stock {
+	name = strings.TrimPrefix(name, "stock.")
+	for i, s := range inv.Stocks {
+		if (s.Ingredient != nil && s.Ingredient.Name == name) || s.Label == name {
+			return &inv.Stocks[i]
+		}
+	}
+	return nil
+}
+
 func (inv *Inventory) prepareInventory() {
 	// put overrided stock to stock
 	for _, v := range inv.XXX_Ingredient {
@@ -262,6 +282,13 @@ func (inv *Inventory) Iter(fun func(s *Stock)) {
 	}
 }
 
+// Values current value of every stock by label
+func (inv *Inventory) Values() map[string]float32 {
+	values := make(map[string]float32, len(inv.Stocks))
+	inv.Iter(func(s *Stock) { values[s.Label] = s.Value() })
+	return values
+}
+
 func (inv *Inventory) WithTuning(ctx context.Context, ingredientName string, adj float32) (context.Context, error) {
 	if s, ok := inv.GetStockByingredientName(ingredientName); ok {
 		if s.Ingredient.TuneKey != "" {
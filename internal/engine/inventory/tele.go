@@ -1,6 +1,8 @@
 package inventory
 
 import (
+	"errors"
+	"fmt"
 	"sort"
 
 	tele_api "github.com/AlexTransit/vender/tele"
@@ -41,3 +43,50 @@ func (inv *Inventory) locked_tele() *tele_api.Inventory {
 	})
 	return pb
 }
+
+// SetTele set stock values by code from remote command and save inventory.
+// Valuef used if not zero, otherwise Value. any unknown code - error, nothing changed.
+func (inv *Inventory) SetTele(pb *tele_api.Inventory) (*tele_api.Inventory, error) {
+	if pb == nil {
+		return nil, errors.New("inventory set: empty")
+	}
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	index := make([]int, len(pb.Stocks))
+	for n, si := range pb.Stocks {
+		index[n] = -1
+		for i := range inv.Stocks {
+			if inv.Stocks[i].Code == int(si.Code) {
+				index[n] = i
+				break
+			}
+		}
+		if index[n] < 0 {
+			return inv.locked_tele(), fmt.Errorf("stock name=%s code=%d not found", si.Name, si.Code)
+		}
+	}
+	for n, si := range pb.Stocks {
+		v := si.Valuef
+		if v == 0 {
+			v = float32(si.Value)
+		}
+		s := &inv.Stocks[index[n]]
+		inv.log.Infof("inventory set stock=%s value=%.0f -> %.0f", s.Label, s.value, v)
+		s.Set(v)
+	}
+	return inv.locked_tele(), inv.InventorySave()
+}
+
+// TeleStock stock values for FromRoboMessage
+func (inv *Inventory) TeleStock() *tele_api.Stock {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	pb := &tele_api.Stock{Stocks: make([]*tele_api.Stock_StockItem, 0, len(inv.Stocks))}
+	for _, s := range inv.Stocks {
+		pb.Stocks = append(pb.Stocks, &tele_api.Stock_StockItem{
+			Code:  uint32(s.Code),
+			Value: int32(s.value),
+		})
+	}
+	return pb
+}
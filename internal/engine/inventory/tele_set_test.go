@@ -0,0 +1,45 @@
+package inventory
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexTransit/vender/log2"
+	tele_api "github.com/AlexTransit/vender/tele"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventorySetTele(t *testing.T) {
+	t.Parallel()
+
+	water := &Ingredient{Name: "water"}
+	sugar := &Ingredient{Name: "sugar"}
+	inv := &Inventory{
+		log:  log2.NewTest(t, log2.LOG_DEBUG),
+		File: filepath.Join(t.TempDir(), "store.file"),
+		Stocks: []Stock{
+			{Label: "water", Code: 1, Ingredient: water},
+			{Label: "sugar", Code: 2, Ingredient: sugar},
+		},
+	}
+
+	// unknown stock, nothing changed
+	_, err := inv.SetTele(&tele_api.Inventory{Stocks: []*tele_api.Inventory_StockItem{{Code: 1, Value: 5}, {Code: 9, Value: 1}}})
+	require.Error(t, err)
+	assert.Equal(t, map[string]float32{"water": 0, "sugar": 0}, inv.Values())
+
+	pb, err := inv.SetTele(&tele_api.Inventory{Stocks: []*tele_api.Inventory_StockItem{{Code: 1, Value: 5000}, {Code: 2, Valuef: 300.5}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(pb.Stocks))
+	assert.Equal(t, map[string]float32{"water": 5000, "sugar": 300.5}, inv.Values())
+
+	// saved
+	inv.Stocks[0].Set(0)
+	inv.InventoryLoad()
+	assert.Equal(t, float32(5000), inv.Stocks[0].Value())
+
+	st := inv.TeleStock()
+	require.Equal(t, 2, len(st.Stocks))
+	assert.Equal(t, int32(5000), st.Stocks[0].Value)
+}
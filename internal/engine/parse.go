@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// scenario syntax:
+// action action(arg) ...           sequence
+// [ a b | c ]                      parallel branches (Par)
+// if(cond){ a b }else{ c }         condition (If), evaluated at Do time
+// timeout(5s){ a b }               deadline (Timeout), expired -> AppError code 99
+// retry(3, 500ms){ a b }           repeat on error (Retry), pause doubles after each attempt
+
+// split scenario into words. "[", "|", "]", "{", "}" are separate tokens outside of action arguments
+func scenarioTokens(text string) []string {
+	tokens := make([]string, 0, 8)
+	var word strings.Builder
+	depth := 0
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == '(':
+			depth++
+			word.WriteRune(r)
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+			word.WriteRune(r)
+		case depth == 0 && strings.ContainsRune("[|]{}", r):
+			flush()
+			tokens = append(tokens, string(r))
+		case depth == 0 && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// ScenarioActions action words of scenario as parser sees them, including actions inside
+// [ ], if, timeout and retry blocks. block syntax and if conditions are not actions.
+func ScenarioActions(text string) []string {
+	tokens := scenarioTokens(text)
+	actions := make([]string, 0, len(tokens))
+	for i, word := range tokens {
+		block := i+1 < len(tokens) && tokens[i+1] == "{" &&
+			(strings.HasPrefix(word, "timeout(") || strings.HasPrefix(word, "retry("))
+		switch {
+		case len(word) == 1 && strings.Contains("[|]{}", word), word == "else", block, strings.HasPrefix(word, "if("):
+			continue
+		}
+		actions = append(actions, word)
+	}
+	return actions
+}
+
+type scenarioParser struct {
+	e      *Engine
+	text   string
+	tokens []string
+	pos    int
+	depth  int // alias with params expansion, do_macro.go
+}
+
+func (sp *scenarioParser) peek() string {
+	if sp.pos < len(sp.tokens) {
+		return sp.tokens[sp.pos]
+	}
+	return ""
+}
+
+func (sp *scenarioParser) next() string {
+	if sp.pos+1 < len(sp.tokens) {
+		return sp.tokens[sp.pos+1]
+	}
+	return ""
+}
+
+// sequence until end of text or closing token of current block.
+// end: "" top level, "]" parallel branch (also "|"), "}" if block
+func (sp *scenarioParser) seq(tag string, end string) (*Seq, error) {
+	tx := NewSeq(tag, len(sp.tokens)-sp.pos)
+	for sp.pos < len(sp.tokens) {
+		word := sp.tokens[sp.pos]
+		switch {
+		case word == "|" && end == "]", word == end && end != "":
+			return tx, nil
+		case word == "|", word == "]", word == "}", word == "{", word == "else":
+			return nil, errors.Errorf("scenario=%s unexpected %s", sp.text, word)
+		case word == "[":
+			sp.pos++
+			d, err := sp.par(tag)
+			if err != nil {
+				return nil, err
+			}
+			tx.Append(d)
+			continue
+		case strings.HasPrefix(word, "timeout(") && sp.next() == "{":
+			d, err := sp.timeoutBlock(tag)
+			if err != nil {
+				return nil, err
+			}
+			tx.Append(d)
+			continue
+		case strings.HasPrefix(word, "retry(") && sp.next() == "{":
+			d, err := sp.retryBlock(tag)
+			if err != nil {
+				return nil, err
+			}
+			tx.Append(d)
+			continue
+		case strings.HasPrefix(word, "if("):
+			d, err := sp.ifBlock(tag)
+			if err != nil {
+				return nil, err
+			}
+			tx.Append(d)
+			continue
+		}
+		d, err := sp.e.resolveOrLazy(word, sp.depth)
+		if err != nil {
+			return nil, errors.Annotatef(err, "scenario=%s unparsed=%s", sp.text, word)
+		}
+		tx.Append(d)
+		sp.pos++
+	}
+	if end != "" {
+		return nil, errors.Errorf("scenario=%s %s expected", sp.text, end)
+	}
+	return tx, nil
+}
+
+// group after "[" until matching "]"
+func (sp *scenarioParser) par(tag string) (*Par, error) {
+	branches := make([]Doer, 0, 2)
+	names := make([]string, 0, 2)
+	for {
+		branchTag := fmt.Sprintf("%s[%d]", tag, len(branches))
+		b, err := sp.seq(branchTag, "]")
+		if err != nil {
+			return nil, err
+		}
+		if len(b.items) == 0 {
+			return nil, errors.Errorf("scenario=%s empty parallel branch", sp.text)
+		}
+		branches = append(branches, b)
+		names = append(names, seqText(b))
+		word := sp.tokens[sp.pos]
+		sp.pos++
+		if word == "]" {
+			return NewPar("[ "+strings.Join(names, " | ")+" ]", branches...), nil
+		}
+	}
+}
+
+// if(cond){ ... } [else{ ... } | else if(cond){ ... }]
+func (sp *scenarioParser) ifBlock(tag string) (*If, error) {
+	word := sp.tokens[sp.pos]
+	sp.pos++
+	if !strings.HasSuffix(word, ")") {
+		return nil, errors.Errorf("scenario=%s invalid %s", sp.text, word)
+	}
+	cond, err := parseCond(word[len("if(") : len(word)-1])
+	if err != nil {
+		return nil, errors.Annotatef(err, "scenario=%s", sp.text)
+	}
+	then, err := sp.block(tag + "-then")
+	if err != nil {
+		return nil, err
+	}
+	d := &If{e: sp.e, cond: cond, then: then}
+	if sp.peek() == "else" {
+		sp.pos++
+		if strings.HasPrefix(sp.peek(), "if(") {
+			elseIf, err := sp.ifBlock(tag)
+			if err != nil {
+				return nil, err
+			}
+			d.els = elseIf
+		} else if d.els, err = sp.block(tag + "-else"); err != nil {
+			return nil, err
+		}
+	}
+	d.name = "if(" + cond.String() + "){" + seqText(then) + "}"
+	if d.els != nil {
+		if s, ok := d.els.(*Seq); ok {
+			d.name += "else{" + seqText(s) + "}"
+		} else {
+			d.name += "else " + d.els.String()
+		}
+	}
+	return d, nil
+}
+
+// timeout(duration){ ... }
+func (sp *scenarioParser) timeoutBlock(tag string) (*Timeout, error) {
+	word := sp.tokens[sp.pos]
+	sp.pos++
+	duration, err := time.ParseDuration(strings.TrimSuffix(strings.TrimPrefix(word, "timeout("), ")"))
+	if err != nil || duration <= 0 {
+		return nil, errors.Errorf("scenario=%s invalid %s", sp.text, word)
+	}
+	b, err := sp.block(tag + "-timeout")
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeout(duration, b), nil
+}
+
+// retry(n, backoff){ ... }
+func (sp *scenarioParser) retryBlock(tag string) (*Retry, error) {
+	n, backoff, err := parseRetry(sp.tokens[sp.pos])
+	if err != nil {
+		return nil, errors.Annotatef(err, "scenario=%s", sp.text)
+	}
+	sp.pos++
+	b, err := sp.block(tag + "-retry")
+	if err != nil {
+		return nil, err
+	}
+	return NewRetry(n, backoff, b), nil
+}
+
+// { ... }
+func (sp *scenarioParser) block(tag string) (*Seq, error) {
+	if sp.peek() != "{" {
+		return nil, errors.Errorf("scenario=%s { expected", sp.text)
+	}
+	sp.pos++
+	b, err := sp.seq(tag, "}")
+	if err != nil {
+		return nil, err
+	}
+	sp.pos++ // }
+	return b, nil
+}
+
+func seqText(s *Seq) string {
+	items := make([]string, len(s.items))
+	for i, d := range s.items {
+		items[i] = d.String()
+	}
+	return strings.Join(items, " ")
+}
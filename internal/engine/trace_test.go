@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/internal/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecTrace(t *testing.T) {
+	t.Parallel()
+
+	ctx, e := newParTestEngine(t)
+	sleep := func(ctx context.Context) error { time.Sleep(5 * time.Millisecond); return nil }
+	e.RegisterNewFunc("a", sleep)
+	e.RegisterNewFunc("b", sleep)
+	e.RegisterNewFuncAgr("c(?)", func(context.Context, Arg) error { return nil })
+	d, err := e.ParseText("make", "[a | b] c(7)")
+	require.NoError(t, err)
+
+	tc := trace.New(1)
+	tctx := tc.Begin(ctx, "order")
+	require.NoError(t, e.Exec(tctx, d))
+	tc.End(tctx, nil)
+
+	lanes := map[string]int{}
+	args := map[string]string{}
+	for _, s := range tc.Traces()[0].Spans() {
+		lanes[s.Name] = s.Lane
+		args[s.Name] = s.Arg
+	}
+	assert.NotEqual(t, lanes["a"], lanes["b"], "parallel branches on different lanes")
+	assert.Equal(t, "7", args["c"])
+}
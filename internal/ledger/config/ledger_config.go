@@ -0,0 +1,16 @@
+package ledger_config
+
+type Config struct {
+	// RU: Если true, то журнал продаж не ведется.
+	// EN: If true, the sales ledger is disabled.
+	Disabled bool `hcl:"disabled,optional"`
+	// RU: Файл журнала продаж. Одна строка JSON на продажу. Старые файлы переименовываются в file.1, file.2 и т.д.
+	// EN: Sales ledger file. One JSON line per sale. Old files are renamed to file.1, file.2 etc.
+	File string `hcl:"file,optional"`
+	// RU: Максимальный размер файла в килобайтах, после которого файл ротируется.
+	// EN: Maximum file size in kilobytes before rotation.
+	MaxSizeKB int `hcl:"max_size_kb,optional"`
+	// RU: Сколько старых файлов хранить.
+	// EN: How many rotated files to keep.
+	Keep int `hcl:"keep,optional"`
+}
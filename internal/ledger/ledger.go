@@ -0,0 +1,230 @@
+// Package ledger keeps local append-only journal of sales.
+// Machine may be offline for days, ledger is used to reconcile cash collections.
+package ledger
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ledger_config "github.com/AlexTransit/vender/internal/ledger/config"
+)
+
+// Record one sale
+type Record struct {
+	Time          time.Time          `json:"time"`
+	Code          string             `json:"code"`
+	Cream         uint8              `json:"cream"`
+	Sugar         uint8              `json:"sugar"`
+	Price         uint32             `json:"price"`
+	PaymentMethod string             `json:"payment_method"`
+	Bills         map[uint32]uint32  `json:"bills,omitempty"` // nominal:count
+	Coins         map[uint32]uint32  `json:"coins,omitempty"` // nominal:count
+	Change        uint32             `json:"change,omitempty"`
+	Stock         map[string]float32 `json:"stock,omitempty"` // stock label:spent
+	Error         string             `json:"error,omitempty"`
+}
+
+type Ledger struct {
+	mu     sync.Mutex
+	config ledger_config.Config
+}
+
+func New(config ledger_config.Config) *Ledger {
+	if config.MaxSizeKB <= 0 {
+		config.MaxSizeKB = 1024
+	}
+	if config.Keep <= 0 {
+		config.Keep = 5
+	}
+	return &Ledger{config: config}
+}
+
+// Write append record to the ledger file. rotate file if it is too large.
+func (l *Ledger) Write(r Record) error {
+	if l == nil || l.config.Disabled || l.config.File == "" {
+		return nil
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if st, err := os.Stat(l.config.File); err == nil && st.Size()+int64(len(b)) > int64(l.config.MaxSizeKB)*1024 {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("ledger rotate (%v)", err)
+		}
+	}
+	f, err := os.OpenFile(l.config.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_SYNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(b)
+	return err
+}
+
+// file -> file.1 -> file.2 ... oldest removed
+func (l *Ledger) rotate() error {
+	_ = os.Remove(rotatedName(l.config.File, l.config.Keep))
+	for i := l.config.Keep - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedName(l.config.File, i), rotatedName(l.config.File, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(l.config.File, rotatedName(l.config.File, 1))
+}
+
+func rotatedName(file string, n int) string {
+	return file + "." + strconv.Itoa(n)
+}
+
+// Read all records since time, oldest first.
+func (l *Ledger) Read(since time.Time, fun func(Record) error) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	files := make([]string, 0, l.config.Keep+1)
+	for i := l.config.Keep; i >= 1; i-- {
+		files = append(files, rotatedName(l.config.File, i))
+	}
+	files = append(files, l.config.File)
+	for _, fn := range files {
+		if err := readFile(fn, since, fun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(fn string, since time.Time, fun func(Record) error) error {
+	f, err := os.Open(fn)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// torn last line after power loss. skip
+			continue
+		}
+		if r.Time.Before(since) {
+			continue
+		}
+		if err := fun(r); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// StockSpent difference of stock values before and after cooking
+func StockSpent(before, after map[string]float32) map[string]float32 {
+	spent := make(map[string]float32)
+	for label, v := range before {
+		if d := v - after[label]; d != 0 {
+			spent[label] = d
+		}
+	}
+	return spent
+}
+
+// ParseTime date 2006-01-02 (local midnight) or RFC3339. empty string is zero time
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time=%s (2006-01-02 or RFC3339)", s)
+	}
+	return t, nil
+}
+
+var csvHeader = []string{"time", "code", "cream", "sugar", "price", "payment_method", "bills", "coins", "change", "stock", "error"}
+
+// Export write records in csv or json (one JSON per line) format
+func (l *Ledger) Export(w io.Writer, since time.Time, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		return l.Read(since, func(r Record) error { return enc.Encode(r) })
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		err := l.Read(since, func(r Record) error {
+			return cw.Write([]string{
+				r.Time.Format(time.RFC3339),
+				r.Code,
+				strconv.Itoa(int(r.Cream)),
+				strconv.Itoa(int(r.Sugar)),
+				strconv.FormatUint(uint64(r.Price), 10),
+				r.PaymentMethod,
+				formatNominals(r.Bills),
+				formatNominals(r.Coins),
+				strconv.FormatUint(uint64(r.Change), 10),
+				formatStock(r.Stock),
+				r.Error,
+			})
+		})
+		cw.Flush()
+		if err != nil {
+			return err
+		}
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown export format=%s (csv|json)", format)
+}
+
+// "nominal:count nominal:count" sorted by nominal
+func formatNominals(m map[uint32]uint32) string {
+	keys := make([]uint32, 0, len(m))
+	for k, v := range m {
+		if v != 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%d:%d", k, m[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatStock(m map[string]float32) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s:%g", k, m[k])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ledger_config "github.com/AlexTransit/vender/internal/ledger/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerWriteRead(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "sales.jsonl")
+	l := New(ledger_config.Config{File: file})
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, l.Write(Record{Time: t0, Code: "1", Price: 3500, PaymentMethod: "Cash",
+		Bills: map[uint32]uint32{5000: 1}, Change: 1500}))
+	require.NoError(t, l.Write(Record{Time: t0.Add(time.Hour), Code: "2", Price: 4000, PaymentMethod: "Cashless",
+		Stock: map[string]float32{"water": 150, "coffee": 8}}))
+	// torn tail after power loss
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, _ = f.WriteString(`{"time":"2024-05`)
+	f.Close()
+
+	var codes []string
+	require.NoError(t, l.Read(t0.Add(time.Minute), func(r Record) error {
+		codes = append(codes, r.Code)
+		return nil
+	}))
+	assert.Equal(t, []string{"2"}, codes)
+
+	var buf bytes.Buffer
+	require.NoError(t, l.Export(&buf, time.Time{}, "csv"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 3, len(lines))
+	assert.Equal(t, "2024-05-01T10:00:00Z,1,0,0,3500,Cash,5000:1,,1500,,", lines[1])
+	assert.Equal(t, "2024-05-01T11:00:00Z,2,0,0,4000,Cashless,,,0,coffee:8 water:150,", lines[2])
+
+	assert.Error(t, l.Export(&buf, time.Time{}, "xml"))
+}
+
+func TestLedgerRotate(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "sales.jsonl")
+	l := New(ledger_config.Config{File: file, MaxSizeKB: 1, Keep: 2})
+	for i := 0; i < 60; i++ {
+		require.NoError(t, l.Write(Record{Time: time.Unix(int64(i), 0), Code: "1", Error: strings.Repeat("x", 50)}))
+	}
+	for _, fn := range []string{file, file + ".1", file + ".2"} {
+		st, err := os.Stat(fn)
+		require.NoError(t, err)
+		assert.True(t, st.Size() <= 1024, fn)
+	}
+	_, err := os.Stat(file + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// oldest first, rotated out records lost
+	var last int64 = -1
+	n := 0
+	require.NoError(t, l.Read(time.Time{}, func(r Record) error {
+		assert.True(t, r.Time.Unix() > last)
+		last = r.Time.Unix()
+		n++
+		return nil
+	}))
+	assert.Equal(t, int64(59), last)
+	assert.True(t, n < 60)
+
+	var nilLedger *Ledger
+	assert.NoError(t, nilLedger.Write(Record{}))
+}
+
+func TestStockSpent(t *testing.T) {
+	t.Parallel()
+
+	spent := StockSpent(map[string]float32{"water": 1000, "cup": 10, "sugar": 5}, map[string]float32{"water": 850, "cup": 9, "sugar": 5})
+	assert.Equal(t, map[string]float32{"water": 150, "cup": 1}, spent)
+}
@@ -0,0 +1,158 @@
+// Package locale plural rules and message formatting for UI message catalogues.
+//
+// сообщения - строки fmt с формами множественного числа в фигурных скобках
+// после глагола, к аргументу которого они относятся:
+//
+//	"цена: %s {рубль|рубля|рублей}"  ru: one|few|many
+//	"price: %s {ruble|rubles}"       en: one|other
+//
+// если форм меньше чем правил языка, берется последняя.
+package locale
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Lang language of locale name: "ru_RU" "ru-RU" -> "ru"
+func Lang(name string) string {
+	if i := strings.IndexAny(name, "_-."); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToLower(name)
+}
+
+// Plural index of plural form for number n (CLDR cardinal rules)
+func Plural(lang string, n float64) int {
+	n = math.Abs(n)
+	integer := n == math.Trunc(n)
+	switch Lang(lang) {
+	case "ru", "uk", "be": // one few many other
+		if !integer {
+			return 3
+		}
+		i := int64(n)
+		switch {
+		case i%10 == 1 && i%100 != 11:
+			return 0
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return 1
+		}
+		return 2
+	case "zh", "ja", "ko", "vi", "th", "id": // other
+		return 0
+	case "fr": // one other
+		if n < 2 {
+			return 0
+		}
+		return 1
+	}
+	// en de es it ... one other
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+// Sprintf fmt.Sprintf with plural forms of lang
+func Sprintf(lang string, format string, args ...any) string {
+	if !strings.Contains(format, "|") {
+		return fmt.Sprintf(format, args...)
+	}
+	var b strings.Builder
+	argNum := 0   // next argument
+	lastArg := -1 // argument of last verb
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; c {
+		case '%':
+			j, arg := verb(format, i+1, argNum)
+			b.WriteString(format[i:j])
+			if arg >= 0 {
+				lastArg, argNum = arg, arg+1
+			}
+			i = j - 1
+		case '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 || lastArg < 0 || lastArg >= len(args) || !strings.Contains(format[i:i+end], "|") {
+				b.WriteByte(c)
+				continue
+			}
+			forms := strings.Split(format[i+1:i+end], "|")
+			idx := Plural(lang, number(args[lastArg]))
+			if idx >= len(forms) {
+				idx = len(forms) - 1
+			}
+			b.WriteString(strings.ReplaceAll(forms[idx], "%", "%%"))
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return fmt.Sprintf(b.String(), args...)
+}
+
+// verb end position and argument index, -1 for "%%"
+func verb(format string, i int, argNum int) (int, int) {
+	for ; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '%':
+			return i + 1, -1
+		case c == '[':
+			end := strings.IndexByte(format[i:], ']')
+			if end < 0 {
+				return len(format), argNum
+			}
+			if n, err := strconv.Atoi(format[i+1 : i+end]); err == nil {
+				argNum = n - 1
+			}
+			i += end
+		case strings.IndexByte("+-# 0123456789.*", c) >= 0:
+		default:
+			return i + 1, argNum
+		}
+	}
+	return i, argNum
+}
+
+// number for plural rule, strings like "25" "2.50" are parsed
+func number(v any) float64 {
+	switch x := v.(type) {
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f
+	case fmt.Stringer:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(x.String()), 64)
+		return f
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return 0
+}
+
+// Apply messages to string fields of struct by hcl name.
+// only names with prefix are replaced ("msg_"), other fields are not messages.
+func Apply(dst any, prefix string, msg map[string]string) {
+	rv := reflect.ValueOf(dst).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("hcl"), ",")
+		if f.Type.Kind() != reflect.String || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if s, ok := msg[name]; ok {
+			rv.Field(i).SetString(s)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlural(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		lang   string
+		n      float64
+		expect int
+	}{
+		{"ru", 1, 0}, {"ru", 21, 0}, {"ru", 11, 2}, {"ru", 2, 1}, {"ru", 24, 1},
+		{"ru", 12, 2}, {"ru", 5, 2}, {"ru", 0, 2}, {"ru", 111, 2}, {"ru", 2.5, 3},
+		{"ru_RU", 3, 1},
+		{"en", 1, 0}, {"en", 0, 1}, {"en", 2, 1}, {"en-GB", 1, 0},
+		{"fr", 0, 0}, {"fr", 1.5, 0}, {"fr", 2, 1},
+		{"zh", 1, 0}, {"zh", 5, 0},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expect, Plural(c.lang, c.n), "%s %v", c.lang, c.n)
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	t.Parallel()
+
+	const price = "цена: %s {рубль|рубля|рублей}"
+	assert.Equal(t, "цена: 21 рубль", Sprintf("ru", price, "21"))
+	assert.Equal(t, "цена: 33 рубля", Sprintf("ru", price, "33"))
+	assert.Equal(t, "цена: 15 рублей", Sprintf("ru", price, "15"))
+	assert.Equal(t, "price: 1 ruble", Sprintf("en", "price: %s {ruble|rubles}", "1"))
+	assert.Equal(t, "price: 2 rubles", Sprintf("en", "price: %s {ruble|rubles}", "2"))
+
+	// forms belong to previous verb, concatenated messages
+	assert.Equal(t, "Код: 12 цена: 2 рубля", Sprintf("ru", "Код: %s "+price, "12", "2"))
+	assert.Equal(t, "1 рубль, 3 кружки", Sprintf("ru", "%[2]d {рубль|рубля|рублей}, %[1]d {кружка|кружки|кружек}", 3, 1))
+	assert.Equal(t, "5 шт 100% {x}", Sprintf("ru", "%d {шт|шт} 100%% {x}", 5))
+	assert.Equal(t, "1 apple", Sprintf("en", "%d apple{|s}", 1))
+	assert.Equal(t, "2 apples", Sprintf("en", "%d apple{|s}", 2))
+	assert.Equal(t, "2 яблока", Sprintf("ru", "%d {яблоко|яблока}", 2))
+	assert.Equal(t, "5 яблока", Sprintf("ru", "%d {яблоко|яблока}", 5), "less forms than rules")
+	assert.Equal(t, "{a|b} 1", Sprintf("en", "{a|b} %d", 1), "no verb before")
+	assert.Equal(t, "температура: 80", Sprintf("ru", "температура: %d", 80))
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	type front struct {
+		MsgWait  string `hcl:"msg_wait"`
+		MsgCream string `hcl:"msg_cream,optional"`
+		Pic      string `hcl:"pic_error"`
+		Reset    int    `hcl:"msg_reset"`
+	}
+	f := front{MsgWait: "ждите", MsgCream: "сливки", Pic: "/pic"}
+	Apply(&f, "msg_", map[string]string{"msg_wait": "wait", "pic_error": "/other", "msg_reset": "1", "hello": "hi"})
+	assert.Equal(t, front{MsgWait: "wait", MsgCream: "сливки", Pic: "/pic"}, f)
+}
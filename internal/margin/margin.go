@@ -0,0 +1,259 @@
+// Package margin calculates cost of menu items and margin of sales from the ledger.
+// Item cost - Doer.Calculation of scenario (ingredient cost * spend, default cream/sugar) + cup.
+// Sale cost - stock really spent by the sale * ingredient cost + cup, so cream/sugar tuning is included.
+package margin
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/internal/engine"
+	"github.com/AlexTransit/vender/internal/ledger"
+	tele_api "github.com/AlexTransit/vender/tele"
+)
+
+// Product menu item as configured now
+type Product struct {
+	Code  string
+	Name  string
+	Price currency.Amount
+	Doer  engine.Doer
+}
+
+type Costing struct {
+	Products  map[string]Product
+	StockCost map[string]float64 // stock label: ingredient cost per spent unit
+	CupCost   float64
+}
+
+// ItemCost cost of menu item by scenario with default cream/sugar
+func (c *Costing) ItemCost(code string) (currency.Amount, bool) {
+	p, ok := c.Products[code]
+	if !ok || p.Doer == nil {
+		return 0, false
+	}
+	return amount(p.Doer.Calculation() + c.CupCost), true
+}
+
+// SaleCost cost of sold item. old records without stock use menu item cost
+func (c *Costing) SaleCost(r ledger.Record) currency.Amount {
+	if len(r.Stock) == 0 {
+		cost, _ := c.ItemCost(r.Code)
+		return cost
+	}
+	return amount(c.stockCost(r.Stock) + c.CupCost)
+}
+
+func (c *Costing) stockCost(spent map[string]float32) (sum float64) {
+	for label, v := range spent {
+		sum += float64(v) * c.StockCost[label]
+	}
+	return sum
+}
+
+// Item cost and sales of menu item for period
+type Item struct {
+	Code    string
+	Name    string
+	Price   currency.Amount // menu price
+	Cost    currency.Amount // menu item cost
+	Sold    int
+	Revenue currency.Amount // sum of sale prices, gifts not included
+	Spent   currency.Amount // cost of sold
+	Errors  int             // failed cooking
+	Waste   currency.Amount // ingredients spent by failed cooking
+}
+
+// Margin of one item by menu price and cost, kopecks
+func (i Item) Margin() int64 { return int64(i.Price) - int64(i.Cost) }
+
+// Profit of period: revenue - cost of sold - waste, kopecks
+func (i Item) Profit() int64 { return int64(i.Revenue) - int64(i.Spent) - int64(i.Waste) }
+
+// MarginPercent margin of item from price
+func (i Item) MarginPercent() float64 {
+	if i.Price == 0 {
+		return 0
+	}
+	return float64(i.Margin()) * 100 / float64(i.Price)
+}
+
+type Period struct {
+	From  time.Time
+	To    time.Time
+	Items []Item // by code
+}
+
+// Total sales of all items. price and cost are not summed
+func (p Period) Total() Item {
+	t := Item{Code: "total"}
+	for _, i := range p.Items {
+		t.Sold += i.Sold
+		t.Revenue += i.Revenue
+		t.Spent += i.Spent
+		t.Errors += i.Errors
+		t.Waste += i.Waste
+	}
+	return t
+}
+
+func (p Period) Tele() *tele_api.Margin {
+	m := &tele_api.Margin{From: p.From.Unix(), To: p.To.Unix()}
+	for _, i := range p.Items {
+		m.Items = append(m.Items, &tele_api.Margin_Item{
+			Code:    i.Code,
+			Price:   uint32(i.Price),
+			Cost:    uint32(i.Cost),
+			Sold:    uint32(i.Sold),
+			Revenue: uint32(i.Revenue),
+			Spent:   uint32(i.Spent),
+			Errors:  uint32(i.Errors),
+			Waste:   uint32(i.Waste),
+		})
+	}
+	return m
+}
+
+const (
+	PeriodAll   = ""
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+type Query struct {
+	Since  time.Time
+	Until  time.Time // zero = now
+	Period string    // "" whole time, day, week, month
+}
+
+const Usage = `[-since 2006-01-02|RFC3339] [-until 2006-01-02|RFC3339] [-period day|week|month]`
+
+// ParseQuery command line arguments, same for vender margin and tele reportMargin
+func ParseQuery(args []string) (q Query, err error) {
+	fs := flag.NewFlagSet("margin", flag.ContinueOnError)
+	since := fs.String("since", "", "sales since date")
+	until := fs.String("until", "", "sales before date")
+	fs.StringVar(&q.Period, "period", "", "split by day|week|month")
+	if err = fs.Parse(args); err != nil {
+		return q, err
+	}
+	if q.Since, err = ledger.ParseTime(*since); err != nil {
+		return q, err
+	}
+	if q.Until, err = ledger.ParseTime(*until); err != nil {
+		return q, err
+	}
+	switch q.Period {
+	case PeriodAll, PeriodDay, PeriodWeek, PeriodMonth:
+	default:
+		return q, fmt.Errorf("invalid period=%s (day|week|month)", q.Period)
+	}
+	return q, nil
+}
+
+// Report sales of ledger by periods, oldest first.
+// period lists items with sales or errors, whole time report (PeriodAll) lists every menu item.
+func (c *Costing) Report(l *ledger.Ledger, q Query) ([]Period, error) {
+	until := q.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	periods := make([]*Period, 0)
+	items := make([]map[string]*Item, 0)
+	var current *Period
+	err := l.Read(q.Since, func(r ledger.Record) error {
+		if !r.Time.Before(until) {
+			return nil
+		}
+		if current == nil || !r.Time.Before(current.To) {
+			from, to := bucket(r.Time, q.Period, q.Since, until)
+			current = &Period{From: from, To: to}
+			periods = append(periods, current)
+			items = append(items, make(map[string]*Item))
+		}
+		c.add(items[len(items)-1], r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if q.Period == PeriodAll {
+		if len(periods) == 0 {
+			periods = append(periods, &Period{From: q.Since, To: until})
+			items = append(items, make(map[string]*Item))
+		}
+		for code := range c.Products {
+			c.item(items[0], code)
+		}
+	}
+	result := make([]Period, len(periods))
+	for n, p := range periods {
+		for _, i := range items[n] {
+			p.Items = append(p.Items, *i)
+		}
+		sort.Slice(p.Items, func(a, b int) bool { return p.Items[a].Code < p.Items[b].Code })
+		result[n] = *p
+	}
+	return result, nil
+}
+
+func (c *Costing) add(items map[string]*Item, r ledger.Record) {
+	i := c.item(items, r.Code)
+	if r.Error != "" {
+		i.Errors++
+		i.Waste += amount(c.stockCost(r.Stock))
+		return
+	}
+	i.Sold++
+	i.Spent += c.SaleCost(r)
+	if r.PaymentMethod != tele_api.PaymentMethod_Gift.String() {
+		i.Revenue += currency.Amount(r.Price)
+	}
+}
+
+func (c *Costing) item(items map[string]*Item, code string) *Item {
+	if i, ok := items[code]; ok {
+		return i
+	}
+	i := &Item{Code: code}
+	if p, ok := c.Products[code]; ok {
+		i.Name, i.Price = p.Name, p.Price
+		i.Cost, _ = c.ItemCost(code)
+	}
+	items[code] = i
+	return i
+}
+
+// period of time t. whole time report begins at since or first sale
+func bucket(t time.Time, period string, since, until time.Time) (from, to time.Time) {
+	t = t.Local()
+	y, m, d := t.Date()
+	switch period {
+	case PeriodDay:
+		from = time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(0, 0, 1)
+	case PeriodWeek:
+		from = time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location()) // monday
+		return from, from.AddDate(0, 0, 7)
+	case PeriodMonth:
+		from = time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(0, 1, 0)
+	}
+	if since.IsZero() {
+		since = t
+	}
+	return since, until
+}
+
+// cost in rub -> kopecks, as ListMenuPriceCost
+func amount(cost float64) currency.Amount {
+	if cost <= 0 {
+		return 0
+	}
+	return currency.Amount(math.Round(cost * 100))
+}
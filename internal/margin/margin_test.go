@@ -0,0 +1,110 @@
+package margin
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/internal/engine"
+	"github.com/AlexTransit/vender/internal/ledger"
+	ledger_config "github.com/AlexTransit/vender/internal/ledger/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCosting() *Costing {
+	scenario := func(cost float64) engine.Doer {
+		return engine.Func0{Name: "scenario", C: func() float64 { return cost }}
+	}
+	return &Costing{
+		Products: map[string]Product{
+			"1": {Code: "1", Name: "espresso", Price: 5000, Doer: scenario(12.5)},
+			"2": {Code: "2", Name: "cappuccino", Price: 8000, Doer: scenario(20)},
+			"3": {Code: "3", Name: "broken"},
+		},
+		StockCost: map[string]float64{"coffee": 1.5, "milk": 0.5, "sugar": 0.1},
+		CupCost:   2.5,
+	}
+}
+
+func TestItemCost(t *testing.T) {
+	t.Parallel()
+
+	c := testCosting()
+	cost, ok := c.ItemCost("1")
+	assert.True(t, ok)
+	assert.Equal(t, currency.Amount(1500), cost)
+	_, ok = c.ItemCost("3")
+	assert.False(t, ok)
+
+	// real spent: extra sugar by tuning
+	r := ledger.Record{Code: "1", Stock: map[string]float32{"coffee": 8, "sugar": 20}}
+	assert.Equal(t, currency.Amount(1650), c.SaleCost(r))
+	// old record without stock
+	assert.Equal(t, currency.Amount(2250), c.SaleCost(ledger.Record{Code: "2"}))
+
+	i := Item{Price: 5000, Cost: 1500}
+	assert.Equal(t, int64(3500), i.Margin())
+	assert.Equal(t, 70.0, i.MarginPercent())
+	assert.Equal(t, int64(-100), Item{Price: 100, Cost: 200}.Margin())
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	l := ledger.New(ledger_config.Config{File: filepath.Join(t.TempDir(), "sales.jsonl")})
+	day := time.Date(2024, 5, 6, 10, 0, 0, 0, time.Local) // monday
+	for _, r := range []ledger.Record{
+		{Time: day, Code: "1", Price: 5000, PaymentMethod: "Cash", Stock: map[string]float32{"coffee": 8}},
+		{Time: day.Add(time.Hour), Code: "1", Price: 5000, PaymentMethod: "Gift", Stock: map[string]float32{"coffee": 8}},
+		{Time: day.Add(2 * time.Hour), Code: "2", Price: 8000, PaymentMethod: "Cashless", Stock: map[string]float32{"milk": 10}, Error: "cup"},
+		{Time: day.AddDate(0, 0, 1), Code: "2", Price: 8000, PaymentMethod: "Cashless"},
+		{Time: day.AddDate(0, 0, 8), Code: "9", Price: 1000, PaymentMethod: "Cash"},
+	} {
+		require.NoError(t, l.Write(r))
+	}
+	c := testCosting()
+
+	ps, err := c.Report(l, Query{Period: PeriodDay, Until: day.AddDate(0, 0, 7)})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(ps))
+	assert.Equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local), ps[0].From)
+	assert.Equal(t, time.Date(2024, 5, 7, 0, 0, 0, 0, time.Local), ps[0].To)
+	assert.Equal(t, []Item{
+		{Code: "1", Name: "espresso", Price: 5000, Cost: 1500, Sold: 2, Revenue: 5000, Spent: 2900},
+		{Code: "2", Name: "cappuccino", Price: 8000, Cost: 2250, Errors: 1, Waste: 500},
+	}, ps[0].Items)
+	total := ps[0].Total()
+	assert.Equal(t, 2, total.Sold)
+	assert.Equal(t, int64(5000-2900-500), total.Profit())
+	assert.Equal(t, []Item{{Code: "2", Name: "cappuccino", Price: 8000, Cost: 2250, Sold: 1, Revenue: 8000, Spent: 2250}}, ps[1].Items)
+
+	ps, err = c.Report(l, Query{Period: PeriodWeek})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(ps))
+	assert.Equal(t, time.Date(2024, 5, 13, 0, 0, 0, 0, time.Local), ps[1].From)
+	assert.Equal(t, "9", ps[1].Items[0].Code) // not in menu
+	assert.Equal(t, 3, ps[0].Total().Sold)
+
+	// whole time lists every menu item
+	ps, err = c.Report(l, Query{Since: day.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(ps))
+	assert.Equal(t, 4, len(ps[0].Items))
+	assert.Equal(t, 2, ps[0].Total().Sold)
+	assert.Equal(t, 4, len(ps[0].Tele().Items))
+}
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	q, err := ParseQuery([]string{"-since", "2024-05-01", "-period", "week"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local), q.Since)
+	assert.Equal(t, PeriodWeek, q.Period)
+	_, err = ParseQuery([]string{"-period", "year"})
+	assert.Error(t, err)
+	_, err = ParseQuery([]string{"-until", "yesterday"})
+	assert.Error(t, err)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/AlexTransit/vender/internal/watchdog"
 )
 
+// MenuMaxPrice without price rules. rules only discount, price may return to full during session
 func MenuMaxPrice() (currency.Amount, error) {
 	max := currency.Amount(0)
 	empty := true
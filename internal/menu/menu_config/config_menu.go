@@ -2,21 +2,32 @@ package menu_config
 
 import (
 	"fmt"
+	"math"
+	"slices"
+	"time"
 
 	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/helpers"
 	"github.com/AlexTransit/vender/internal/engine"
 	tele_api "github.com/AlexTransit/vender/tele"
 )
 
 type XXX_MenuStruct struct {
-	XXX_Items []MenuItem `hcl:"item,block"`
+	// RU: стоимость стакана. добавляется к себестоимости каждого напитка (vender margin, menu.cost).
+	XXX_CupCost float64    `hcl:"cup_cost,optional"`
+	XXX_Items   []MenuItem `hcl:"item,block"`
+	// RU: скидки по дням недели и времени (happy hours). действует самая низкая цена из подходящих правил.
+	// EN: discounts by weekday and time (happy hours). the lowest price of matching rules is used.
+	XXX_PriceRules []PriceRule `hcl:"price_rule,block"`
 }
 type MenuStruct struct {
 	DefaultCream    uint8 `hcl:"default_cream,optional"`
 	DefaultCreamMax uint8 `hcl:"default_cream_max,optional"`
 	DefaultSugar    uint8 `hcl:"default_sugar,optional"`
 	DefaultSugarMax uint8 `hcl:"default_sugar_max,optional"`
+	CupCost         float64
 	Items           map[string]MenuItem
+	PriceRules      map[string]PriceRule
 }
 
 type MenuItem struct {
@@ -34,6 +45,9 @@ type MenuItem struct {
 	CreamMax uint8 `hcl:"creamMax,optional"`
 	// RU: максимальное количество сахара для этого напитка. если 0, то будет использоваться значение из DefaultSugarMax.
 	SugarMax uint8 `hcl:"sugarMax,optional"`
+	// RU: категория напитка для правил цены (price_rule categories). например "coffee".
+	// EN: item category for price rules (price_rule categories). e.g. "coffee".
+	Category string `hcl:"category,optional"`
 
 	Price currency.Amount
 	Doer  engine.Doer
@@ -49,4 +63,60 @@ type UIMenuStruct struct {
 	QRPayAmount   uint32
 }
 
+type PriceRule struct {
+	Name string `hcl:"name,label"`
+	// RU: дни и время действия, синтаксис ui.front.light_sheduler. например "(1-5 15:00-17:00) (6 10:00-12:00)".
+	// EN: days and time, ui.front.light_sheduler syntax. e.g. "(1-5 15:00-17:00) (6 10:00-12:00)".
+	Schedule string `hcl:"schedule"`
+	// RU: скидка в процентах от цены.
+	// EN: discount percent of price.
+	Percent float64 `hcl:"percent,optional"`
+	// RU: скидка суммой, в единицах цены напитка (price).
+	// EN: discount amount, in units of item price.
+	XXX_Discount int `hcl:"discount,optional"` // use scaled `Discount`
+	// RU: коды напитков и категории, к которым применяется правило. если оба пустые - ко всем.
+	// EN: menu codes and categories of rule. both empty - all items.
+	Items      []string `hcl:"items,optional"`
+	Categories []string `hcl:"categories,optional"`
+
+	Discount currency.Amount
+	Week     helpers.WeekSchedule // parsed Schedule
+}
+
+// Match rule applies to item
+func (r *PriceRule) Match(mi *MenuItem) bool {
+	if len(r.Items) == 0 && len(r.Categories) == 0 {
+		return true
+	}
+	return slices.Contains(r.Items, mi.Code) || (mi.Category != "" && slices.Contains(r.Categories, mi.Category))
+}
+
+// Apply discount to price, not below zero
+func (r *PriceRule) Apply(price currency.Amount) currency.Amount {
+	off := currency.Amount(math.Round(float64(price)*r.Percent/100)) + r.Discount
+	if off >= price {
+		return 0
+	}
+	return price - off
+}
+
+// Price of item at time t with price rules, rule name is empty without discount.
+// Rule that makes paid item free is ignored.
+func (m *MenuStruct) Price(mi *MenuItem, t time.Time) (currency.Amount, string) {
+	price, rule := mi.Price, ""
+	for name, r := range m.PriceRules {
+		if !r.Week.Contains(t) || !r.Match(mi) {
+			continue
+		}
+		p := r.Apply(mi.Price)
+		if p <= 0 && mi.Price > 0 { // paid item never becomes free, rule error logged at start
+			continue
+		}
+		if p < price || (p == price && rule != "" && name < rule) {
+			price, rule = p, name
+		}
+	}
+	return price, rule
+}
+
 func (m *MenuItem) String() string { return fmt.Sprintf("menu.%s %s", m.Code, m.Code) }
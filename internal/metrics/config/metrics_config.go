@@ -0,0 +1,10 @@
+package metrics_config
+
+type Config struct {
+	// RU: Если true, то включается HTTP точка /metrics в формате Prometheus/OpenMetrics.
+	// EN: If true, HTTP endpoint /metrics in Prometheus/OpenMetrics text format is enabled.
+	Enabled bool `hcl:"enabled,optional"`
+	// RU: Адрес для HTTP сервера, например ":9110" или "127.0.0.1:9110".
+	// EN: HTTP listen address, e.g. ":9110" or "127.0.0.1:9110".
+	Listen string `hcl:"listen,optional"`
+}
@@ -0,0 +1,191 @@
+// Prometheus text format exporter.
+// counters accumulate in Metrics, current values (stock, temperature, state) read by collectors at scrape time.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	metrics_config "github.com/AlexTransit/vender/internal/metrics/config"
+	"github.com/AlexTransit/vender/log2"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type Counter struct {
+	Name string
+	Help string
+}
+
+type CollectFunc func(w *Writer)
+
+// nil = disabled, all methods are nil-safe
+type Metrics struct {
+	config     metrics_config.Config
+	log        *log2.Log
+	mu         sync.Mutex
+	counters   map[string]*counter
+	collectors []CollectFunc
+	handlers   map[string]http.Handler
+}
+
+type counter struct {
+	Counter
+	values map[string]float64 // formatted labels -> value
+}
+
+func New(c metrics_config.Config, log *log2.Log) *Metrics {
+	if !c.Enabled {
+		return nil
+	}
+	return &Metrics{
+		config:   c,
+		log:      log,
+		counters: make(map[string]*counter),
+	}
+}
+
+// Add increments counter. labels are name/value pairs
+func (m *Metrics) Add(c Counter, v float64, labels ...string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	x, ok := m.counters[c.Name]
+	if !ok {
+		x = &counter{Counter: c, values: make(map[string]float64)}
+		m.counters[c.Name] = x
+	}
+	x.values[formatLabels(labels)] += v
+}
+
+// Collect registers function called on every scrape
+func (m *Metrics) Collect(f CollectFunc) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.collectors = append(m.collectors, f)
+	m.mu.Unlock()
+}
+
+func (m *Metrics) Write(out io.Writer) error {
+	if m == nil {
+		return nil
+	}
+	bw := bufio.NewWriter(out)
+	w := &Writer{w: bw, seen: make(map[string]bool)}
+	m.mu.Lock()
+	names := make([]string, 0, len(m.counters))
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := m.counters[name]
+		keys := make([]string, 0, len(c.values))
+		for k := range c.values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			w.sample(c.Name, c.Help, "counter", k, c.values[k])
+		}
+	}
+	collectors := append([]CollectFunc(nil), m.collectors...)
+	m.mu.Unlock()
+	for _, f := range collectors {
+		f(w)
+	}
+	return bw.Flush()
+}
+
+func (m *Metrics) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", contentType)
+	if err := m.Write(rw); err != nil {
+		m.log.Errorf("metrics write err=%v", err)
+	}
+}
+
+// Handle adds endpoint to metrics HTTP server, before Start
+func (m *Metrics) Handle(path string, h http.Handler) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	if m.handlers == nil {
+		m.handlers = make(map[string]http.Handler)
+	}
+	m.handlers[path] = h
+	m.mu.Unlock()
+}
+
+// Start http server in background
+func (m *Metrics) Start() {
+	if m == nil {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	m.mu.Lock()
+	for path, h := range m.handlers {
+		mux.Handle(path, h)
+	}
+	m.mu.Unlock()
+	go func() {
+		m.log.Infof("metrics listen %s", m.config.Listen)
+		if err := http.ListenAndServe(m.config.Listen, mux); err != nil {
+			m.log.Errorf("metrics listen %s err=%v", m.config.Listen, err)
+		}
+	}()
+}
+
+// Writer for collectors. samples of one metric must be written together
+type Writer struct {
+	w    io.Writer
+	seen map[string]bool
+}
+
+func (w *Writer) Gauge(name, help string, v float64, labels ...string) {
+	w.sample(name, help, "gauge", formatLabels(labels), v)
+}
+
+func (w *Writer) Counter(name, help string, v float64, labels ...string) {
+	w.sample(name, help, "counter", formatLabels(labels), v)
+}
+
+func (w *Writer) sample(name, help, typ, labels string, v float64) {
+	if !w.seen[name] {
+		w.seen[name] = true
+		fmt.Fprintf(w.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	fmt.Fprintf(w.w, "%s%s %s\n", name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+var labelEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscape.Replace(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metrics_config "github.com/AlexTransit/vender/internal/metrics/config"
+	"github.com/AlexTransit/vender/log2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	t.Parallel()
+
+	sales := Counter{Name: "vender_sales_total", Help: "sales"}
+	m := New(metrics_config.Config{Enabled: true}, log2.NewTest(t, log2.LOG_DEBUG))
+	m.Add(sales, 1, "code", "2")
+	m.Add(sales, 1, "code", "1")
+	m.Add(sales, 1, "code", "2")
+	m.Collect(func(w *Writer) {
+		w.Gauge("vender_stock", "stock level", 10.5, "code", "1", "name", `su"gar`)
+		w.Gauge("vender_stock", "stock level", 0, "code", "2", "name", "water")
+		w.Counter("vender_mega_error_total", "mega errors", 3)
+	})
+
+	ts := httptest.NewServer(m)
+	defer ts.Close()
+	r, err := ts.Client().Get(ts.URL)
+	require.NoError(t, err)
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "text/plain; version=0.0.4"))
+	assert.Equal(t, `# HELP vender_sales_total sales
+# TYPE vender_sales_total counter
+vender_sales_total{code="1"} 1
+vender_sales_total{code="2"} 2
+# HELP vender_stock stock level
+# TYPE vender_stock gauge
+vender_stock{code="1",name="su\"gar"} 10.5
+vender_stock{code="2",name="water"} 0
+# HELP vender_mega_error_total mega errors
+# TYPE vender_mega_error_total counter
+vender_mega_error_total 3
+`, string(b))
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	t.Parallel()
+
+	m := New(metrics_config.Config{}, log2.NewTest(t, log2.LOG_DEBUG))
+	assert.Nil(t, m)
+	m.Add(Counter{Name: "x"}, 1)
+	m.Collect(func(*Writer) {})
+	m.Start()
+	var sb strings.Builder
+	assert.NoError(t, m.Write(&sb))
+	assert.Empty(t, sb.String())
+}
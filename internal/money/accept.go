@@ -31,6 +31,18 @@ func (ms *MoneySystem) SetAcceptMax(ctx context.Context, limit currency.Amount)
 
 func (ms *MoneySystem) AcceptCredit(ctx context.Context, maxPrice currency.Amount, mainAlive *alive.Alive, out chan<- types.Event) error {
 	g := state.GetGlobal(ctx)
+	ms.lk.Lock()
+	ms.creditOut, ms.creditAlive = out, mainAlive // gift credit notify
+	ms.lk.Unlock()
+	go func() {
+		<-mainAlive.StopChan()
+		ms.lk.Lock()
+		if ms.creditAlive == mainAlive {
+			ms.creditOut, ms.creditAlive = nil, nil
+		}
+		ms.lk.Unlock()
+	}()
+	ms.acceptCashless(ctx, mainAlive, out)
 	if ms.bill.GetState() != bill.Broken {
 		go ms.bill.BillRun(mainAlive, func(e money.ValidatorEvent) {
 			if e.Err != nil {
@@ -52,6 +64,7 @@ func (ms *MoneySystem) AcceptCredit(ctx context.Context, maxPrice currency.Amoun
 				}
 				event.Kind = types.EventMoneyPreCredit
 				ms.lk.Lock()
+				ms.journal(journalEscrow, currency.Amount(e.Nominal))
 				ms.billCredit.Add(e.Nominal)
 				credit := ms.billCredit.Total() + ms.coinCredit.Total()
 				ms.lk.Unlock()
@@ -61,6 +74,7 @@ func (ms *MoneySystem) AcceptCredit(ctx context.Context, maxPrice currency.Amoun
 			case money.OutEscrow:
 				event.Kind = types.EventMoneyPreCredit
 				ms.lk.Lock()
+				ms.journal(journalEscrowOut, currency.Amount(e.Nominal))
 				if ms.billCredit.Total() > 0 {
 					ms.billCredit.Sub(e.Nominal)
 				}
@@ -70,8 +84,11 @@ func (ms *MoneySystem) AcceptCredit(ctx context.Context, maxPrice currency.Amoun
 				if !ms.bill.BillStacked() {
 					ms.Log.Error("bill not stacked. substruct bill credit")
 					ms.lk.Lock()
+					ms.journal(journalEscrowOut, currency.Amount(e.Nominal))
 					ms.billCredit.Sub(e.Nominal)
 					ms.lk.Unlock()
+				} else {
+					ms.journal(journalBill, currency.Amount(e.Nominal))
 				}
 			default:
 				return
@@ -111,6 +128,7 @@ func (ms *MoneySystem) AcceptCredit(ctx context.Context, maxPrice currency.Amoun
 		case money.CoinCredit:
 			event.Kind = types.EventMoneyCredit
 			ms.lk.Lock()
+			ms.journal(journalCoin, currency.Amount(e.Nominal))
 			ms.coinCredit.Add(e.Nominal)
 			x := ms.billCredit.Total() + ms.coinCredit.Total()
 			ms.lk.Unlock()
@@ -1,6 +1,7 @@
 package money
 
 import (
+	"context"
 	"math/rand"
 	"testing"
 	"time"
@@ -8,6 +9,8 @@ import (
 	"github.com/AlexTransit/vender/hardware"
 	"github.com/AlexTransit/vender/hardware/mdb"
 	state_new "github.com/AlexTransit/vender/internal/state/new"
+	"github.com/AlexTransit/vender/log2"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -70,3 +73,12 @@ func TestWithdrawGift(t *testing.T) {
 	t.Logf("gift=%s", ms.giftCredit.FormatCtx(ctx))
 	ms.lk.RUnlock()
 }
+
+func TestWithdrawPrepareNeedMoreMoney(t *testing.T) {
+	t.Parallel()
+
+	ms := MoneySystem{Log: log2.NewTest(t, log2.LOG_DEBUG)}
+	ms.lastWithdraw = Withdraw{Bills: map[uint32]uint32{10000: 1}, Change: 2500} // previous sale
+	require.Equal(t, ErrNeedMoreMoney, ms.WithdrawPrepare(context.Background(), 5000))
+	assert.Equal(t, Withdraw{}, ms.LastWithdraw())
+}
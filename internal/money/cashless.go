@@ -0,0 +1,93 @@
+package money
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/input"
+	"github.com/AlexTransit/vender/hardware/money"
+	"github.com/AlexTransit/vender/internal/state"
+	"github.com/AlexTransit/vender/internal/types"
+	oerr "github.com/juju/errors"
+	"github.com/temoto/alive/v2"
+)
+
+// CashlessFunds card balance of the open cashless session. 0 = no session
+func (ms *MoneySystem) CashlessFunds() currency.Amount {
+	return ms.cashless.SessionFunds()
+}
+
+// poll card reader while the client choose the drink
+func (ms *MoneySystem) acceptCashless(ctx context.Context, mainAlive *alive.Alive, out chan<- types.Event) {
+	g := state.GetGlobal(ctx)
+	if !mainAlive.Add(1) {
+		return
+	}
+	go ms.cashless.CashlessRun(mainAlive, func(e money.ValidatorEvent) {
+		if e.Err != nil {
+			ms.Log.Warning(e.Err)
+			return
+		}
+		event := types.Event{}
+		switch e.Event {
+		case money.CashlessSessionBegin, money.CashlessSessionEnd:
+			event.Kind = types.EventMoneyCredit
+		case money.CashlessSessionCancel:
+			if g.Hardware.Input != nil {
+				g.Hardware.Input.Emit(types.InputEvent{Source: input.MoneySourceTag, Key: input.MoneyKeyAbort})
+			}
+			return
+		default:
+			return
+		}
+		go func() { out <- event }()
+	})
+}
+
+// CashlessVend request bank approval. after approve, amount is dirty until money.commit
+func (ms *MoneySystem) CashlessVend(ctx context.Context, amount currency.Amount, code string) error {
+	const tag = "money.cashless-vend"
+	ms.Log.Debugf("%s amount=%s", tag, amount.FormatCtx(ctx))
+	if err := ms.cashless.VendRequest(amount, cashlessItem(code)); err != nil {
+		return oerr.Annotate(err, tag)
+	}
+	ms.SetDirty(amount)
+	return nil
+}
+
+// CashlessVendEnd report vend result and close session. on failure reader returns money to the card.
+func (ms *MoneySystem) CashlessVendEnd(ctx context.Context, code string, success bool) error {
+	const tag = "money.cashless-vend-end"
+	var err error
+	if success {
+		err = ms.cashless.VendSuccess(cashlessItem(code))
+	} else {
+		err = ms.cashless.VendFailure()
+		ms.SetDirty(0)
+	}
+	if e := ms.cashless.SessionComplete(); e != nil && err == nil {
+		err = e
+	}
+	return oerr.Annotate(err, tag)
+}
+
+// CashlessSessionClose close session without vend (client gone)
+func (ms *MoneySystem) CashlessSessionClose() {
+	if err := ms.cashless.SessionComplete(); err != nil {
+		ms.Log.WarningF("cashless session close error:%v", err)
+	}
+}
+
+// CashlessRevalue return money to the card
+func (ms *MoneySystem) CashlessRevalue(ctx context.Context, amount currency.Amount) error {
+	return oerr.Annotatef(ms.cashless.Revalue(amount), "money.cashless-revalue amount=%s", amount.FormatCtx(ctx))
+}
+
+func cashlessItem(code string) uint16 {
+	n, err := strconv.ParseUint(code, 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(n)
+}
@@ -0,0 +1,245 @@
+package money
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexTransit/vender/currency"
+)
+
+// write-ahead journal of customer money.
+// every change of credit/dirty is appended (O_SYNC) before use,
+// after power loss or restart Start() replays it and restores the credit or reports lost money.
+// line format: "unixtime operation amount"
+
+const journalFileName = "money.journal"
+
+type journalOp string
+
+const (
+	journalEscrow    journalOp = "escrow"     // bill in escrow position (not credit yet)
+	journalEscrowOut journalOp = "escrow-out" // bill returned from escrow
+	journalBill      journalOp = "bill"       // bill stacked, credit
+	journalCoin      journalOp = "coin"       // coin credit
+	journalGift      journalOp = "gift"       // gift credit set
+	journalDirty     journalOp = "dirty"      // dirty set
+	journalPrepare   journalOp = "prepare"    // withdraw prepare. credit -> dirty
+	journalCommit    journalOp = "commit"     // sale complete
+	journalReturn    journalOp = "return"     // money returned to customer
+	journalZero      journalOp = "zero"       // money reset
+)
+
+type journal struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// money state restored from journal
+type journalState struct {
+	bills   []currency.Nominal
+	coins   []currency.Nominal
+	escrow  currency.Nominal
+	gift    currency.Amount
+	dirty   currency.Amount
+	updated time.Time
+}
+
+func (js *journalState) credit() (a currency.Amount) {
+	for _, n := range js.bills {
+		a += currency.Amount(n)
+	}
+	for _, n := range js.coins {
+		a += currency.Amount(n)
+	}
+	return a
+}
+
+func (js *journalState) empty() bool {
+	return js.credit() == 0 && js.dirty == 0 && js.gift == 0
+}
+
+func (js *journalState) apply(op journalOp, amount currency.Amount) error {
+	switch op {
+	case journalEscrow:
+		js.escrow = currency.Nominal(amount)
+	case journalEscrowOut:
+		js.escrow = 0
+	case journalBill:
+		js.escrow = 0
+		js.bills = append(js.bills, currency.Nominal(amount))
+	case journalCoin:
+		js.coins = append(js.coins, currency.Nominal(amount))
+	case journalGift:
+		js.gift = amount
+	case journalDirty:
+		js.dirty = amount
+	case journalPrepare:
+		js.bills, js.coins, js.escrow = nil, nil, 0
+		js.dirty = amount
+	case journalCommit, journalReturn, journalZero:
+		*js = journalState{updated: js.updated}
+	default:
+		return fmt.Errorf("unknown operation=%s", op)
+	}
+	return nil
+}
+
+func journalPath(inventoryFile string) string {
+	return filepath.Join(filepath.Dir(inventoryFile), journalFileName)
+}
+
+// replay journal records
+func readJournal(r io.Reader) (js journalState, err error) {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		text, e := br.ReadString('\n')
+		if e == io.EOF {
+			if text != "" { // last record torn by power loss
+				err = fmt.Errorf("money journal line:%d torn record (%s)", line, text)
+			}
+			return js, err
+		} else if e != nil {
+			return js, e
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			err = fmt.Errorf("money journal line:%d invalid record (%s)", line, text)
+			continue
+		}
+		t, e1 := strconv.ParseInt(fields[0], 10, 64)
+		amount, e2 := strconv.ParseUint(fields[2], 10, 32)
+		if e1 != nil || e2 != nil {
+			err = fmt.Errorf("money journal line:%d invalid record (%s)", line, text)
+			continue
+		}
+		if e := js.apply(journalOp(fields[1]), currency.Amount(amount)); e != nil {
+			err = fmt.Errorf("money journal line:%d %v", line, e)
+			continue
+		}
+		js.updated = time.Unix(t, 0)
+	}
+}
+
+// record for new (compacted) journal
+type journalRecord struct {
+	op     journalOp
+	amount currency.Amount
+}
+
+// readJournalFile read previous state. no file - empty state
+func readJournalFile(path string) (journalState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return journalState{}, nil
+		}
+		return journalState{}, err
+	}
+	defer f.Close()
+	return readJournal(f)
+}
+
+// openJournal start new journal with restored records.
+// records written to temp file, synced and renamed over old journal,
+// so power loss at any moment leaves old or new journal, never empty one.
+func openJournal(path string, records []journalRecord) (*journal, error) {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	for _, r := range records {
+		if _, err = fmt.Fprintf(f, "%d %s %d\n", now, r.op, r.amount); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if d, err := os.Open(filepath.Dir(path)); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_SYNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{path: path, f: f}, nil
+}
+
+// write record. nil journal (money system not started) do nothing
+func (j *journal) write(op journalOp, amount currency.Amount) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err := fmt.Fprintf(j.f, "%d %s %d\n", time.Now().Unix(), op, amount)
+	return err
+}
+
+func (j *journal) close() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.f.Close()
+}
+
+func (ms *MoneySystem) journal(op journalOp, amount currency.Amount) {
+	if err := ms.wal.write(op, amount); err != nil {
+		ms.Log.Errorf("money journal write op=%s amount=%s err=%v", op, amount.Format100I(), err)
+	}
+}
+
+// restore credit from journal state. returns money that can not be restored
+// and records of restored money for new journal
+func (ms *MoneySystem) restoreLocked(js journalState) (restored, lost currency.Amount, records []journalRecord) {
+	for _, n := range js.bills {
+		if err := ms.billCredit.Add(n); err != nil {
+			lost += currency.Amount(n)
+			continue
+		}
+		records = append(records, journalRecord{journalBill, currency.Amount(n)})
+		restored += currency.Amount(n)
+	}
+	for _, n := range js.coins {
+		if err := ms.coinCredit.Add(n); err != nil {
+			lost += currency.Amount(n)
+			continue
+		}
+		records = append(records, journalRecord{journalCoin, currency.Amount(n)})
+		restored += currency.Amount(n)
+	}
+	if js.gift != 0 {
+		ms.giftCredit = js.gift
+		records = append(records, journalRecord{journalGift, js.gift})
+	}
+	// withdraw prepared but sale not committed. product state unknown
+	lost += js.dirty
+	ms.restoredCredit = restored != 0
+	return restored, lost, records
+}
@@ -0,0 +1,83 @@
+package money
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalReplay(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		input  string
+		credit currency.Amount
+		dirty  currency.Amount
+		err    bool
+	}{
+		{"empty", "", 0, 0, false},
+		{"credit", "1 escrow 10000\n2 bill 10000\n3 coin 500\n", 10500, 0, false},
+		{"escrow-only", "1 escrow 10000\n", 0, 0, false},
+		{"escrow-returned", "1 escrow 5000\n2 escrow-out 5000\n3 coin 1000\n", 1000, 0, false},
+		{"gift-only", "1 gift 5000\n", 0, 0, false},
+		{"prepare", "1 bill 10000\n2 prepare 3500\n", 0, 3500, false},
+		{"commit", "1 bill 10000\n2 prepare 3500\n3 commit 3500\n", 0, 0, false},
+		{"return", "1 coin 1000\n2 return 1000\n", 0, 0, false},
+		{"torn-tail", "1 coin 1000\n2 coin 50", 1000, 0, true},
+		{"unknown-op", "1 coin 1000\n2 foo 50\n", 1000, 0, true},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			js, err := readJournal(strings.NewReader(c.input))
+			if c.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.credit, js.credit())
+			assert.Equal(t, c.dirty, js.dirty)
+		})
+	}
+}
+
+func TestJournalReopen(t *testing.T) {
+	t.Parallel()
+
+	path := journalPath(filepath.Join(t.TempDir(), "store.file"))
+	js, err := readJournalFile(path)
+	require.NoError(t, err)
+	assert.True(t, js.empty())
+	j, err := openJournal(path, nil)
+	require.NoError(t, err)
+	require.NoError(t, j.write(journalCoin, 1000))
+	require.NoError(t, j.write(journalPrepare, 700))
+	// power loss, no close
+	js, err = readJournalFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, currency.Amount(700), js.dirty)
+	assert.Equal(t, currency.Amount(0), js.credit())
+
+	// new journal starts with restored records only
+	j2, err := openJournal(path, []journalRecord{{journalBill, 10000}, {journalGift, 500}})
+	require.NoError(t, err)
+	require.NoError(t, j2.write(journalCoin, 1000))
+	js, err = readJournalFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, currency.Amount(11000), js.credit())
+	assert.Equal(t, currency.Amount(500), js.gift)
+	assert.Equal(t, currency.Amount(0), js.dirty)
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+	require.NoError(t, j.close())
+	require.NoError(t, j2.close())
+
+	var nilJournal *journal
+	assert.NoError(t, nilJournal.write(journalZero, 0))
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/AlexTransit/vender/currency"
 	"github.com/AlexTransit/vender/hardware/mdb/bill"
+	"github.com/AlexTransit/vender/internal/types"
 	oerr "github.com/juju/errors"
 )
 
@@ -22,12 +23,12 @@ var (
 	ErrCoinAcceptorOffline  = errors.New("coin-acceptor-offline")
 )
 
-func (ms *MoneySystem) TestingDispense() {
+func (ms *MoneySystem) TestingDispense(ctx context.Context) {
 	if ms.CoinValidator == nil {
 		ms.Log.Error(ErrCoinAcceptorOffline)
 		return
 	}
-	ms.CoinValidator.TestingDispense()
+	ms.CoinValidator.TestingDispense(ctx)
 }
 
 func (ms *MoneySystem) WaitEscrowAccept(amount currency.Amount) (wait bool) {
@@ -35,6 +36,7 @@ func (ms *MoneySystem) WaitEscrowAccept(amount currency.Amount) (wait bool) {
 	bc := ms.billCredit.Total()
 	cc := ms.coinCredit.Total()
 	ec := ms.bill.EscrowAmount()
+	amount -= min(ms.giftCredit, amount)
 	ms.lk.RUnlock()
 	if amount > bc-ec+cc {
 		ms.Log.Infof("bill credit:%v coin credit:%v, escrow bill:%v. send command accept escrow", bc.Format100I(), cc.Format100I(), ec.Format100I())
@@ -66,17 +68,24 @@ func (ms *MoneySystem) GetCredit() currency.Amount {
 	return ms.billCredit.Total() + ms.coinCredit.Total()
 }
 
-// возвращаем сдачу. если неполучиться приготовить то вернем стоимость напитка
+// возвращаем сдачу. если неполучиться приготовить то вернем стоимость напитка.
+// подарок (gift credit) оплачивает часть цены первым, сдачи с подарка нет и он не возвращается.
 func (ms *MoneySystem) WithdrawPrepare(ctx context.Context, amount currency.Amount) error {
 	const tag = "money.withdraw-prepare"
 	ms.Log.Debugf("%s amount=%s", tag, amount.FormatCtx(ctx))
 	ms.lk.Lock()
+	ms.lastWithdraw = Withdraw{} // new sale, previous withdraw must not get to ledger
 	available := ms.billCredit.Total() + ms.coinCredit.Total()
+	amount -= min(ms.giftCredit, amount)
 	if available < amount {
 		ms.lk.Unlock()
 		return ErrNeedMoreMoney
 	}
 	change := available - amount
+	ms.journal(journalPrepare, amount)
+	ms.lastWithdraw = Withdraw{Bills: map[uint32]uint32{}, Coins: map[uint32]uint32{}, Change: change}
+	ms.billCredit.ToMapUint32(ms.lastWithdraw.Bills)
+	ms.coinCredit.ToMapUint32(ms.lastWithdraw.Coins)
 	// ms.Log.Debugf("%s. return short change=%s", tag, change.FormatCtx(ctx))
 	ms.billCredit.Clear()
 	ms.coinCredit.Clear()
@@ -87,7 +96,7 @@ func (ms *MoneySystem) WithdrawPrepare(ctx context.Context, amount currency.Amou
 			ms.Log.WarningF("%s CRITICAL change err=%v", tag, ErrCoinAcceptorOffline)
 			return
 		}
-		if err := ms.CoinValidator.Dispense(change); err != nil {
+		if err := ms.CoinValidator.Dispense(context.Background(), change); err != nil {
 			err = oerr.Annotate(err, tag)
 			ms.Log.Errorf("%s CRITICAL change err=%v", tag, err)
 		}
@@ -104,17 +113,22 @@ func (ms *MoneySystem) GetGiftCredit() currency.Amount {
 	return c
 }
 
+// SetGiftCredit ui-front is notified with EventMoneyCredit while accepting credit
 func (ms *MoneySystem) SetGiftCredit(ctx context.Context, value currency.Amount) {
 	const tag = "money.set-gift-credit"
 
 	ms.lk.Lock()
 	// copy both values to release lock ASAP
 	before, after := ms.giftCredit, value
+	ms.journal(journalGift, value)
 	ms.giftCredit = after
+	out := ms.creditOut
 	ms.lk.Unlock()
 	ms.Log.Infof("%s before=%s after=%s", tag, before.FormatCtx(ctx), after.FormatCtx(ctx))
 
-	// TODO notify ui-front
+	if out != nil && before != after {
+		go func() { out <- types.Event{Kind: types.EventMoneyCredit} }()
+	}
 }
 
 // WithdrawCommit Store spending to durable memory, no user initiated return after this point.
@@ -123,6 +137,7 @@ func (ms *MoneySystem) WithdrawCommit(ctx context.Context, amount currency.Amoun
 	ms.lk.Lock()
 	defer ms.lk.Unlock()
 	ms.Log.Debugf("%s amount=%s dirty=%s", tag, amount.FormatCtx(ctx), ms.dirty.FormatCtx(ctx))
+	ms.journal(journalCommit, amount)
 	ms.locked_zero()
 	return nil
 }
@@ -134,23 +149,25 @@ func (ms *MoneySystem) ReturnDirty() error {
 	if ms.CoinValidator == nil {
 		return ErrCoinAcceptorOffline
 	}
-	return ms.CoinValidator.ReturnMoney(dirty)
+	return ms.CoinValidator.ReturnMoney(context.Background(), dirty)
 }
 
 func (ms *MoneySystem) ReturnMoney() error {
 	ms.lk.Lock()
 	cash := ms.billCredit.Total() + ms.coinCredit.Total() - ms.bill.EscrowAmount()
+	ms.journal(journalReturn, cash)
 	ms.setDirtyLocked(0)
 	ms.billCredit.Clear()
 	ms.coinCredit.Clear()
 	ms.giftCredit = 0
 	ms.lk.Unlock()
+	ms.CashlessSessionClose()
 	if cash > 0 {
 		if ms.CoinValidator == nil {
 			return ErrCoinAcceptorOffline
 		}
 		ms.Log.Infof("return money (%v)", cash)
-		return ms.CoinValidator.Dispense(cash)
+		return ms.CoinValidator.Dispense(context.Background(), cash)
 	}
 	return nil
 }
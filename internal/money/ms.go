@@ -8,6 +8,7 @@ import (
 
 	"github.com/AlexTransit/vender/currency"
 	"github.com/AlexTransit/vender/hardware/mdb/bill"
+	"github.com/AlexTransit/vender/hardware/mdb/cashless"
 	"github.com/AlexTransit/vender/hardware/mdb/coin"
 	"github.com/temoto/alive/v2"
 
@@ -39,7 +40,23 @@ type MoneySystem struct { //nolint:maligned
 	coinCashbox   currency.NominalGroup
 	coinCredit    currency.NominalGroup
 
-	giftCredit currency.Amount
+	cashless cashless.Cashless
+
+	giftCredit  currency.Amount
+	creditOut   chan<- types.Event // ui events while accepting credit
+	creditAlive *alive.Alive
+
+	wal            *journal
+	restoredCredit bool // credit restored from journal after restart
+
+	lastWithdraw Withdraw // money taken by last WithdrawPrepare, for sales ledger
+}
+
+// Withdraw money taken from the client for one sale
+type Withdraw struct {
+	Bills  map[uint32]uint32 // nominal:count
+	Coins  map[uint32]uint32 // nominal:count
+	Change currency.Amount
 }
 
 func GetGlobal(ctx context.Context) *MoneySystem {
@@ -54,8 +71,10 @@ func (ms *MoneySystem) Start(ctx context.Context) error {
 	ms.Log = g.Log
 	g.XXX_money.Store(ms)
 	const devNameBill = "bill"
+	const devNameCashless = "cashless"
 	// const devNameCoin = "coin"
 	ms.bill = bill.Stub{}
+	ms.cashless = cashless.Stub{}
 	// ms.coin = coin.Stub{}
 	errs := make([]error, 0, 2)
 	if dev, err := g.GetDevice(devNameBill); err == nil {
@@ -65,6 +84,13 @@ func (ms *MoneySystem) Start(ctx context.Context) error {
 	} else {
 		errs = append(errs, oerr.Annotatef(err, "device=%s", devNameBill))
 	}
+	if dev, err := g.GetDevice(devNameCashless); err == nil {
+		ms.cashless = dev.(cashless.Cashless)
+	} else if oerr.IsNotFound(err) {
+		ms.Log.Debugf("device=%s is not enabled in config", devNameCashless)
+	} else {
+		errs = append(errs, oerr.Annotatef(err, "device=%s", devNameCashless))
+	}
 	if e := helpers.FoldErrors(errs); e != nil {
 		return e
 	}
@@ -76,6 +102,26 @@ func (ms *MoneySystem) Start(ctx context.Context) error {
 		ms.coinCredit.SetValid(ms.CoinValidator.SupportedNominals())
 	}
 
+	walPath := journalPath(g.Config.Inventory.File)
+	js, err := readJournalFile(walPath)
+	if err != nil {
+		ms.Log.Errorf("money journal (%v)", err)
+	}
+	var restored, lost currency.Amount
+	var records []journalRecord
+	if !js.empty() {
+		restored, lost, records = ms.restoreLocked(js)
+	}
+	if ms.wal, err = openJournal(walPath, records); err != nil {
+		ms.Log.Errorf("money journal (%v)", err)
+	}
+	if !js.empty() {
+		ms.Log.Infof("money journal restored credit=%s gift=%s", restored.Format100I(), js.gift.Format100I())
+		if lost != 0 {
+			g.Tele.Error(fmt.Errorf("money journal. lost money=%s (dirty=%s) last operation time=%s", lost.Format100I(), js.dirty.Format100I(), js.updated.Format(time.RFC3339)))
+		}
+	}
+
 	g.Engine.RegisterNewFunc(
 		"money.cashbox_zero",
 		func(ctx context.Context) error {
@@ -152,14 +198,14 @@ func (ms *MoneySystem) Start(ctx context.Context) error {
 		if ms.CoinValidator == nil {
 			return ErrCoinAcceptorOffline
 		}
-		return ms.CoinValidator.Dispense(g.Config.ScaleU(uint32(arg.(int16))))
+		return ms.CoinValidator.Dispense(ctx, g.Config.ScaleU(uint32(arg.(int16))))
 	})
 
 	g.Engine.RegisterNewFuncAgr("money.return(?)", func(ctx context.Context, arg engine.Arg) error {
 		if ms.CoinValidator == nil {
 			return ErrCoinAcceptorOffline
 		}
-		return ms.CoinValidator.ReturnMoney(g.Config.ScaleU(uint32(arg.(int16))))
+		return ms.CoinValidator.ReturnMoney(ctx, g.Config.ScaleU(uint32(arg.(int16))))
 	})
 
 	doSetGiftCredit := engine.FuncArg{
@@ -184,6 +230,7 @@ func (ms *MoneySystem) Stop(ctx context.Context) error {
 	if ms.CoinValidator != nil {
 		errs = append(errs, g.Engine.Exec(ctx, ms.CoinValidator.AcceptMax(0)))
 	}
+	errs = append(errs, ms.wal.close())
 	return oerr.Annotate(helpers.FoldErrors(errs), tag)
 }
 
@@ -245,12 +292,14 @@ func SetCurrentPrice(ctx context.Context, p currency.Amount) context.Context {
 
 func (ms *MoneySystem) AddDirty(dirty currency.Amount) {
 	ms.lk.Lock()
+	ms.journal(journalDirty, ms.dirty+dirty)
 	ms.setDirtyLocked(ms.dirty + dirty)
 	ms.lk.Unlock()
 }
 
 func (ms *MoneySystem) SetDirty(dirty currency.Amount) {
 	ms.lk.Lock()
+	ms.journal(journalDirty, dirty)
 	ms.setDirtyLocked(dirty)
 	ms.lk.Unlock()
 }
@@ -267,5 +316,23 @@ func (ms *MoneySystem) setDirtyLocked(dirty currency.Amount) {
 }
 
 func (ms *MoneySystem) ResetMoney() {
+	ms.journal(journalZero, 0)
 	ms.locked_zero()
+	ms.CashlessSessionClose()
+}
+
+// LastWithdraw money taken by last WithdrawPrepare
+func (ms *MoneySystem) LastWithdraw() Withdraw {
+	ms.lk.RLock()
+	defer ms.lk.RUnlock()
+	return ms.lastWithdraw
+}
+
+// TakeRestoredCredit returns true once after credit was restored from journal.
+func (ms *MoneySystem) TakeRestoredCredit() bool {
+	ms.lk.Lock()
+	defer ms.lk.Unlock()
+	r := ms.restoredCredit
+	ms.restoredCredit = false
+	return r
 }
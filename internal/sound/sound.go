@@ -17,6 +17,7 @@ import (
 	"syscall"
 	"time"
 
+	config_global "github.com/AlexTransit/vender/internal/config"
 	"github.com/AlexTransit/vender/internal/engine"
 	sound_config "github.com/AlexTransit/vender/internal/sound/config"
 	"github.com/AlexTransit/vender/internal/state"
@@ -64,7 +65,9 @@ func Init(ctx context.Context, startingVMC bool) {
 	})
 
 	g.Engine.RegisterNewFuncAgr("speech(?)", func(ctx context.Context, arg engine.Arg) error {
-		TextSpeech(arg.(string))
+		// фраза и голос языка клиента
+		text, ttsExec := g.Config.UI_config.Speech(config_global.VMC.User.Locale, arg.(string))
+		speech(ttsExec, text)
 		return nil
 	})
 
@@ -113,14 +116,23 @@ func getCurrentVolume() float64 {
 	return v
 }
 
-func TextSpeech(tts string) {
-	if s.config == nil || s.audioContext == nil || len(s.config.TTSExec) == 0 || s.config.TTSExec[0] == "" {
+func TextSpeech(tts string) { speech(nil, tts) }
+
+// speech text by tts command, nil - sound.tts_exec
+func speech(ttsExec []string, tts string) {
+	if s.config == nil || s.audioContext == nil {
+		return
+	}
+	if len(ttsExec) == 0 {
+		ttsExec = s.config.TTSExec
+	}
+	if len(ttsExec) == 0 || ttsExec[0] == "" {
 		return
 	}
 	stdout := bytes.NewBuffer(nil)
 	stdin := strings.NewReader(tts)
 	stderr := bytes.NewBuffer(nil)
-	ttsArgs := append([]string{}, s.config.TTSExec[1:]...)
+	ttsArgs := append([]string{}, ttsExec[1:]...)
 	hasOutputRaw := false
 	for _, arg := range ttsArgs {
 		if arg == "--output_raw" {
@@ -131,9 +143,9 @@ func TextSpeech(tts string) {
 	if !hasOutputRaw {
 		ttsArgs = append(ttsArgs, "--output_raw")
 	}
-	cmd := exec.Command(s.config.TTSExec[0], ttsArgs...)
+	cmd := exec.Command(ttsExec[0], ttsArgs...)
 	// cmd.Dir = "/home/vmc/00"
-	cmd.Dir = filepath.Dir(s.config.TTSExec[0])
+	cmd.Dir = filepath.Dir(ttsExec[0])
 	cmd.Stdin = stdin
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
@@ -0,0 +1,189 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlexTransit/vender/hardware/display"
+	"github.com/AlexTransit/vender/helpers"
+	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/engine"
+	"github.com/AlexTransit/vender/internal/voucher"
+)
+
+// PBKDF2 iterations of technician pin_hash, less is too fast to brute force PIN
+const minPinIterations = 10000
+
+// ConfigCheck parses and resolves every scenario of config without running it (vender config-check).
+// drivers must register actions before, hardware is not used.
+// at runtime Lazy actions resolve only on first run, here every Lazy is forced.
+func (g *Global) ConfigCheck(ctx context.Context, src *config_global.Sources) (problems []config_global.Problem) {
+	add := func(key string, err error) { problems = append(problems, src.Problem(key, err)) }
+	_ = g.initEngine() // registers aliases, errors reported below with positions
+	if err := g.Inventory.Init(ctx, g.Engine, g.Log); err != nil {
+		add("inventory", err)
+	}
+	g.RegisterCommands(ctx)
+
+	check := func(key, tag, text string) engine.Doer {
+		d, err := g.Engine.ParseText(tag, text)
+		if err == nil {
+			_, _, err = engine.Force(d)
+		}
+		if err != nil {
+			add(key, err)
+			return nil
+		}
+		return d
+	}
+	cfg := g.Config
+
+	for _, name := range sortedKeys(cfg.Engine.Aliases) {
+		x := cfg.Engine.Aliases[name]
+		key := "engine.alias." + name
+		if len(x.Params) != 0 {
+			// scenario with $param is checked where alias is called
+			if _, err := engine.NewMacro(name, x.Params, x.Scenario); err != nil {
+				add(key+".params", err)
+			}
+		} else {
+			check(key+".scenario", name, x.Scenario)
+		}
+		for _, code := range sortedKeys(x.OnError) {
+			check(key+".onError."+code+".scenario", fmt.Sprintf("%s-Err:%s", name, code), x.OnError[code].Scenario)
+		}
+	}
+
+	// как в CheckMenuExecution, склад полный - проверяется только сценарий
+	for i := range g.Inventory.Stocks {
+		g.Inventory.Stocks[i].Set(math.MaxFloat32)
+	}
+	for _, code := range sortedKeys(cfg.Engine.Menu.Items) {
+		x := cfg.Engine.Menu.Items[code]
+		key := "engine.menu.item." + code + ".scenario"
+		if d := check(key, "menu."+code, x.Scenario); d != nil {
+			if err := d.Validate(); err != nil {
+				add(key, err)
+			}
+		}
+	}
+
+	lists := []struct {
+		name string
+		list []string
+	}{
+		{"on_boot", cfg.Engine.OnBoot},
+		{"first_init", cfg.Engine.FirstInit},
+		{"on_menu_error", cfg.Engine.OnMenuError},
+		{"on_service_begin", cfg.Engine.OnServiceBegin},
+		{"on_service_end", cfg.Engine.OnServiceEnd},
+		{"on_front_begin", cfg.Engine.OnFrontBegin},
+		{"on_broken", cfg.Engine.OnBroken},
+		{"on_shutdown", cfg.Engine.OnShutdown},
+	}
+	for _, l := range lists {
+		for i, text := range l.list {
+			check(fmt.Sprintf("engine.%s.%d", l.name, i), l.name, text)
+		}
+	}
+
+	for _, name := range sortedKeys(cfg.UI_config.Service.Tests) {
+		check("ui.service.test."+name+".scenario", name, cfg.UI_config.Service.Tests[name].Scenario)
+	}
+
+	for _, s := range g.Inventory.Stocks {
+		if s.RegisterAdd != "" {
+			check("inventory.stock."+s.Label+".register_add", s.Label, s.RegisterAdd)
+		}
+	}
+
+	for _, name := range sortedKeys(cfg.UI_config.Service.Technicians) {
+		if h := cfg.UI_config.Service.Technicians[name].PinHash; len(h) != sha256.Size*2 || !isHex(h) {
+			add("ui.service.technician."+name+".pin_hash", errors.New("pin_hash must be hex pbkdf2-sha256 of PIN with salt"))
+		}
+	}
+	if n := cfg.UI_config.Service.PinIterations; len(cfg.UI_config.Service.Technicians) != 0 && n < minPinIterations {
+		add("ui.service.pin_iterations", fmt.Errorf("pin_iterations must be >= %d, got %d", minPinIterations, n))
+	}
+
+	if k := cfg.UI_config.Front.LocaleKey; len(cfg.UI_config.Locales) != 0 && len(k) != 1 {
+		add("ui.front.locale_key", fmt.Errorf("locale_key must be one key, got '%s'", k))
+	}
+	for _, name := range sortedKeys(cfg.UI_config.Locales) {
+		for _, c := range sortedKeys(cfg.UI_config.Locales[name].CharMap) {
+			if b := cfg.UI_config.Locales[name].CharMap[c]; utf8.RuneCountInString(c) != 1 || b < 0 || b > 0xff {
+				add("ui.locale."+name+".char_map", fmt.Errorf("'%s' = %d must be one character = code 0-255", c, b))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(cfg.Engine.Menu.PriceRules) {
+		r := cfg.Engine.Menu.PriceRules[name]
+		key := "engine.menu.price_rule." + name
+		if _, err := helpers.ParseWeekSchedule(r.Schedule); err != nil || r.Schedule == "" {
+			add(key+".schedule", fmt.Errorf("schedule must be like \"(1-5 15:00-17:00)\", got '%s'", r.Schedule))
+		}
+		if r.Percent < 0 || r.Percent > 100 || r.XXX_Discount < 0 {
+			add(key, fmt.Errorf("percent must be 0-100 and discount not negative"))
+		}
+		for _, code := range r.Items {
+			if _, ok := cfg.Engine.Menu.Items[code]; !ok {
+				add(key+".items", fmt.Errorf("menu code=%s not found", code))
+			}
+		}
+		// discount must not make paid item free
+		for _, code := range sortedKeys(cfg.Engine.Menu.Items) {
+			if mi := cfg.Engine.Menu.Items[code]; mi.Price > 0 && r.Match(&mi) && r.Apply(mi.Price) <= 0 {
+				add(key, fmt.Errorf("discount makes menu code=%s price=%s free", code, mi.Price.Format100I()))
+			}
+		}
+	}
+
+	if vc := cfg.Voucher; vc.HmacKey != "" || vc.Ed25519PublicKey != "" {
+		if _, err := voucher.New(vc); err != nil && !errors.Is(err, voucher.ErrTorn) {
+			add("voucher", err)
+		}
+		if vc.Prefix == "" || strings.ContainsAny(vc.Prefix, "0123456789") {
+			add("voucher.prefix", fmt.Errorf("prefix must be not empty and without digits, got '%s'", vc.Prefix))
+		}
+	}
+
+	if gc := cfg.Hardware.Display.Graphic; gc.Enable { // font and colors, framebuffer is not opened
+		if _, err := display.NewRenderer(display.NewMock(image.Pt(320, 240)), gc); err != nil {
+			add("hardware.display.graphic", err)
+		}
+	}
+	return problems
+}
+
+// InitScenarios parses aliases and menu, inits inventory and registers actions without hardware (vender margin).
+func (g *Global) InitScenarios(ctx context.Context) error {
+	err := g.initEngine()
+	if e := g.Inventory.Init(ctx, g.Engine, g.Log); e != nil {
+		err = errors.Join(err, e)
+	}
+	g.RegisterCommands(ctx)
+	return err
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
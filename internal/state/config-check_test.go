@@ -0,0 +1,188 @@
+package state_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexTransit/vender/helpers"
+	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/engine"
+	state_new "github.com/AlexTransit/vender/internal/state/new"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCheck(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "vender.hcl")
+	require.NoError(t, os.WriteFile(mainFile, []byte(`
+inventory {
+  stock_file = "`+filepath.Join(dir, "store.file")+`"
+}
+engine {
+  on_boot = ["cup.dispense", "cup.lite_on"]
+  alias "make" {
+    scenario = "cup.dispense conveyor.move(pos=abc)"
+  }
+  alias "good" {
+    scenario = "cup.dispense conveyor.move(pos=?)"
+    onError "1" { scenario = "cup.reset" }
+  }
+  menu {
+    item "1" {
+      price = 30
+      scenario = "good"
+    }
+    item "2" {
+      price = 30
+      scenario = "cup.dispense(5)"
+    }
+    price_rule "half" {
+      schedule = "(* 10:00-12:00)"
+      percent  = 50
+    }
+    price_rule "free" {
+      schedule = "(* 10:00-12:00)"
+      discount = 30
+      items    = ["2"]
+    }
+  }
+}
+ui {
+  service {
+    test "cup" { scenario = "cup.dispense" }
+    technician "alex" {
+      salt     = "salt1"
+      pin_hash = "d7d4bd315ad785cab021acb3e9321539ae6f3f2fd8a9cf61779107a5535430c7"
+    }
+    pin_iterations = 1000
+  }
+}
+`), 0o644))
+	ctx, g := state_new.NewTestContext(t, "", "")
+	cfg, src, problems := config_global.ReadConfigCheck(g.Log, mainFile)
+	require.Empty(t, problems)
+	g.Config = cfg
+	g.Inventory = &cfg.Inventory
+	g.Engine.RegisterNewFunc("cup.dispense", func(context.Context) error { return nil })
+	g.Engine.RegisterNewFuncArgs("conveyor.move", []engine.Param{{Name: "pos", Type: engine.ArgInt}}, func(context.Context, engine.Args) error { return nil })
+
+	lines := []string{}
+	for _, p := range g.ConfigCheck(ctx, src) {
+		lines = append(lines, strings.TrimPrefix(p.String(), dir+"/"))
+	}
+	require.Len(t, lines, 7, strings.Join(lines, "\n"))
+	assert.Contains(t, lines[0], "vender.hcl:12: engine.alias.good.onError.1.scenario:")
+	assert.Contains(t, lines[0], "cup.reset not resolved")
+	assert.Contains(t, lines[1], "vender.hcl:8: engine.alias.make.scenario:")
+	assert.Contains(t, lines[1], "param=pos value=abc expected int")
+	assert.Contains(t, lines[2], "vender.hcl:17: engine.menu.item.1.scenario:")
+	assert.Contains(t, lines[2], "not applied", "alias placeholder is not filled in menu")
+	assert.Contains(t, lines[3], "vender.hcl:21: engine.menu.item.2.scenario:")
+	assert.Contains(t, lines[3], "cup.dispense(?) not resolved")
+	assert.Contains(t, lines[4], "vender.hcl:6: engine.on_boot.1:")
+	assert.Contains(t, lines[4], "cup.lite_on not resolved")
+	assert.Contains(t, lines[5], "vender.hcl:41: ui.service.pin_iterations: pin_iterations must be >= 10000")
+	assert.Contains(t, lines[6], "vender.hcl:27: engine.menu.price_rule.free: discount makes menu code=2 price=30 free")
+}
+
+func TestConfigCheckAliasParams(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "vender.hcl")
+	require.NoError(t, os.WriteFile(mainFile, []byte(`
+inventory {
+  stock_file = "`+filepath.Join(dir, "store.file")+`"
+}
+engine {
+  alias "serve" {
+    params = ["pos"]
+    scenario = "cup.dispense conveyor.move(pos=$pos)"
+  }
+  alias "unused" {
+    params = ["pos", "speed"]
+    scenario = "conveyor.move($pos)"
+  }
+  menu {
+    item "1" {
+      price = 30
+      scenario = "serve(300)"
+    }
+    item "2" {
+      price = 30
+      scenario = "serve(300, 5)"
+    }
+  }
+}
+`), 0o644))
+	ctx, g := state_new.NewTestContext(t, "", "")
+	cfg, src, problems := config_global.ReadConfigCheck(g.Log, mainFile)
+	require.Empty(t, problems)
+	g.Config = cfg
+	g.Inventory = &cfg.Inventory
+	g.Engine.RegisterNewFunc("cup.dispense", func(context.Context) error { return nil })
+	g.Engine.RegisterNewFuncArgs("conveyor.move", []engine.Param{{Name: "pos", Type: engine.ArgInt}}, func(context.Context, engine.Args) error { return nil })
+
+	lines := []string{}
+	for _, p := range g.ConfigCheck(ctx, src) {
+		lines = append(lines, strings.TrimPrefix(p.String(), dir+"/"))
+	}
+	require.Len(t, lines, 2, strings.Join(lines, "\n"))
+	assert.Contains(t, lines[0], "vender.hcl:11: engine.alias.unused.params:")
+	assert.Contains(t, lines[0], "param=speed not used")
+	assert.Contains(t, lines[1], "vender.hcl:21: engine.menu.item.2.scenario:")
+	assert.Contains(t, lines[1], "serve takes 1 arguments, given 2")
+}
+
+// onError of alias with params is in every expansion, map order of aliases does not matter
+func TestAliasParamsOnError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "vender.hcl")
+	config := "inventory { stock_file = \"" + filepath.Join(dir, "store.file") + "\" }\n" +
+		"engine {\n" +
+		"  alias \"serve\" {\n" +
+		"    params = [\"pos\"]\n" +
+		"    scenario = \"fail($pos)\"\n" +
+		"    onError \"5\" {\n" +
+		"      scenario = \"fixed\"\n" +
+		"      skip = true\n" +
+		"    }\n" +
+		"  }\n"
+	const aliases = 20
+	for i := 0; i < aliases; i++ {
+		config += fmt.Sprintf("  alias \"a%02d\" { scenario = \"serve(%d)\" }\n", i, i)
+	}
+	config += "}\n"
+	require.NoError(t, os.WriteFile(mainFile, []byte(config), 0o644))
+
+	for n := 0; n < 10; n++ {
+		ctx, g := state_new.NewTestContext(t, "", "")
+		cfg, _, problems := config_global.ReadConfigCheck(g.Log, mainFile)
+		require.Empty(t, problems)
+		g.Config = cfg
+		g.Inventory = &cfg.Inventory
+		fixed := 0
+		g.Engine.RegisterNewFuncAgr("fail(?)", func(context.Context, engine.Arg) error {
+			return &helpers.AppError{ErrorCode: 5, Err: errors.New("fail")}
+		})
+		g.Engine.RegisterNewFunc("fixed", func(context.Context) error { fixed++; return nil })
+		require.NoError(t, g.InitScenarios(ctx))
+		for i := 0; i < aliases; i++ {
+			name := fmt.Sprintf("a%02d", i)
+			d := g.Engine.Resolve(name)
+			require.NotNil(t, d, name)
+			assert.NoError(t, g.Engine.Exec(ctx, d), "init=%d alias=%s without onError", n, name)
+		}
+		assert.Equal(t, aliases, fixed)
+	}
+}
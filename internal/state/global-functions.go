@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -9,7 +10,10 @@ import (
 	"time"
 
 	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/display"
 	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/engine/inventory"
+	"github.com/AlexTransit/vender/internal/margin"
 	"github.com/AlexTransit/vender/internal/types"
 	"github.com/AlexTransit/vender/internal/watchdog"
 	tele_api "github.com/AlexTransit/vender/tele"
@@ -40,16 +44,40 @@ func (g *Global) CheckMenuExecution() {
 }
 
 func (g *Global) ListMenuPriceCost() {
-	g.Log.Infof("code;price;cost;name;scenario")
+	c := g.Costing()
+	g.Log.Infof("code;price;cost;margin;name;scenario")
 	for _, v := range g.Config.Engine.Menu.Items {
-		if v.Doer == nil {
-			g.Log.Infof("%s;%v;%v;%v;%v", v.Code, v.Price.Format100I(), "ERR", v.Name, v.Scenario)
+		cost, ok := c.ItemCost(v.Code)
+		if !ok {
+			g.Log.Infof("%s;%v;%v;%v;%v;%v", v.Code, v.Price.Format100I(), "ERR", "ERR", v.Name, v.Scenario)
 			continue
 		}
-		c := v.Doer.Calculation()
-		cost := currency.Amount(int(math.Round(c * 100)))
-		g.Log.Infof("%s;%v;%v;%v;%v", v.Code, v.Price.Format100I(), cost.Format100I(), v.Name, v.Scenario)
+		item := margin.Item{Price: v.Price, Cost: cost}
+		g.Log.Infof("%s;%v;%v;%.2f;%v;%v", v.Code, v.Price.Format100I(), cost.Format100I(), float64(item.Margin())/100, v.Name, v.Scenario)
+	}
+}
+
+// Costing menu prices and scenarios, ingredient cost of stocks
+func (g *Global) Costing() *margin.Costing {
+	c := &margin.Costing{
+		Products:  make(map[string]margin.Product, len(g.Config.Engine.Menu.Items)),
+		StockCost: make(map[string]float64),
+		CupCost:   g.Config.Engine.Menu.CupCost,
+	}
+	for code, v := range g.Config.Engine.Menu.Items {
+		c.Products[code] = margin.Product{Code: code, Name: v.Name, Price: v.Price, Doer: v.Doer}
 	}
+	g.Inventory.Iter(func(s *inventory.Stock) {
+		if s.Ingredient != nil {
+			c.StockCost[s.Label] = s.Ingredient.Cost
+		}
+	})
+	return c
+}
+
+// Margin report of sales from ledger
+func (g *Global) Margin(q margin.Query) ([]margin.Period, error) {
+	return g.Costing().Report(g.Ledger, q)
 }
 
 func (g *Global) UpgradeVender() {
@@ -74,6 +102,8 @@ func (g *Global) VmcStopWOInitRequared(ctx context.Context) {
 	watchdog.Disable()
 	g.TeleCancelOrder(tele_api.State_Shutdown)
 	g.Log.Infof("--- event vmc stop ---")
+	g.AbortJobs(errors.New("vmc stop"))
+	ctx = context.WithoutCancel(ctx) // may be called from aborted job, on_shutdown must run
 	go func() {
 		time.Sleep(10 * time.Second)
 		g.Log.Infof("--- vmc timeout EXIT ---")
@@ -102,17 +132,27 @@ func (g *Global) RunBashSript(script string) (err error) {
 }
 
 func (g *Global) ShowQR(t string) {
-	display, err := g.Display()
+	d, err := g.Display()
 	if err != nil {
 		g.Log.Error(err, "display")
 		return
 	}
-	if display == nil {
+	if d == nil {
 		g.Log.Error("display is not configured")
 		return
 	}
 	g.Log.Infof("show QR:'%v'", t)
-	err = display.QR(t, true, 2)
+	if r := g.Hardware.Display.Renderer; r != nil {
+		caption := ""
+		if amount := config_global.VMC.User.QRPayAmount; amount != 0 {
+			lang := config_global.VMC.User.Locale
+			front := g.Config.UI_config.LocaleFront(lang)
+			caption = g.Config.UI_config.Sprintf(lang, front.MsgRemotePay+front.MsgPrice, currency.Amount(amount).Format100I())
+		}
+		err = r.Draw(display.Screen{Kind: display.ScreenQR, QR: t, Lines: [2]string{caption}})
+	} else {
+		err = d.QR(t, true, 2)
+	}
 	if err != nil {
 		g.Log.Error(err, "QR show error")
 	}
@@ -13,8 +13,12 @@ import (
 	config_global "github.com/AlexTransit/vender/internal/config"
 	"github.com/AlexTransit/vender/internal/engine"
 	"github.com/AlexTransit/vender/internal/engine/inventory"
+	"github.com/AlexTransit/vender/internal/ledger"
+	"github.com/AlexTransit/vender/internal/metrics"
+	"github.com/AlexTransit/vender/internal/voucher"
 	"github.com/AlexTransit/vender/internal/watchdog"
 
+	"github.com/AlexTransit/vender/internal/trace"
 	"github.com/AlexTransit/vender/internal/types"
 	"github.com/AlexTransit/vender/log2"
 	tele_api "github.com/AlexTransit/vender/tele"
@@ -30,8 +34,14 @@ type Global struct {
 	Engine       *engine.Engine
 	Hardware     hardware // hardware.go
 	Inventory    *inventory.Inventory
+	Ledger       *ledger.Ledger
 	Log          *log2.Log
+	Metrics      *metrics.Metrics
 	Tele         tele_api.Teler
+	Tracer       *trace.Tracer
+	Voucher      *voucher.Vouchers // nil when vouchers are disabled
+
+	jobs jobs // job.go
 
 	XXX_money atomic.Value // *money.MoneySystem crutch to import cycle
 	XXX_uier  atomic.Value // UIer crutch to import/init cycle
@@ -95,6 +105,26 @@ func (g *Global) Init(ctx context.Context, cfg *config_global.Config) (err error
 	// errch := make(chan error, initTasks)
 	g.initInput()
 	g.Inventory = &g.Config.Inventory
+	g.Ledger = ledger.New(g.Config.Ledger)
+	if g.Voucher, err = voucher.New(g.Config.Voucher); errors.Is(err, voucher.ErrTorn) {
+		g.Log.WarningF("voucher (%v)", err)
+		err = nil
+	} else if err != nil {
+		g.Log.Errorf("voucher disabled (%v)", err)
+		g.Voucher, err = nil, nil
+	}
+	for name, r := range g.Config.Engine.Menu.PriceRules {
+		if _, err := helpers.ParseWeekSchedule(r.Schedule); err != nil {
+			g.Log.Errorf("price_rule %s disabled (%v)", name, err)
+		}
+		for _, code := range sortedKeys(g.Config.Engine.Menu.Items) {
+			if mi := g.Config.Engine.Menu.Items[code]; mi.Price > 0 && r.Match(&mi) && r.Apply(mi.Price) <= 0 {
+				g.Log.Errorf("price_rule %s ignored for menu code=%s (discount makes paid item free)", name, code)
+			}
+		}
+	}
+	g.Tracer = trace.New(g.Config.Engine.TraceOrders)
+	g.initMetrics()
 	// go helpers.WrapErrChan(&wg, errch, g.initDisplay) // AlexM хрень переделать
 	g.initDisplay()
 	// g.prepareInventory()
@@ -118,6 +148,8 @@ func (g *Global) ScheduleSync(ctx context.Context, fun types.TaskFunc) error {
 
 	g.Alive.Add(1)
 	defer g.Alive.Done()
+	ctx, done := g.JobContext(ctx)
+	defer done()
 	return fun(ctx)
 
 	// switch priority {
@@ -151,13 +183,35 @@ func (g *Global) UI() types.UIer {
 func (g *Global) initEngine() error {
 	errs := make([]error, 0)
 
+	// aliases with params first, they are expanded while other scenarios are parsed.
+	// onError is attached before any expansion, every expansion gets it
 	for _, x := range g.Config.Engine.Aliases {
-		var err error
-		x.Doer, err = g.Engine.ParseText(x.Name, x.Scenario)
+		if len(x.Params) == 0 {
+			continue
+		}
+		m, err := engine.NewMacro(x.Name, x.Params, x.Scenario)
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
+		for i, v := range x.OnError {
+			d, _ := g.Engine.ParseText(fmt.Sprintf("%s-Err:%s", x.Name, i), v.Scenario)
+			m.AddErrorAction(i, d, v.SkipMain)
+		}
+		g.Engine.RegisterMacro(m)
+	}
+	for _, x := range g.Config.Engine.Aliases {
+		if len(x.Params) != 0 {
+			if ok, d := g.Engine.CheckAction(x.Name + "(?)"); ok {
+				g.Engine.Register(x.Name, d)
+			}
+			continue
+		}
+		var err error
+		if x.Doer, err = g.Engine.ParseText(x.Name, x.Scenario); err != nil {
+			errs = append(errs, err)
+			continue
+		}
 		for i, v := range x.OnError {
 			errActionName := fmt.Sprintf("%s-Err:%s", x.Name, i)
 			var d engine.Doer
@@ -241,8 +295,10 @@ func (g *Global) RegisterCommands(ctx context.Context) {
 					if e.Key == input.EvendKeyAccept {
 						return nil
 					}
+				case <-ctx.Done():
+					return ctx.Err()
 				case <-tmr.C:
-					return &helpers.AppError{ErrorCode: 99, Err: errors.New("timeout wait.ok")}
+					return &helpers.AppError{ErrorCode: engine.ErrCodeTimeout, Err: errors.New("timeout wait.ok")}
 				}
 			}
 		},
@@ -273,6 +329,13 @@ func (g *Global) RegisterCommands(ctx context.Context) {
 		func(ctx context.Context) error { g.UpgradeVender(); return nil },
 	)
 
+	// scenario variables for if(cond){}
+	g.Engine.RegisterVar("user.cream", func(context.Context, string) (interface{}, error) { return config_global.VMC.User.Cream, nil })
+	g.Engine.RegisterVar("user.sugar", func(context.Context, string) (interface{}, error) { return config_global.VMC.User.Sugar, nil })
+	g.Engine.RegisterVar("payment.method", func(context.Context, string) (interface{}, error) {
+		return config_global.VMC.User.PaymentMethod.String(), nil
+	})
+
 	g.Engine.RegisterNewFunc("check.menu",
 		func(ctx context.Context) error { g.CheckMenuExecution(); return nil },
 	)
@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/AlexTransit/vender/hardware/mdb"
 	mdb_client "github.com/AlexTransit/vender/hardware/mdb/client"
 	"github.com/AlexTransit/vender/hardware/mega-client"
+	"github.com/AlexTransit/vender/hardware/sim"
 	"github.com/AlexTransit/vender/hardware/text_display"
 	"github.com/AlexTransit/vender/helpers"
 	config_global "github.com/AlexTransit/vender/internal/config"
@@ -24,7 +26,8 @@ import (
 type hardware struct {
 	Display struct {
 		once
-		Graphic *display.Display
+		Graphic  *display.Display
+		Renderer *display.Renderer // graphic UI, nil if not enabled
 	}
 	HD44780 struct {
 		once
@@ -50,6 +53,10 @@ type hardware struct {
 		once
 		client *mega.Client
 	}
+	simulator struct {
+		once
+		machine *sim.Machine
+	}
 }
 
 type devWrap struct {
@@ -64,8 +71,16 @@ func (g *Global) Display() (*display.Display, error) {
 		cfg := &g.Config.Hardware.Display
 		switch {
 		case cfg.Framebuffer != "":
-			x.Graphic, x.err = display.NewFb(cfg.Framebuffer)
-			return x.err
+			if x.Graphic, x.err = display.NewFb(cfg.Framebuffer); x.err != nil {
+				return x.err
+			}
+			if cfg.Graphic.Enable {
+				var err error
+				if x.Renderer, err = display.NewRenderer(x.Graphic, cfg.Graphic); err != nil {
+					g.Log.Errorf("graphic ui disabled err=%v", err) // QR and pictures still work
+				}
+			}
+			return nil
 
 		default:
 			// return fmt.Errorf("config: no display device (try framebuffer)")
@@ -123,8 +138,11 @@ func (g *Global) Mdb() (*mdb.Bus, error) {
 		case "dummy":
 			x.Uarter = mdb_client.NewDummyUart()
 
+		case "sim":
+			x.Uarter = g.Sim().Uart()
+
 		default:
-			return fmt.Errorf("config: unknown mdb.uart_driver=\"%s\" valid: file, mega, iodin, dummy", g.Config.Hardware.Mdb.UartDriver)
+			return fmt.Errorf("config: unknown mdb.uart_driver=\"%s\" valid: file, mega, iodin, dummy, sim", g.Config.Hardware.Mdb.UartDriver)
 		}
 
 		mdbLog := g.Log.Clone(log2.LOG_INFO)
@@ -135,11 +153,25 @@ func (g *Global) Mdb() (*mdb.Bus, error) {
 			return errors.Annotatef(err, "config: mdb=%v", g.Config.Hardware.Mdb)
 		}
 		x.Bus = mdb.NewBus(x.Uarter, mdbLog, g.Tele.Error)
+		if g.Tracer != nil {
+			x.Bus.OnTx = g.Tracer.Tx
+		}
 		return nil
 	})
 	return x.Bus, x.err
 }
 
+// Sim hardware simulator (mdb.uart_driver = "sim"), operator console on stdin
+func (g *Global) Sim() *sim.Machine {
+	x := &g.Hardware.simulator
+	_ = x.do(func() error {
+		x.machine = sim.New(g.Log, os.Stdout)
+		go x.machine.RunConsole(os.Stdin)
+		return nil
+	})
+	return x.machine
+}
+
 func (g *Global) Mega() (*mega.Client, error) {
 	x := &g.Hardware.mega
 	_ = x.do(func() error {
@@ -189,7 +221,10 @@ func (g *Global) TextDisplay() (*text_display.TextDisplay, error) {
 			return nil
 		}
 		var dev text_display.Devicer
-		if devConfig.PinChip == "dummy" {
+		if g.Config.Hardware.Mdb.UartDriver == "sim" {
+			dev = g.Sim().Display()
+			g.Log.Info("text display hd44780 simulator is enabled")
+		} else if devConfig.PinChip == "dummy" {
 			dev = hd44780.NewDummy()
 			g.Log.Info("text display hd44780 dummy stub is enabled")
 		} else {
@@ -351,6 +386,9 @@ func (g *Global) initInput() {
 	g.Hardware.Input = &input.Dispatch{
 		Log: g.Log,
 		Bus: make(chan types.InputEvent),
+		OnService: func(types.InputEvent) {
+			g.AbortJobs(errors.New("service key"))
+		},
 	}
 
 	// read key event from evend keyboard
@@ -361,6 +399,9 @@ func (g *Global) initInput() {
 		g.Log.Errorf("evend keyboard not work. sourse(%v) error(%v)", srcEvendKbd, err)
 	}
 
+	if g.Config.Hardware.Mdb.UartDriver == "sim" { // service key is in simulator keyboard
+		return
+	}
 	// read service key
 	srcServiceKey, err := input.NewDevInputEventSource(g.Config.Hardware.Input.ServiceKey)
 	if err == nil {
@@ -377,6 +418,9 @@ func (g *Global) initInputEvendKeyboard() (input.Source, error) {
 		return nil, nil
 	}
 
+	if g.Config.Hardware.Mdb.UartDriver == "sim" {
+		return g.Sim().Keyboard(), nil
+	}
 	mc, err := g.Mega()
 	if err != nil {
 		err = errors.Annotatef(err, "input=%s", tag)
@@ -0,0 +1,48 @@
+package state
+
+import (
+	"context"
+	"sync"
+)
+
+// running interruptible scenarios (cook, remote exec, service test).
+// service key, remote stop and shutdown cancel them, device wait loops see ctx.Done()
+type jobs struct {
+	mu     sync.Mutex
+	id     uint64
+	cancel map[uint64]context.CancelCauseFunc
+}
+
+// JobContext context of interruptible job. call done when job finished
+func (g *Global) JobContext(ctx context.Context) (jctx context.Context, done func()) {
+	jctx, cancel := context.WithCancelCause(ctx)
+	g.jobs.mu.Lock()
+	defer g.jobs.mu.Unlock()
+	if g.jobs.cancel == nil {
+		g.jobs.cancel = make(map[uint64]context.CancelCauseFunc)
+	}
+	g.jobs.id++
+	id := g.jobs.id
+	g.jobs.cancel[id] = cancel
+	return jctx, func() {
+		g.jobs.mu.Lock()
+		delete(g.jobs.cancel, id)
+		g.jobs.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// AbortJobs cancel all running jobs with cause. returns number of cancelled jobs
+func (g *Global) AbortJobs(cause error) int {
+	g.jobs.mu.Lock()
+	defer g.jobs.mu.Unlock()
+	n := len(g.jobs.cancel)
+	for id, cancel := range g.jobs.cancel {
+		cancel(cause)
+		delete(g.jobs.cancel, id)
+	}
+	if n != 0 {
+		g.Log.Infof("abort running jobs=%d cause=%v", n, cause)
+	}
+	return n
+}
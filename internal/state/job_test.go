@@ -0,0 +1,40 @@
+package state_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	state_new "github.com/AlexTransit/vender/internal/state/new"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbortJobs(t *testing.T) {
+	t.Parallel()
+
+	ctx, g := state_new.NewTestContext(t, "", "")
+	assert.Equal(t, 0, g.AbortJobs(errors.New("idle")))
+
+	d, err := g.Engine.ParseText("long", "sleep(10ms) sleep(5s)")
+	require.NoError(t, err)
+	result := make(chan error, 1)
+	go func() {
+		result <- g.ScheduleSync(ctx, func(ctx context.Context) error { return g.Engine.Exec(ctx, d) })
+	}()
+	cause := errors.New("service key")
+	require.Eventually(t, func() bool { return g.AbortJobs(cause) == 1 }, time.Second, time.Millisecond)
+	select {
+	case err = <-result:
+		assert.True(t, errors.Is(err, context.Canceled), err)
+	case <-time.After(time.Second):
+		t.Fatal("job not interrupted")
+	}
+
+	// finished job is forgotten
+	jctx, done := g.JobContext(ctx)
+	done()
+	assert.Error(t, jctx.Err())
+	assert.Equal(t, 0, g.AbortJobs(cause))
+}
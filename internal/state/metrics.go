@@ -0,0 +1,54 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/AlexTransit/vender/internal/engine/inventory"
+	"github.com/AlexTransit/vender/internal/metrics"
+)
+
+func (g *Global) initMetrics() {
+	g.Metrics = metrics.New(g.Config.Metrics, g.Log)
+	g.Metrics.Collect(g.collectHardware)
+	g.Metrics.Collect(g.collectInventory)
+	if g.Tracer != nil {
+		g.Metrics.Handle("/trace", g.Tracer)
+	}
+}
+
+func (g *Global) collectHardware(w *metrics.Writer) {
+	if bus := g.Hardware.Mdb.Bus; bus != nil {
+		stat := bus.Stat()
+		addrs := make([]int, 0, len(stat))
+		for a := range stat {
+			addrs = append(addrs, int(a))
+		}
+		sort.Ints(addrs)
+		for _, a := range addrs {
+			w.Counter("vender_mdb_tx_total", "MDB transactions by device address", float64(stat[uint8(a)].Tx), "addr", fmt.Sprintf("0x%02x", a))
+		}
+		for _, a := range addrs {
+			w.Counter("vender_mdb_error_total", "MDB transaction errors by device address", float64(stat[uint8(a)].Error), "addr", fmt.Sprintf("0x%02x", a))
+		}
+		for _, a := range addrs {
+			w.Counter("vender_mdb_timeout_total", "MDB response timeouts by device address", float64(stat[uint8(a)].Timeout), "addr", fmt.Sprintf("0x%02x", a))
+		}
+	}
+	if mc := g.Hardware.mega.client; mc != nil {
+		stat := mc.Stat()
+		w.Counter("vender_mega_request_total", "mega SPI requests", float64(stat.Request))
+		w.Counter("vender_mega_error_total", "mega SPI errors", float64(stat.Error))
+		w.Counter("vender_mega_reset_total", "mega resets", float64(stat.Reset))
+	}
+}
+
+func (g *Global) collectInventory(w *metrics.Writer) {
+	if g.Inventory == nil {
+		return
+	}
+	g.Inventory.Iter(func(s *inventory.Stock) {
+		w.Gauge("vender_stock", "stock level by stock code", float64(s.Value()), "code", strconv.Itoa(s.Code), "name", s.Label)
+	})
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	config_global "github.com/AlexTransit/vender/internal/config"
+)
+
+// SetRemoteConfig checks config fragment from server the same way as boot does
+// (ReadConfig + initEngine + Doer.Validate) and stores it. applied at next StateFrontBegin.
+func (g *Global) SetRemoteConfig(ctx context.Context, name string, fragment []byte) error {
+	cfg, err := config_global.CheckRemoteConfig(g.Log, g.Config.ConfigFile, name, fragment)
+	if err != nil {
+		return err
+	}
+	if err = g.checkConfig(ctx, cfg); err != nil {
+		return fmt.Errorf("config(%s) check error(%v)", name, err)
+	}
+	if err = config_global.WriteRemoteConfig(cfg.RemoteConfig, fragment); err != nil {
+		return fmt.Errorf("write remote config error(%v)", err)
+	}
+	g.Log.Infof("remote config(%s) accepted, apply at front begin", name)
+	return nil
+}
+
+// checkConfig init engine and inventory from cfg on copy of working engine. working state is not touched.
+func (g *Global) checkConfig(ctx context.Context, cfg *config_global.Config) error {
+	if cfg.Money.Scale <= 0 {
+		return fmt.Errorf("money.scale=%d not valid", cfg.Money.Scale)
+	}
+	check := &Global{
+		Config:    cfg,
+		Engine:    g.Engine.Clone(),
+		Inventory: &cfg.Inventory,
+		Log:       g.Log,
+	}
+	errs := errors.Join(check.initEngine(), check.Inventory.Init(ctx, check.Engine, g.Log))
+	if errs != nil {
+		return errs
+	}
+	if len(cfg.Engine.Menu.Items) == 0 {
+		return errors.New("menu is empty")
+	}
+	// как в CheckMenuExecution, склад полный - проверяется только сценарий
+	for i := range check.Inventory.Stocks {
+		check.Inventory.Stocks[i].Set(math.MaxFloat32)
+	}
+	for _, v := range cfg.Engine.Menu.Items {
+		if err := v.Doer.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("menu code:%s error(%v)", v.Code, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,50 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	config_global "github.com/AlexTransit/vender/internal/config"
+	state_new "github.com/AlexTransit/vender/internal/state/new"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRemoteConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "vender.hcl")
+	remoteFile := filepath.Join(dir, "remote.hcl")
+	require.NoError(t, os.WriteFile(mainFile, []byte(`
+remote_config = "`+remoteFile+`"
+inventory {
+  stock_file = "`+filepath.Join(dir, "store.file")+`"
+}
+`), 0o644))
+	ctx, g := state_new.NewTestContext(t, "", "")
+	g.Config = config_global.ReadConfig(g.Log, mainFile)
+	g.Engine.RegisterNewFunc("cup.dispense", nil)
+
+	item := func(scenario string) []byte {
+		return []byte("engine {\n alias \"make\" {\n scenario = \"cup.dispense\"\n }\n menu {\n item \"1\" {\n price = 30\n scenario = \"" + scenario + "\"\n }\n }\n}\n")
+	}
+
+	// not resolved action, nothing written
+	err := g.SetRemoteConfig(ctx, "menu", item("cup.dispense cup.unknown"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cup.unknown")
+	_, err = os.Stat(remoteFile + ".new")
+	assert.True(t, os.IsNotExist(err))
+
+	good := item("make sleep(1ms)")
+	require.NoError(t, g.SetRemoteConfig(ctx, "menu", good))
+	b, err := os.ReadFile(remoteFile + ".new")
+	require.NoError(t, err)
+	assert.Equal(t, good, b)
+
+	// check runs on engine copy, working engine not changed until restart
+	ok, _ := g.Engine.CheckAction("make")
+	assert.False(t, ok)
+}
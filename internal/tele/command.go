@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/helpers"
 	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/margin"
 	"github.com/AlexTransit/vender/internal/money"
 	"github.com/AlexTransit/vender/internal/sound"
 	"github.com/AlexTransit/vender/internal/state"
@@ -32,6 +35,11 @@ func (t *tele) onCommandMessage(ctx context.Context, payload []byte) bool {
 		return true
 	}
 	t.log.Debugf("tele command raw=%x task=%#v", payload, cmd.String())
+	if err = t.auth.verify(cmd); err != nil {
+		t.log.Errorf("tele command rejected executer=%d err=%v", cmd.Executer, err)
+		t.commandError(cmd, err)
+		return true
+	}
 
 	if err = t.dispatchCommand(ctx, cmd); err != nil {
 		t.log.Errorf("command message error (%v)", err)
@@ -60,9 +68,16 @@ func (t *tele) messageForRobot(ctx context.Context, payload []byte) bool {
 			State: t.currentState,
 		})
 	case tele_api.MessageType_reportStock:
+		t.RoboSend(&tele_api.FromRoboMessage{
+			State: t.currentState,
+			Stock: state.GetGlobal(ctx).Inventory.TeleStock(),
+		})
+	case tele_api.MessageType_reportMargin:
+		go t.messageReportMargin(ctx, &m)
 	case tele_api.MessageType_showQR:
 		t.messageShowQr(ctx, &m)
 	case tele_api.MessageType_executeCommand:
+		go t.messageExecuteCommand(ctx, &m)
 	default: // unknow mesage type
 	}
 	return true
@@ -114,30 +129,14 @@ func (t *tele) mesageMakeOrger(ctx context.Context, m *tele_api.ToRoboMessage) {
 			t.makeOrderImposible(tele_api.OrderStatus_robotIsBusy, m)
 			return
 		}
-		var found bool
-		config_global.VMC.User.SelectedItem, found = config_global.GetMenuItem(m.MakeOrder.MenuCode)
-		if !found {
-			t.log.Infof("remote cook error: code not found")
-			t.makeOrderImposible(tele_api.OrderStatus_executionInaccessible, m)
-			return
-		}
-		if config_global.VMC.User.SelectedItem.Doer == nil {
-			t.makeOrderImposible(tele_api.OrderStatus_executionInaccessible, m)
-			t.log.Infof("remote cook error: code doer is nil")
-			return
-		}
-		if err := config_global.VMC.User.SelectedItem.Doer.Validate(); err != nil {
+		switch t.remoteCookSelect(m.MakeOrder.MenuCode, m.MakeOrder.GetCream(), m.MakeOrder.GetSugar(), m.MakeOrder.Amount) {
+		case tele_api.CookReplay_cookInaccessible:
 			t.makeOrderImposible(tele_api.OrderStatus_executionInaccessible, m)
-			t.log.Infof("remote cook error: code not valid")
 			return
-		}
-		if m.MakeOrder.Amount < uint32(config_global.VMC.User.SelectedItem.Price) { // стоимость заказа не меньше баланса
+		case tele_api.CookReplay_cookOverdraft:
 			t.makeOrderImposible(tele_api.OrderStatus_overdraft, m)
-			t.log.Infof("remote cook error: money overdraft")
 			return
 		}
-		config_global.VMC.User.Sugar = tuneCook(m.MakeOrder.GetSugar(), config_global.VMC.Engine.Menu.DefaultSugar, config_global.VMC.Engine.Menu.DefaultSugarMax)
-		config_global.VMC.User.Cream = tuneCook(m.MakeOrder.GetCream(), config_global.VMC.Engine.Menu.DefaultCream, config_global.VMC.Engine.Menu.DefaultCreamMax)
 	default: // unknown status
 		t.log.Errorf("unknown order status(%v)", m.MakeOrder.OrderStatus)
 		return
@@ -156,6 +155,32 @@ func (t *tele) mesageMakeOrger(ctx context.Context, m *tele_api.ToRoboMessage) {
 	g.UI().CreateEvent(types.EventAccept)
 }
 
+// remoteCookSelect select menu item, check it and balance, set cream/sugar.
+// returns CookReplay_cookStart if cook possible
+func (t *tele) remoteCookSelect(code string, cream []byte, sugar []byte, balance uint32) tele_api.CookReplay {
+	var found bool
+	config_global.VMC.User.SelectedItem, found = config_global.GetMenuItem(code)
+	if !found {
+		t.log.Infof("remote cook error: code not found")
+		return tele_api.CookReplay_cookInaccessible
+	}
+	if config_global.VMC.User.SelectedItem.Doer == nil {
+		t.log.Infof("remote cook error: code doer is nil")
+		return tele_api.CookReplay_cookInaccessible
+	}
+	if err := config_global.VMC.User.SelectedItem.Doer.Validate(); err != nil {
+		t.log.Infof("remote cook error: code not valid")
+		return tele_api.CookReplay_cookInaccessible
+	}
+	if balance < uint32(config_global.VMC.User.SelectedItem.Price) { // стоимость заказа не меньше баланса
+		t.log.Infof("remote cook error: money overdraft")
+		return tele_api.CookReplay_cookOverdraft
+	}
+	config_global.VMC.User.Sugar = tuneCook(sugar, config_global.VMC.Engine.Menu.DefaultSugar, config_global.VMC.Engine.Menu.DefaultSugarMax)
+	config_global.VMC.User.Cream = tuneCook(cream, config_global.VMC.Engine.Menu.DefaultCream, config_global.VMC.Engine.Menu.DefaultCreamMax)
+	return tele_api.CookReplay_cookStart
+}
+
 func (t *tele) makeOrderImposible(oStatus tele_api.OrderStatus, m *tele_api.ToRoboMessage) {
 	rm := tele_api.FromRoboMessage{
 		State: t.currentState,
@@ -198,7 +223,9 @@ func (t *tele) messageShowQr(ctx context.Context, m *tele_api.ToRoboMessage) {
 			}
 			g.Log.Infof("show paymeng QR for order:%s", m.ShowQR.OrderId)
 			g.ShowQR(m.ShowQR.QrText)
-			l1 := fmt.Sprintf(g.Config.UI_config.Front.MsgRemotePay+g.Config.UI_config.Front.MsgPrice, currency.Amount(config_global.VMC.User.QRPayAmount).Format100I())
+			lang := config_global.VMC.User.Locale
+			front := g.Config.UI_config.LocaleFront(lang)
+			l1 := g.Config.UI_config.Sprintf(lang, front.MsgRemotePay+front.MsgPrice, currency.Amount(config_global.VMC.User.QRPayAmount).Format100I())
 			g.MustTextDisplay().SetLine(1, l1)
 			config_global.VMC.User.DirtyMoney = currency.Amount(config_global.VMC.User.QRPayAmount)
 			config_global.VMC.User.PaymentType = tele_api.OwnerType_qrCashLessUser
@@ -212,8 +239,8 @@ func (t *tele) messageShowQr(ctx context.Context, m *tele_api.ToRoboMessage) {
 			_ = g.Hardware.Display.Graphic.CopyFile2FB(g.Config.UI_config.Front.PicQRPayError)
 		}
 	case tele_api.ShowQR_errorOverdraft:
-		g.MustTextDisplay().SetLines(g.Config.UI_config.Front.MsgMenuInsufficientCreditL1,
-			g.Config.UI_config.Front.MsgRemotePayReject)
+		front := g.Config.UI_config.LocaleFront(config_global.VMC.User.Locale)
+		g.MustTextDisplay().SetLines(front.MsgMenuInsufficientCreditL1, front.MsgRemotePayReject)
 		if g.Hardware.Display.Graphic != nil {
 			_ = g.Hardware.Display.Graphic.CopyFile2FB(g.Config.UI_config.Front.PicPayReject)
 		}
@@ -233,8 +260,13 @@ func (t *tele) dispatchCommand(ctx context.Context, cmd *tele_api.Command) error
 		return t.cmdExec(ctx, cmd, task.Exec)
 
 	case *tele_api.Command_SetInventory:
-		// return t.cmdSetInventory(ctx, task.SetInventory)
-		return nil
+		return t.cmdSetInventory(ctx, cmd, task.SetInventory)
+
+	case *tele_api.Command_SetConfig:
+		return t.cmdSetConfig(ctx, cmd, task.SetConfig)
+
+	case *tele_api.Command_Stop:
+		return t.cmdStop(ctx, cmd)
 
 	case *tele_api.Command_ValidateCode:
 		if task.ValidateCode == nil {
@@ -244,7 +276,10 @@ func (t *tele) dispatchCommand(ctx context.Context, cmd *tele_api.Command) error
 		return nil
 
 	case *tele_api.Command_Cook:
-		// return t.cmdCook(ctx, cmd, task.Cook)
+		if task.Cook == nil || task.Cook.Menucode == "" {
+			return errInvalidArg
+		}
+		go t.cmdCook(ctx, cmd, task.Cook)
 		return nil
 
 	case *tele_api.Command_Show_QR:
@@ -299,6 +334,10 @@ func (t *tele) cmdExec(ctx context.Context, cmd *tele_api.Command, arg *tele_api
 	if arg.Scenario == "" {
 		return errInvalidArg
 	}
+	if err := t.auth.allowExec(cmd.Executer, arg.Scenario); err != nil {
+		t.commandError(cmd, err)
+		return err
+	}
 	if arg.Scenario[:1] == "_" { // If the command contains the "_" prefix, then you ignore the client lock flag
 		arg.Scenario = arg.Scenario[1:]
 	} else if config_global.VMC.User.Lock {
@@ -333,15 +372,170 @@ func (t *tele) cmdExec(ctx context.Context, cmd *tele_api.Command, arg *tele_api
 	return err
 }
 
-// func (t *tele) cmdSetInventory(ctx context.Context, arg *tele_api.Command_ArgSetInventory) error {
-// 	if arg == nil || arg.New == nil {
-// 		return errInvalidArg
-// 	}
+func (t *tele) cmdSetInventory(ctx context.Context, cmd *tele_api.Command, arg *tele_api.Command_ArgSetInventory) error {
+	if arg == nil || arg.New == nil {
+		return errInvalidArg
+	}
+	if err := t.auth.allowInventory(cmd.Executer); err != nil {
+		t.commandError(cmd, err)
+		return err
+	}
+
+	g := state.GetGlobal(ctx)
+	_, err := g.Inventory.SetTele(arg.New)
+	if err != nil {
+		err = errors.Annotate(err, "set inventory")
+		t.commandError(cmd, err)
+		return err
+	}
+	t.CommandReply(cmd, tele_api.CmdReplay_done)
+	return t.Report(ctx, false)
+}
+
+// new config fragment checked and stored, applied at next StateFrontBegin
+func (t *tele) cmdSetConfig(ctx context.Context, cmd *tele_api.Command, arg *tele_api.Command_ArgSetConfig) error {
+	if arg == nil || arg.Name == "" || len(arg.New) == 0 {
+		return errInvalidArg
+	}
+	if err := t.auth.allowConfig(cmd.Executer); err != nil {
+		t.commandError(cmd, err)
+		return err
+	}
+	t.log.Infof("income remote config from: (%v) name: (%s)", cmd.Executer, arg.Name)
+	g := state.GetGlobal(ctx)
+	if err := g.SetRemoteConfig(ctx, arg.Name, arg.New); err != nil {
+		err = errors.Annotate(err, "set config")
+		t.commandError(cmd, err)
+		return err
+	}
+	t.CommandReply(cmd, tele_api.CmdReplay_done)
+	return nil
+}
+
+// interrupt running scenarios (cook, exec)
+func (t *tele) cmdStop(ctx context.Context, cmd *tele_api.Command) error {
+	if err := t.auth.allowAbort(cmd.Executer); err != nil {
+		t.commandError(cmd, err)
+		return err
+	}
+	g := state.GetGlobal(ctx)
+	n := g.AbortJobs(fmt.Errorf("remote stop executer=%d", cmd.Executer))
+	t.log.Infof("remote stop from: (%v) aborted jobs=%d", cmd.Executer, n)
+	t.CommandReply(cmd, tele_api.CmdReplay_done)
+	return nil
+}
+
+// remote cook. same checks as makeOrder, payment already done on server side (balance only).
+// replies cookStart, then cookFinish or cookError when cooking ends
+func (t *tele) cmdCook(ctx context.Context, cmd *tele_api.Command, arg *tele_api.Command_ArgCook) {
+	if err := t.auth.allowCook(cmd.Executer); err != nil {
+		t.log.Errorf("remote cook code:%s (%v)", arg.Menucode, err)
+		t.commandError(cmd, err)
+		return
+	}
+	g := state.GetGlobal(ctx)
+	if g.XXX_uier.Load() == nil || g.XXX_money.Load() == nil || t.currentState != tele_api.State_Nominal || config_global.VMC.User.Lock {
+		t.log.Infof("remote cook code:%s robot busy state:%s", arg.Menucode, t.currentState)
+		t.CookReply(cmd, tele_api.CookReplay_vmcbusy)
+		return
+	}
+	if arg.PaymentMethod != tele_api.PaymentMethod_Balance {
+		t.log.Errorf("remote cook code:%s unposible. PaymentMethod %s <> %s", arg.Menucode, arg.PaymentMethod.String(), tele_api.PaymentMethod_Balance.String())
+		t.CookReply(cmd, tele_api.CookReplay_cookError)
+		return
+	}
+	g.UI().PauseStateMashine(true)
+	defer g.UI().PauseStateMashine(false)
+	balance := uint32(0)
+	if arg.Balance > 0 {
+		balance = uint32(arg.Balance)
+	}
+	if reply := t.remoteCookSelect(arg.Menucode, arg.Cream, arg.Sugar, balance); reply != tele_api.CookReplay_cookStart {
+		t.CookReply(cmd, reply)
+		return
+	}
+	done := make(chan error, 1)
+	config_global.VMC.User.RemoteOrderInProgress = true
+	config_global.VMC.User.RemoteCookDone = done
+	config_global.VMC.User.DirtyMoney = config_global.VMC.User.SelectedItem.Price
+	config_global.VMC.User.PaymenId = cmd.Executer
+	config_global.VMC.User.PaymentMethod = arg.PaymentMethod
+	money.GetGlobal(ctx).SetDirty(config_global.VMC.User.DirtyMoney)
+	t.CookReply(cmd, tele_api.CookReplay_cookStart, uint32(config_global.VMC.User.SelectedItem.Price))
+	g.UI().CreateEvent(types.EventAccept)
+	// ждем окончания приготовления. UI may never accept (event lost), so wait is limited.
+	// UI clears remote order flags itself before sending result
+	timeout := helpers.IntSecondConfigDefault(t.config.CookTimeoutSec, 300)
+	tmr := time.NewTimer(timeout)
+	defer tmr.Stop()
+	giveUp := func() {
+		config_global.VMC.User.RemoteCookDone = nil
+		config_global.VMC.User.RemoteOrderInProgress = false
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.log.Errorf("remote cook code:%s error:%v", arg.Menucode, err)
+			t.CookReply(cmd, tele_api.CookReplay_cookError)
+			return
+		}
+		t.CookReply(cmd, tele_api.CookReplay_cookFinish)
+	case <-tmr.C:
+		giveUp()
+		t.log.Errorf("remote cook code:%s no result after %v", arg.Menucode, timeout)
+		t.CookReply(cmd, tele_api.CookReplay_cookError)
+	case <-ctx.Done():
+		giveUp()
+		t.CookReply(cmd, tele_api.CookReplay_cookError)
+	}
+}
 
-// 	g := state.GetGlobal(ctx)
-// 	_, err := g.Inventory.SetTele(arg.New)
-// 	return err
-// }
+// ToRoboMessage.command - scenario text. unsigned, so refused when command_auth enabled
+func (t *tele) messageExecuteCommand(ctx context.Context, m *tele_api.ToRoboMessage) {
+	if m.Command == "" {
+		return
+	}
+	if t.auth != nil {
+		t.ErrorStr(fmt.Sprintf("execute command refused, signed Command required (%s)", m.Command))
+		return
+	}
+	scenario := m.Command
+	if scenario[:1] == "_" { // ignore client lock
+		scenario = scenario[1:]
+	} else if config_global.VMC.User.Lock {
+		t.ErrorStr(fmt.Sprintf("execute command (%s) robot busy", m.Command))
+		return
+	}
+	t.log.Infof("execute remote command scenario: (%s)", scenario)
+	g := state.GetGlobal(ctx)
+	doer, err := g.Engine.ParseText("tele-exec", scenario)
+	if err == nil {
+		err = doer.Validate()
+	}
+	if err == nil {
+		err = g.ScheduleSync(ctx, doer.Do)
+	}
+	if err != nil {
+		t.Error(errors.Annotatef(err, "execute command (%s)", scenario))
+	}
+}
+
+// margin report from ledger, command = margin query, see margin.Usage
+func (t *tele) messageReportMargin(ctx context.Context, m *tele_api.ToRoboMessage) {
+	rm := &tele_api.FromRoboMessage{State: t.currentState}
+	q, err := margin.ParseQuery(strings.Fields(m.Command))
+	var periods []margin.Period
+	if err == nil {
+		periods, err = state.GetGlobal(ctx).Margin(q)
+	}
+	if err != nil {
+		rm.Err = &tele_api.Err{Message: fmt.Sprintf("report margin (%s) error(%v)", m.Command, err)}
+	}
+	for _, p := range periods {
+		rm.Margin = append(rm.Margin, p.Tele())
+	}
+	t.RoboSend(rm)
+}
 
 func (t *tele) cmdShowQR(ctx context.Context, arg *tele_api.Command_ArgShowQR) error {
 	if arg == nil {
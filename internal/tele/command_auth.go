@@ -0,0 +1,257 @@
+package tele
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexTransit/vender/internal/engine"
+	tele_api "github.com/AlexTransit/vender/tele"
+	tele_config "github.com/AlexTransit/vender/tele/config"
+	"github.com/juju/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// remote command authentication.
+// signature (HMAC-SHA256 or Ed25519) over commandSignData: "executer:timestamp:hex(nonce):" + task,
+// checked with key of claimed executer.
+// task is deterministic protobuf of Command with only lock and task set.
+// command accepted once: timestamp within max_age, nonce not seen before.
+
+const defaultCommandMaxAge = 5 * time.Minute
+
+var (
+	errCommandUnsigned  = errors.Unauthorizedf("command signature required")
+	errCommandExecuter  = errors.Unauthorizedf("command executer unknown")
+	errCommandSignature = errors.Unauthorizedf("command signature invalid")
+	errCommandReplay    = errors.Unauthorizedf("command replay")
+	errCommandExpired   = errors.Unauthorizedf("command timestamp out of window")
+)
+
+type commandAuth struct {
+	mu     sync.Mutex
+	maxAge time.Duration
+	acl    map[int64]executerAuth
+	nonces map[string]time.Time // nonce:expire
+	now    func() time.Time
+}
+
+type executerAuth struct {
+	tele_config.ExecuterStruct
+	hmacKey []byte
+	pubKey  ed25519.PublicKey
+}
+
+// newCommandAuth nil when auth disabled
+func newCommandAuth(c tele_config.CommandAuthStruct) (*commandAuth, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	a := &commandAuth{
+		maxAge: time.Duration(c.MaxAgeSec) * time.Second,
+		acl:    make(map[int64]executerAuth, len(c.Executer)),
+		nonces: make(map[string]time.Time),
+		now:    time.Now,
+	}
+	if a.maxAge <= 0 {
+		a.maxAge = defaultCommandMaxAge
+	}
+	if len(c.Executer) == 0 {
+		return nil, errors.NotValidf("command_auth enabled without executer")
+	}
+	for _, e := range c.Executer {
+		id, err := strconv.ParseInt(e.Id, 10, 64)
+		if err != nil {
+			return nil, errors.NotValidf("command_auth executer=%s", e.Id)
+		}
+		ea := executerAuth{ExecuterStruct: e}
+		if e.HmacKey != "" {
+			ea.hmacKey = []byte(e.HmacKey)
+		}
+		if e.Ed25519PublicKey != "" {
+			key, err := base64.StdEncoding.DecodeString(e.Ed25519PublicKey)
+			if err != nil || len(key) != ed25519.PublicKeySize {
+				return nil, errors.NotValidf("command_auth executer=%s ed25519_public_key", e.Id)
+			}
+			ea.pubKey = key
+		}
+		if ea.hmacKey == nil && ea.pubKey == nil {
+			return nil, errors.NotValidf("command_auth executer=%s without hmac_key or ed25519_public_key", e.Id)
+		}
+		a.acl[id] = ea
+	}
+	return a, nil
+}
+
+func commandSignData(cmd *tele_api.Command) ([]byte, error) {
+	task, err := proto.MarshalOptions{Deterministic: true}.Marshal(&tele_api.Command{Lock: cmd.Lock, Task: cmd.Task})
+	if err != nil {
+		return nil, err
+	}
+	b := []byte(fmt.Sprintf("%d:%d:%x:", cmd.Executer, cmd.Timestamp, cmd.Nonce))
+	return append(b, task...), nil
+}
+
+// SignCommandHmac set timestamp, nonce and HMAC signature. server side and tests
+func SignCommandHmac(cmd *tele_api.Command, key []byte, nonce []byte, now time.Time) error {
+	cmd.Timestamp = now.Unix()
+	cmd.Nonce = nonce
+	data, err := commandSignData(cmd)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	cmd.Signature = mac.Sum(nil)
+	return nil
+}
+
+// SignCommandEd25519 set timestamp, nonce and Ed25519 signature. server side and tests
+func SignCommandEd25519(cmd *tele_api.Command, key ed25519.PrivateKey, nonce []byte, now time.Time) error {
+	cmd.Timestamp = now.Unix()
+	cmd.Nonce = nonce
+	data, err := commandSignData(cmd)
+	if err != nil {
+		return err
+	}
+	cmd.Signature = ed25519.Sign(key, data)
+	return nil
+}
+
+// verify signature with executer key and reject replays. nil auth accepts everything
+func (a *commandAuth) verify(cmd *tele_api.Command) error {
+	if a == nil {
+		return nil
+	}
+	if len(cmd.Signature) == 0 || len(cmd.Nonce) == 0 {
+		return errCommandUnsigned
+	}
+	e, ok := a.acl[cmd.Executer]
+	if !ok {
+		return errCommandExecuter
+	}
+	data, err := commandSignData(cmd)
+	if err != nil {
+		return err
+	}
+	valid := false
+	if e.hmacKey != nil {
+		mac := hmac.New(sha256.New, e.hmacKey)
+		mac.Write(data)
+		valid = hmac.Equal(mac.Sum(nil), cmd.Signature)
+	}
+	if !valid && e.pubKey != nil {
+		valid = len(cmd.Signature) == ed25519.SignatureSize && ed25519.Verify(e.pubKey, data, cmd.Signature)
+	}
+	if !valid {
+		return errCommandSignature
+	}
+
+	now := a.now()
+	ts := time.Unix(cmd.Timestamp, 0)
+	if ts.Before(now.Add(-a.maxAge)) || ts.After(now.Add(a.maxAge)) {
+		return errCommandExpired
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for n, expire := range a.nonces {
+		if now.After(expire) {
+			delete(a.nonces, n)
+		}
+	}
+	nonce := string(cmd.Nonce)
+	if _, seen := a.nonces[nonce]; seen {
+		return errCommandReplay
+	}
+	// timestamp window protects after nonce expired
+	a.nonces[nonce] = ts.Add(a.maxAge)
+	return nil
+}
+
+// allowExec check executer permissions for scenario (with optional "_" lock bypass prefix)
+func (a *commandAuth) allowExec(executer int64, scenario string) error {
+	if a == nil {
+		return nil
+	}
+	e, ok := a.acl[executer]
+	if !ok || !e.Exec {
+		return errors.Forbiddenf("executer=%d exec", executer)
+	}
+	if strings.HasPrefix(scenario, "_") {
+		if !e.BypassLock {
+			return errors.Forbiddenf("executer=%d bypass lock", executer)
+		}
+		scenario = scenario[1:]
+	}
+	if len(e.ScenarioPrefix) == 0 {
+		return nil
+	}
+	actions := engine.ScenarioActions(scenario)
+	if len(actions) == 0 {
+		return errors.Forbiddenf("executer=%d scenario=%s", executer, scenario)
+	}
+	// every action, also inside blocks: "cup.x money.set_gift_credit(1000)" is not "cup."
+	for _, action := range actions {
+		allowed := false
+		for _, prefix := range e.ScenarioPrefix {
+			if strings.HasPrefix(action, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.Forbiddenf("executer=%d scenario=%s action=%s", executer, scenario, action)
+		}
+	}
+	return nil
+}
+
+// allowAbort check executer may interrupt running scenario, including customer order
+func (a *commandAuth) allowAbort(executer int64) error {
+	if a == nil {
+		return nil
+	}
+	if e, ok := a.acl[executer]; !ok || !e.Exec || !e.BypassLock {
+		return errors.Forbiddenf("executer=%d stop", executer)
+	}
+	return nil
+}
+
+// allowConfig check executer may replace remote config
+func (a *commandAuth) allowConfig(executer int64) error {
+	if a == nil {
+		return nil
+	}
+	if e, ok := a.acl[executer]; !ok || !e.Config {
+		return errors.Forbiddenf("executer=%d config", executer)
+	}
+	return nil
+}
+
+// allowInventory check executer may set stock levels
+func (a *commandAuth) allowInventory(executer int64) error {
+	if a == nil {
+		return nil
+	}
+	if e, ok := a.acl[executer]; !ok || !e.Inventory {
+		return errors.Forbiddenf("executer=%d inventory", executer)
+	}
+	return nil
+}
+
+// allowCook check executer may start remote cook
+func (a *commandAuth) allowCook(executer int64) error {
+	if a == nil {
+		return nil
+	}
+	if e, ok := a.acl[executer]; !ok || !e.Cook {
+		return errors.Forbiddenf("executer=%d cook", executer)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package tele
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/log2"
+	tele_api "github.com/AlexTransit/vender/tele"
+	tele_config "github.com/AlexTransit/vender/tele/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+type transportCapture struct {
+	responses []*tele_api.Response
+}
+
+func (tc *transportCapture) Init(context.Context, *log2.Log, tele_config.Config, CommandCallback, CommandCallback) error {
+	return nil
+}
+func (tc *transportCapture) SendState([]byte) bool     { return true }
+func (tc *transportCapture) SendTelemetry([]byte) bool { return true }
+func (tc *transportCapture) SendCommandResponse(_ string, payload []byte) bool {
+	r := new(tele_api.Response)
+	if err := proto.Unmarshal(payload, r); err == nil {
+		tc.responses = append(tc.responses, r)
+	}
+	return true
+}
+func (tc *transportCapture) SendFromRobot([]byte) {}
+func (tc *transportCapture) CloseTele()           {}
+func (tc *transportCapture) RoboConnected() bool  { return true }
+
+func TestCommandAuthVerify(t *testing.T) {
+	t.Parallel()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	a, err := newCommandAuth(tele_config.CommandAuthStruct{
+		Enabled: true,
+		Executer: []tele_config.ExecuterStruct{
+			{Id: "1", HmacKey: "secret", Ed25519PublicKey: base64.StdEncoding.EncodeToString(pub)},
+			{Id: "2", HmacKey: "other", Exec: true, BypassLock: true},
+		},
+	})
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	a.now = func() time.Time { return now }
+
+	newCmd := func() *tele_api.Command {
+		return &tele_api.Command{Executer: 1, Task: &tele_api.Command_Exec{Exec: &tele_api.Command_ArgExec{Scenario: "mdb.bus_reset"}}}
+	}
+
+	cmd := newCmd()
+	assert.Equal(t, errCommandUnsigned, a.verify(cmd))
+
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n1"), now))
+	assert.NoError(t, a.verify(cmd))
+	assert.Equal(t, errCommandReplay, a.verify(cmd))
+
+	cmd = newCmd()
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n2"), now))
+	cmd.GetExec().Scenario = "money.abort" // tampered task
+	assert.Equal(t, errCommandSignature, a.verify(cmd))
+
+	cmd = newCmd()
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n2l"), now))
+	cmd.Lock = !cmd.Lock // replay with other lock
+	assert.Equal(t, errCommandSignature, a.verify(cmd))
+
+	cmd = newCmd()
+	require.NoError(t, SignCommandHmac(cmd, []byte("wrong"), []byte("n3"), now))
+	assert.Equal(t, errCommandSignature, a.verify(cmd))
+
+	cmd = newCmd()
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n4"), now.Add(-time.Hour)))
+	assert.Equal(t, errCommandExpired, a.verify(cmd))
+
+	cmd = newCmd()
+	require.NoError(t, SignCommandEd25519(cmd, priv, []byte("n5"), now))
+	assert.NoError(t, a.verify(cmd))
+
+	// key of executer 1 can not sign as executer 2
+	cmd = newCmd()
+	cmd.Executer = 2
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n6"), now))
+	assert.Equal(t, errCommandSignature, a.verify(cmd))
+	cmd = newCmd()
+	cmd.Executer = 2
+	require.NoError(t, SignCommandEd25519(cmd, priv, []byte("n7"), now))
+	assert.Equal(t, errCommandSignature, a.verify(cmd))
+	cmd = newCmd()
+	cmd.Executer = 2
+	require.NoError(t, SignCommandHmac(cmd, []byte("other"), []byte("n8"), now))
+	assert.NoError(t, a.verify(cmd))
+	cmd = newCmd()
+	cmd.Executer = 9
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n9"), now))
+	assert.Equal(t, errCommandExecuter, a.verify(cmd))
+
+	var nilAuth *commandAuth
+	assert.NoError(t, nilAuth.verify(newCmd()))
+	assert.NoError(t, nilAuth.allowExec(0, "_anything"))
+
+	_, err = newCommandAuth(tele_config.CommandAuthStruct{Enabled: true})
+	assert.Error(t, err)
+	_, err = newCommandAuth(tele_config.CommandAuthStruct{Enabled: true, Executer: []tele_config.ExecuterStruct{{Id: "1", Exec: true}}})
+	assert.Error(t, err, "executer without key")
+}
+
+func TestCommandAuthACL(t *testing.T) {
+	t.Parallel()
+
+	a, err := newCommandAuth(tele_config.CommandAuthStruct{
+		Enabled: true,
+		Executer: []tele_config.ExecuterStruct{
+			{Id: "1", HmacKey: "k1", Exec: true, BypassLock: true, Config: true, Inventory: true, Cook: true},
+			{Id: "2", HmacKey: "k2", Exec: true, ScenarioPrefix: []string{"cup.", "sound."}},
+			{Id: "3", HmacKey: "k3", Cook: true},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, a.allowExec(1, "_mdb.bus_reset"))
+	assert.NoError(t, a.allowExec(2, "cup.dispense"))
+	assert.Error(t, a.allowExec(2, "mdb.bus_reset"))
+	assert.Error(t, a.allowExec(2, "_cup.dispense"))
+	assert.Error(t, a.allowExec(3, "cup.dispense"))
+	assert.Error(t, a.allowExec(4, "cup.dispense"))
+	assert.NoError(t, a.allowExec(2, "cup.dispense sound.beep [ cup.a | sound.b ]"))
+	assert.Error(t, a.allowExec(2, "cup.dispense money.set_gift_credit(1000)"), "extra action after allowed")
+	assert.Error(t, a.allowExec(2, "cup.x [ sound.y | money.abort ]"))
+	assert.Error(t, a.allowExec(2, "if(cup.ok){ cup.x }else{ money.abort }"))
+	assert.Error(t, a.allowExec(2, "retry(3, 1s){ cup.x money.abort }"))
+	assert.Error(t, a.allowExec(2, "timeout(5s){ money.abort }"))
+	assert.NoError(t, a.allowExec(2, "timeout(5s){ retry(2, 1s){ cup.x } }"))
+	assert.Error(t, a.allowExec(2, " "))
+	assert.NoError(t, a.allowConfig(1))
+	assert.Error(t, a.allowConfig(2))
+	assert.NoError(t, a.allowAbort(1))
+	assert.Error(t, a.allowAbort(2)) // stop needs bypass lock
+	assert.Error(t, a.allowAbort(4))
+	assert.NoError(t, a.allowInventory(1))
+	assert.Error(t, a.allowInventory(2), "exec does not allow inventory")
+	assert.Error(t, a.allowInventory(4))
+	assert.NoError(t, a.allowCook(1))
+	assert.Error(t, a.allowCook(2), "exec does not allow cook")
+	assert.NoError(t, a.allowCook(3))
+	assert.Error(t, a.allowCook(4))
+}
+
+func TestCommandDenied(t *testing.T) {
+	t.Parallel()
+
+	tc := &transportCapture{}
+	tl := NewWithTransporter(tc).(*tele)
+	config := tele_config.Config{
+		Enabled: true,
+		CommandAuth: tele_config.CommandAuthStruct{Enabled: true, Executer: []tele_config.ExecuterStruct{
+			{Id: "5", HmacKey: "secret"},
+		}},
+	}
+	require.NoError(t, tl.Init(context.Background(), log2.NewTest(t, log2.LOG_DEBUG), config, "test"))
+	tl.currentState = tele_api.State_Nominal
+
+	cmd := &tele_api.Command{Executer: 5, Task: &tele_api.Command_Exec{Exec: &tele_api.Command_ArgExec{Scenario: "_mdb.bus_reset"}}}
+	payload, err := proto.Marshal(cmd)
+	require.NoError(t, err)
+	tl.onCommandMessage(context.Background(), payload)
+
+	// signed, but executer not allowed to exec
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n1"), time.Now()))
+	payload, err = proto.Marshal(cmd)
+	require.NoError(t, err)
+	tl.onCommandMessage(context.Background(), payload)
+
+	// signed, but executer not allowed to set stock
+	cmd = &tele_api.Command{Executer: 5, Task: &tele_api.Command_SetInventory{SetInventory: &tele_api.Command_ArgSetInventory{New: &tele_api.Inventory{}}}}
+	require.NoError(t, SignCommandHmac(cmd, []byte("secret"), []byte("n2"), time.Now()))
+	payload, err = proto.Marshal(cmd)
+	require.NoError(t, err)
+	tl.onCommandMessage(context.Background(), payload)
+
+	require.Equal(t, 3, len(tc.responses))
+	for _, r := range tc.responses {
+		assert.Equal(t, int64(5), r.Executer)
+		assert.Equal(t, tele_api.CmdReplay_error, r.CmdReplay)
+		assert.NotEmpty(t, r.Error)
+	}
+	assert.Contains(t, tc.responses[0].Error, "signature required")
+	assert.Contains(t, tc.responses[1].Error, "executer=5")
+	assert.Contains(t, tc.responses[2].Error, "executer=5 inventory")
+}
@@ -0,0 +1,322 @@
+package tele
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexTransit/vender/log2"
+)
+
+// durable store-and-forward queue for messages that must be delivered at least once.
+// every message gets sequence number and is appended (O_SYNC) to journal file before send.
+// messages are delivered one by one in sequence order, next message is sent only after ack of previous.
+// journal records:
+//   "m seq kind base64(payload)" - message
+//   "a seq"                      - all messages up to seq acked
+// journal is rewritten with pending messages only at open and when acked records grow over limit.
+
+const (
+	outboxFileName      = "outbox.journal"
+	outboxMaxPending    = 10000
+	outboxRetryInterval = 30 * time.Second
+	outboxCloseTimeout  = 5 * time.Second
+	// journal rewritten when acked records take more than that
+	outboxCompactRecords = 1000
+	outboxCompactBytes   = 1 << 20
+)
+
+type outboxKind byte
+
+const (
+	outboxRobot     outboxKind = 'r' // FromRoboMessage
+	outboxTelemetry outboxKind = 't' // Telemetry
+	outboxState     outboxKind = 's' // State
+)
+
+type outboxItem struct {
+	seq     uint64
+	kind    outboxKind
+	payload []byte
+	size    int // journal record length
+}
+
+type outbox struct {
+	mu      sync.Mutex
+	log     *log2.Log
+	path    string
+	f       *os.File
+	lastSeq uint64 // last enqueued
+	acked   uint64 // last acked
+	pending []outboxItem
+	// acked records and bytes in journal since last compact
+	deadRecords int
+	deadBytes   int
+	wake        chan struct{}
+	// publish blocks until message acked by receiver or error
+	publish func(outboxItem) error
+}
+
+func outboxPath(storePath string) string {
+	return filepath.Join(storePath, outboxFileName)
+}
+
+// openOutbox read undelivered messages and rewrite compact journal
+func openOutbox(log *log2.Log, path string, publish func(outboxItem) error) (*outbox, error) {
+	o := &outbox{
+		log:     log,
+		path:    path,
+		wake:    make(chan struct{}, 1),
+		publish: publish,
+	}
+	if f, err := os.Open(path); err == nil {
+		err = o.replay(f)
+		f.Close()
+		if err != nil {
+			log.Errorf("tele outbox %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := o.compact(); err != nil {
+		return nil, err
+	}
+	if len(o.pending) != 0 {
+		log.Infof("tele outbox restored %d undelivered messages", len(o.pending))
+	}
+	return o, nil
+}
+
+func (o *outbox) replay(r io.Reader) error {
+	var err error
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		text, e := br.ReadString('\n')
+		if e == io.EOF {
+			if text != "" {
+				err = fmt.Errorf("journal line:%d torn record", line)
+			}
+			break
+		} else if e != nil {
+			return e
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			continue
+		}
+		seq, e := strconv.ParseUint(fields[1], 10, 64)
+		if e != nil {
+			err = fmt.Errorf("journal line:%d invalid record", line)
+			continue
+		}
+		switch {
+		case fields[0] == "a" && len(fields) == 2:
+			if seq > o.acked {
+				o.acked = seq
+			}
+		case fields[0] == "m" && len(fields) == 4 && len(fields[2]) == 1:
+			payload, e := base64.StdEncoding.DecodeString(fields[3])
+			if e != nil {
+				err = fmt.Errorf("journal line:%d invalid payload", line)
+				continue
+			}
+			o.pending = append(o.pending, outboxItem{seq: seq, kind: outboxKind(fields[2][0]), payload: payload, size: len(text)})
+		default:
+			err = fmt.Errorf("journal line:%d invalid record", line)
+			continue
+		}
+		if seq > o.lastSeq {
+			o.lastSeq = seq
+		}
+	}
+	o.dropAckedLocked()
+	return err
+}
+
+// remove acked messages from queue head
+func (o *outbox) dropAckedLocked() {
+	i := 0
+	for i < len(o.pending) && o.pending[i].seq <= o.acked {
+		i++
+	}
+	o.pending = o.pending[i:]
+}
+
+// rewrite journal with pending messages only
+func (o *outbox) compact() error {
+	tmp := o.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "a %d\n", o.acked)
+	for i := range o.pending {
+		o.pending[i].size = writeOutboxItem(w, o.pending[i])
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, o.path); err != nil {
+		return err
+	}
+	if o.f != nil {
+		o.f.Close()
+	}
+	o.deadRecords, o.deadBytes = 0, 0
+	o.f, err = os.OpenFile(o.path, os.O_WRONLY|os.O_APPEND|os.O_SYNC, 0o644)
+	return err
+}
+
+func writeOutboxItem(w io.Writer, item outboxItem) int {
+	n, _ := fmt.Fprintf(w, "m %d %c %s\n", item.seq, item.kind, base64.StdEncoding.EncodeToString(item.payload))
+	return n
+}
+
+// enqueue store message and wake up delivery. returns sequence number
+func (o *outbox) enqueue(kind outboxKind, payload []byte) uint64 {
+	o.mu.Lock()
+	o.lastSeq++
+	item := outboxItem{seq: o.lastSeq, kind: kind, payload: append([]byte(nil), payload...)}
+	if len(o.pending) >= outboxMaxPending {
+		o.log.Errorf("tele outbox full, drop message seq=%d", o.pending[0].seq)
+		o.ackHeadLocked()
+	}
+	item.size = o.writeLocked(func(w io.Writer) { writeOutboxItem(w, item) })
+	o.pending = append(o.pending, item)
+	o.mu.Unlock()
+	o.kick()
+	return item.seq
+}
+
+// ack mark message delivered. duplicate or out of order acks are ignored.
+func (o *outbox) ack(seq uint64) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if seq <= o.acked || len(o.pending) == 0 || o.pending[0].seq != seq {
+		o.log.Debugf("tele outbox ignore ack seq=%d acked=%d", seq, o.acked)
+		return false
+	}
+	o.ackHeadLocked()
+	return true
+}
+
+// drop queue head, append ack record and compact journal when it has too much garbage
+func (o *outbox) ackHeadLocked() {
+	o.acked = o.pending[0].seq
+	o.deadBytes += o.pending[0].size
+	o.pending = o.pending[1:]
+	o.deadBytes += o.writeLocked(func(w io.Writer) { fmt.Fprintf(w, "a %d\n", o.acked) })
+	o.deadRecords += 2
+	if o.deadRecords < outboxCompactRecords && o.deadBytes < outboxCompactBytes {
+		return
+	}
+	if err := o.compact(); err != nil {
+		o.log.Errorf("tele outbox compact err=%v", err)
+	}
+}
+
+// append record to journal. one write syscall per record. returns record length
+func (o *outbox) writeLocked(fun func(io.Writer)) int {
+	var b strings.Builder
+	fun(&b)
+	if o.f == nil {
+		return b.Len()
+	}
+	if _, err := o.f.WriteString(b.String()); err != nil {
+		o.log.Errorf("tele outbox write err=%v", err)
+	}
+	return b.Len()
+}
+
+func (o *outbox) head() (outboxItem, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.pending) == 0 {
+		return outboxItem{}, false
+	}
+	return o.pending[0], true
+}
+
+func (o *outbox) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// kick wake up delivery (new message or connection established)
+func (o *outbox) kick() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run deliver messages in order until done closed
+func (o *outbox) run(done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-o.wake:
+		case <-time.After(outboxRetryInterval):
+		}
+		o.deliver(done)
+	}
+}
+
+// deliver send pending messages while receiver acks them
+func (o *outbox) deliver(done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		item, ok := o.head()
+		if !ok {
+			return
+		}
+		if err := o.publish(item); err != nil {
+			o.log.Debugf("tele outbox seq=%d not delivered err=%v", item.seq, err)
+			return
+		}
+		o.ack(item.seq)
+	}
+}
+
+// flush wait until all messages delivered or timeout
+func (o *outbox) flush(timeout time.Duration) bool {
+	o.kick()
+	deadline := time.Now().Add(timeout)
+	for o.len() != 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
+
+func (o *outbox) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.f != nil {
+		o.f.Close()
+		o.f = nil
+	}
+}
@@ -0,0 +1,147 @@
+package tele
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/log2"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outboxReceiver struct {
+	mu        sync.Mutex
+	connected bool
+	got       []string
+}
+
+func (r *outboxReceiver) publish(item outboxItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.connected {
+		return errors.New("not connected")
+	}
+	r.got = append(r.got, string(item.kind)+string(item.payload))
+	return nil
+}
+
+func (r *outboxReceiver) set(connected bool) {
+	r.mu.Lock()
+	r.connected = connected
+	r.mu.Unlock()
+}
+
+func (r *outboxReceiver) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.got...)
+}
+
+func TestOutboxDeliverInOrder(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	path := outboxPath(t.TempDir())
+	recv := &outboxReceiver{}
+	o, err := openOutbox(log, path, recv.publish)
+	require.NoError(t, err)
+	done := make(chan struct{})
+	defer close(done)
+	go o.run(done)
+
+	// offline, messages queued
+	assert.Equal(t, uint64(1), o.enqueue(outboxRobot, []byte("order")))
+	assert.Equal(t, uint64(2), o.enqueue(outboxTelemetry, []byte("tm")))
+	assert.Equal(t, uint64(3), o.enqueue(outboxRobot, []byte("state")))
+	assert.Equal(t, uint64(4), o.enqueue(outboxState, []byte{byte(2)}))
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, recv.messages())
+	assert.Equal(t, 4, o.len())
+
+	// connect handler
+	recv.set(true)
+	assert.True(t, o.flush(time.Second))
+	assert.Equal(t, []string{"rorder", "ttm", "rstate", "s\x02"}, recv.messages())
+
+	// duplicate ack ignored
+	assert.False(t, o.ack(2))
+	o.close()
+}
+
+func TestOutboxRestore(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	path := outboxPath(t.TempDir())
+	recv := &outboxReceiver{}
+	o, err := openOutbox(log, path, recv.publish)
+	require.NoError(t, err)
+	o.enqueue(outboxRobot, []byte("a"))
+	o.enqueue(outboxRobot, []byte("b"))
+	o.enqueue(outboxTelemetry, []byte("c"))
+	o.enqueue(outboxState, []byte("s"))
+	assert.False(t, o.ack(2)) // out of order
+	assert.True(t, o.ack(1))
+	assert.False(t, o.ack(1)) // duplicate
+	// power loss, torn tail
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, _ = f.WriteString("m 5 r Zm9")
+	f.Close()
+
+	o2, err := openOutbox(log, path, recv.publish)
+	require.NoError(t, err)
+	require.Equal(t, 3, o2.len())
+	item, _ := o2.head()
+	assert.Equal(t, uint64(2), item.seq)
+	assert.Equal(t, []byte("b"), item.payload)
+	assert.Equal(t, uint64(5), o2.enqueue(outboxRobot, []byte("d")))
+
+	recv.set(true)
+	o2.deliver(nil)
+	assert.Equal(t, []string{"rb", "tc", "ss", "rd"}, recv.messages())
+	o2.close()
+
+	// all delivered, journal compacted
+	o3, err := openOutbox(log, path, recv.publish)
+	require.NoError(t, err)
+	assert.Equal(t, 0, o3.len())
+	assert.Equal(t, uint64(6), o3.enqueue(outboxRobot, []byte("e")))
+	o3.close()
+	_, err = os.Stat(filepath.Join(filepath.Dir(path), outboxFileName+".tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// journal is not rewritten on every drain, only when acked records grow over limit
+func TestOutboxCompactLimit(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	path := outboxPath(t.TempDir())
+	recv := &outboxReceiver{connected: true}
+	o, err := openOutbox(log, path, recv.publish)
+	require.NoError(t, err)
+	o.enqueue(outboxRobot, []byte("a"))
+	o.enqueue(outboxRobot, []byte("b"))
+	o.deliver(nil)
+	assert.Equal(t, 0, o.len())
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a 0\nm 1 r YQ==\nm 2 r Yg==\na 1\na 2\n", string(b))
+	assert.Equal(t, 4, o.deadRecords)
+	assert.Equal(t, len(b)-len("a 0\n"), o.deadBytes)
+
+	o.deadRecords = outboxCompactRecords - 1
+	o.enqueue(outboxRobot, []byte("c"))
+	o.enqueue(outboxRobot, []byte("d"))
+	assert.True(t, o.ack(3))
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a 3\nm 4 r ZA==\n", string(b))
+	assert.Equal(t, 0, o.deadRecords)
+	o.close()
+}
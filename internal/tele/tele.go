@@ -20,7 +20,8 @@ const (
 //   - Transaction/Error/Service/etc public API calls block at most for disk write
 //     network may be slow or absent, messages will be delivered in background
 //   - Close() will block until all messages are delivered
-//   - Telemetry/Response messages delivered at least once
+//   - Telemetry, State and FromRobot (order, error, state) messages delivered at least once and in order,
+//     they are stored in durable outbox until broker ack, survive restart
 //   - Status messages may be lost
 type tele struct { //nolint:maligned
 	config       tele_config.Config
@@ -29,6 +30,7 @@ type tele struct { //nolint:maligned
 	vmId         int32
 	stat         tele_api.Stat
 	currentState tele_api.State
+	auth         *commandAuth
 }
 
 func New() tele_api.Teler {
@@ -48,9 +50,21 @@ func (t *tele) Init(ctx context.Context, log *log2.Log, teleConfig tele_config.C
 
 	t.vmId = int32(t.config.VmId)
 	t.stat.Locked_Reset()
+	auth, err := newCommandAuth(t.config.CommandAuth)
+	if err != nil {
+		return errors.Annotate(err, "tele")
+	}
+	t.auth = auth
 
 	if t.transport == nil { // production path
-		t.transport = &transportMqtt{}
+		switch t.config.Transport {
+		case "", "mqtt":
+			t.transport = &transportMqtt{}
+		case "http":
+			t.transport = &transportHttp{}
+		default:
+			return errors.NotValidf("tele transport=%s (mqtt|http)", t.config.Transport)
+		}
 	}
 	if err := t.transport.Init(ctx, log, teleConfig, t.onCommandMessage, t.messageForRobot); err != nil {
 		return errors.Annotate(err, "tele transport")
@@ -61,7 +75,8 @@ func (t *tele) Init(ctx context.Context, log *log2.Log, teleConfig tele_config.C
 	rm := tele_api.FromRoboMessage{
 		State: tele_api.State_Boot,
 		RoboHardware: &tele_api.RoboHardware{
-			SwVersion: swVersion,
+			SwVersion:  swVersion,
+			CertExpire: t.certExpire(),
 		},
 	}
 	t.marshalAndSendMessage(&rm)
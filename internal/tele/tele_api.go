@@ -24,6 +24,19 @@ func (t *tele) CommandReply(c *tele_api.Command, cr tele_api.CmdReplay) {
 	t.CommandResponse(&r)
 }
 
+// command rejected or failed, reason in Response.error
+func (t *tele) commandError(c *tele_api.Command, err error) {
+	if te := t.teleEnable(); te {
+		return
+	}
+	r := tele_api.Response{
+		Executer:  c.Executer,
+		CmdReplay: tele_api.CmdReplay_error,
+		Error:     err.Error(),
+	}
+	t.CommandResponse(&r)
+}
+
 func (t *tele) CookReply(c *tele_api.Command, cr tele_api.CookReplay, price ...uint32) {
 	if te := t.teleEnable(); te {
 		return
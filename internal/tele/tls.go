@@ -0,0 +1,80 @@
+package tele
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"os"
+	"time"
+
+	tele_config "github.com/AlexTransit/vender/tele/config"
+	"github.com/juju/errors"
+)
+
+const certExpireWarning = 30 * 24 * time.Hour
+
+// tlsConfig from tele config. nil without tls options.
+// certExpire is client certificate NotAfter, zero without client certificate.
+func tlsConfig(c tele_config.Config) (conf *tls.Config, certExpire time.Time, err error) {
+	if c.TlsCaFile == "" && c.TlsCertFile == "" && c.TlsKeyFile == "" && c.TlsServerName == "" {
+		return nil, time.Time{}, nil
+	}
+	conf = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.TlsServerName,
+	}
+	if c.TlsCaFile != "" {
+		pem, err := os.ReadFile(c.TlsCaFile)
+		if err != nil {
+			return nil, certExpire, errors.Annotate(err, "tls_ca_file")
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, certExpire, errors.NotValidf("tls_ca_file=%s no certificates", c.TlsCaFile)
+		}
+	}
+	if (c.TlsCertFile == "") != (c.TlsKeyFile == "") {
+		return nil, certExpire, errors.NotValidf("tls_cert_file and tls_key_file must be set together")
+	}
+	if c.TlsCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TlsCertFile, c.TlsKeyFile)
+		if err != nil {
+			return nil, certExpire, errors.Annotate(err, "tls client certificate")
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, certExpire, errors.Annotate(err, "tls client certificate")
+		}
+		certExpire = leaf.NotAfter
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, certExpire, nil
+}
+
+// tls options allowed only with tls broker
+func checkBrokerTLS(c tele_config.Config, conf *tls.Config) error {
+	u, err := url.Parse(c.MqttBroker)
+	if err != nil {
+		return errors.NotValidf("mqtt_broker=%s", c.MqttBroker)
+	}
+	switch u.Scheme {
+	case "ssl", "tls", "mqtts", "mqtt+ssl", "tcps", "wss":
+		return nil
+	}
+	if conf != nil {
+		return errors.NotValidf("tls options with mqtt_broker=%s, use mqtts:// or wss://", c.MqttBroker)
+	}
+	return nil
+}
+
+// unix time of client certificate expiry for RoboHardware. 0 = no client certificate
+func (t *tele) certExpire() int64 {
+	_, expire, err := tlsConfig(t.config)
+	if err != nil || expire.IsZero() {
+		return 0
+	}
+	if left := time.Until(expire); left < certExpireWarning {
+		t.log.Errorf("tele client certificate expires %s", expire.Format(time.RFC3339))
+	}
+	return expire.Unix()
+}
@@ -0,0 +1,84 @@
+package tele
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tele_config "github.com/AlexTransit/vender/tele/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCert(t *testing.T, dir string, notAfter time.Time) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "vm1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+func TestTlsConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	notAfter := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	certFile, keyFile := writeTestCert(t, dir, notAfter)
+
+	conf, expire, err := tlsConfig(tele_config.Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, conf)
+	assert.True(t, expire.IsZero())
+
+	c := tele_config.Config{
+		MqttBroker:    "mqtts://broker.example.com:8883",
+		TlsCaFile:     certFile,
+		TlsCertFile:   certFile,
+		TlsKeyFile:    keyFile,
+		TlsServerName: "broker.example.com",
+	}
+	conf, expire, err = tlsConfig(c)
+	require.NoError(t, err)
+	require.NotNil(t, conf)
+	assert.Equal(t, "broker.example.com", conf.ServerName)
+	assert.Equal(t, 1, len(conf.Certificates))
+	assert.NotNil(t, conf.RootCAs)
+	assert.True(t, notAfter.Equal(expire))
+	assert.NoError(t, checkBrokerTLS(c, conf))
+
+	c.MqttBroker = "tcp://broker.example.com:1883"
+	assert.Error(t, checkBrokerTLS(c, conf))
+
+	for _, bad := range []tele_config.Config{
+		{TlsCaFile: filepath.Join(dir, "missing.pem")},
+		{TlsCaFile: keyFile},
+		{TlsCertFile: certFile},
+		{TlsCertFile: keyFile, TlsKeyFile: certFile},
+	} {
+		_, _, err = tlsConfig(bad)
+		assert.Error(t, err, "%#v", bad)
+	}
+}
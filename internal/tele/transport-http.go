@@ -0,0 +1,273 @@
+package tele
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/AlexTransit/vender/helpers"
+	"github.com/AlexTransit/vender/log2"
+	tele_config "github.com/AlexTransit/vender/tele/config"
+	"github.com/juju/errors"
+)
+
+// HTTP(S) transport for sites where only outbound https allowed.
+// paths are the same as mqtt topics: POST {http_url}/vm{id}/ro, /vm{id}/w/1t, /vm{id}/w/1s, /vm{id}/cr
+// body is protobuf payload. any 2xx status is ack.
+// incoming messages: long-poll GET {http_url}/vm{id}/poll?timeout=sec
+// 200 with payload and header X-Vender-Topic: ri (ToRoboMessage) or r/c (Command), 204 no messages.
+// basic auth user vm{id} password mqtt_password.
+
+const (
+	httpContentType = "application/x-protobuf"
+	httpTopicHeader = "X-Vender-Topic"
+)
+
+type transportHttp struct {
+	netRestarter
+	config        *tele_config.Config
+	client        *http.Client
+	baseUrl       string
+	user          string
+	onCommand     func([]byte) bool
+	inRobo        func([]byte) bool
+	outbox        *outbox
+	pollTimeout   time.Duration
+	retryInterval time.Duration
+	pollCancel    context.CancelFunc
+}
+
+func (th *transportHttp) Init(ctx context.Context, log *log2.Log, teleConfig tele_config.Config, onCommand CommandCallback, inRobo CommandCallback) error {
+	if !teleConfig.Enabled {
+		return nil
+	}
+	u, err := url.Parse(teleConfig.HttpUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.NotValidf("tele http_url=%q", teleConfig.HttpUrl)
+	}
+	tlsconf, _, err := tlsConfig(teleConfig)
+	if err != nil {
+		return errors.Annotate(err, "http tls")
+	}
+	th.config = &teleConfig
+	th.log = log
+	th.closech = make(chan struct{})
+	th.baseUrl = strings.TrimRight(teleConfig.HttpUrl, "/")
+	th.user = fmt.Sprintf("vm%d", teleConfig.VmId)
+	th.onCommand = func(payload []byte) bool { return onCommand(ctx, payload) }
+	th.inRobo = func(payload []byte) bool { return inRobo(ctx, payload) }
+	th.pollTimeout = helpers.IntSecondConfigDefault(teleConfig.HttpPollTimeoutSec, 30)
+	if th.retryInterval == 0 {
+		th.retryInterval = helpers.IntSecondConfigDefault(teleConfig.KeepaliveSec/2, 30)
+	}
+	th.networkRestartTimeout = helpers.IntSecondConfigDefault(teleConfig.NetworkRestartTimeout, 600)
+	th.networkRestartScript = teleConfig.NetworkRestartScript
+	th.client = &http.Client{Timeout: th.pollTimeout + DefaultNetworkTimeout}
+	if tlsconf != nil {
+		th.client.Transport = &http.Transport{TLSClientConfig: tlsconf, Proxy: http.ProxyFromEnvironment}
+	}
+	th.enabled.Store(true)
+
+	storePath := teleConfig.StorePath
+	if storePath == "" {
+		storePath = DefaultStorePath
+	}
+	if ob, err := openOutbox(log, outboxPath(storePath), th.outboxPublish); err != nil {
+		th.log.Errorf("tele outbox disabled, messages may be lost err=%v", err)
+	} else {
+		th.outbox = ob
+		go ob.run(th.closech)
+	}
+	pollCtx, cancel := context.WithCancel(context.Background())
+	th.pollCancel = cancel
+	go th.pollLoop(pollCtx)
+	th.startRestartNetworkLoop()
+	return nil
+}
+
+func (th *transportHttp) RoboConnected() bool { return th.connected.Load() }
+
+func (th *transportHttp) CloseTele() {
+	if !th.enabled.Load() {
+		return
+	}
+	if th.outbox != nil && th.connected.Load() {
+		th.outbox.flush(outboxCloseTimeout)
+	}
+	th.enabled.Store(false)
+	select {
+	case <-th.closech:
+	default:
+		close(th.closech)
+	}
+	th.pollCancel()
+	if th.outbox != nil {
+		if n := th.outbox.len(); n != 0 {
+			th.log.Infof("tele outbox %d messages will be delivered after restart", n)
+		}
+		th.outbox.close()
+	}
+}
+
+func (th *transportHttp) SendState(payload []byte) bool {
+	if !th.enabled.Load() {
+		return false
+	}
+	th.log.Infof("transport sendstate payload=%x", payload)
+	th.sendDurable(outboxState, payload)
+	return true
+}
+
+func (th *transportHttp) SendTelemetry(payload []byte) bool {
+	th.sendDurable(outboxTelemetry, payload)
+	return true
+}
+
+func (th *transportHttp) SendCommandResponse(topicSuffix string, payload []byte) bool {
+	if th.enabled.Load() {
+		go th.postLog(topicSuffix, payload)
+	}
+	return true
+}
+
+func (th *transportHttp) SendFromRobot(payload []byte) {
+	th.sendDurable(outboxRobot, payload)
+}
+
+func (th *transportHttp) sendDurable(kind outboxKind, payload []byte) {
+	if !th.enabled.Load() {
+		return
+	}
+	if th.outbox == nil {
+		go th.postLog(th.outboxTopic(kind), payload)
+		return
+	}
+	th.outbox.enqueue(kind, payload)
+}
+
+func (th *transportHttp) outboxTopic(kind outboxKind) string {
+	switch kind {
+	case outboxTelemetry:
+		return "w/1t"
+	case outboxState:
+		return "w/1s"
+	}
+	return "ro"
+}
+
+func (th *transportHttp) outboxPublish(item outboxItem) error {
+	if !th.enabled.Load() || !th.connected.Load() {
+		return errNotConnected
+	}
+	return th.post(th.outboxTopic(item.kind), item.payload)
+}
+
+func (th *transportHttp) postLog(topic string, payload []byte) {
+	if err := th.post(topic, payload); err != nil {
+		th.log.Errorf("tele http post topic=%s err=%v", topic, err)
+	}
+}
+
+func (th *transportHttp) post(topic string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, th.url(topic), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", httpContentType)
+	resp, err := th.do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
+
+func (th *transportHttp) url(topic string) string {
+	return th.baseUrl + "/" + th.user + "/" + topic
+}
+
+// do request with auth. network error or bad status means disconnected
+func (th *transportHttp) do(req *http.Request) (*http.Response, error) {
+	req.SetBasicAuth(th.user, th.config.MqttPassword)
+	resp, err := th.client.Do(req)
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		resp.Body.Close()
+		err = fmt.Errorf("%s %s status=%s", req.Method, req.URL.Path, resp.Status)
+	}
+	if err != nil {
+		th.setConnected(false)
+		return nil, err
+	}
+	th.setConnected(true)
+	return resp, nil
+}
+
+func (th *transportHttp) setConnected(connected bool) {
+	if th.connected.Swap(connected) == connected {
+		return
+	}
+	if connected {
+		th.log.Infof("tele http connect")
+		if th.outbox != nil {
+			th.outbox.kick()
+		}
+	} else if th.enabled.Load() {
+		th.log.Infof("tele http disconnect")
+		th.startRestartNetworkLoop()
+	}
+}
+
+// long-poll incoming messages until close
+func (th *transportHttp) pollLoop(ctx context.Context) {
+	for th.enabled.Load() {
+		if err := th.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			th.log.Debugf("tele http poll err=%v", err)
+			select {
+			case <-th.closech:
+				return
+			case <-time.After(th.retryInterval):
+			}
+		}
+	}
+}
+
+func (th *transportHttp) poll(ctx context.Context) error {
+	u := fmt.Sprintf("%s?timeout=%d", th.url("poll"), int(th.pollTimeout.Seconds()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := th.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	th.log.Debugf("tele http income message (%x)", payload)
+	switch topic := resp.Header.Get(httpTopicHeader); topic {
+	case "ri":
+		th.inRobo(payload)
+	case "r/c":
+		th.onCommand(payload)
+	default:
+		th.log.Errorf("tele http poll unknown topic=%q", topic)
+	}
+	return nil
+}
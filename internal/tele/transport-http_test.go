@@ -0,0 +1,119 @@
+package tele
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/log2"
+	tele_config "github.com/AlexTransit/vender/tele/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type httpServerMock struct {
+	mu    sync.Mutex
+	down  atomic.Bool
+	posts []string
+	queue chan string // topic for poll response, payload = topic
+}
+
+func (s *httpServerMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "vm7" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if s.down.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method == http.MethodGet && r.URL.Path == "/api/vm7/poll" {
+		select {
+		case topic := <-s.queue:
+			w.Header().Set(httpTopicHeader, topic)
+			_, _ = w.Write([]byte(topic))
+		case <-time.After(50 * time.Millisecond):
+			w.WriteHeader(http.StatusNoContent)
+		case <-r.Context().Done():
+		}
+		return
+	}
+	b, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.posts = append(s.posts, r.URL.Path+"="+string(b))
+	s.mu.Unlock()
+}
+
+func (s *httpServerMock) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.posts...)
+}
+
+func TestTransportHttp(t *testing.T) {
+	t.Parallel()
+
+	srv := &httpServerMock{queue: make(chan string, 2)}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	config := tele_config.Config{
+		Enabled:      true,
+		VmId:         7,
+		Transport:    "http",
+		HttpUrl:      ts.URL + "/api/",
+		MqttPassword: "secret",
+		StorePath:    t.TempDir(),
+	}
+	var inRobo, command atomic.Int32
+	onCommand := func(_ context.Context, b []byte) bool {
+		command.Add(1)
+		return true
+	}
+	onRobo := func(_ context.Context, b []byte) bool {
+		inRobo.Add(1)
+		return true
+	}
+	th := &transportHttp{retryInterval: 10 * time.Millisecond}
+	require.NoError(t, th.Init(context.Background(), log, config, onCommand, onRobo))
+
+	srv.queue <- "ri"
+	srv.queue <- "r/c"
+	require.Eventually(t, func() bool { return inRobo.Load() == 1 && command.Load() == 1 }, time.Second, 10*time.Millisecond)
+	assert.True(t, th.RoboConnected())
+
+	// server unavailable, messages wait in outbox
+	srv.down.Store(true)
+	require.Eventually(t, func() bool { return !th.RoboConnected() }, time.Second, 10*time.Millisecond)
+	th.SendFromRobot([]byte("order"))
+	th.SendTelemetry([]byte("tm"))
+	th.SendFromRobot([]byte("state"))
+	th.SendState([]byte("s"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, srv.received())
+
+	// reconnect, delivered in order
+	srv.down.Store(false)
+	require.Eventually(t, func() bool { return len(srv.received()) == 4 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"/api/vm7/ro=order", "/api/vm7/w/1t=tm", "/api/vm7/ro=state", "/api/vm7/w/1s=s"}, srv.received())
+
+	th.SendCommandResponse("cr", []byte("resp"))
+	require.Eventually(t, func() bool { return len(srv.received()) == 5 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "/api/vm7/cr=resp", srv.received()[4])
+	th.CloseTele()
+}
+
+func TestTransportHttpInvalidUrl(t *testing.T) {
+	t.Parallel()
+
+	log := log2.NewTest(t, log2.LOG_DEBUG)
+	th := &transportHttp{}
+	err := th.Init(context.Background(), log, tele_config.Config{Enabled: true, Transport: "http", HttpUrl: "ftp://x"}, nil, nil)
+	assert.Error(t, err)
+}
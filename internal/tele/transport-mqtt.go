@@ -3,7 +3,6 @@ package tele
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"sync/atomic"
 	"time"
 
@@ -11,34 +10,38 @@ import (
 	"github.com/AlexTransit/vender/log2"
 	tele_config "github.com/AlexTransit/vender/tele/config"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/juju/errors"
 )
 
 type transportMqtt struct {
-	enabled               atomic.Bool
-	connected             atomic.Bool
-	parseMessage          atomic.Bool
-	restartLoopRunning    atomic.Bool
-	closech               chan struct{}
-	config                *tele_config.Config
-	log                   *log2.Log
-	onCommand             func([]byte) bool
-	inRobo                func([]byte) bool
-	m                     mqtt.Client
-	mopt                  *mqtt.ClientOptions
-	networkRestartTimeout time.Duration
-	topicPrefix           string
-	topicConnect          string
-	topicState            string
-	topicTelemetry        string
-	topicCommand          string
-	topicRoboIn           string
-	topicRoboOut          string
+	netRestarter
+	parseMessage   atomic.Bool
+	config         *tele_config.Config
+	onCommand      func([]byte) bool
+	inRobo         func([]byte) bool
+	m              mqtt.Client
+	mopt           *mqtt.ClientOptions
+	outbox         *outbox
+	topicPrefix    string
+	topicConnect   string
+	topicState     string
+	topicTelemetry string
+	topicCommand   string
+	topicRoboIn    string
+	topicRoboOut   string
 }
 
 func (tm *transportMqtt) Init(ctx context.Context, log *log2.Log, teleConfig tele_config.Config, onCommand CommandCallback, inRobo CommandCallback) error {
 	if !teleConfig.Enabled {
 		return nil
 	}
+	tlsconf, _, err := tlsConfig(teleConfig)
+	if err != nil {
+		return errors.Annotate(err, "mqtt tls")
+	}
+	if err = checkBrokerTLS(teleConfig, tlsconf); err != nil {
+		return err
+	}
 	tm.config = &teleConfig
 	tm.enabled.Store(true)
 	tm.log = log
@@ -71,11 +74,17 @@ func (tm *transportMqtt) Init(ctx context.Context, log *log2.Log, teleConfig tel
 	pingTimeout := helpers.IntSecondConfigDefault(teleConfig.PingTimeoutSec, 30)
 	retryInterval := helpers.IntSecondConfigDefault(teleConfig.KeepaliveSec/2, 30)
 	tm.networkRestartTimeout = helpers.IntSecondConfigDefault(teleConfig.NetworkRestartTimeout, 600)
-	tm.config.NetworkRestartScript = teleConfig.NetworkRestartScript
+	tm.networkRestartScript = teleConfig.NetworkRestartScript
 
 	storePath := teleConfig.StorePath
 	if teleConfig.StorePath == "" {
-		storePath = "/home/vmc/vender-db/telemessages"
+		storePath = DefaultStorePath
+	}
+	if ob, err := openOutbox(log, outboxPath(storePath), tm.outboxPublish); err != nil {
+		tm.log.Errorf("tele outbox disabled, messages may be lost err=%v", err)
+	} else {
+		tm.outbox = ob
+		go ob.run(tm.closech)
 	}
 	tm.mopt = mqtt.NewClientOptions().
 		AddBroker(teleConfig.MqttBroker).
@@ -87,13 +96,15 @@ func (tm *transportMqtt) Init(ctx context.Context, log *log2.Log, teleConfig tel
 		SetKeepAlive(keepAlive).
 		SetPingTimeout(pingTimeout).
 		SetOrderMatters(false).
-		// SetTLSConfig(tlsconf).
 		SetResumeSubs(true).SetCleanSession(false).
 		SetStore(mqtt.NewFileStore(storePath)).
 		SetConnectRetryInterval(retryInterval).
 		SetOnConnectHandler(tm.onConnectHandler).
 		SetConnectionLostHandler(tm.connectLostHandler).
 		SetConnectRetry(true)
+	if tlsconf != nil {
+		tm.mopt.SetTLSConfig(tlsconf)
+	}
 	tm.m = mqtt.NewClient(tm.mopt)
 	sConnToken := tm.m.Connect()
 	// if sConnToken.Wait() && sConnToken.Error() != nil {
@@ -110,12 +121,21 @@ func (tm *transportMqtt) CloseTele() {
 	if tm.m == nil {
 		return
 	}
+	if tm.outbox != nil && tm.connected.Load() {
+		tm.outbox.flush(outboxCloseTimeout)
+	}
 	tm.enabled.Store(false)
 	select {
 	case <-tm.closech:
 	default:
 		close(tm.closech)
 	}
+	if tm.outbox != nil {
+		if n := tm.outbox.len(); n != 0 {
+			tm.log.Infof("tele outbox %d messages will be delivered after restart", n)
+		}
+		tm.outbox.close()
+	}
 	tm.log.Infof("mqtt unsubscribe")
 	if token := tm.m.Unsubscribe(tm.topicCommand); token.Wait() && token.Error() != nil {
 		tm.log.Infof("mqtt unsubscribe error")
@@ -134,14 +154,12 @@ func (tm *transportMqtt) SendState(payload []byte) bool {
 		return false
 	}
 	tm.log.Infof("transport sendstate payload=%x", payload)
-	tm.publish2Telemetry(tm.topicState, 1, false, payload)
+	tm.sendDurable(outboxState, payload)
 	return true
 }
 
 func (tm *transportMqtt) SendTelemetry(payload []byte) bool {
-	if tm.enabled.Load() {
-		tm.publish2Telemetry(tm.topicTelemetry, 1, false, payload)
-	}
+	tm.sendDurable(outboxTelemetry, payload)
 	return true
 }
 
@@ -155,8 +173,43 @@ func (tm *transportMqtt) SendCommandResponse(topicSuffix string, payload []byte)
 }
 func (tm *transportMqtt) SendFromRobot(payload []byte) {
 	// tm.log.Infof("mqtt publish message from robot to topic=%s", tm.topicRoboOut)
-	tm.publish2Telemetry(tm.topicRoboOut, 1, false, payload)
+	tm.sendDurable(outboxRobot, payload)
+}
 
+// message stored in outbox and delivered in order when broker is available
+func (tm *transportMqtt) sendDurable(kind outboxKind, payload []byte) {
+	if !tm.enabled.Load() {
+		return
+	}
+	if tm.outbox == nil {
+		tm.publish2Telemetry(tm.outboxTopic(kind), 1, false, payload)
+		return
+	}
+	tm.outbox.enqueue(kind, payload)
+}
+
+func (tm *transportMqtt) outboxTopic(kind outboxKind) string {
+	switch kind {
+	case outboxTelemetry:
+		return tm.topicTelemetry
+	case outboxState:
+		return tm.topicState
+	}
+	return tm.topicRoboOut
+}
+
+var errNotConnected = errors.New("not connected")
+
+// publish and wait broker ack (PUBACK)
+func (tm *transportMqtt) outboxPublish(item outboxItem) error {
+	if !tm.enabled.Load() || !tm.connected.Load() {
+		return errNotConnected
+	}
+	token := tm.m.Publish(tm.outboxTopic(item.kind), 1, false, item.payload)
+	if !token.WaitTimeout(DefaultNetworkTimeout) {
+		return errors.Timeoutf("publish")
+	}
+	return token.Error()
 }
 
 func (tm *transportMqtt) messageHandler(c mqtt.Client, msg mqtt.Message) {
@@ -206,39 +259,7 @@ func (tm *transportMqtt) onConnectHandler(c mqtt.Client) {
 		tm.log.Infof("mqtt subscribe error")
 	}
 	tm.connected.Store(true)
-}
-
-func (tm *transportMqtt) restartNetwork() {
-	defer tm.restartLoopRunning.Store(false)
-	if tm.config.NetworkRestartScript == "" {
-		return
-	}
-	ticker := time.NewTicker(tm.networkRestartTimeout)
-	defer ticker.Stop()
-	for tm.enabled.Load() {
-		select {
-		case <-tm.closech:
-			return
-		case <-ticker.C:
-		}
-		if tm.connected.Load() {
-			continue
-		}
-		tm.runNetworkRestartScript()
-	}
-}
-
-func (tm *transportMqtt) startRestartNetworkLoop() {
-	if tm.restartLoopRunning.CompareAndSwap(false, true) {
-		go tm.restartNetwork()
-	}
-}
-
-func (tm *transportMqtt) runNetworkRestartScript() {
-	tm.log.Infof("run script fot restart network")
-	cmd := exec.Command(tm.config.NetworkRestartScript)
-	execOutput, execErr := cmd.CombinedOutput()
-	if execErr != nil {
-		tm.log.Errorf("script execute=%s output=%s error=%v", tm.config.NetworkRestartScript, execOutput, execErr)
+	if tm.outbox != nil {
+		tm.outbox.kick()
 	}
 }
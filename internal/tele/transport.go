@@ -2,6 +2,9 @@ package tele
 
 import (
 	"context"
+	"os/exec"
+	"sync/atomic"
+	"time"
 
 	"github.com/AlexTransit/vender/log2"
 	tele_config "github.com/AlexTransit/vender/tele/config"
@@ -24,3 +27,51 @@ type Transporter interface {
 }
 
 type CommandCallback func(context.Context, []byte) bool
+
+const DefaultStorePath = "/home/vmc/vender-db/telemessages"
+
+// connection state and network restart script runner, common for transports
+type netRestarter struct {
+	enabled               atomic.Bool
+	connected             atomic.Bool
+	restartLoopRunning    atomic.Bool
+	closech               chan struct{}
+	log                   *log2.Log
+	networkRestartTimeout time.Duration
+	networkRestartScript  string
+}
+
+func (nr *netRestarter) restartNetwork() {
+	defer nr.restartLoopRunning.Store(false)
+	if nr.networkRestartScript == "" {
+		return
+	}
+	ticker := time.NewTicker(nr.networkRestartTimeout)
+	defer ticker.Stop()
+	for nr.enabled.Load() {
+		select {
+		case <-nr.closech:
+			return
+		case <-ticker.C:
+		}
+		if nr.connected.Load() {
+			continue
+		}
+		nr.runNetworkRestartScript()
+	}
+}
+
+func (nr *netRestarter) startRestartNetworkLoop() {
+	if nr.restartLoopRunning.CompareAndSwap(false, true) {
+		go nr.restartNetwork()
+	}
+}
+
+func (nr *netRestarter) runNetworkRestartScript() {
+	nr.log.Infof("run script fot restart network")
+	cmd := exec.Command(nr.networkRestartScript)
+	execOutput, execErr := cmd.CombinedOutput()
+	if execErr != nil {
+		nr.log.Errorf("script execute=%s output=%s error=%v", nr.networkRestartScript, execOutput, execErr)
+	}
+}
@@ -0,0 +1,290 @@
+// Package trace keeps span timeline of last orders: every engine action with nesting, argument, result
+// and MDB transactions. Export in Chrome trace-event JSON (chrome://tracing, ui.perfetto.dev).
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	laneMdb    = 1
+	laneEngine = 2 // parallel branches get next lanes
+
+	maxSpans = 20000 // stuck order must not eat memory, MDB poll is ~10 spans per second
+)
+
+type Span struct {
+	Name     string
+	Arg      string
+	Parent   int // index in Trace.spans, -1 root
+	Lane     int
+	Begin    time.Time
+	Duration time.Duration
+	Err      string
+	Code     int32 // error code, see helpers.AppError
+	open     int   // running children
+}
+
+// Trace one order
+type Trace struct {
+	Name  string
+	mu    sync.Mutex
+	spans []Span
+	lanes int
+	inner int // last started running span, parent of MDB transactions
+}
+
+// nil = disabled, all methods are nil-safe
+type Tracer struct {
+	mu     sync.Mutex
+	ring   []*Trace
+	next   int
+	active *Trace // MDB bus has no context, transactions go to running order
+}
+
+type ctxKey struct{}
+
+type span struct {
+	t  *Trace
+	id int
+}
+
+func New(orders int) *Tracer {
+	if orders <= 0 {
+		return nil
+	}
+	return &Tracer{ring: make([]*Trace, orders)}
+}
+
+// Begin starts order trace. End must be called with returned context.
+func (tc *Tracer) Begin(ctx context.Context, name string) context.Context {
+	if tc == nil {
+		return ctx
+	}
+	t := &Trace{Name: name, lanes: laneEngine}
+	t.spans = append(t.spans, Span{Name: name, Parent: -1, Lane: laneEngine, Begin: time.Now()})
+	tc.mu.Lock()
+	tc.active = t
+	tc.mu.Unlock()
+	return context.WithValue(ctx, ctxKey{}, span{t: t, id: 0})
+}
+
+// End finishes order trace and keeps it in ring buffer
+func (tc *Tracer) End(ctx context.Context, err error) {
+	if tc == nil {
+		return
+	}
+	s, ok := ctx.Value(ctxKey{}).(span)
+	if !ok {
+		return
+	}
+	s.t.end(s.id, err)
+	tc.mu.Lock()
+	if tc.active == s.t {
+		tc.active = nil
+	}
+	tc.ring[tc.next] = s.t
+	tc.next = (tc.next + 1) % len(tc.ring)
+	tc.mu.Unlock()
+}
+
+// Active context is inside order trace
+func Active(ctx context.Context) bool {
+	_, ok := ctx.Value(ctxKey{}).(span)
+	return ok
+}
+
+// Start child span of span in context. without order trace does nothing.
+func Start(ctx context.Context, name, arg string) (context.Context, func(error)) {
+	parent, ok := ctx.Value(ctxKey{}).(span)
+	if !ok {
+		return ctx, func(error) {}
+	}
+	t := parent.t
+	t.mu.Lock()
+	if len(t.spans) >= maxSpans {
+		t.mu.Unlock()
+		return ctx, func(error) {}
+	}
+	p := &t.spans[parent.id]
+	lane := p.Lane
+	if p.open != 0 { // sibling is running - parallel branch
+		t.lanes++
+		lane = t.lanes
+	}
+	p.open++
+	id := len(t.spans)
+	t.spans = append(t.spans, Span{Name: name, Arg: arg, Parent: parent.id, Lane: lane, Begin: time.Now()})
+	t.inner = id
+	t.mu.Unlock()
+	return context.WithValue(ctx, ctxKey{}, span{t: t, id: id}), func(err error) {
+		t.end(id, err)
+	}
+}
+
+func (t *Trace) end(id int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &t.spans[id]
+	s.Duration = time.Since(s.Begin)
+	if err != nil {
+		s.Err = err.Error()
+		var coder interface{ Code() int32 }
+		if errors.As(err, &coder) {
+			s.Code = coder.Code()
+		}
+	}
+	if s.Parent >= 0 {
+		t.spans[s.Parent].open--
+	}
+	if t.inner == id && s.Parent >= 0 {
+		t.inner = s.Parent
+	}
+}
+
+// Tx records MDB transaction of running order as child of innermost running span
+func (tc *Tracer) Tx(request, response []byte, begin time.Time, err error) {
+	if tc == nil {
+		return
+	}
+	tc.mu.Lock()
+	t := tc.active
+	tc.mu.Unlock()
+	if t == nil {
+		return
+	}
+	s := Span{
+		Name:     "mdb " + hex.EncodeToString(request),
+		Arg:      hex.EncodeToString(response),
+		Lane:     laneMdb,
+		Begin:    begin,
+		Duration: time.Since(begin),
+	}
+	if err != nil {
+		s.Err = err.Error()
+	}
+	t.mu.Lock()
+	s.Parent = t.inner
+	if len(t.spans) < maxSpans {
+		t.spans = append(t.spans, s)
+	}
+	t.mu.Unlock()
+}
+
+// Traces finished orders, newest first
+func (tc *Tracer) Traces() []*Trace {
+	if tc == nil {
+		return nil
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	result := make([]*Trace, 0, len(tc.ring))
+	for i := 1; i <= len(tc.ring); i++ {
+		if t := tc.ring[(tc.next-i+len(tc.ring))%len(tc.ring)]; t != nil {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func (t *Trace) Spans() []Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Span(nil), t.spans...)
+}
+
+type chromeEvent struct {
+	Name string            `json:"name"`
+	Cat  string            `json:"cat,omitempty"`
+	Ph   string            `json:"ph"`
+	Ts   int64             `json:"ts"` // microseconds
+	Dur  int64             `json:"dur,omitempty"`
+	Pid  int               `json:"pid"`
+	Tid  int               `json:"tid"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// WriteChrome trace-event JSON, time from order begin
+func (t *Trace) WriteChrome(w io.Writer) error {
+	spans := t.Spans()
+	events := make([]chromeEvent, 0, len(spans)+4)
+	lanes := map[int]bool{}
+	for _, s := range spans {
+		if !lanes[s.Lane] {
+			lanes[s.Lane] = true
+			name := "engine"
+			switch {
+			case s.Lane == laneMdb:
+				name = "mdb"
+			case s.Lane > laneEngine:
+				name = "engine " + strconv.Itoa(s.Lane-laneEngine)
+			}
+			events = append(events, chromeEvent{Name: "thread_name", Ph: "M", Pid: 1, Tid: s.Lane, Args: map[string]string{"name": name}})
+		}
+		e := chromeEvent{
+			Name: s.Name,
+			Cat:  "engine",
+			Ph:   "X",
+			Ts:   s.Begin.Sub(spans[0].Begin).Microseconds(),
+			Dur:  s.Duration.Microseconds(),
+			Pid:  1,
+			Tid:  s.Lane,
+			Args: map[string]string{},
+		}
+		if s.Lane == laneMdb {
+			e.Cat = "mdb"
+		}
+		if s.Arg != "" {
+			e.Args["arg"] = s.Arg
+		}
+		if s.Err != "" {
+			e.Args["error"] = s.Err
+		}
+		if s.Code != 0 {
+			e.Args["code"] = strconv.Itoa(int(s.Code))
+		}
+		events = append(events, e)
+	}
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []chromeEvent `json:"traceEvents"`
+		DisplayTimeUnit string        `json:"displayTimeUnit"`
+	}{events, "ms"})
+}
+
+// ServeHTTP GET /trace - list of last orders, /trace?n=0 - Chrome trace of newest order
+func (tc *Tracer) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	traces := tc.Traces()
+	rw.Header().Set("Content-Type", "application/json")
+	if q := r.URL.Query().Get("n"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 0 || n >= len(traces) {
+			http.Error(rw, fmt.Sprintf("trace n=%s not found, have %d", q, len(traces)), http.StatusNotFound)
+			return
+		}
+		rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"trace-%d.json\"", traces[n].Spans()[0].Begin.Unix()))
+		_ = traces[n].WriteChrome(rw)
+		return
+	}
+	type item struct {
+		N        int       `json:"n"`
+		Name     string    `json:"name"`
+		Begin    time.Time `json:"begin"`
+		Duration string    `json:"duration"`
+		Error    string    `json:"error,omitempty"`
+	}
+	list := make([]item, len(traces))
+	for i, t := range traces {
+		root := t.Spans()[0]
+		list[i] = item{N: i, Name: t.Name, Begin: root.Begin, Duration: root.Duration.String(), Error: root.Err}
+	}
+	_ = json.NewEncoder(rw).Encode(list)
+}
@@ -0,0 +1,150 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codeError struct{ code int32 }
+
+func (e codeError) Error() string { return "device error" }
+func (e codeError) Code() int32   { return e.code }
+
+func TestNil(t *testing.T) {
+	t.Parallel()
+
+	var tc *Tracer
+	assert.Nil(t, New(0))
+	ctx := tc.Begin(context.Background(), "order")
+	assert.False(t, Active(ctx))
+	_, end := Start(ctx, "a", "")
+	end(nil)
+	tc.Tx([]byte{0x30}, nil, time.Now(), nil)
+	tc.End(ctx, nil)
+	assert.Nil(t, tc.Traces())
+}
+
+func TestNesting(t *testing.T) {
+	t.Parallel()
+
+	tc := New(2)
+	ctx := tc.Begin(context.Background(), "order code:11")
+	require.True(t, Active(ctx))
+	cctx, end := Start(ctx, "make_coffee", "150")
+	_, end2 := Start(cctx, "evend.cup.dispense", "")
+	tc.Tx([]byte{0xe0, 0x01}, []byte{0x00}, time.Now(), nil)
+	end2(codeError{code: 42})
+	end(errors.New("wrap"))
+	tc.End(ctx, nil)
+
+	traces := tc.Traces()
+	require.Equal(t, 1, len(traces))
+	spans := traces[0].Spans()
+	require.Equal(t, 4, len(spans))
+	assert.Equal(t, "order code:11", spans[0].Name)
+	assert.Equal(t, Span{Name: "make_coffee", Arg: "150", Parent: 0, Lane: laneEngine, Err: "wrap"},
+		Span{Name: spans[1].Name, Arg: spans[1].Arg, Parent: spans[1].Parent, Lane: spans[1].Lane, Err: spans[1].Err})
+	assert.Equal(t, 1, spans[2].Parent)
+	assert.Equal(t, int32(42), spans[2].Code)
+	assert.Equal(t, "mdb e001", spans[3].Name)
+	assert.Equal(t, laneMdb, spans[3].Lane)
+	assert.Equal(t, 2, spans[3].Parent)
+
+	// after child ended transactions go to its parent
+	ctx = tc.Begin(context.Background(), "order code:12")
+	cctx, end = Start(ctx, "make_tea", "")
+	_, end2 = Start(cctx, "evend.cup.dispense", "")
+	end2(nil)
+	tc.Tx([]byte{0xe0, 0x02}, nil, time.Now(), nil)
+	end(nil)
+	tc.Tx([]byte{0xe0, 0x03}, nil, time.Now(), nil)
+	tc.End(ctx, nil)
+	spans = tc.Traces()[0].Spans()
+	require.Equal(t, 5, len(spans))
+	assert.Equal(t, 1, spans[3].Parent)
+	assert.Equal(t, 0, spans[4].Parent)
+
+	// no order - no MDB spans
+	tc.Tx([]byte{0x30}, nil, time.Now(), nil)
+	assert.Equal(t, 5, len(tc.Traces()[0].Spans()))
+}
+
+func TestParallelLanes(t *testing.T) {
+	t.Parallel()
+
+	tc := New(1)
+	ctx := tc.Begin(context.Background(), "order")
+	_, endA := Start(ctx, "a", "")
+	_, endB := Start(ctx, "b", "")
+	endA(nil)
+	endB(nil)
+	_, endC := Start(ctx, "c", "")
+	endC(nil)
+	tc.End(ctx, nil)
+
+	spans := tc.Traces()[0].Spans()
+	assert.Equal(t, laneEngine, spans[1].Lane)
+	assert.Equal(t, laneEngine+1, spans[2].Lane)
+	assert.Equal(t, laneEngine, spans[3].Lane)
+}
+
+func TestRing(t *testing.T) {
+	t.Parallel()
+
+	tc := New(2)
+	for _, name := range []string{"1", "2", "3"} {
+		tc.End(tc.Begin(context.Background(), name), nil)
+	}
+	traces := tc.Traces()
+	require.Equal(t, 2, len(traces))
+	assert.Equal(t, "3", traces[0].Name)
+	assert.Equal(t, "2", traces[1].Name)
+}
+
+func TestChrome(t *testing.T) {
+	t.Parallel()
+
+	tc := New(1)
+	ctx := tc.Begin(context.Background(), "order")
+	_, end := Start(ctx, "evend.conveyor.move", "pos=300")
+	end(codeError{code: 3})
+	tc.Tx([]byte{0x30}, []byte{0x00}, time.Now(), nil)
+	tc.End(ctx, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, tc.Traces()[0].WriteChrome(&buf))
+	var doc struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	var move *chromeEvent
+	threads := map[string]bool{}
+	for i, e := range doc.TraceEvents {
+		switch {
+		case e.Ph == "M":
+			threads[e.Args["name"]] = true
+		case e.Name == "evend.conveyor.move":
+			move = &doc.TraceEvents[i]
+		}
+	}
+	assert.Equal(t, map[string]bool{"engine": true, "mdb": true}, threads)
+	require.NotNil(t, move)
+	assert.Equal(t, "X", move.Ph)
+	assert.Equal(t, map[string]string{"arg": "pos=300", "error": "device error", "code": "3"}, move.Args)
+
+	rec := httptest.NewRecorder()
+	tc.ServeHTTP(rec, httptest.NewRequest("GET", "/trace?n=0", nil))
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"traceEvents"`)
+	rec = httptest.NewRecorder()
+	tc.ServeHTTP(rec, httptest.NewRequest("GET", "/trace?n=1", nil))
+	assert.Equal(t, 404, rec.Code)
+}
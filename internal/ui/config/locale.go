@@ -0,0 +1,91 @@
+package ui_config
+
+import (
+	"sort"
+
+	"github.com/AlexTransit/vender/internal/locale"
+)
+
+// языки клиента. сообщения front берутся из блока front, поверх - msg языка Lang (если есть блок),
+// поверх - msg выбранного языка. service всегда на языке Lang.
+
+// LocaleNames Lang first, then configured locales by name
+func (c *Config) LocaleNames() []string {
+	names := make([]string, 0, len(c.Locales)+1)
+	for name := range c.Locales {
+		if name != c.Lang {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{c.Lang}, names...)
+}
+
+// NextLocale next customer language after name, "" - Lang
+func (c *Config) NextLocale(name string) string {
+	names := c.LocaleNames()
+	for i, n := range names {
+		if n == c.localeName(name) {
+			return names[(i+1)%len(names)]
+		}
+	}
+	return c.Lang
+}
+
+func (c *Config) localeName(name string) string {
+	if _, ok := c.Locales[name]; !ok {
+		return c.Lang
+	}
+	return name
+}
+
+// LocaleTitle language name for display
+func (c *Config) LocaleTitle(name string) string {
+	name = c.localeName(name)
+	if l := c.Locales[name]; l.Title != "" {
+		return l.Title
+	}
+	return name
+}
+
+// LocaleFront front messages of language
+func (c *Config) LocaleFront(name string) FrontStruct {
+	f := c.Front
+	locale.Apply(&f, "msg_", c.Locales[c.Lang].Msg)
+	if name = c.localeName(name); name != c.Lang {
+		locale.Apply(&f, "msg_", c.Locales[name].Msg)
+	}
+	return f
+}
+
+// Sprintf message format with plural forms of language
+func (c *Config) Sprintf(name string, format string, args ...any) string {
+	return locale.Sprintf(c.localeName(name), format, args...)
+}
+
+// Speech phrase of language by name and tts command (nil - sound.tts_exec).
+// unknown phrase is spoken as is
+func (c *Config) Speech(name string, phrase string) (string, []string) {
+	name = c.localeName(name)
+	l := c.Locales[name]
+	if s, ok := l.Msg[phrase]; ok {
+		return s, l.TTSExec
+	}
+	if s, ok := c.Locales[c.Lang].Msg[phrase]; ok {
+		return s, c.Locales[c.Lang].TTSExec
+	}
+	return phrase, l.TTSExec
+}
+
+// CharMap text display characters of language
+func (c *Config) CharMap(name string) map[rune]byte {
+	m := map[rune]byte{}
+	for _, n := range []string{c.Lang, c.localeName(name)} {
+		for s, b := range c.Locales[n].CharMap {
+			if r := []rune(s); len(r) == 1 {
+				m[r[0]] = byte(b)
+			}
+		}
+	}
+	return m
+}
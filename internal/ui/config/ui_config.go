@@ -9,6 +9,28 @@ type Config struct { //nolint:maligned
 	LogDebug bool          `hcl:"log_debug,optional"`
 	Front    FrontStruct   `hcl:"front,block"`
 	Service  ServiceStruct `hcl:"service,block"`
+	// RU: язык сообщений front и service (правила множественного числа), имя языка по умолчанию.
+	// EN: language of front and service messages (plural rules), default locale name.
+	Lang string `hcl:"lang,optional"`
+	// RU: языки для клиента. переключаются клавишей front.locale_key.
+	// EN: customer languages. switched by front.locale_key.
+	XXX_Locales []LocaleStruct `hcl:"locale,block"`
+	Locales     map[string]LocaleStruct
+}
+
+type LocaleStruct struct {
+	Name string `hcl:"name,label"`
+	// RU: название языка, показывается при переключении.
+	// Example: "English"
+	Title string `hcl:"title,optional"`
+	// RU: сообщения front по имени параметра (msg_wait = "...") и фразы для speech(имя).
+	// EN: front messages by parameter name (msg_wait = "...") and phrases for speech(name).
+	Msg map[string]string `hcl:"msg,optional"`
+	// RU: символы текстового дисплея: символ = код знакогенератора HD44780.
+	// Example: { "ä" = 225 }
+	CharMap map[string]int `hcl:"char_map,optional"`
+	// RU: команда синтеза речи для языка. по умолчанию sound.tts_exec.
+	TTSExec []string `hcl:"tts_exec,optional"`
 }
 
 type FrontStruct struct {
@@ -68,6 +90,16 @@ type FrontStruct struct {
 	PicPayReject string `hcl:"pic_pay_reject"`
 	// RU: Расписание включения витрины. Например, "(* 06:00-23:00)" - включать подсветку каждый день с 6 утра до 11 вечера.
 	LightShedule string `hcl:"light_sheduler"`
+	// RU: клавиша смены языка при пустом коде напитка. язык возвращается через reset_sec.
+	// EN: key to switch language when drink code is empty. language reverts after reset_sec.
+	LocaleKey string `hcl:"locale_key,optional"`
+	// RU: сообщения промокода (voucher). подарок показывается как msg_credit.
+	// EN: voucher messages. gift is shown as msg_credit.
+	MsgVoucherInvalid string `hcl:"msg_voucher_invalid,optional"`
+	MsgVoucherExpired string `hcl:"msg_voucher_expired,optional"`
+	MsgVoucherUsed    string `hcl:"msg_voucher_used,optional"`
+	MsgVoucherLocked  string `hcl:"msg_voucher_locked,optional"` // "locked %.0f sec"
+	MsgVoucherGift    string `hcl:"msg_voucher_gift,optional"`
 }
 
 type ServiceStruct struct {
@@ -76,6 +108,52 @@ type ServiceStruct struct {
 	// RU: Сценарии для тестов в сервисном меню. указывается имя теста и список действий для выполнения.
 	XXX_Tests []TestsStruct `hcl:"test,block"`
 	Tests     map[string]TestsStruct
+	// RU: техники с PIN кодом для входа в сервисное меню. если техников нет - вход без PIN.
+	// EN: technicians with PIN for service menu. no technicians - service menu without PIN.
+	XXX_Technicians []TechnicianStruct `hcl:"technician,block"`
+	Technicians     map[string]TechnicianStruct
+	// RU: неверных PIN подряд до блокировки входа.
+	AuthMaxFail int `hcl:"auth_max_fail,optional"`
+	// RU: время блокировки входа в секундах.
+	AuthLockoutSec int `hcl:"auth_lockout_sec,optional"`
+	// RU: файл счетчика неверных PIN и времени блокировки, переживает перезапуск.
+	// EN: wrong PIN counter and lockout time file, survives restart.
+	AuthFile string `hcl:"auth_file,optional"`
+	// RU: итераций PBKDF2 для pin_hash. больше - медленнее подбор PIN по конфигу.
+	// EN: PBKDF2 iterations for pin_hash. more - slower PIN brute force from config.
+	PinIterations int `hcl:"pin_iterations,optional"`
+
+	// RU: сообщения сервисного меню.
+	// EN: service menu messages.
+	MsgMenu          string `hcl:"msg_menu,optional"`
+	MsgInputPin      string `hcl:"msg_input_pin,optional"` // "PIN %s"
+	MsgPinFail       string `hcl:"msg_pin_fail,optional"`
+	MsgLocked        string `hcl:"msg_locked,optional"`
+	MsgInvEmpty      string `hcl:"msg_inv_empty,optional"`
+	MsgInvInvalid    string `hcl:"msg_inv_invalid,optional"`
+	MsgSetOrClear    string `hcl:"msg_set_or_clear,optional"`
+	MsgEmpty         string `hcl:"msg_empty,optional"`
+	MsgNumberInvalid string `hcl:"msg_number_invalid,optional"`
+	MsgNoTests       string `hcl:"msg_no_tests,optional"`
+	MsgInProgress    string `hcl:"msg_in_progress,optional"`
+	MsgOK            string `hcl:"msg_ok,optional"`
+	MsgError         string `hcl:"msg_error,optional"`
+	MsgPress1        string `hcl:"msg_press_1,optional"`
+	MsgRebootAsk     string `hcl:"msg_reboot_ask,optional"`
+	MsgReboot        string `hcl:"msg_reboot,optional"`
+	MsgNetworkAsk    string `hcl:"msg_network_ask,optional"`
+	MsgNetwork       string `hcl:"msg_network,optional"`
+	MsgCoinOffline   string `hcl:"msg_coin_offline,optional"`
+	MsgReportAsk     string `hcl:"msg_report_ask,optional"`
+}
+
+type TechnicianStruct struct {
+	Name string `hcl:"name,label"`
+	// RU: соль и PBKDF2-HMAC-SHA256 от PIN с солью (pin_iterations итераций) в hex:
+	// EN: salt and hex PBKDF2-HMAC-SHA256 of PIN with salt (pin_iterations iterations):
+	// python3 -c 'import hashlib,sys; print(hashlib.pbkdf2_hmac("sha256", sys.argv[2].encode(), sys.argv[1].encode(), 100000).hex())' "$salt" "$pin"
+	Salt    string `hcl:"salt"`
+	PinHash string `hcl:"pin_hash"`
 }
 
 type TestsStruct struct {
@@ -91,4 +169,6 @@ type UIUser struct {
 	Lock                  bool
 	KeyboardReadEnable    bool
 	RemoteOrderInProgress bool
+	RemoteCookDone        chan<- error // результат удаленного приготовления (tele cook)
+	Locale                string       // выбранный клиентом язык, "" - Lang
 }
@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/AlexTransit/vender/hardware/mdb/evend"
+	"github.com/AlexTransit/vender/internal/metrics"
+)
+
+var (
+	metricSales       = metrics.Counter{Name: "vender_sales_total", Help: "sales by menu code and payment method"}
+	metricSalesAmount = metrics.Counter{Name: "vender_sales_amount_total", Help: "sales amount (minor currency units) by menu code and payment method"}
+	metricCookError   = metrics.Counter{Name: "vender_cook_error_total", Help: "failed cooks by menu code"}
+)
+
+func (ui *UI) collectMetrics(w *metrics.Writer) {
+	w.Gauge("vender_ui_state", "current ui state (types.UiState)", float64(ui.GetUiState()))
+	if since := ui.stateSince.Load(); since != 0 {
+		w.Gauge("vender_ui_state_seconds", "time in current ui state", time.Since(time.Unix(0, since)).Seconds())
+	}
+	if ui.ms != nil {
+		w.Gauge("vender_credit_amount", "current credit (minor currency units)", float64(ui.ms.GetCredit()))
+	}
+	if ui.g.Config.Hardware.Evend.Valve.TemperatureHot != 0 {
+		w.Gauge("vender_water_temperature", "last read hot water temperature", float64(evend.EValve.LastTemperature()))
+		w.Gauge("vender_water_temperature_target", "configured hot water temperature", float64(ui.g.Config.Hardware.Evend.Valve.TemperatureHot))
+	}
+}
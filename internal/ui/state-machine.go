@@ -5,6 +5,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/AlexTransit/vender/hardware/display"
 	"github.com/AlexTransit/vender/helpers"
 	"github.com/AlexTransit/vender/tele"
 	"github.com/juju/errors"
@@ -15,8 +16,12 @@ import (
 	"github.com/AlexTransit/vender/internal/watchdog"
 )
 
-func (ui *UI) State() types.UiState               { return types.UiState(atomic.LoadUint32((*uint32)(&ui.state))) }
-func (ui *UI) setState(new types.UiState)         { atomic.StoreUint32((*uint32)(&ui.state), uint32(new)) }
+func (ui *UI) State() types.UiState { return types.UiState(atomic.LoadUint32((*uint32)(&ui.state))) }
+func (ui *UI) setState(new types.UiState) {
+	if old := atomic.SwapUint32((*uint32)(&ui.state), uint32(new)); old != uint32(new) || ui.stateSince.Load() == 0 {
+		ui.stateSince.Store(time.Now().UnixNano())
+	}
+}
 func (ui *UI) XXX_testSetState(new types.UiState) { ui.setState(new) }
 
 func (ui *UI) Loop(ctx context.Context) {
@@ -85,6 +90,11 @@ func (ui *UI) enter(ctx context.Context, s types.UiState) types.UiState {
 		ui.g.Tele.RoboSendState(tele.State_Broken)
 		ui.g.RunBashSript(ui.g.Config.ScriptIfBroken)
 		ui.g.Log.Infof("state=broken")
+		if config_global.RemoteConfigTrial(ui.g.Config.RemoteConfig) { // new remote config, restart and rollback
+			ui.g.GlobalError = "broken with new remote config"
+			ui.g.VmcStopWOInitRequared(ctx)
+			return types.StateStop
+		}
 		if !ui.broken {
 			// ui.g.Tele.RoboSendState(tele_api.State_Broken)
 			if errs := ui.g.Engine.ExecList(ctx, "on_broken", ui.g.Config.Engine.OnBroken); len(errs) != 0 {
@@ -94,6 +104,7 @@ func (ui *UI) enter(ctx context.Context, s types.UiState) types.UiState {
 		}
 		ui.broken = true
 		ui.RefreshUserPresets()
+		ui.drawScreen(display.Screen{Kind: display.ScreenError, Lines: [2]string{ui.display.GetLine(1), ui.display.GetLine(2)}})
 		for ui.g.Alive.IsRunning() {
 			// e := ui.wait(5* time.Second)
 			e := ui.wait(ui.frontResetTimeout)
@@ -146,6 +157,8 @@ func (ui *UI) enter(ctx context.Context, s types.UiState) types.UiState {
 
 	case types.StateServiceBegin:
 		return ui.onServiceBegin(ctx)
+	case types.StateServiceAuth:
+		return ui.onServiceAuth()
 	case types.StateServiceMenu:
 		return ui.onServiceMenu()
 	case types.StateServiceInventory:
@@ -13,8 +13,3 @@ var ScaleAlpha = []byte{
 	0x97, // full
 	// '0', '1', '2', '3',
 }
-
-const (
-	// msgServiceInputAuth = "\x8d %s\x00"
-	msgServiceMenu = "Menu"
-)
@@ -1,8 +1,12 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/display"
 	"github.com/AlexTransit/vender/hardware/input"
 	config_global "github.com/AlexTransit/vender/internal/config"
 	"github.com/AlexTransit/vender/internal/sound"
@@ -12,23 +16,39 @@ import (
 )
 
 func (ui *UI) linesCreate(l1 *string, l2 *string, tuneScreen *bool) {
-	c := ui.ms.GetCredit()
+	c, method := ui.credit()
+	if c == currency.MaxAmount { // card balance unknown
+		c = 0
+	}
 	if c == 0 {
 		currentLine := ui.display.GetLine(1)
 		*l1 = currentLine
 	} else {
-		*l1 = ui.g.Config.UI_config.Front.MsgCredit + c.Format100I()
+		*l1 = ui.creditLine(c, method)
 	}
 	if len(ui.inputBuf) > 0 {
-		*l2 = fmt.Sprintf(ui.g.Config.UI_config.Front.MsgInputCode, string(ui.inputBuf))
-		*l1 = ui.g.Config.UI_config.Front.MsgCredit + c.Format100I()
+		*l2 = ui.sprintf(ui.front().MsgInputCode, string(ui.inputBuf))
+		*l1 = ui.creditLine(c, method)
 	} else {
 		*l2 = " "
 	}
 	*tuneScreen = false
+	ui.screen = display.Screen{Kind: display.ScreenMenu}
+}
+
+// credit line, msg_credit (msg_voucher_gift for gift only) with format or followed by credit
+func (ui *UI) creditLine(c currency.Amount, method tele_api.PaymentMethod) string {
+	msg := ui.front().MsgCredit
+	if method == tele_api.PaymentMethod_Gift && ui.front().MsgVoucherGift != "" {
+		msg = ui.front().MsgVoucherGift
+	}
+	if strings.Contains(msg, "%") {
+		return ui.sprintf(msg, c.Format100I())
+	}
+	return msg + c.Format100I()
 }
 
-func (ui *UI) parseKeyEvent(e types.Event, l1 *string, l2 *string, tuneScreen *bool, alive *alive.Alive) (nextState types.UiState) {
+func (ui *UI) parseKeyEvent(ctx context.Context, e types.Event, l1 *string, l2 *string, tuneScreen *bool, alive *alive.Alive) (nextState types.UiState) {
 	sound.PlayKeyBeep()
 	rm := tele_api.FromRoboMessage{}
 	defer func() {
@@ -43,7 +63,7 @@ func (ui *UI) parseKeyEvent(e types.Event, l1 *string, l2 *string, tuneScreen *b
 		if credit > 0 {
 			// FIXME alexm
 			sound.PlayFileNoWait("trash.mp3")
-			ui.display.SetLines("  :-(", fmt.Sprintf(" -%v", credit.Format100I()))
+			ui.setLines("  :-(", fmt.Sprintf(" -%v", credit.Format100I()))
 			err := ui.ms.ReturnMoney()
 			ui.g.Error(err)
 		}
@@ -59,7 +79,7 @@ func (ui *UI) parseKeyEvent(e types.Event, l1 *string, l2 *string, tuneScreen *b
 			ui.inputBuf = ui.inputBuf[:len(ui.inputBuf)-1]
 		}
 		if len(ui.inputBuf) == 0 {
-			if ui.ms.GetCredit() == 0 {
+			if ui.ms.GetCredit() == 0 && ui.ms.GetGiftCredit() == 0 {
 				return types.StateFrontEnd
 			}
 		}
@@ -74,6 +94,12 @@ func (ui *UI) parseKeyEvent(e types.Event, l1 *string, l2 *string, tuneScreen *b
 	if currentState != tele_api.State_Client {
 		rm.State = tele_api.State_Client
 	}
+	if ui.isLocaleKey(e.Input) {
+		*tuneScreen = false
+		*l1, *l2 = ui.switchLocale(), " "
+		ui.screen = display.Screen{Kind: display.ScreenMenu}
+		return types.StateDoesNotChange
+	}
 	if e.Input.IsTuneKey() {
 		*tuneScreen = true
 		*l1, *l2 = ui.tuneScreen(e.Input)
@@ -88,44 +114,60 @@ func (ui *UI) parseKeyEvent(e types.Event, l1 *string, l2 *string, tuneScreen *b
 		*tuneScreen = false
 		if len(ui.inputBuf) == 0 {
 			*l1 = ""
-			*l2 = ui.g.Config.UI_config.Front.MsgMenuCodeEmpty
+			*l2 = ui.front().MsgMenuCodeEmpty
+			return types.StateDoesNotChange
+		}
+		if ui.voucherLocaleKey() && string(ui.inputBuf) == ui.g.Config.UI_config.Front.LocaleKey && len(ui.g.Config.UI_config.Locales) != 0 {
+			ui.inputBuf = ui.inputBuf[:0]
+			*l1, *l2 = ui.switchLocale(), " "
+			ui.screen = display.Screen{Kind: display.ScreenMenu}
 			return types.StateDoesNotChange
 		}
+		if ui.isVoucher() {
+			return ui.redeemVoucher(ctx, l1, l2)
+		}
 		// var checkValidCode bool
 		// types.UI.FrontResult.Item, checkValidCode = types.UI.Menu[string(ui.inputBuf)]
 		mi, checkValidCode := config_global.GetMenuItem(string(ui.inputBuf))
 		if !checkValidCode {
-			*l1 = ui.g.Config.UI_config.Front.MsgMenuError
-			*l2 = ui.g.Config.UI_config.Front.MsgMenuCodeInvalid
+			ui.screen = display.Screen{Kind: display.ScreenError}
+			*l1 = ui.front().MsgMenuError
+			*l2 = ui.front().MsgMenuCodeInvalid
 			ui.inputBuf = []byte{}
 			return types.StateDoesNotChange
 		}
 		if mi.Doer == nil {
 			ui.g.Log.WarningF("validate menu:%v error: doer=nil", mi.Code)
-			*l1 = ui.g.Config.UI_config.Front.MsgMenuError
-			*l2 = ui.g.Config.UI_config.Front.MsgMenuNotAvailable
+			ui.screen = display.Screen{Kind: display.ScreenError}
+			*l1 = ui.front().MsgMenuError
+			*l2 = ui.front().MsgMenuNotAvailable
 			ui.inputBuf = []byte{}
 			return types.StateDoesNotChange
 		}
 		if err := mi.Doer.Validate(); err != nil {
 			ui.g.Log.WarningF("validate menu:%v error:%v", mi.Code, err)
-			*l1 = ui.g.Config.UI_config.Front.MsgMenuError
-			*l2 = ui.g.Config.UI_config.Front.MsgMenuNotAvailable
+			ui.screen = display.Screen{Kind: display.ScreenError}
+			*l1 = ui.front().MsgMenuError
+			*l2 = ui.front().MsgMenuNotAvailable
 			ui.inputBuf = []byte{}
 			return types.StateDoesNotChange
 		}
-		credit := ui.ms.GetCredit()
+		credit, method := ui.credit()
+		if credit != 0 {
+			config_global.VMC.User.PaymentMethod = method
+		}
 		config_global.VMC.User.SelectedItem = mi
 		if mi.Price > credit {
-			*l2 = fmt.Sprintf(ui.g.Config.UI_config.Front.MsgInputCode+" "+ui.g.Config.UI_config.Front.MsgPrice, mi.Code, mi.Price.Format100I())
+			front := ui.front()
+			*l2 = ui.sprintf(front.MsgInputCode+" "+front.MsgPrice, mi.Code, mi.Price.Format100I())
 			if credit == 0 {
 				*l1 = *ui.sendRequestForQrPayment(&rm)
 			} else {
-				*l1 = ui.g.Config.UI_config.Front.MsgMenuInsufficientCreditL1
+				*l1 = ui.front().MsgMenuInsufficientCreditL1
 			}
 			return types.StateDoesNotChange
 		}
-		if ui.ms.WaitEscrowAccept(mi.Price) {
+		if method == tele_api.PaymentMethod_Cash && ui.ms.WaitEscrowAccept(mi.Price) {
 			return types.StateDoesNotChange
 		}
 		return types.StateFrontAccept // success path
@@ -146,8 +188,8 @@ func (ui *UI) parseMoneyEvent(ek types.EventKind) types.UiState {
 		config_global.VMC.User.QrText = ""
 		config_global.VMC.User.DirtyMoney = 0
 	}
-	config_global.VMC.User.PaymentMethod = tele_api.PaymentMethod_Cash
-	credit := ui.ms.GetCredit()
+	credit, method := ui.credit()
+	config_global.VMC.User.PaymentMethod = method
 	price := config_global.VMC.User.SelectedItem.Price
 	if price != 0 && credit >= price && config_global.VMC.User.SelectedItem.Doer != nil {
 		// menu selected, almost paided and have item doer
@@ -163,3 +205,19 @@ func (ui *UI) parseMoneyEvent(ek types.EventKind) types.UiState {
 	}
 	return types.StateDoesNotChange
 }
+
+// credit available for the client and how it will be paid.
+// cash with gift first, gift only, card balance if cash is empty
+func (ui *UI) credit() (currency.Amount, tele_api.PaymentMethod) {
+	gift := ui.ms.GetGiftCredit()
+	if credit := ui.ms.GetCredit(); credit != 0 {
+		return credit + gift, tele_api.PaymentMethod_Cash
+	}
+	if gift != 0 {
+		return gift, tele_api.PaymentMethod_Gift
+	}
+	if funds := ui.ms.CashlessFunds(); funds != 0 {
+		return funds, tele_api.PaymentMethod_Cashless
+	}
+	return 0, tele_api.PaymentMethod_Cash
+}
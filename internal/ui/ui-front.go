@@ -6,9 +6,11 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/AlexTransit/vender/hardware/display"
 	"github.com/AlexTransit/vender/hardware/input"
 	"github.com/AlexTransit/vender/hardware/mdb/evend"
 	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/ledger"
 	menu_vmc "github.com/AlexTransit/vender/internal/menu"
 	"github.com/AlexTransit/vender/internal/menu/menu_config"
 	"github.com/AlexTransit/vender/internal/money"
@@ -50,10 +52,10 @@ func (ui *UI) checkTemperature() (correct bool, stateIfNotCorrect types.UiState)
 			return false, types.StateBroken
 		}
 		if curTemp < int32(ui.g.Config.Hardware.Evend.Valve.TemperatureHot-10) {
-			line2 := fmt.Sprintf(ui.g.Config.UI_config.Front.MsgWaterTemp, curTemp)
-			evend.Cup.LightOff() // light off
+			line2 := ui.sprintf(ui.front().MsgWaterTemp, curTemp)
+			evend.Cup.LightOff(context.Background()) // light off
 			if ui.display.GetLine(2) != line2 {
-				ui.display.SetLines(ui.g.Config.UI_config.Front.MsgWait, line2)
+				ui.setLines(ui.front().MsgWait, line2)
 				rm := tele_api.FromRoboMessage{
 					State: tele_api.State_TemperatureProblem,
 					RoboHardware: &tele_api.RoboHardware{
@@ -82,15 +84,24 @@ func (ui *UI) onFrontBegin(ctx context.Context) types.UiState {
 		ui.g.VmcStopWOInitRequared(ctx)
 		return types.StateStop
 	}
+	if config_global.ApplyRemoteConfig(ui.g.Log, ui.g.Config.RemoteConfig) { // new config from server
+		ui.g.GlobalError = "remote config update"
+		ui.g.VmcStopWOInitRequared(ctx)
+		return types.StateStop
+	}
 	if valid, nextState := ui.checkTemperature(); !valid {
 		return nextState
 	}
 	watchdog.Refresh()
-	credit := (ui.ms.GetCredit() - ui.ms.BillEscrow()) / 100
-	if credit != 0 {
-		ui.g.Log.Errorf("money timeout lost (%v)", credit)
+	if ui.ms.TakeRestoredCredit() { // credit restored after restart, client can continue
+		ui.g.Log.Infof("money credit restored (%v)", ui.ms.GetCredit().Format100I())
+	} else {
+		credit := (ui.ms.GetCredit() - ui.ms.BillEscrow()) / 100
+		if credit != 0 {
+			ui.g.Log.Errorf("money timeout lost (%v)", credit)
+		}
+		ui.ms.ResetMoney()
 	}
-	ui.ms.ResetMoney()
 
 	ui.g.ClientEnd(ctx)
 	runtime.GC() // чистка мусора в памяти
@@ -106,6 +117,7 @@ func (ui *UI) onFrontBegin(ctx context.Context) types.UiState {
 		return types.StateBroken
 
 	}
+	config_global.RemoteConfigCommit(ui.g.Log, ui.g.Config.RemoteConfig)
 	return types.StateFrontSelect
 }
 
@@ -117,6 +129,7 @@ func (ui *UI) RefreshUserPresets() {
 			Sugar: config_global.VMC.Engine.Menu.DefaultSugar,
 		},
 	}
+	ui.applyLocale()
 }
 
 func (ui *UI) onFrontSelect(ctx context.Context) types.UiState {
@@ -131,8 +144,9 @@ func (ui *UI) onFrontSelect(ctx context.Context) types.UiState {
 	// ui.g.Config.UI.Front.MsgStateIntro
 	l2 := ui.display.GetLine(2)
 	tuneScreen := false
+	ui.screen = display.Screen{Kind: display.ScreenMenu}
 	for {
-		ui.display.SetLines(l1, l2)
+		ui.setLines(l1, l2)
 		timeout := ui.frontResetTimeout
 		if tuneScreen {
 			timeout = modTuneTimeout
@@ -142,7 +156,7 @@ func (ui *UI) onFrontSelect(ctx context.Context) types.UiState {
 		case types.EventAccept:
 			return types.StateFrontAccept
 		case types.EventInput: // from keyboard
-			if nextState := ui.parseKeyEvent(e, &l1, &l2, &tuneScreen, alive); nextState != types.StateDoesNotChange {
+			if nextState := ui.parseKeyEvent(ctx, e, &l1, &l2, &tuneScreen, alive); nextState != types.StateDoesNotChange {
 				return nextState
 			}
 		case types.EventMoneyPreCredit, types.EventMoneyCredit: // from validators
@@ -182,7 +196,8 @@ func (ui *UI) sendRequestForQrPayment(rm *tele_api.FromRoboMessage) (message_for
 		if ui.g.Hardware.Display.Graphic != nil {
 			ui.g.Hardware.Display.Graphic.CopyFile2FB(ui.g.Config.UI_config.Front.PicQRPayError)
 		}
-		return &ui.g.Config.UI_config.Front.MsgNoNetwork
+		msg := ui.front().MsgNoNetwork
+		return &msg
 	}
 	config_global.VMC.User.UiState = uint32(types.StatePrepare)
 	// config_global.VMC.UIState(uint32(types.StatePrepare))
@@ -193,7 +208,8 @@ func (ui *UI) sendRequestForQrPayment(rm *tele_api.FromRoboMessage) (message_for
 		MenuCode:    config_global.VMC.User.SelectedItem.Code,
 		Amount:      uint32(config_global.VMC.User.SelectedItem.Price),
 	}
-	return &ui.g.Config.UI_config.Front.MsgRemotePayRequest
+	msg := ui.front().MsgRemotePayRequest
+	return &msg
 }
 
 func (ui *UI) onFrontTune(ctx context.Context) types.UiState {
@@ -222,13 +238,20 @@ func (ui *UI) tuneScreen(e types.InputEvent) (l1, l2 string) {
 	default:
 	}
 	var l2b [13]byte
+	front := ui.front()
 	switch e.Key {
 	case input.EvendKeyCreamLess, input.EvendKeyCreamMore:
-		l1 = fmt.Sprintf("%s  /%d", ui.g.Config.UI_config.Front.MsgCream, config_global.VMC.User.Cream)
+		l1 = fmt.Sprintf("%s  /%d", front.MsgCream, config_global.VMC.User.Cream)
 		l2b = createScale(config_global.VMC.User.Cream, config_global.CreamMax(), config_global.DefaultCream())
+		ui.screen = display.Screen{Kind: display.ScreenTune, Scale: display.Scale{
+			Name: front.MsgCream, Value: config_global.VMC.User.Cream, Max: config_global.CreamMax(), Default: config_global.DefaultCream(),
+		}}
 	case input.EvendKeySugarLess, input.EvendKeySugarMore:
-		l1 = fmt.Sprintf("%s  /%d", ui.g.Config.UI_config.Front.MsgSugar, config_global.VMC.User.Sugar)
+		l1 = fmt.Sprintf("%s  /%d", front.MsgSugar, config_global.VMC.User.Sugar)
 		l2b = createScale(config_global.VMC.User.Sugar, config_global.SugarMax(), config_global.DefaultSugar())
+		ui.screen = display.Screen{Kind: display.ScreenTune, Scale: display.Scale{
+			Name: front.MsgSugar, Value: config_global.VMC.User.Sugar, Max: config_global.SugarMax(), Default: config_global.DefaultSugar(),
+		}}
 	default:
 	}
 	l2 = string(l2b[:])
@@ -250,17 +273,41 @@ func (ui *UI) onFrontAccept(ctx context.Context) types.UiState {
 	ui.g.SendCooking()
 	moneysys := money.GetGlobal(ctx)
 	selected := config_global.VMC.User.SelectedItem.Code
+	remoteDone := config_global.VMC.User.RemoteCookDone // remote cook waits result
 	ui.g.Log.Infof("ui-front accepted code:%v cream:%v sugar:%v", selected, config_global.VMC.User.Cream, config_global.VMC.User.Sugar)
 
+	cashlessVend := false
 	// FIXME AlexM заглушка пока не переделал
-	if config_global.VMC.User.PaymentMethod == tele_api.PaymentMethod_Cash {
+	if method := config_global.VMC.User.PaymentMethod; method == tele_api.PaymentMethod_Cash || method == tele_api.PaymentMethod_Gift {
 		ui.g.Log.Debugf("ui-front selected=%s begin", selected)
 		if err := moneysys.WithdrawPrepare(ctx, config_global.VMC.User.SelectedItem.Price); err != nil {
 			ui.g.Log.Errorf("ui-front CRITICAL error while return change")
 		}
 	}
+	if config_global.VMC.User.PaymentMethod == tele_api.PaymentMethod_Cashless && moneysys.CashlessFunds() != 0 {
+		if err := moneysys.CashlessVend(ctx, config_global.VMC.User.SelectedItem.Price, selected); err != nil {
+			ui.g.Log.Errorf("ui-front cashless vend code:%s err:%v", selected, err)
+			ui.screen = display.Screen{Kind: display.ScreenError}
+			front := ui.front()
+			ui.setLines(front.MsgMenuInsufficientCreditL1, front.MsgRemotePayReject)
+			moneysys.CashlessSessionClose()
+			remoteCookEnd(remoteDone, err)
+			ui.RefreshUserPresets()
+			return types.StateFrontEnd
+		}
+		cashlessVend = true
+	}
 	watchdog.DevicesInitializationRequired()
-	err := menu_vmc.Cook(ctx)
+	stockBefore := ui.g.Inventory.Values()
+	cookCtx, cookDone := ui.g.JobContext(ctx) // service key, remote stop, shutdown interrupt cooking
+	cookCtx = ui.g.Tracer.Begin(cookCtx, "order code:"+selected)
+	progressDone := ui.showProgress(selected, config_global.VMC.User.SelectedItem.Name)
+	err := menu_vmc.Cook(cookCtx)
+	progressDone(err == nil)
+	ui.g.Tracer.End(cookCtx, err)
+	cookDone()
+	ui.writeLedger(moneysys, stockBefore, err)
+	remoteCookEnd(remoteDone, err)
 	rm := tele_api.FromRoboMessage{}
 	rm.Order = ui.g.OrderToMessage()
 	if ui.ms.GetDirty() == 0 { // order complete
@@ -268,6 +315,11 @@ func (ui *UI) onFrontAccept(ctx context.Context) types.UiState {
 	} else {
 		rm.Order.OrderStatus = tele_api.OrderStatus_orderError
 	}
+	if cashlessVend {
+		if e := moneysys.CashlessVendEnd(ctx, selected, err == nil); e != nil {
+			ui.g.Error(e)
+		}
+	}
 	defer ui.g.Tele.RoboSend(&rm)
 
 	if err == nil { // success path
@@ -288,6 +340,45 @@ func (ui *UI) onFrontAccept(ctx context.Context) types.UiState {
 	return types.StateBroken
 }
 
+// send result to waiting tele cook. remote order flags are cleared before,
+// so next FrontBegin refreshes presets even if tele goroutine is still replying
+func remoteCookEnd(done chan<- error, err error) {
+	if done == nil {
+		return
+	}
+	config_global.VMC.User.RemoteCookDone = nil
+	config_global.VMC.User.RemoteOrderInProgress = false
+	done <- err
+}
+
+// write sale to local ledger
+func (ui *UI) writeLedger(moneysys *money.MoneySystem, stockBefore map[string]float32, cookErr error) {
+	user := config_global.VMC.User
+	r := ledger.Record{
+		Time:          time.Now(),
+		Code:          user.SelectedItem.Code,
+		Cream:         user.Cream,
+		Sugar:         user.Sugar,
+		Price:         uint32(user.SelectedItem.Price),
+		PaymentMethod: user.PaymentMethod.String(),
+		Stock:         ledger.StockSpent(stockBefore, ui.g.Inventory.Values()),
+	}
+	if user.PaymentMethod == tele_api.PaymentMethod_Cash {
+		w := moneysys.LastWithdraw()
+		r.Bills, r.Coins, r.Change = w.Bills, w.Coins, uint32(w.Change)
+	}
+	if cookErr != nil {
+		r.Error = cookErr.Error()
+		ui.g.Metrics.Add(metricCookError, 1, "code", r.Code)
+	} else {
+		ui.g.Metrics.Add(metricSales, 1, "code", r.Code, "method", r.PaymentMethod)
+		ui.g.Metrics.Add(metricSalesAmount, float64(r.Price), "code", r.Code, "method", r.PaymentMethod)
+	}
+	if err := ui.g.Ledger.Write(r); err != nil {
+		ui.g.Log.Errorf("ledger write err=%v", err)
+	}
+}
+
 func TuneValueToByte(currentValue uint8, defaultValue uint8) []byte {
 	if currentValue == defaultValue {
 		return nil
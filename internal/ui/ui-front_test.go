@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware"
+	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/engine"
+	menu_config "github.com/AlexTransit/vender/internal/menu/menu_config"
+	"github.com/AlexTransit/vender/internal/money"
+	state_new "github.com/AlexTransit/vender/internal/state/new"
+	"github.com/AlexTransit/vender/internal/tele"
+	"github.com/AlexTransit/vender/internal/types"
+	"github.com/AlexTransit/vender/internal/watchdog"
+	"github.com/AlexTransit/vender/log2"
+	tele_api "github.com/AlexTransit/vender/tele"
+	tele_config "github.com/AlexTransit/vender/tele/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// tele transport, command responses go to channel and block until read
+type transportCook struct {
+	onCommand tele.CommandCallback
+	replies   chan tele_api.CookReplay
+}
+
+func (tr *transportCook) Init(ctx context.Context, log *log2.Log, teleConfig tele_config.Config, onCommand tele.CommandCallback, messageForRobot tele.CommandCallback) error {
+	tr.onCommand = onCommand
+	return nil
+}
+func (tr *transportCook) SendState(payload []byte) bool     { return true }
+func (tr *transportCook) SendTelemetry(payload []byte) bool { return true }
+func (tr *transportCook) SendFromRobot(payload []byte)      {}
+func (tr *transportCook) CloseTele()                        {}
+func (tr *transportCook) RoboConnected() bool               { return false }
+func (tr *transportCook) SendCommandResponse(topicSuffix string, payload []byte) bool {
+	r := tele_api.Response{}
+	if err := proto.Unmarshal(payload, &r); err != nil {
+		panic(err)
+	}
+	tr.replies <- r.CookReplay
+	return true
+}
+
+// remote cook result is replied after UI moved on, next FrontBegin must not inherit remote order
+func TestRemoteCookPresets(t *testing.T) {
+	ctx, g := state_new.NewTestContext(t, "", "")
+	dir := t.TempDir()
+	g.Config.Inventory.File = dir + "/inventory"
+	g.Config.Hardware.HD44780.Enable = false
+	g.Config.Hardware.Evend.Valve.TemperatureHot = 0
+	g.Config.Watchdog.Disabled = true
+	g.Config.Watchdog.Folder = dir + "/"
+	watchdog.Init(g.Config, g.Log, 0)
+	require.NoError(t, hardware.InitMDBDevices(ctx))
+	ms := &money.MoneySystem{}
+	require.NoError(t, ms.Start(ctx))
+
+	tr := &transportCook{replies: make(chan tele_api.CookReplay)}
+	g.Tele = tele.NewWithTransporter(tr)
+	require.NoError(t, g.Tele.Init(ctx, g.Log, tele_config.Config{Enabled: true}, "test"))
+	g.Tele.RoboSendState(tele_api.State_Nominal)
+
+	ui := &UI{g: g, ms: ms, eventch: make(chan types.Event), display: g.MustTextDisplay()}
+	g.XXX_uier.Store(types.UIer(ui))
+	saveVMC := config_global.VMC
+	defer func() { config_global.VMC = saveVMC }()
+	config_global.VMC.Engine.Menu = menu_config.MenuStruct{Items: map[string]menu_config.MenuItem{
+		"1": {Code: "1", Name: "tea", Price: 500, Doer: engine.Func{F: func(context.Context) error { return fmt.Errorf("no water") }}},
+	}}
+	ui.RefreshUserPresets()
+
+	cmd := tele_api.Command{
+		Executer: 77,
+		Task: &tele_api.Command_Cook{Cook: &tele_api.Command_ArgCook{
+			Menucode:      "1",
+			Balance:       1000,
+			PaymentMethod: tele_api.PaymentMethod_Balance,
+		}},
+	}
+	payload, err := proto.Marshal(&cmd)
+	require.NoError(t, err)
+	tr.onCommand(ctx, payload)
+	assert.Equal(t, tele_api.CookReplay_cookStart, <-tr.replies)
+	e := <-ui.eventch
+	require.Equal(t, types.EventAccept, e.Kind)
+	require.True(t, config_global.VMC.User.RemoteOrderInProgress)
+
+	// tele goroutine is blocked replying cookError, UI goes on
+	assert.Equal(t, types.StateBroken, ui.onFrontAccept(ctx))
+	assert.False(t, config_global.VMC.User.RemoteOrderInProgress)
+	assert.Nil(t, config_global.VMC.User.RemoteCookDone)
+	ui.onFrontBegin(ctx)
+	user := config_global.VMC.User
+	assert.Equal(t, tele_api.PaymentMethod_Nothing, user.PaymentMethod)
+	assert.Equal(t, int64(0), user.PaymenId)
+	assert.Equal(t, currency.Amount(0), user.DirtyMoney)
+	assert.Equal(t, "", user.SelectedItem.Code)
+	assert.Nil(t, user.RemoteCookDone)
+
+	select {
+	case r := <-tr.replies:
+		assert.Equal(t, tele_api.CookReplay_cookError, r)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no cook result reply")
+	}
+}
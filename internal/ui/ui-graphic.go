@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AlexTransit/vender/hardware/display"
+	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/types"
+	tele_api "github.com/AlexTransit/vender/tele"
+)
+
+// графический интерфейс рисуется там же где пишется текстовый дисплей (setLines).
+// вид экрана выбирается состоянием UI и ui.screen, строки текстового дисплея - заголовок и подвал.
+
+const progressTick = 500 * time.Millisecond
+
+// setLines text display and graphic screen
+func (ui *UI) setLines(l1, l2 string) {
+	ui.display.SetLines(l1, l2)
+	ui.draw(l1, l2)
+}
+
+func (ui *UI) draw(l1, l2 string) {
+	if ui.graphic == nil {
+		return
+	}
+	s := display.Screen{Kind: display.ScreenText}
+	switch ui.State() {
+	case types.StateFrontSelect, types.StateFrontTune, types.StateFrontAccept:
+		s = ui.screen
+	}
+	s.Lines = [2]string{l1, l2}
+	if qr := config_global.VMC.User.QrText; qr != "" && ui.g.Tele.GetState() == tele_api.State_WaitingForExternalPayment {
+		s.Kind, s.QR = display.ScreenQR, qr
+	}
+	if s.Kind == display.ScreenMenu {
+		s.Credit, _ = ui.credit()
+		s.Input = string(ui.inputBuf)
+		s.Menu = graphicMenu()
+	}
+	ui.drawScreen(s)
+}
+
+func (ui *UI) drawScreen(s display.Screen) {
+	if ui.graphic == nil {
+		return
+	}
+	if err := ui.graphic.Draw(s); err != nil {
+		ui.g.Log.Errorf("graphic ui err=%v", err)
+	}
+}
+
+// menu items by code, disabled items are not shown
+func graphicMenu() []display.MenuItem {
+	items := make([]display.MenuItem, 0, len(config_global.VMC.Engine.Menu.Items))
+	for _, mi := range config_global.VMC.Engine.Menu.Items {
+		if mi.Disabled {
+			continue
+		}
+		items = append(items, display.MenuItem{
+			Code:      mi.Code,
+			Name:      mi.Name,
+			Price:     config_global.MenuPrice(&mi),
+			Available: mi.Doer != nil && mi.Doer.Validate() == nil,
+		})
+	}
+	sort.Slice(items, func(a, b int) bool {
+		na, ea := strconv.Atoi(items[a].Code)
+		nb, eb := strconv.Atoi(items[b].Code)
+		if ea == nil && eb == nil {
+			return na < nb
+		}
+		return items[a].Code < items[b].Code
+	})
+	return items
+}
+
+// showProgress draws cooking progress until returned func is called.
+// expected time - last cooking of this item or progress_sec
+func (ui *UI) showProgress(code, name string) (stop func(ok bool)) {
+	if ui.graphic == nil {
+		return func(bool) {}
+	}
+	expect, ok := ui.cookTime[code]
+	if !ok {
+		expect = time.Duration(ui.g.Config.Hardware.Display.Graphic.ProgressSec) * time.Second
+		if expect <= 0 {
+			expect = 30 * time.Second
+		}
+	}
+	begin := time.Now()
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		tmr := time.NewTicker(progressTick)
+		defer tmr.Stop()
+		for {
+			p := float64(time.Since(begin)) / float64(expect)
+			if p > 0.99 {
+				p = 0.99 // 100% only when cooked
+			}
+			ui.drawScreen(display.Screen{
+				Kind:     display.ScreenProgress,
+				Lines:    [2]string{name, ui.front().MsgWait},
+				Progress: float64(int(p*100)) / 100,
+			})
+			select {
+			case <-done:
+				return
+			case <-tmr.C:
+			}
+		}
+	}()
+	return func(ok bool) {
+		close(done)
+		<-finished
+		if ok {
+			ui.cookTime[code] = time.Since(begin)
+			ui.drawScreen(display.Screen{Kind: display.ScreenProgress, Lines: [2]string{name, ""}, Progress: 1})
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/types"
+	ui_config "github.com/AlexTransit/vender/internal/ui/config"
+)
+
+// язык клиента хранится в VMC.User и сбрасывается вместе с ним (RefreshUserPresets, reset_sec).
+// переключается клавишей front.locale_key при пустом коде напитка
+// (locale_key и Accept, если с этой клавиши начинается префикс промокода).
+
+// front messages of customer language
+func (ui *UI) front() ui_config.FrontStruct {
+	return ui.g.Config.UI_config.LocaleFront(config_global.VMC.User.Locale)
+}
+
+// sprintf message format with plural forms of customer language
+func (ui *UI) sprintf(format string, args ...any) string {
+	return ui.g.Config.UI_config.Sprintf(config_global.VMC.User.Locale, format, args...)
+}
+
+func (ui *UI) isLocaleKey(e types.InputEvent) bool {
+	key := ui.g.Config.UI_config.Front.LocaleKey
+	return len(ui.g.Config.UI_config.Locales) != 0 && len(ui.inputBuf) == 0 &&
+		len(key) == 1 && e.Key == types.InputKey(key[0]) && !ui.voucherLocaleKey()
+}
+
+// switchLocale next customer language, returns language title
+func (ui *UI) switchLocale() string {
+	config_global.VMC.User.Locale = ui.g.Config.UI_config.NextLocale(config_global.VMC.User.Locale)
+	ui.g.Log.Infof("ui locale=%s", config_global.VMC.User.Locale)
+	ui.applyLocale()
+	return ui.g.Config.UI_config.LocaleTitle(config_global.VMC.User.Locale)
+}
+
+// applyLocale text display characters of customer language
+func (ui *UI) applyLocale() {
+	ui.display.SetCharMap(ui.g.Config.UI_config.CharMap(config_global.VMC.User.Locale))
+}
@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AlexTransit/vender/hardware/input"
+	"github.com/AlexTransit/vender/internal/types"
+	ui_config "github.com/AlexTransit/vender/internal/ui/config"
+	tele_api "github.com/AlexTransit/vender/tele"
+)
+
+// вход в сервисное меню по PIN техника. в конфиге хранится соль и PBKDF2-SHA256(PIN, соль, pin_iterations).
+// после auth_max_fail неверных PIN подряд вход блокируется на auth_lockout_sec.
+// счетчик и время блокировки хранятся в auth_file, перезапуск не снимает блокировку.
+// сессия и действия техника пишутся в лог и отправляются в tele (ServiceAudit).
+
+const pinMaxLen = 12
+
+type serviceAuth struct {
+	fails       int
+	lockedUntil time.Time
+	file        string // durable fails and lockedUntil, empty - memory only
+	msg         string // second line until next key
+	session     bool   // service menu entered, end is reported
+}
+
+// auth_file content
+type serviceAuthState struct {
+	Fails       int       `json:"fails"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// PinHash hex PBKDF2-HMAC-SHA256 of PIN with salt, technician pin_hash.
+// slow by iterations, PIN has few digits
+func PinHash(salt, pin string, iterations int) string {
+	h, err := pbkdf2.Key(sha256.New, pin, []byte(salt), iterations, sha256.Size)
+	if err != nil { // never matches
+		return ""
+	}
+	return hex.EncodeToString(h)
+}
+
+// technician by PIN
+func checkPin(technicians map[string]ui_config.TechnicianStruct, pin string, iterations int) (string, bool) {
+	names := make([]string, 0, len(technicians))
+	for name := range technicians {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := technicians[name]
+		if subtle.ConstantTimeCompare([]byte(PinHash(t.Salt, pin, iterations)), []byte(strings.ToLower(t.PinHash))) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// locked time left
+func (a *serviceAuth) locked(now time.Time) time.Duration {
+	if left := a.lockedUntil.Sub(now); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// load state saved before restart, missing file is clean state
+func (a *serviceAuth) load(file string) error {
+	a.file = file
+	if file == "" {
+		return nil
+	}
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var s serviceAuthState
+	if err = json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	a.fails, a.lockedUntil = s.Fails, s.LockedUntil
+	return nil
+}
+
+// save state: temp file, sync, rename
+func (a *serviceAuth) save() error {
+	if a.file == "" {
+		return nil
+	}
+	b, err := json.Marshal(serviceAuthState{Fails: a.fails, LockedUntil: a.lockedUntil})
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(a.file), 0o755); err != nil {
+		return err
+	}
+	tmp := a.file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, a.file)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// fail wrong PIN, true if login locked now. maxFail 0 - no lockout
+func (a *serviceAuth) fail(now time.Time, maxFail int, lockout time.Duration) bool {
+	a.fails++
+	if maxFail <= 0 || a.fails < maxFail {
+		return false
+	}
+	a.fails = 0
+	a.lockedUntil = now.Add(lockout)
+	return true
+}
+
+func (ui *UI) onServiceAuth() types.UiState {
+	cfg := &ui.g.Config.UI_config.Service
+	if left := ui.Service.auth.locked(time.Now()); left > 0 {
+		ui.setLines(cfg.MsgLocked, fmt.Sprintf("%.0f sec", left.Seconds()))
+		ui.wait(left)
+		return types.StateServiceEnd
+	}
+	ui.setLines(fmt.Sprintf(cfg.MsgInputPin, strings.Repeat("*", len(ui.inputBuf))), ui.Service.auth.msg)
+
+	next, e := ui.serviceWaitInput()
+	if next != types.StateDefault {
+		ui.inputBuf = ui.inputBuf[:0]
+		return next
+	}
+	ui.Service.auth.msg = ""
+	switch {
+	case e.IsDigit():
+		if len(ui.inputBuf) < pinMaxLen {
+			ui.inputBuf = append(ui.inputBuf, byte(e.Key))
+		}
+
+	case input.IsReject(&e):
+		if len(ui.inputBuf) == 0 {
+			return types.StateServiceEnd
+		}
+		ui.inputBuf = ui.inputBuf[:len(ui.inputBuf)-1]
+
+	case input.IsAccept(&e):
+		pin := string(ui.inputBuf)
+		ui.inputBuf = ui.inputBuf[:0]
+		if name, ok := checkPin(cfg.Technicians, pin, cfg.PinIterations); ok {
+			if ui.Service.auth.fails != 0 {
+				ui.Service.auth.fails = 0
+				ui.saveServiceAuth()
+			}
+			ui.Service.technician = name
+			ui.Service.auth.session = true
+			ui.serviceAudit(tele_api.ServiceAudit_sessionBegin, "")
+			return types.StateServiceMenu
+		}
+		ui.serviceAudit(tele_api.ServiceAudit_authFail, fmt.Sprintf("fail=%d", ui.Service.auth.fails+1))
+		lockout := time.Duration(cfg.AuthLockoutSec) * time.Second
+		locked := ui.Service.auth.fail(time.Now(), cfg.AuthMaxFail, lockout)
+		ui.saveServiceAuth()
+		if locked {
+			ui.serviceAudit(tele_api.ServiceAudit_lockout, lockout.String())
+			return types.StateServiceAuth
+		}
+		ui.Service.auth.msg = cfg.MsgPinFail
+	}
+	return types.StateServiceAuth
+}
+
+func (ui *UI) saveServiceAuth() {
+	if err := ui.Service.auth.save(); err != nil {
+		ui.g.Log.Errorf("service auth file(%s) err=%v", ui.Service.auth.file, err)
+	}
+}
+
+// serviceAudit technician action to log and tele
+func (ui *UI) serviceAudit(action tele_api.ServiceAudit_Action, detail string) {
+	ui.g.Log.Infof("service audit technician=%s action=%s %s", ui.Service.technician, action, detail)
+	ui.g.Tele.RoboSend(&tele_api.FromRoboMessage{
+		RoboTime: time.Now().Unix(),
+		ServiceAudit: &tele_api.ServiceAudit{
+			Technician: ui.Service.technician,
+			Action:     action,
+			Detail:     detail,
+		},
+	})
+}
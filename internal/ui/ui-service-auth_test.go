@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ui_config "github.com/AlexTransit/vender/internal/ui/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPin(t *testing.T) {
+	t.Parallel()
+
+	// python3 -c 'import hashlib; print(hashlib.pbkdf2_hmac("sha256", b"1234", b"salt1", 100000).hex())'
+	assert.Equal(t, "d7d4bd315ad785cab021acb3e9321539ae6f3f2fd8a9cf61779107a5535430c7", PinHash("salt1", "1234", 100000))
+	const iter = 1000 // fast test
+	technicians := map[string]ui_config.TechnicianStruct{
+		"alex": {Name: "alex", Salt: "salt1", PinHash: PinHash("salt1", "1234", iter)},
+		"bob":  {Name: "bob", Salt: "salt2", PinHash: PinHash("salt2", "1234", iter)},
+		"ivan": {Name: "ivan", Salt: "x", PinHash: "F51CFCF687851827D5C8E0D4865F147B7A71F512A7F8BD50ADA82FE5A3214C8F"}, // upper case
+	}
+	name, ok := checkPin(technicians, "1234", iter)
+	assert.True(t, ok)
+	assert.Equal(t, "alex", name, "same PIN, first by name")
+	name, ok = checkPin(technicians, "", iter)
+	assert.True(t, ok)
+	assert.Equal(t, "ivan", name)
+	_, ok = checkPin(technicians, "4321", iter)
+	assert.False(t, ok)
+	_, ok = checkPin(technicians, "1234", iter+1)
+	assert.False(t, ok, "other iterations")
+	_, ok = checkPin(nil, "1234", iter)
+	assert.False(t, ok)
+}
+
+func TestServiceAuthLockout(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+	a := serviceAuth{}
+	assert.False(t, a.fail(now, 3, time.Minute))
+	assert.False(t, a.fail(now, 3, time.Minute))
+	assert.Equal(t, time.Duration(0), a.locked(now))
+	assert.True(t, a.fail(now, 3, time.Minute))
+	assert.Equal(t, time.Minute, a.locked(now))
+	assert.Equal(t, 20*time.Second, a.locked(now.Add(40*time.Second)))
+	assert.Equal(t, time.Duration(0), a.locked(now.Add(time.Minute)))
+	assert.Equal(t, 0, a.fails, "counter starts again after lockout")
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, a.fail(now, 0, time.Minute), "no lockout")
+	}
+}
+
+// restart must not reset lockout
+func TestServiceAuthFile(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "auth", "service-auth.json")
+	now := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+	a := serviceAuth{}
+	require.NoError(t, a.load(file), "missing file")
+	assert.False(t, a.fail(now, 3, time.Minute))
+	require.NoError(t, a.save())
+	b := serviceAuth{}
+	require.NoError(t, b.load(file))
+	assert.Equal(t, 1, b.fails)
+
+	assert.False(t, b.fail(now, 3, time.Minute))
+	assert.True(t, b.fail(now, 3, time.Minute))
+	require.NoError(t, b.save())
+	c := serviceAuth{}
+	require.NoError(t, c.load(file))
+	assert.Equal(t, 0, c.fails)
+	assert.Equal(t, 30*time.Second, c.locked(now.Add(30*time.Second)))
+
+	require.NoError(t, os.WriteFile(file, []byte("{"), 0o644))
+	assert.Error(t, c.load(file))
+	require.NoError(t, (&serviceAuth{}).load(""))
+}
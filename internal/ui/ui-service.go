@@ -50,8 +50,10 @@ type uiService struct { //nolint:maligned
 	menuIdx      uint8
 	invIdx       uint8
 	// invList   []*inventory.Stock
-	testIdx  uint8
-	testList []engine.Doer
+	testIdx    uint8
+	testList   []engine.Doer
+	technician string // logged in by PIN, actions are attributed to
+	auth       serviceAuth
 }
 
 func (ui *uiService) Init(ctx context.Context) {
@@ -69,6 +71,9 @@ func (ui *uiService) Init(ctx context.Context) {
 	if err := helpers.FoldErrors(errs); err != nil {
 		g.Log.Fatal(err)
 	}
+	if err := ui.auth.load(config.AuthFile); err != nil {
+		g.Log.Errorf("service auth file(%s) err=%v", config.AuthFile, err)
+	}
 }
 
 func (ui *UI) onServiceBegin(ctx context.Context) types.UiState {
@@ -82,13 +87,18 @@ func (ui *UI) onServiceBegin(ctx context.Context) types.UiState {
 
 	ui.g.Log.Debugf("ui service begin")
 	ui.g.Tele.RoboSendState(tele_api.State_Service)
+	if len(ui.g.Config.UI_config.Service.Technicians) != 0 {
+		return types.StateServiceAuth
+	}
+	ui.Service.auth.session = true
+	ui.serviceAudit(tele_api.ServiceAudit_sessionBegin, "")
 	return types.StateServiceMenu
 }
 
 func (ui *UI) onServiceMenu() types.UiState {
 	menuName := serviceMenu[ui.Service.menuIdx]
-	ui.display.SetLines(
-		msgServiceMenu,
+	ui.setLines(
+		ui.g.Config.UI_config.Service.MsgMenu,
 		fmt.Sprintf("%d %s", ui.Service.menuIdx+1, menuName),
 	)
 
@@ -139,13 +149,13 @@ func (ui *UI) onServiceMenu() types.UiState {
 
 func (ui *UI) onServiceInventory() types.UiState {
 	if len(ui.g.Inventory.Stocks) == 0 {
-		ui.display.SetLine(1, "inv empty") // FIXME extract message string)
+		ui.display.SetLine(1, ui.g.Config.UI_config.Service.MsgInvEmpty)
 		ui.serviceWaitInput()
 		return types.StateServiceMenu
 	}
 	s := ui.g.Inventory.Stocks[ui.Service.invIdx]
 	if s.Ingredient == nil {
-		ui.display.SetLines("inv invalid", fmt.Sprintf("%d %s", s.Code, s.Label))
+		ui.setLines(ui.g.Config.UI_config.Service.MsgInvInvalid, fmt.Sprintf("%d %s", s.Code, s.Label))
 		next, e := ui.serviceWaitInput()
 		if next != types.StateDefault {
 			return next
@@ -163,13 +173,13 @@ func (ui *UI) onServiceInventory() types.UiState {
 	}
 	if ui.Service.invByLevel {
 		// l1 := fmt.Sprintf("%.0f %s\x00", s.Value(), iname)
-		ui.display.SetLines(
+		ui.setLines(
 			fmt.Sprintf("%.0f %s", s.Value(), s.Ingredient.Name),
 			fmt.Sprintf("%d Lev:%s %s", s.Code, s.ShowLevel(), string(ui.inputBuf)), // TODO configurable decimal point
 		)
 	} else {
 		// l2 := fmt.Sprintf("%s %s", s.ShowLevel(), iname)
-		ui.display.SetLines(
+		ui.setLines(
 			fmt.Sprintf("%s %s", s.ShowLevel(), s.Ingredient.Name),
 			fmt.Sprintf("%d Val:%.0f %s", s.Code, s.Value(), string(ui.inputBuf)), // TODO configurable decimal point
 		)
@@ -183,7 +193,7 @@ func (ui *UI) onServiceInventory() types.UiState {
 	switch {
 	case e.Key == input.EvendKeyCreamLess || e.Key == input.EvendKeyCreamMore:
 		if len(ui.inputBuf) != 0 {
-			ui.display.SetLine(2, "set or clear?") // FIXME extract message string
+			ui.display.SetLine(2, ui.g.Config.UI_config.Service.MsgSetOrClear)
 			ui.serviceWaitInput()
 			return types.StateServiceInventory
 		}
@@ -200,7 +210,7 @@ func (ui *UI) onServiceInventory() types.UiState {
 	case input.IsAccept(&e):
 		if len(ui.inputBuf) == 0 {
 			ui.g.Log.WarningF("ui onServiceInventory input=accept inputBuf=empty")
-			ui.display.SetLine(2, "empty") // FIXME extract message string
+			ui.display.SetLine(2, ui.g.Config.UI_config.Service.MsgEmpty)
 			ui.serviceWaitInput()
 			return types.StateServiceInventory
 		}
@@ -209,7 +219,7 @@ func (ui *UI) onServiceInventory() types.UiState {
 		x := int(xt * 100)
 		if err != nil {
 			ui.g.Log.WarningF("ui onServiceInventory input=accept inputBuf='%s'", string(ui.inputBuf))
-			ui.display.SetLine(2, "number-invalid") // FIXME extract message string
+			ui.display.SetLine(2, ui.g.Config.UI_config.Service.MsgNumberInvalid)
 			ui.serviceWaitInput()
 			return types.StateServiceInventory
 		}
@@ -223,6 +233,8 @@ func (ui *UI) onServiceInventory() types.UiState {
 		} else {
 			ui.g.Inventory.Stocks[ui.Service.invIdx].Set(float32(x) / 100)
 		}
+		stock := &ui.g.Inventory.Stocks[ui.Service.invIdx]
+		ui.serviceAudit(tele_api.ServiceAudit_inventorySet, fmt.Sprintf("stock=%d %s value=%.0f", stock.Code, stock.Label, stock.Value()))
 		ui.Service.askReport = true
 		// invCurrent.TeleLow = false
 
@@ -240,13 +252,13 @@ func (ui *UI) onServiceInventory() types.UiState {
 func (ui *UI) onServiceTest(ctx context.Context) types.UiState {
 	ui.inputBuf = ui.inputBuf[:0]
 	if len(ui.Service.testList) == 0 {
-		ui.g.MustTextDisplay().SetLine(1, "no tests") // FIXME extract message string
+		ui.g.MustTextDisplay().SetLine(1, ui.g.Config.UI_config.Service.MsgNoTests)
 		ui.serviceWaitInput()
 		return types.StateServiceMenu
 	}
 	testCurrent := ui.Service.testList[ui.Service.testIdx]
 	line1 := fmt.Sprintf("T%d %s", ui.Service.testIdx+1, testCurrent.String())
-	ui.display.SetLines(line1, "")
+	ui.setLines(line1, "")
 
 wait:
 	next, e := ui.serviceWaitInput()
@@ -262,12 +274,17 @@ wait:
 		ui.Service.testIdx = addWrap(ui.Service.testIdx, testIdxMax, +1)
 
 	case input.IsAccept(&e):
-		ui.display.SetLines(line1, "in progress")
-		if err := ui.g.Engine.ValidateExec(ctx, testCurrent); err == nil {
-			ui.display.SetLines(line1, "OK")
+		ui.setLines(line1, ui.g.Config.UI_config.Service.MsgInProgress)
+		testCtx, done := ui.g.JobContext(ctx)
+		err := ui.g.Engine.ValidateExec(testCtx, testCurrent)
+		done()
+		if err == nil {
+			ui.serviceAudit(tele_api.ServiceAudit_test, testCurrent.String()+" OK")
+			ui.setLines(line1, ui.g.Config.UI_config.Service.MsgOK)
 		} else {
+			ui.serviceAudit(tele_api.ServiceAudit_test, fmt.Sprintf("%s error=%v", testCurrent.String(), err))
 			ui.g.Error(err)
-			ui.display.SetLines(line1, "error")
+			ui.setLines(line1, ui.g.Config.UI_config.Service.MsgError)
 		}
 		goto wait
 
@@ -278,7 +295,7 @@ wait:
 }
 
 func (ui *UI) onServiceReboot(ctx context.Context) types.UiState {
-	ui.display.SetLines("for reboot", "press 1") // FIXME extract message string
+	ui.setLines(ui.g.Config.UI_config.Service.MsgRebootAsk, ui.g.Config.UI_config.Service.MsgPress1)
 
 	next, e := ui.serviceWaitInput()
 	if next != types.StateDefault {
@@ -287,7 +304,8 @@ func (ui *UI) onServiceReboot(ctx context.Context) types.UiState {
 
 	switch {
 	case e.Key == '1':
-		ui.display.SetLines("reboot", "in progress") // FIXME extract message string
+		ui.setLines(ui.g.Config.UI_config.Service.MsgReboot, ui.g.Config.UI_config.Service.MsgInProgress)
+		ui.serviceAudit(tele_api.ServiceAudit_reboot, "")
 		ui.g.GlobalError = "reboot from menu"
 		ui.g.VmcStop(ctx)
 		return types.StateStop
@@ -296,7 +314,7 @@ func (ui *UI) onServiceReboot(ctx context.Context) types.UiState {
 }
 
 func (ui *UI) onServiceNetwork() types.UiState {
-	ui.display.SetLines("for select net 0", "press 1") // FIXME extract message string
+	ui.setLines(ui.g.Config.UI_config.Service.MsgNetworkAsk, ui.g.Config.UI_config.Service.MsgPress1)
 
 	next, e := ui.serviceWaitInput()
 	if next != types.StateDefault {
@@ -305,7 +323,8 @@ func (ui *UI) onServiceNetwork() types.UiState {
 
 	switch {
 	case e.Key == '1':
-		ui.display.SetLines("wifi restart", "in progress") // FIXME extract message string
+		ui.setLines(ui.g.Config.UI_config.Service.MsgNetwork, ui.g.Config.UI_config.Service.MsgInProgress)
+		ui.serviceAudit(tele_api.ServiceAudit_network, "wifi restart")
 
 		// lsCmd := exec.Command("bash", "-c", "wpa_cli select_network 0 && wpa_cli enable_network 1")
 		lsCmd := exec.Command("bash", "-c", "wpa_cli select_network 0")
@@ -331,22 +350,24 @@ func (ui *UI) onServiceNetwork() types.UiState {
 
 func (ui *UI) onServiceMoneyLoad(ctx context.Context) types.UiState {
 	if ui.ms.CoinValidator == nil {
-		ui.display.SetLines("coin offline", "")
+		ui.setLines(ui.g.Config.UI_config.Service.MsgCoinOffline, "")
 		ui.serviceWaitInput()
 		return types.StateServiceMenu
 	}
-	ui.ms.TestingDispense()
+	ui.ms.TestingDispense(ctx)
 	alive := alive.NewAlive()
 	defer func() {
 		alive.Stop() // stop pending AcceptCredit
 		alive.Wait()
+		l1, l2 := ui.ShowCountCoins()
+		ui.serviceAudit(tele_api.ServiceAudit_moneyLoad, fmt.Sprintf("loaded=%s tubes=%s%s", ui.ms.GetCredit().Format100I(), l1, l2))
 		ui.ms.ResetMoney()
 	}()
 	alive.Add(2)
 	ui.Service.askReport = true
 	go ui.ms.AcceptCredit(ctx, 500000, alive, ui.eventch)
 	for {
-		ui.display.SetLines(ui.ShowCountCoins())
+		ui.setLines(ui.ShowCountCoins())
 		switch e := ui.wait(ui.Service.resetTimeout); e.Kind {
 		case types.EventInput:
 			if e.Input.Source == "money" {
@@ -381,6 +402,8 @@ func (ui *UI) onServiceReport(ctx context.Context) types.UiState {
 	_ = ui.g.Tele.Report(ctx, true)
 	if errs := ui.g.Engine.ExecList(ctx, "service-report", []string{"money.cashbox_zero"}); len(errs) != 0 {
 		ui.g.Error(errors.Annotate(helpers.FoldErrors(errs), "service-report"))
+	} else {
+		ui.serviceAudit(tele_api.ServiceAudit_cashboxZero, "")
 	}
 	return types.StateServiceMenu
 }
@@ -390,13 +413,19 @@ func (ui *UI) onServiceEnd(ctx context.Context) types.UiState {
 	ui.inputBuf = ui.inputBuf[:0]
 
 	if ui.Service.askReport {
-		ui.display.SetLines("for tele report", "press 1") // FIXME extract message string
+		ui.setLines(ui.g.Config.UI_config.Service.MsgReportAsk, ui.g.Config.UI_config.Service.MsgPress1)
 		if e := ui.wait(ui.Service.resetTimeout); e.Kind == types.EventInput && e.Input.Key == '1' {
 			ui.Service.askReport = false
 			ui.onServiceReport(ctx)
 		}
 	}
 
+	if ui.Service.auth.session {
+		ui.serviceAudit(tele_api.ServiceAudit_sessionEnd, "")
+	}
+	ui.Service.auth.session = false
+	ui.Service.technician = ""
+
 	if errs := ui.g.Engine.ExecList(ctx, "on_service_end", ui.g.Config.Engine.OnServiceEnd); len(errs) != 0 {
 		ui.g.Error(errors.Annotate(helpers.FoldErrors(errs), "on_service_end"))
 		return types.StateBroken
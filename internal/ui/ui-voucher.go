@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/AlexTransit/vender/hardware/display"
+	config_global "github.com/AlexTransit/vender/internal/config"
+	"github.com/AlexTransit/vender/internal/types"
+	"github.com/AlexTransit/vender/internal/voucher"
+	tele_api "github.com/AlexTransit/vender/tele"
+	"github.com/juju/errors"
+)
+
+// промокод набирается на клавиатуре после voucher.prefix и подтверждается Accept.
+// кредит промокода - подарок (gift credit), бесплатный напиток готовится сразу с оплатой Gift.
+// если префикс начинается с locale_key, язык переключается нажатием locale_key и Accept.
+
+func (ui *UI) isVoucher() bool {
+	return ui.g.Voucher != nil && len(ui.inputBuf) != 0 && strings.HasPrefix(string(ui.inputBuf), ui.g.Voucher.Prefix())
+}
+
+// locale key is first key of voucher prefix
+func (ui *UI) voucherLocaleKey() bool {
+	key := ui.g.Config.UI_config.Front.LocaleKey
+	return ui.g.Voucher != nil && key != "" && strings.HasPrefix(ui.g.Voucher.Prefix(), key)
+}
+
+func (ui *UI) redeemVoucher(ctx context.Context, l1 *string, l2 *string) types.UiState {
+	code := strings.TrimPrefix(string(ui.inputBuf), ui.g.Voucher.Prefix())
+	ui.inputBuf = ui.inputBuf[:0]
+	front := ui.front()
+	v, err := ui.g.Voucher.Verify(code)
+	if err == nil && v.Kind == voucher.KindItem {
+		err = ui.voucherItem(v)
+	}
+	if err == nil {
+		// не записанный промокод можно использовать повторно, поэтому не принимается
+		if err = ui.g.Voucher.Use(v); err != nil && !errors.Is(err, voucher.ErrUsed) {
+			ui.g.Log.Errorf("voucher %s not saved (%v)", v, err)
+		}
+	}
+	if err != nil {
+		ui.g.Log.Infof("voucher rejected %s (%v)", v, err)
+		ui.screen = display.Screen{Kind: display.ScreenError}
+		*l1 = front.MsgMenuError
+		switch {
+		case errors.Is(err, voucher.ErrLocked):
+			*l2 = ui.sprintf(front.MsgVoucherLocked, ui.g.Voucher.Locked().Seconds())
+		case errors.Is(err, voucher.ErrExpired):
+			*l2 = front.MsgVoucherExpired
+		case errors.Is(err, voucher.ErrUsed):
+			*l2 = front.MsgVoucherUsed
+		case errors.Is(err, errMenuNotAvailable):
+			*l2 = front.MsgMenuNotAvailable
+		default:
+			*l2 = front.MsgVoucherInvalid
+		}
+		return types.StateDoesNotChange
+	}
+
+	ui.g.Log.Infof("voucher accepted %s", v)
+	go ui.g.Tele.RoboSend(&tele_api.FromRoboMessage{
+		RoboTime: time.Now().Unix(),
+		Voucher: &tele_api.Voucher{
+			Serial: v.Serial,
+			Kind:   tele_api.Voucher_Kind(v.Kind),
+			Value:  v.Value,
+			Until:  v.Until.Unix(),
+		},
+	})
+	gift := ui.ms.GetGiftCredit()
+	if v.Kind == voucher.KindItem {
+		config_global.VMC.User.PaymentMethod = tele_api.PaymentMethod_Gift
+		ui.ms.SetGiftCredit(ctx, gift+config_global.VMC.User.SelectedItem.Price)
+		return types.StateFrontAccept
+	}
+	ui.ms.SetGiftCredit(ctx, gift+ui.g.Config.ScaleU(v.Value))
+	c, method := ui.credit()
+	config_global.VMC.User.PaymentMethod = method
+	ui.screen = display.Screen{Kind: display.ScreenMenu}
+	*l1, *l2 = ui.creditLine(c, method), " "
+	return types.StateDoesNotChange
+}
+
+var errMenuNotAvailable = errors.New("menu not available")
+
+// voucherItem selects free menu item, voucher is not used if item is not available
+func (ui *UI) voucherItem(v voucher.Voucher) error {
+	mi, ok := config_global.GetMenuItem(v.MenuCode())
+	if !ok || mi.Doer == nil {
+		return errors.NotFoundf("voucher menu code=%s", v.MenuCode())
+	}
+	if err := mi.Doer.Validate(); err != nil {
+		ui.g.Log.WarningF("voucher validate menu:%v error:%v", mi.Code, err)
+		return errMenuNotAvailable
+	}
+	config_global.VMC.User.SelectedItem = mi
+	return nil
+}
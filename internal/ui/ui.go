@@ -2,9 +2,11 @@ package ui
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/AlexTransit/vender/currency"
+	"github.com/AlexTransit/vender/hardware/display"
 	"github.com/AlexTransit/vender/hardware/input"
 	"github.com/AlexTransit/vender/hardware/text_display"
 	"github.com/AlexTransit/vender/helpers"
@@ -21,8 +23,12 @@ type UI struct { //nolint:maligned
 	g             *state.Global
 	ms            *money.MoneySystem
 	state         types.UiState
+	stateSince    atomic.Int64 // unix nano of last state change, metrics
 	broken        bool
 	display       *text_display.TextDisplay // FIXME
+	graphic       *display.Renderer         // nil without graphic UI
+	screen        display.Screen            // front screen drawn by setLines
+	cookTime      map[string]time.Duration  // last cooking by menu code, progress
 	inputBuf      []byte
 	eventch       chan types.Event
 	inputch       chan types.InputEvent
@@ -63,6 +69,8 @@ func (ui *UI) Init(ctx context.Context) error {
 	ui.g.Log.Debugf("menu len=%d", len(config_global.VMC.Engine.Menu.Items))
 
 	ui.display = ui.g.MustTextDisplay()
+	ui.graphic = ui.g.Hardware.Display.Renderer
+	ui.cookTime = make(map[string]time.Duration)
 	ui.eventch = make(chan types.Event)
 	ui.inputBuf = make([]byte, 0, 32)
 	if ui.g.Hardware.Input == nil {
@@ -73,6 +81,7 @@ func (ui *UI) Init(ctx context.Context) error {
 	ui.frontResetTimeout = helpers.IntSecondDefault(ui.g.Config.UI_config.Front.ResetTimeoutSec, 0)
 	ui.Service.Init(ctx)
 	ui.ms = money.GetGlobal(ctx)
+	ui.g.Metrics.Collect(ui.collectMetrics)
 	ui.g.XXX_uier.Store(types.UIer(ui)) // FIXME import cycle traded for pointer cycle
 	return nil
 }
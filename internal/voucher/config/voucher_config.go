@@ -0,0 +1,23 @@
+package voucher_config
+
+type Config struct {
+	// RU: префикс промокода на клавиатуре. отличает промокод от кода напитка.
+	// EN: voucher code prefix on keypad. distinguishes voucher from menu code.
+	Prefix string `hcl:"prefix,optional"`
+	// RU: открытый ключ Ed25519 (base64) для проверки подписи. в автомате нет секрета, но код 172 цифры.
+	// EN: Ed25519 public key (base64) to verify signature. no secret on machine, but code is 172 digits.
+	Ed25519PublicKey string `hcl:"ed25519_public_key,optional"`
+	// RU: секрет HMAC-SHA256 для коротких кодов. подпись обрезается до hmac_digits цифр.
+	// RU: не открытый ключ: кто может прочитать конфиг, тот может выпускать промокоды.
+	// EN: HMAC-SHA256 secret for short codes. signature is truncated to hmac_digits digits.
+	// EN: not a public key: anyone who can read the config can issue vouchers.
+	HmacKey    string `hcl:"hmac_key,optional"`
+	HmacDigits int    `hcl:"hmac_digits,optional"`
+	// RU: файл использованных промокодов. одна строка JSON на промокод.
+	// EN: used vouchers file. one JSON line per voucher.
+	File string `hcl:"file,optional"`
+	// RU: неверных промокодов подряд до блокировки ввода и время блокировки в секундах.
+	// EN: wrong codes in a row before lockout and lockout time in seconds.
+	MaxFail    int `hcl:"max_fail,optional"`
+	LockoutSec int `hcl:"lockout_sec,optional"`
+}
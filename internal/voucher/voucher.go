@@ -0,0 +1,370 @@
+// Package voucher offline promo codes typed on keypad.
+//
+// код только из цифр (клавиатура автомата), после префикса:
+//
+//	K VVVV YYMMDD SSSSSS подпись
+//
+// K=1 - кредит VVVV (как money.set_gift_credit), K=2 - бесплатный напиток с кодом меню VVVV.
+// YYMMDD - последний день действия, SSSSSS - номер промокода, каждый номер принимается один раз.
+// подпись: Ed25519 десятичным числом (155 цифр) или HMAC-SHA256 обрезанный до hmac_digits цифр.
+// Ed25519 - открытый ключ, но код 172 цифры, на клавиатуре практичен только HMAC.
+// HMAC - общий секрет в конфиге автомата: кто его прочитал, тот может выпускать промокоды.
+// использованные номера пишутся в файл и отправляются в tele.
+package voucher
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	voucher_config "github.com/AlexTransit/vender/internal/voucher/config"
+	"github.com/juju/errors"
+)
+
+const (
+	payloadLen        = 17
+	ed25519Digits     = 155 // 2^512 < 10^155
+	defaultHmacDigits = 10
+	signPrefix        = "vender-voucher:"
+	untilLayout       = "060102"
+)
+
+var (
+	ErrSignature = errors.Unauthorizedf("voucher signature invalid")
+	ErrExpired   = errors.New("voucher expired")
+	ErrUsed      = errors.New("voucher already used")
+	ErrLocked    = errors.New("voucher input locked")
+	ErrTorn      = errors.New("voucher used file last record torn, skipped")
+)
+
+type Kind uint8
+
+const (
+	KindCredit Kind = 1 // gift credit
+	KindItem   Kind = 2 // free menu item
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindCredit:
+		return "credit"
+	case KindItem:
+		return "item"
+	}
+	return "kind" + strconv.Itoa(int(k))
+}
+
+type Voucher struct {
+	Kind   Kind
+	Value  uint32    // credit (money scale) or menu code
+	Until  time.Time // last day, local time
+	Serial uint32
+}
+
+func (v Voucher) Payload() string {
+	return fmt.Sprintf("%d%04d%s%06d", v.Kind, v.Value, v.Until.Format(untilLayout), v.Serial)
+}
+
+// MenuCode of free item
+func (v Voucher) MenuCode() string { return strconv.FormatUint(uint64(v.Value), 10) }
+
+// Expired after last day
+func (v Voucher) Expired(now time.Time) bool {
+	y, m, d := v.Until.Date()
+	return !now.Before(time.Date(y, m, d+1, 0, 0, 0, 0, time.Local))
+}
+
+func (v Voucher) String() string {
+	return fmt.Sprintf("serial=%d %s=%d until=%s", v.Serial, v.Kind, v.Value, v.Until.Format("2006-01-02"))
+}
+
+func parsePayload(s string) (Voucher, error) {
+	if len(s) != payloadLen || strings.Trim(s, "0123456789") != "" {
+		return Voucher{}, errors.NotValidf("voucher code")
+	}
+	value, _ := strconv.ParseUint(s[1:5], 10, 32)
+	serial, _ := strconv.ParseUint(s[11:17], 10, 32)
+	until, err := time.ParseInLocation(untilLayout, s[5:11], time.Local)
+	if err != nil {
+		return Voucher{}, errors.NotValidf("voucher date")
+	}
+	v := Voucher{Kind: Kind(s[0] - '0'), Value: uint32(value), Until: until, Serial: uint32(serial)}
+	if (v.Kind != KindCredit && v.Kind != KindItem) || v.Value == 0 {
+		return Voucher{}, errors.NotValidf("voucher %s", v.Kind)
+	}
+	return v, nil
+}
+
+func hmacDigits(payload string, key []byte, digits int) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signPrefix + payload))
+	n := binary.BigEndian.Uint64(mac.Sum(nil)[:8])
+	if digits < 19 {
+		mod := uint64(1)
+		for i := 0; i < digits; i++ {
+			mod *= 10
+		}
+		n %= mod
+	}
+	return fmt.Sprintf("%0*d", digits, n)
+}
+
+// SignHmac voucher code with short HMAC signature. server side and vender voucher sign
+func SignHmac(v Voucher, key []byte, digits int) string {
+	if digits <= 0 {
+		digits = defaultHmacDigits
+	}
+	return v.Payload() + hmacDigits(v.Payload(), key, digits)
+}
+
+// SignEd25519 voucher code with Ed25519 signature. server side and vender voucher sign
+func SignEd25519(v Voucher, key ed25519.PrivateKey) string {
+	sig := new(big.Int).SetBytes(ed25519.Sign(key, []byte(signPrefix+v.Payload()))).String()
+	return v.Payload() + strings.Repeat("0", ed25519Digits-len(sig)) + sig
+}
+
+// Record used voucher, line of file
+type Record struct {
+	Time   time.Time `json:"time"`
+	Serial uint32    `json:"serial"`
+	Kind   Kind      `json:"kind"`
+	Value  uint32    `json:"value"`
+	Until  string    `json:"until"`
+}
+
+type Vouchers struct {
+	mu          sync.Mutex
+	config      voucher_config.Config
+	hmacKey     []byte
+	pubKey      ed25519.PublicKey
+	used        map[uint32]time.Time
+	fails       int
+	lockedUntil time.Time
+	torn        bool // used file ends with torn record, rewrite on next Use
+	now         func() time.Time
+}
+
+// New nil when vouchers are disabled (no key).
+// torn last record of used file is skipped, vouchers are returned with ErrTorn warning
+func New(c voucher_config.Config) (*Vouchers, error) {
+	if c.HmacKey == "" && c.Ed25519PublicKey == "" {
+		return nil, nil
+	}
+	vs := &Vouchers{config: c, used: make(map[uint32]time.Time), now: time.Now}
+	if vs.config.HmacDigits <= 0 {
+		vs.config.HmacDigits = defaultHmacDigits
+	}
+	if c.HmacKey != "" {
+		vs.hmacKey = []byte(c.HmacKey)
+	}
+	if c.Ed25519PublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.Ed25519PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, errors.NotValidf("voucher ed25519_public_key")
+		}
+		vs.pubKey = key
+	}
+	records, err := ReadUsed(c.File)
+	vs.torn = errors.Is(err, ErrTorn)
+	if err != nil && !vs.torn {
+		return nil, errors.Annotate(err, "voucher used file")
+	}
+	for _, r := range records {
+		vs.used[r.Serial] = r.Time
+	}
+	return vs, err
+}
+
+func (vs *Vouchers) Prefix() string { return vs.config.Prefix }
+
+// Locked wrong codes lockout time left
+func (vs *Vouchers) Locked() time.Duration {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if left := vs.lockedUntil.Sub(vs.now()); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Verify code without prefix: signature, last day, not used.
+// wrong code counts to lockout, voucher is not used until Use
+func (vs *Vouchers) Verify(code string) (Voucher, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	now := vs.now()
+	if now.Before(vs.lockedUntil) {
+		return Voucher{}, ErrLocked
+	}
+	v, err := vs.verify(code)
+	if err != nil {
+		vs.fails++
+		if vs.config.MaxFail > 0 && vs.fails >= vs.config.MaxFail {
+			vs.fails = 0
+			vs.lockedUntil = now.Add(time.Duration(vs.config.LockoutSec) * time.Second)
+		}
+		return Voucher{}, err
+	}
+	vs.fails = 0
+	if v.Expired(now) {
+		return v, ErrExpired
+	}
+	if _, used := vs.used[v.Serial]; used {
+		return v, ErrUsed
+	}
+	return v, nil
+}
+
+func (vs *Vouchers) verify(code string) (Voucher, error) {
+	if len(code) < payloadLen {
+		return Voucher{}, errors.NotValidf("voucher code")
+	}
+	payload, sig := code[:payloadLen], code[payloadLen:]
+	v, err := parsePayload(payload)
+	if err != nil {
+		return v, err
+	}
+	switch {
+	case vs.hmacKey != nil && len(sig) == vs.config.HmacDigits:
+		if hmac.Equal([]byte(hmacDigits(payload, vs.hmacKey, len(sig))), []byte(sig)) {
+			return v, nil
+		}
+	case vs.pubKey != nil && len(sig) == ed25519Digits:
+		n, ok := new(big.Int).SetString(sig, 10)
+		if ok && n.Sign() >= 0 && n.BitLen() <= ed25519.SignatureSize*8 &&
+			ed25519.Verify(vs.pubKey, []byte(signPrefix+payload), n.FillBytes(make([]byte, ed25519.SignatureSize))) {
+			return v, nil
+		}
+	}
+	return v, ErrSignature
+}
+
+// Use write voucher to file and mark it used.
+// voucher must not be accepted on error
+func (vs *Vouchers) Use(v Voucher) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if _, used := vs.used[v.Serial]; used {
+		return ErrUsed
+	}
+	r := Record{Time: vs.now(), Serial: v.Serial, Kind: v.Kind, Value: v.Value, Until: v.Until.Format("2006-01-02")}
+	if err := vs.write(r); err != nil {
+		return errors.Annotate(err, "voucher used file")
+	}
+	vs.used[v.Serial] = r.Time
+	return nil
+}
+
+func (vs *Vouchers) write(r Record) error {
+	if vs.config.File == "" {
+		return nil
+	}
+	if vs.torn {
+		return vs.rewrite(r)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(vs.config.File), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(vs.config.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_SYNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rewrite used file without torn record: temp file, sync, rename
+func (vs *Vouchers) rewrite(r Record) error {
+	records, err := ReadUsed(vs.config.File)
+	if err != nil && !errors.Is(err, ErrTorn) {
+		return err
+	}
+	var buf []byte
+	for _, x := range append(records, r) {
+		b, err := json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+	tmp := vs.config.File + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf)
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, vs.config.File)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	vs.torn = false
+	return nil
+}
+
+// ReadUsed records of used vouchers file, missing file is empty.
+// malformed last line (power loss while writing) is skipped with ErrTorn
+func ReadUsed(file string) ([]Record, error) {
+	if file == "" {
+		return nil, nil
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records := []Record{}
+	var bad error
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		if bad != nil { // malformed line is not last
+			return records, bad
+		}
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			bad = fmt.Errorf("%s:%d (%v)", file, line, err)
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := sc.Err(); err != nil {
+		return records, err
+	}
+	if bad != nil {
+		return records, fmt.Errorf("%w %v", ErrTorn, bad)
+	}
+	return records, nil
+}
@@ -0,0 +1,147 @@
+package voucher
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	voucher_config "github.com/AlexTransit/vender/internal/voucher/config"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoucherHmac(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "used.jsonl")
+	cfg := voucher_config.Config{HmacKey: "secret", File: file, MaxFail: 3, LockoutSec: 60}
+	vs, err := New(cfg)
+	require.NoError(t, err)
+	now := time.Date(2026, 5, 6, 10, 0, 0, 0, time.Local)
+	vs.now = func() time.Time { return now }
+
+	v := Voucher{Kind: KindCredit, Value: 50, Until: time.Date(2026, 5, 6, 0, 0, 0, 0, time.Local), Serial: 42}
+	code := SignHmac(v, []byte("secret"), 0)
+	assert.Len(t, code, payloadLen+defaultHmacDigits)
+	assert.Equal(t, "10050260506000042", code[:payloadLen])
+
+	got, err := vs.Verify(code)
+	require.NoError(t, err)
+	assert.Equal(t, v, got)
+	require.NoError(t, vs.Use(got))
+	_, err = vs.Verify(code)
+	assert.Equal(t, ErrUsed, err)
+	assert.Equal(t, ErrUsed, vs.Use(got))
+
+	// used serials are kept in file
+	vs2, err := New(cfg)
+	require.NoError(t, err)
+	vs2.now = vs.now
+	_, err = vs2.Verify(code)
+	assert.Equal(t, ErrUsed, err)
+	records, err := ReadUsed(file)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, Record{Time: records[0].Time, Serial: 42, Kind: KindCredit, Value: 50, Until: "2026-05-06"}, records[0])
+
+	item := Voucher{Kind: KindItem, Value: 12, Until: v.Until, Serial: 43}
+	now = now.Add(14 * time.Hour) // next day
+	_, err = vs.Verify(SignHmac(item, []byte("secret"), 10))
+	assert.Equal(t, ErrExpired, err)
+	now = now.Add(-time.Hour)
+	got, err = vs.Verify(SignHmac(item, []byte("secret"), 10))
+	require.NoError(t, err)
+	assert.Equal(t, "12", got.MenuCode())
+
+	// wrong codes lock input
+	for _, bad := range []string{SignHmac(item, []byte("other"), 10), "123", SignHmac(item, []byte("secret"), 8)} {
+		_, err = vs.Verify(bad)
+		assert.Error(t, err, bad)
+	}
+	assert.Equal(t, time.Minute, vs.Locked())
+	_, err = vs.Verify(SignHmac(item, []byte("secret"), 10))
+	assert.Equal(t, ErrLocked, err)
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), vs.Locked())
+	_, err = vs.Verify(SignHmac(item, []byte("secret"), 10))
+	assert.NoError(t, err)
+}
+
+func TestVoucherEd25519(t *testing.T) {
+	t.Parallel()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	vs, err := New(voucher_config.Config{Ed25519PublicKey: base64.StdEncoding.EncodeToString(pub)})
+	require.NoError(t, err)
+
+	v := Voucher{Kind: KindItem, Value: 3, Until: time.Now().AddDate(0, 1, 0), Serial: 999999}
+	v.Until = time.Date(v.Until.Year(), v.Until.Month(), v.Until.Day(), 0, 0, 0, 0, time.Local)
+	code := SignEd25519(v, priv)
+	assert.Len(t, code, payloadLen+ed25519Digits)
+	got, err := vs.Verify(code)
+	require.NoError(t, err)
+	assert.Equal(t, v, got)
+
+	// hmac code is not accepted without hmac key
+	_, err = vs.Verify(SignHmac(v, []byte("secret"), 10))
+	assert.Equal(t, ErrSignature, err)
+	bad := []byte(code)
+	bad[len(bad)-1] = '0' + (bad[len(bad)-1]-'0'+1)%10
+	_, err = vs.Verify(string(bad))
+	assert.Equal(t, ErrSignature, err)
+	_, err = vs.Verify("3" + code[1:])
+	assert.Error(t, err, "unknown kind")
+}
+
+func TestVoucherDisabled(t *testing.T) {
+	t.Parallel()
+
+	vs, err := New(voucher_config.Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, vs)
+	_, err = New(voucher_config.Config{Ed25519PublicKey: "short"})
+	assert.Error(t, err)
+}
+
+func TestVoucherUsedFile(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "used.jsonl")
+	good := `{"time":"2026-05-06T10:00:00Z","serial":42,"kind":1,"value":50,"until":"2026-05-06"}`
+	cfg := voucher_config.Config{HmacKey: "secret", File: file}
+	until := time.Now().AddDate(0, 0, 1)
+
+	// power loss while writing last record
+	require.NoError(t, os.WriteFile(file, []byte(good+"\n"+`{"time":"2026-05`), 0o644))
+	vs, err := New(cfg)
+	assert.True(t, errors.Is(err, ErrTorn))
+	require.NotNil(t, vs)
+	_, err = vs.Verify(SignHmac(Voucher{Kind: KindCredit, Value: 50, Until: until, Serial: 42}, []byte("secret"), 0))
+	assert.Equal(t, ErrUsed, err)
+	require.NoError(t, vs.Use(Voucher{Kind: KindCredit, Value: 10, Until: until, Serial: 44}))
+	// file rewritten without torn record
+	_, err = New(cfg)
+	require.NoError(t, err)
+	records, err := ReadUsed(file)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, uint32(44), records[1].Serial)
+
+	// malformed record in the middle
+	require.NoError(t, os.WriteFile(file, []byte("garbage\n"+good+"\n"), 0o644))
+	_, err = New(cfg)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrTorn))
+
+	// not written voucher is not used
+	vs.config.File = filepath.Join(file, "not-dir")
+	v := Voucher{Kind: KindCredit, Value: 10, Until: until, Serial: 45}
+	assert.Error(t, vs.Use(v))
+	_, err = vs.Verify(SignHmac(v, []byte("secret"), 0))
+	assert.NoError(t, err)
+}
@@ -13,6 +13,40 @@ type Config struct { //nolint:maligned
 	MqttPassword   string `hcl:"mqtt_password,optional"` // secret
 	StorePath      string `hcl:"store_path,optional"`
 
+	// TLS for mqtts:// ssl:// wss:// brokers (and https for transport=http)
+	TlsCaFile     string `hcl:"tls_ca_file,optional"`     // PEM CA bundle, empty = system roots
+	TlsCertFile   string `hcl:"tls_cert_file,optional"`   // PEM client certificate
+	TlsKeyFile    string `hcl:"tls_key_file,optional"`    // PEM client key
+	TlsServerName string `hcl:"tls_server_name,optional"` // expected server certificate name
+
+	Transport          string `hcl:"transport,optional"` // mqtt (default) | http
+	HttpUrl            string `hcl:"http_url,optional"`  // base url for transport=http
+	HttpPollTimeoutSec int    `hcl:"http_poll_timeout_sec,optional"`
+
+	CookTimeoutSec int `hcl:"cook_timeout_sec,optional"` // remote cook result wait, default 300
+
 	NetworkRestartTimeout int    `hcl:"network_restart_timeout_sec,optional"`
 	NetworkRestartScript  string `hcl:"network_restart_script,optional"`
+
+	CommandAuth CommandAuthStruct `hcl:"command_auth,block"`
+}
+
+// signed remote commands and executer permissions
+type CommandAuthStruct struct {
+	Enabled   bool             `hcl:"enable,optional"`      // require signature and check executer ACL
+	MaxAgeSec int              `hcl:"max_age_sec,optional"` // command timestamp window, default 300
+	Executer  []ExecuterStruct `hcl:"executer,block"`
+}
+
+// signature checked with key of Command.executer, so one executer can not sign as another
+type ExecuterStruct struct {
+	Id               string   `hcl:"id,label"`                    // Command.executer
+	HmacKey          string   `hcl:"hmac_key,optional"`           // secret, HMAC-SHA256
+	Ed25519PublicKey string   `hcl:"ed25519_public_key,optional"` // base64
+	Exec             bool     `hcl:"exec,optional"`               // may run scenarios
+	ScenarioPrefix   []string `hcl:"scenario_prefix,optional"`    // allowed prefixes of every scenario action, empty = any
+	BypassLock       bool     `hcl:"bypass_lock,optional"`        // may use "_" prefix to ignore client lock
+	Config           bool     `hcl:"config,optional"`             // may send remote config (set_config)
+	Inventory        bool     `hcl:"inventory,optional"`          // may set stock levels (set_inventory)
+	Cook             bool     `hcl:"cook,optional"`               // may start remote cook
 }
@@ -423,6 +423,7 @@ const (
 	MessageType_executeCommand MessageType = 3
 	MessageType_reportStock    MessageType = 4
 	MessageType_reportState    MessageType = 5
+	MessageType_reportMargin   MessageType = 6 // command = "[-since date] [-until date] [-period day|week|month]"
 )
 
 // Enum value maps for MessageType.
@@ -434,6 +435,7 @@ var (
 		3: "executeCommand",
 		4: "reportStock",
 		5: "reportState",
+		6: "reportMargin",
 	}
 	MessageType_value = map[string]int32{
 		"invalid":        0,
@@ -442,6 +444,7 @@ var (
 		"executeCommand": 3,
 		"reportStock":    4,
 		"reportState":    5,
+		"reportMargin":   6,
 	}
 )
 
@@ -472,6 +475,128 @@ func (MessageType) EnumDescriptor() ([]byte, []int) {
 	return file_tele_proto_rawDescGZIP(), []int{6}
 }
 
+type Voucher_Kind int32
+
+const (
+	Voucher_invalid Voucher_Kind = 0
+	Voucher_credit  Voucher_Kind = 1
+	Voucher_item    Voucher_Kind = 2
+)
+
+// Enum value maps for Voucher_Kind.
+var (
+	Voucher_Kind_name = map[int32]string{
+		0: "invalid",
+		1: "credit",
+		2: "item",
+	}
+	Voucher_Kind_value = map[string]int32{
+		"invalid": 0,
+		"credit":  1,
+		"item":    2,
+	}
+)
+
+func (x Voucher_Kind) Enum() *Voucher_Kind {
+	p := new(Voucher_Kind)
+	*p = x
+	return p
+}
+
+func (x Voucher_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Voucher_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_tele_proto_enumTypes[7].Descriptor()
+}
+
+func (Voucher_Kind) Type() protoreflect.EnumType {
+	return &file_tele_proto_enumTypes[7]
+}
+
+func (x Voucher_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Voucher_Kind.Descriptor instead.
+func (Voucher_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_tele_proto_rawDescGZIP(), []int{5, 0}
+}
+
+type ServiceAudit_Action int32
+
+const (
+	ServiceAudit_invalid      ServiceAudit_Action = 0
+	ServiceAudit_sessionBegin ServiceAudit_Action = 1
+	ServiceAudit_sessionEnd   ServiceAudit_Action = 2
+	ServiceAudit_authFail     ServiceAudit_Action = 3
+	ServiceAudit_lockout      ServiceAudit_Action = 4 // too many wrong PIN
+	ServiceAudit_inventorySet ServiceAudit_Action = 5
+	ServiceAudit_moneyLoad    ServiceAudit_Action = 6
+	ServiceAudit_test         ServiceAudit_Action = 7
+	ServiceAudit_cashboxZero  ServiceAudit_Action = 8
+	ServiceAudit_reboot       ServiceAudit_Action = 9
+	ServiceAudit_network      ServiceAudit_Action = 10
+)
+
+// Enum value maps for ServiceAudit_Action.
+var (
+	ServiceAudit_Action_name = map[int32]string{
+		0:  "invalid",
+		1:  "sessionBegin",
+		2:  "sessionEnd",
+		3:  "authFail",
+		4:  "lockout",
+		5:  "inventorySet",
+		6:  "moneyLoad",
+		7:  "test",
+		8:  "cashboxZero",
+		9:  "reboot",
+		10: "network",
+	}
+	ServiceAudit_Action_value = map[string]int32{
+		"invalid":      0,
+		"sessionBegin": 1,
+		"sessionEnd":   2,
+		"authFail":     3,
+		"lockout":      4,
+		"inventorySet": 5,
+		"moneyLoad":    6,
+		"test":         7,
+		"cashboxZero":  8,
+		"reboot":       9,
+		"network":      10,
+	}
+)
+
+func (x ServiceAudit_Action) Enum() *ServiceAudit_Action {
+	p := new(ServiceAudit_Action)
+	*p = x
+	return p
+}
+
+func (x ServiceAudit_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ServiceAudit_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_tele_proto_enumTypes[8].Descriptor()
+}
+
+func (ServiceAudit_Action) Type() protoreflect.EnumType {
+	return &file_tele_proto_enumTypes[8]
+}
+
+func (x ServiceAudit_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ServiceAudit_Action.Descriptor instead.
+func (ServiceAudit_Action) EnumDescriptor() ([]byte, []int) {
+	return file_tele_proto_rawDescGZIP(), []int{6, 0}
+}
+
 type ShowQR_QRType int32
 
 const (
@@ -511,11 +636,11 @@ func (x ShowQR_QRType) String() string {
 }
 
 func (ShowQR_QRType) Descriptor() protoreflect.EnumDescriptor {
-	return file_tele_proto_enumTypes[7].Descriptor()
+	return file_tele_proto_enumTypes[9].Descriptor()
 }
 
 func (ShowQR_QRType) Type() protoreflect.EnumType {
-	return &file_tele_proto_enumTypes[7]
+	return &file_tele_proto_enumTypes[9]
 }
 
 func (x ShowQR_QRType) Number() protoreflect.EnumNumber {
@@ -524,7 +649,7 @@ func (x ShowQR_QRType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ShowQR_QRType.Descriptor instead.
 func (ShowQR_QRType) EnumDescriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{7, 0}
+	return file_tele_proto_rawDescGZIP(), []int{10, 0}
 }
 
 type Inventory struct {
@@ -703,6 +828,9 @@ type Command struct {
 	//	*Command_ValidateCode
 	//	*Command_Cook
 	Task          isCommand_Task `protobuf_oneof:"task"`
+	Timestamp     int64          `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix time of signing
+	Nonce         []byte         `protobuf:"bytes,8,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Signature     []byte         `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"` // HMAC-SHA256 or Ed25519
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -839,6 +967,27 @@ func (x *Command) GetCook() *Command_ArgCook {
 	return nil
 }
 
+func (x *Command) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Command) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *Command) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 type isCommand_Task interface {
 	isCommand_Task()
 }
@@ -998,6 +1147,9 @@ type FromRoboMessage struct {
 	Err           *Err                   `protobuf:"bytes,4,opt,name=err,proto3" json:"err,omitempty"`
 	RoboHardware  *RoboHardware          `protobuf:"bytes,5,opt,name=RoboHardware,proto3" json:"RoboHardware,omitempty"`
 	Stock         *Stock                 `protobuf:"bytes,6,opt,name=Stock,proto3" json:"Stock,omitempty"`
+	Margin        []*Margin              `protobuf:"bytes,7,rep,name=margin,proto3" json:"margin,omitempty"`
+	ServiceAudit  *ServiceAudit          `protobuf:"bytes,8,opt,name=serviceAudit,proto3" json:"serviceAudit,omitempty"`
+	Voucher       *Voucher               `protobuf:"bytes,9,opt,name=voucher,proto3" json:"voucher,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1074,6 +1226,157 @@ func (x *FromRoboMessage) GetStock() *Stock {
 	return nil
 }
 
+func (x *FromRoboMessage) GetMargin() []*Margin {
+	if x != nil {
+		return x.Margin
+	}
+	return nil
+}
+
+func (x *FromRoboMessage) GetServiceAudit() *ServiceAudit {
+	if x != nil {
+		return x.ServiceAudit
+	}
+	return nil
+}
+
+func (x *FromRoboMessage) GetVoucher() *Voucher {
+	if x != nil {
+		return x.Voucher
+	}
+	return nil
+}
+
+// used voucher code, serial is accepted once
+type Voucher struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Serial        uint32                 `protobuf:"varint,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	Kind          Voucher_Kind           `protobuf:"varint,2,opt,name=kind,proto3,enum=Voucher_Kind" json:"kind,omitempty"`
+	Value         uint32                 `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"` // credit or menu code
+	Until         int64                  `protobuf:"varint,4,opt,name=until,proto3" json:"until,omitempty"` // last day, unix time
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Voucher) Reset() {
+	*x = Voucher{}
+	mi := &file_tele_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Voucher) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Voucher) ProtoMessage() {}
+
+func (x *Voucher) ProtoReflect() protoreflect.Message {
+	mi := &file_tele_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Voucher.ProtoReflect.Descriptor instead.
+func (*Voucher) Descriptor() ([]byte, []int) {
+	return file_tele_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Voucher) GetSerial() uint32 {
+	if x != nil {
+		return x.Serial
+	}
+	return 0
+}
+
+func (x *Voucher) GetKind() Voucher_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Voucher_invalid
+}
+
+func (x *Voucher) GetValue() uint32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Voucher) GetUntil() int64 {
+	if x != nil {
+		return x.Until
+	}
+	return 0
+}
+
+// service menu session and actions of technician, who changed what
+type ServiceAudit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Technician    string                 `protobuf:"bytes,1,opt,name=technician,proto3" json:"technician,omitempty"` // empty - service menu without PIN
+	Action        ServiceAudit_Action    `protobuf:"varint,2,opt,name=action,proto3,enum=ServiceAudit_Action" json:"action,omitempty"`
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"` // stock code and value, test name, error
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceAudit) Reset() {
+	*x = ServiceAudit{}
+	mi := &file_tele_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceAudit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceAudit) ProtoMessage() {}
+
+func (x *ServiceAudit) ProtoReflect() protoreflect.Message {
+	mi := &file_tele_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceAudit.ProtoReflect.Descriptor instead.
+func (*ServiceAudit) Descriptor() ([]byte, []int) {
+	return file_tele_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ServiceAudit) GetTechnician() string {
+	if x != nil {
+		return x.Technician
+	}
+	return ""
+}
+
+func (x *ServiceAudit) GetAction() ServiceAudit_Action {
+	if x != nil {
+		return x.Action
+	}
+	return ServiceAudit_invalid
+}
+
+func (x *ServiceAudit) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
 type Stock struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Stocks        []*Stock_StockItem     `protobuf:"bytes,1,rep,name=stocks,proto3" json:"stocks,omitempty"`
@@ -1083,7 +1386,7 @@ type Stock struct {
 
 func (x *Stock) Reset() {
 	*x = Stock{}
-	mi := &file_tele_proto_msgTypes[5]
+	mi := &file_tele_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1095,7 +1398,7 @@ func (x *Stock) String() string {
 func (*Stock) ProtoMessage() {}
 
 func (x *Stock) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[5]
+	mi := &file_tele_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1108,7 +1411,7 @@ func (x *Stock) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Stock.ProtoReflect.Descriptor instead.
 func (*Stock) Descriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{5}
+	return file_tele_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Stock) GetStocks() []*Stock_StockItem {
@@ -1118,6 +1421,67 @@ func (x *Stock) GetStocks() []*Stock_StockItem {
 	return nil
 }
 
+// cost and margin of sold menu items for period (reportMargin)
+type Margin struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          int64                  `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"` // unix time, period begin
+	To            int64                  `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+	Items         []*Margin_Item         `protobuf:"bytes,3,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Margin) Reset() {
+	*x = Margin{}
+	mi := &file_tele_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Margin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Margin) ProtoMessage() {}
+
+func (x *Margin) ProtoReflect() protoreflect.Message {
+	mi := &file_tele_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Margin.ProtoReflect.Descriptor instead.
+func (*Margin) Descriptor() ([]byte, []int) {
+	return file_tele_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Margin) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *Margin) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *Margin) GetItems() []*Margin_Item {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
 type Err struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Code          uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
@@ -1128,7 +1492,7 @@ type Err struct {
 
 func (x *Err) Reset() {
 	*x = Err{}
-	mi := &file_tele_proto_msgTypes[6]
+	mi := &file_tele_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1140,7 +1504,7 @@ func (x *Err) String() string {
 func (*Err) ProtoMessage() {}
 
 func (x *Err) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[6]
+	mi := &file_tele_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1153,7 +1517,7 @@ func (x *Err) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Err.ProtoReflect.Descriptor instead.
 func (*Err) Descriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{6}
+	return file_tele_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Err) GetCode() uint32 {
@@ -1185,7 +1549,7 @@ type ShowQR struct {
 
 func (x *ShowQR) Reset() {
 	*x = ShowQR{}
-	mi := &file_tele_proto_msgTypes[7]
+	mi := &file_tele_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1197,7 +1561,7 @@ func (x *ShowQR) String() string {
 func (*ShowQR) ProtoMessage() {}
 
 func (x *ShowQR) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[7]
+	mi := &file_tele_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1210,7 +1574,7 @@ func (x *ShowQR) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShowQR.ProtoReflect.Descriptor instead.
 func (*ShowQR) Descriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{7}
+	return file_tele_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ShowQR) GetQrType() ShowQR_QRType {
@@ -1275,7 +1639,7 @@ type ToRoboMessage struct {
 
 func (x *ToRoboMessage) Reset() {
 	*x = ToRoboMessage{}
-	mi := &file_tele_proto_msgTypes[8]
+	mi := &file_tele_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1287,7 +1651,7 @@ func (x *ToRoboMessage) String() string {
 func (*ToRoboMessage) ProtoMessage() {}
 
 func (x *ToRoboMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[8]
+	mi := &file_tele_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1300,7 +1664,7 @@ func (x *ToRoboMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToRoboMessage.ProtoReflect.Descriptor instead.
 func (*ToRoboMessage) Descriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{8}
+	return file_tele_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ToRoboMessage) GetCmd() MessageType {
@@ -1342,13 +1706,14 @@ type RoboHardware struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SwVersion     string                 `protobuf:"bytes,1,opt,name=SwVersion,proto3" json:"SwVersion,omitempty"`
 	Temperature   int32                  `protobuf:"varint,3,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	CertExpire    int64                  `protobuf:"varint,4,opt,name=certExpire,proto3" json:"certExpire,omitempty"` // unix time, client certificate NotAfter
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RoboHardware) Reset() {
 	*x = RoboHardware{}
-	mi := &file_tele_proto_msgTypes[9]
+	mi := &file_tele_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1360,7 +1725,7 @@ func (x *RoboHardware) String() string {
 func (*RoboHardware) ProtoMessage() {}
 
 func (x *RoboHardware) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[9]
+	mi := &file_tele_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1373,7 +1738,7 @@ func (x *RoboHardware) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RoboHardware.ProtoReflect.Descriptor instead.
 func (*RoboHardware) Descriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{9}
+	return file_tele_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *RoboHardware) GetSwVersion() string {
@@ -1390,6 +1755,13 @@ func (x *RoboHardware) GetTemperature() int32 {
 	return 0
 }
 
+func (x *RoboHardware) GetCertExpire() int64 {
+	if x != nil {
+		return x.CertExpire
+	}
+	return 0
+}
+
 type Order struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	MenuCode        string                 `protobuf:"bytes,1,opt,name=menuCode,proto3" json:"menuCode,omitempty"`
@@ -1408,7 +1780,7 @@ type Order struct {
 
 func (x *Order) Reset() {
 	*x = Order{}
-	mi := &file_tele_proto_msgTypes[10]
+	mi := &file_tele_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1420,7 +1792,7 @@ func (x *Order) String() string {
 func (*Order) ProtoMessage() {}
 
 func (x *Order) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[10]
+	mi := &file_tele_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1433,7 +1805,7 @@ func (x *Order) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Order.ProtoReflect.Descriptor instead.
 func (*Order) Descriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{10}
+	return file_tele_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *Order) GetMenuCode() string {
@@ -1519,7 +1891,7 @@ type Inventory_StockItem struct {
 
 func (x *Inventory_StockItem) Reset() {
 	*x = Inventory_StockItem{}
-	mi := &file_tele_proto_msgTypes[11]
+	mi := &file_tele_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1531,7 +1903,7 @@ func (x *Inventory_StockItem) String() string {
 func (*Inventory_StockItem) ProtoMessage() {}
 
 func (x *Inventory_StockItem) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[11]
+	mi := &file_tele_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1593,7 +1965,7 @@ type Telemetry_Error struct {
 
 func (x *Telemetry_Error) Reset() {
 	*x = Telemetry_Error{}
-	mi := &file_tele_proto_msgTypes[12]
+	mi := &file_tele_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1605,7 +1977,7 @@ func (x *Telemetry_Error) String() string {
 func (*Telemetry_Error) ProtoMessage() {}
 
 func (x *Telemetry_Error) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[12]
+	mi := &file_tele_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1654,7 +2026,7 @@ type Telemetry_Money struct {
 
 func (x *Telemetry_Money) Reset() {
 	*x = Telemetry_Money{}
-	mi := &file_tele_proto_msgTypes[13]
+	mi := &file_tele_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1666,7 +2038,7 @@ func (x *Telemetry_Money) String() string {
 func (*Telemetry_Money) ProtoMessage() {}
 
 func (x *Telemetry_Money) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[13]
+	mi := &file_tele_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1726,7 +2098,7 @@ type Telemetry_Transaction struct {
 
 func (x *Telemetry_Transaction) Reset() {
 	*x = Telemetry_Transaction{}
-	mi := &file_tele_proto_msgTypes[14]
+	mi := &file_tele_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1738,7 +2110,7 @@ func (x *Telemetry_Transaction) String() string {
 func (*Telemetry_Transaction) ProtoMessage() {}
 
 func (x *Telemetry_Transaction) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[14]
+	mi := &file_tele_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1822,7 +2194,7 @@ type Telemetry_Stat struct {
 
 func (x *Telemetry_Stat) Reset() {
 	*x = Telemetry_Stat{}
-	mi := &file_tele_proto_msgTypes[15]
+	mi := &file_tele_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1834,7 +2206,7 @@ func (x *Telemetry_Stat) String() string {
 func (*Telemetry_Stat) ProtoMessage() {}
 
 func (x *Telemetry_Stat) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[15]
+	mi := &file_tele_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1886,7 +2258,7 @@ type Command_ArgReport struct {
 
 func (x *Command_ArgReport) Reset() {
 	*x = Command_ArgReport{}
-	mi := &file_tele_proto_msgTypes[20]
+	mi := &file_tele_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1898,7 +2270,7 @@ func (x *Command_ArgReport) String() string {
 func (*Command_ArgReport) ProtoMessage() {}
 
 func (x *Command_ArgReport) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[20]
+	mi := &file_tele_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1922,7 +2294,7 @@ type Command_ArgGetState struct {
 
 func (x *Command_ArgGetState) Reset() {
 	*x = Command_ArgGetState{}
-	mi := &file_tele_proto_msgTypes[21]
+	mi := &file_tele_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1934,7 +2306,7 @@ func (x *Command_ArgGetState) String() string {
 func (*Command_ArgGetState) ProtoMessage() {}
 
 func (x *Command_ArgGetState) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[21]
+	mi := &file_tele_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1959,7 +2331,7 @@ type Command_ArgExec struct {
 
 func (x *Command_ArgExec) Reset() {
 	*x = Command_ArgExec{}
-	mi := &file_tele_proto_msgTypes[22]
+	mi := &file_tele_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1971,7 +2343,7 @@ func (x *Command_ArgExec) String() string {
 func (*Command_ArgExec) ProtoMessage() {}
 
 func (x *Command_ArgExec) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[22]
+	mi := &file_tele_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2003,7 +2375,7 @@ type Command_ArgSetInventory struct {
 
 func (x *Command_ArgSetInventory) Reset() {
 	*x = Command_ArgSetInventory{}
-	mi := &file_tele_proto_msgTypes[23]
+	mi := &file_tele_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2015,7 +2387,7 @@ func (x *Command_ArgSetInventory) String() string {
 func (*Command_ArgSetInventory) ProtoMessage() {}
 
 func (x *Command_ArgSetInventory) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[23]
+	mi := &file_tele_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2048,7 +2420,7 @@ type Command_ArgSetConfig struct {
 
 func (x *Command_ArgSetConfig) Reset() {
 	*x = Command_ArgSetConfig{}
-	mi := &file_tele_proto_msgTypes[24]
+	mi := &file_tele_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2060,7 +2432,7 @@ func (x *Command_ArgSetConfig) String() string {
 func (*Command_ArgSetConfig) ProtoMessage() {}
 
 func (x *Command_ArgSetConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[24]
+	mi := &file_tele_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2098,7 +2470,7 @@ type Command_ArgSendStatus struct {
 
 func (x *Command_ArgSendStatus) Reset() {
 	*x = Command_ArgSendStatus{}
-	mi := &file_tele_proto_msgTypes[25]
+	mi := &file_tele_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2110,7 +2482,7 @@ func (x *Command_ArgSendStatus) String() string {
 func (*Command_ArgSendStatus) ProtoMessage() {}
 
 func (x *Command_ArgSendStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[25]
+	mi := &file_tele_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2136,7 +2508,7 @@ type Command_ArgShowQR struct {
 
 func (x *Command_ArgShowQR) Reset() {
 	*x = Command_ArgShowQR{}
-	mi := &file_tele_proto_msgTypes[26]
+	mi := &file_tele_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2148,7 +2520,7 @@ func (x *Command_ArgShowQR) String() string {
 func (*Command_ArgShowQR) ProtoMessage() {}
 
 func (x *Command_ArgShowQR) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[26]
+	mi := &file_tele_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2187,7 +2559,7 @@ type Command_ArgValidateCode struct {
 
 func (x *Command_ArgValidateCode) Reset() {
 	*x = Command_ArgValidateCode{}
-	mi := &file_tele_proto_msgTypes[27]
+	mi := &file_tele_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2199,7 +2571,7 @@ func (x *Command_ArgValidateCode) String() string {
 func (*Command_ArgValidateCode) ProtoMessage() {}
 
 func (x *Command_ArgValidateCode) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[27]
+	mi := &file_tele_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2235,7 +2607,7 @@ type Command_ArgCook struct {
 
 func (x *Command_ArgCook) Reset() {
 	*x = Command_ArgCook{}
-	mi := &file_tele_proto_msgTypes[28]
+	mi := &file_tele_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2247,7 +2619,7 @@ func (x *Command_ArgCook) String() string {
 func (*Command_ArgCook) ProtoMessage() {}
 
 func (x *Command_ArgCook) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[28]
+	mi := &file_tele_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2308,7 +2680,7 @@ type Stock_StockItem struct {
 
 func (x *Stock_StockItem) Reset() {
 	*x = Stock_StockItem{}
-	mi := &file_tele_proto_msgTypes[29]
+	mi := &file_tele_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2320,7 +2692,7 @@ func (x *Stock_StockItem) String() string {
 func (*Stock_StockItem) ProtoMessage() {}
 
 func (x *Stock_StockItem) ProtoReflect() protoreflect.Message {
-	mi := &file_tele_proto_msgTypes[29]
+	mi := &file_tele_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2333,7 +2705,7 @@ func (x *Stock_StockItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Stock_StockItem.ProtoReflect.Descriptor instead.
 func (*Stock_StockItem) Descriptor() ([]byte, []int) {
-	return file_tele_proto_rawDescGZIP(), []int{5, 0}
+	return file_tele_proto_rawDescGZIP(), []int{7, 0}
 }
 
 func (x *Stock_StockItem) GetCode() uint32 {
@@ -2350,6 +2722,106 @@ func (x *Stock_StockItem) GetValue() int32 {
 	return 0
 }
 
+type Margin_Item struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Price         uint32                 `protobuf:"varint,2,opt,name=price,proto3" json:"price,omitempty"` // menu price, kopecks
+	Cost          uint32                 `protobuf:"varint,3,opt,name=cost,proto3" json:"cost,omitempty"`   // menu item cost by scenario (default cream/sugar) and cup
+	Sold          uint32                 `protobuf:"varint,4,opt,name=sold,proto3" json:"sold,omitempty"`
+	Revenue       uint32                 `protobuf:"varint,5,opt,name=revenue,proto3" json:"revenue,omitempty"` // sum of sale prices, gifts not included
+	Spent         uint32                 `protobuf:"varint,6,opt,name=spent,proto3" json:"spent,omitempty"`     // cost of sold: real spent stock * ingredient cost + cup
+	Errors        uint32                 `protobuf:"varint,7,opt,name=errors,proto3" json:"errors,omitempty"`   // failed cooking
+	Waste         uint32                 `protobuf:"varint,8,opt,name=waste,proto3" json:"waste,omitempty"`     // ingredients spent by failed cooking
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Margin_Item) Reset() {
+	*x = Margin_Item{}
+	mi := &file_tele_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Margin_Item) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Margin_Item) ProtoMessage() {}
+
+func (x *Margin_Item) ProtoReflect() protoreflect.Message {
+	mi := &file_tele_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Margin_Item.ProtoReflect.Descriptor instead.
+func (*Margin_Item) Descriptor() ([]byte, []int) {
+	return file_tele_proto_rawDescGZIP(), []int{8, 0}
+}
+
+func (x *Margin_Item) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Margin_Item) GetPrice() uint32 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Margin_Item) GetCost() uint32 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *Margin_Item) GetSold() uint32 {
+	if x != nil {
+		return x.Sold
+	}
+	return 0
+}
+
+func (x *Margin_Item) GetRevenue() uint32 {
+	if x != nil {
+		return x.Revenue
+	}
+	return 0
+}
+
+func (x *Margin_Item) GetSpent() uint32 {
+	if x != nil {
+		return x.Spent
+	}
+	return 0
+}
+
+func (x *Margin_Item) GetErrors() uint32 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *Margin_Item) GetWaste() uint32 {
+	if x != nil {
+		return x.Waste
+	}
+	return 0
+}
+
 var File_tele_proto protoreflect.FileDescriptor
 
 const file_tele_proto_rawDesc = "" +
@@ -2418,7 +2890,7 @@ const file_tele_proto_rawDesc = "" +
 	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\x1a?\n" +
 	"\x11CoinRejectedEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\rR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\xcf\a\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\xa1\b\n" +
 	"\aCommand\x12\x1a\n" +
 	"\bexecuter\x18\x05 \x01(\x03R\bexecuter\x12\x12\n" +
 	"\x04lock\x18\x06 \x01(\bR\x04lock\x12,\n" +
@@ -2431,7 +2903,10 @@ const file_tele_proto_rawDesc = "" +
 	"\x04stop\x18\x15 \x01(\v2\x16.Command.ArgSendStatusH\x00R\x04stop\x12-\n" +
 	"\ashow_QR\x18\x16 \x01(\v2\x12.Command.ArgShowQRH\x00R\x06showQR\x12?\n" +
 	"\rvalidate_code\x18\x17 \x01(\v2\x18.Command.ArgValidateCodeH\x00R\fvalidateCode\x12&\n" +
-	"\x04cook\x18\x18 \x01(\v2\x10.Command.ArgCookH\x00R\x04cook\x1a\v\n" +
+	"\x04cook\x18\x18 \x01(\v2\x10.Command.ArgCookH\x00R\x04cook\x12\x1c\n" +
+	"\ttimestamp\x18\a \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\b \x01(\fR\x05nonce\x12\x1c\n" +
+	"\tsignature\x18\t \x01(\fR\tsignature\x1a\v\n" +
 	"\tArgReport\x1a\r\n" +
 	"\vArgGetState\x1a%\n" +
 	"\aArgExec\x12\x1a\n" +
@@ -2465,19 +2940,66 @@ const file_tele_proto_rawDesc = "" +
 	"\vcook_replay\x18\x06 \x01(\x0e2\v.CookReplayR\n" +
 	"cookReplay\x12&\n" +
 	"\x0evalidateReplay\x18\a \x01(\rR\x0evalidateReplay\x12&\n" +
-	"\x0eINTERNAL_topic\x18\x80\x10 \x01(\tR\rINTERNALTopic\"\xd2\x01\n" +
+	"\x0eINTERNAL_topic\x18\x80\x10 \x01(\tR\rINTERNALTopic\"\xca\x02\n" +
 	"\x0fFromRoboMessage\x12\x1c\n" +
 	"\x05state\x18\x01 \x01(\x0e2\x06.StateR\x05state\x12\x1a\n" +
 	"\broboTime\x18\x02 \x01(\x03R\broboTime\x12\x1c\n" +
 	"\x05Order\x18\x03 \x01(\v2\x06.OrderR\x05Order\x12\x16\n" +
 	"\x03err\x18\x04 \x01(\v2\x04.ErrR\x03err\x121\n" +
 	"\fRoboHardware\x18\x05 \x01(\v2\r.RoboHardwareR\fRoboHardware\x12\x1c\n" +
-	"\x05Stock\x18\x06 \x01(\v2\x06.StockR\x05Stock\"h\n" +
+	"\x05Stock\x18\x06 \x01(\v2\x06.StockR\x05Stock\x12\x1f\n" +
+	"\x06margin\x18\a \x03(\v2\a.MarginR\x06margin\x121\n" +
+	"\fserviceAudit\x18\b \x01(\v2\r.ServiceAuditR\fserviceAudit\x12\"\n" +
+	"\avoucher\x18\t \x01(\v2\b.VoucherR\avoucher\"\x9b\x01\n" +
+	"\aVoucher\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\rR\x06serial\x12!\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\r.Voucher.KindR\x04kind\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\rR\x05value\x12\x14\n" +
+	"\x05until\x18\x04 \x01(\x03R\x05until\")\n" +
+	"\x04Kind\x12\v\n" +
+	"\ainvalid\x10\x00\x12\n" +
+	"\n" +
+	"\x06credit\x10\x01\x12\b\n" +
+	"\x04item\x10\x02\"\x9e\x02\n" +
+	"\fServiceAudit\x12\x1e\n" +
+	"\n" +
+	"technician\x18\x01 \x01(\tR\n" +
+	"technician\x12,\n" +
+	"\x06action\x18\x02 \x01(\x0e2\x14.ServiceAudit.ActionR\x06action\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"\xa7\x01\n" +
+	"\x06Action\x12\v\n" +
+	"\ainvalid\x10\x00\x12\x10\n" +
+	"\fsessionBegin\x10\x01\x12\x0e\n" +
+	"\n" +
+	"sessionEnd\x10\x02\x12\f\n" +
+	"\bauthFail\x10\x03\x12\v\n" +
+	"\alockout\x10\x04\x12\x10\n" +
+	"\finventorySet\x10\x05\x12\r\n" +
+	"\tmoneyLoad\x10\x06\x12\b\n" +
+	"\x04test\x10\a\x12\x0f\n" +
+	"\vcashboxZero\x10\b\x12\n" +
+	"\n" +
+	"\x06reboot\x10\t\x12\v\n" +
+	"\anetwork\x10\n" +
+	"\"h\n" +
 	"\x05Stock\x12(\n" +
 	"\x06stocks\x18\x01 \x03(\v2\x10.Stock.StockItemR\x06stocks\x1a5\n" +
 	"\tStockItem\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\rR\x04code\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x05R\x05value\"3\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value\"\x89\x02\n" +
+	"\x06Margin\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\x03R\x02to\x12\"\n" +
+	"\x05items\x18\x03 \x03(\v2\f.Margin.ItemR\x05items\x1a\xb6\x01\n" +
+	"\x04Item\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\rR\x05price\x12\x12\n" +
+	"\x04cost\x18\x03 \x01(\rR\x04cost\x12\x12\n" +
+	"\x04sold\x18\x04 \x01(\rR\x04sold\x12\x18\n" +
+	"\arevenue\x18\x05 \x01(\rR\arevenue\x12\x14\n" +
+	"\x05spent\x18\x06 \x01(\rR\x05spent\x12\x16\n" +
+	"\x06errors\x18\a \x01(\rR\x06errors\x12\x14\n" +
+	"\x05waste\x18\b \x01(\rR\x05waste\"3\n" +
 	"\x03Err\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\rR\x04code\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\"\x96\x02\n" +
@@ -2502,10 +3024,13 @@ const file_tele_proto_rawDesc = "" +
 	"serverTime\x12$\n" +
 	"\tmakeOrder\x18\x03 \x01(\v2\x06.OrderR\tmakeOrder\x12\x1f\n" +
 	"\x06showQR\x18\x04 \x01(\v2\a.ShowQRR\x06showQR\x12\x18\n" +
-	"\acommand\x18\x05 \x01(\tR\acommand\"N\n" +
+	"\acommand\x18\x05 \x01(\tR\acommand\"n\n" +
 	"\fRoboHardware\x12\x1c\n" +
 	"\tSwVersion\x18\x01 \x01(\tR\tSwVersion\x12 \n" +
-	"\vtemperature\x18\x03 \x01(\x05R\vtemperature\"\xd9\x02\n" +
+	"\vtemperature\x18\x03 \x01(\x05R\vtemperature\x12\x1e\n" +
+	"\n" +
+	"certExpire\x18\x04 \x01(\x03R\n" +
+	"certExpire\"\xd9\x02\n" +
 	"\x05Order\x12\x1a\n" +
 	"\bmenuCode\x18\x01 \x01(\tR\bmenuCode\x12\x14\n" +
 	"\x05cream\x18\x02 \x01(\fR\x05cream\x12\x14\n" +
@@ -2578,7 +3103,7 @@ const file_tele_proto_rawDesc = "" +
 	"\x06cancel\x10\b\x12\x0e\n" +
 	"\n" +
 	"doSelected\x10@\x12\x11\n" +
-	"\rdoTransferred\x10A*k\n" +
+	"\rdoTransferred\x10A*}\n" +
 	"\vMessageType\x12\v\n" +
 	"\ainvalid\x10\x00\x12\n" +
 	"\n" +
@@ -2586,7 +3111,8 @@ const file_tele_proto_rawDesc = "" +
 	"\tmakeOrder\x10\x02\x12\x12\n" +
 	"\x0eexecuteCommand\x10\x03\x12\x0f\n" +
 	"\vreportStock\x10\x04\x12\x0f\n" +
-	"\vreportState\x10\x05B\bZ\x06./teleb\x06proto3"
+	"\vreportState\x10\x05\x12\x10\n" +
+	"\freportMargin\x10\x06B\bZ\x06./teleb\x06proto3"
 
 var (
 	file_tele_proto_rawDescOnce sync.Once
@@ -2600,8 +3126,8 @@ func file_tele_proto_rawDescGZIP() []byte {
 	return file_tele_proto_rawDescData
 }
 
-var file_tele_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
-var file_tele_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
+var file_tele_proto_enumTypes = make([]protoimpl.EnumInfo, 10)
+var file_tele_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
 var file_tele_proto_goTypes = []any{
 	(CmdReplay)(0),                  // 0: CmdReplay
 	(CookReplay)(0),                 // 1: CookReplay
@@ -2610,84 +3136,96 @@ var file_tele_proto_goTypes = []any{
 	(OwnerType)(0),                  // 4: OwnerType
 	(OrderStatus)(0),                // 5: OrderStatus
 	(MessageType)(0),                // 6: MessageType
-	(ShowQR_QRType)(0),              // 7: ShowQR.QRType
-	(*Inventory)(nil),               // 8: Inventory
-	(*Telemetry)(nil),               // 9: Telemetry
-	(*Command)(nil),                 // 10: Command
-	(*Response)(nil),                // 11: Response
-	(*FromRoboMessage)(nil),         // 12: FromRoboMessage
-	(*Stock)(nil),                   // 13: Stock
-	(*Err)(nil),                     // 14: Err
-	(*ShowQR)(nil),                  // 15: ShowQR
-	(*ToRoboMessage)(nil),           // 16: ToRoboMessage
-	(*RoboHardware)(nil),            // 17: RoboHardware
-	(*Order)(nil),                   // 18: Order
-	(*Inventory_StockItem)(nil),     // 19: Inventory.StockItem
-	(*Telemetry_Error)(nil),         // 20: Telemetry.Error
-	(*Telemetry_Money)(nil),         // 21: Telemetry.Money
-	(*Telemetry_Transaction)(nil),   // 22: Telemetry.Transaction
-	(*Telemetry_Stat)(nil),          // 23: Telemetry.Stat
-	nil,                             // 24: Telemetry.Money.BillsEntry
-	nil,                             // 25: Telemetry.Money.CoinsEntry
-	nil,                             // 26: Telemetry.Stat.BillRejectedEntry
-	nil,                             // 27: Telemetry.Stat.CoinRejectedEntry
-	(*Command_ArgReport)(nil),       // 28: Command.ArgReport
-	(*Command_ArgGetState)(nil),     // 29: Command.ArgGetState
-	(*Command_ArgExec)(nil),         // 30: Command.ArgExec
-	(*Command_ArgSetInventory)(nil), // 31: Command.ArgSetInventory
-	(*Command_ArgSetConfig)(nil),    // 32: Command.ArgSetConfig
-	(*Command_ArgSendStatus)(nil),   // 33: Command.ArgSendStatus
-	(*Command_ArgShowQR)(nil),       // 34: Command.ArgShowQR
-	(*Command_ArgValidateCode)(nil), // 35: Command.ArgValidateCode
-	(*Command_ArgCook)(nil),         // 36: Command.ArgCook
-	(*Stock_StockItem)(nil),         // 37: Stock.StockItem
+	(Voucher_Kind)(0),               // 7: Voucher.Kind
+	(ServiceAudit_Action)(0),        // 8: ServiceAudit.Action
+	(ShowQR_QRType)(0),              // 9: ShowQR.QRType
+	(*Inventory)(nil),               // 10: Inventory
+	(*Telemetry)(nil),               // 11: Telemetry
+	(*Command)(nil),                 // 12: Command
+	(*Response)(nil),                // 13: Response
+	(*FromRoboMessage)(nil),         // 14: FromRoboMessage
+	(*Voucher)(nil),                 // 15: Voucher
+	(*ServiceAudit)(nil),            // 16: ServiceAudit
+	(*Stock)(nil),                   // 17: Stock
+	(*Margin)(nil),                  // 18: Margin
+	(*Err)(nil),                     // 19: Err
+	(*ShowQR)(nil),                  // 20: ShowQR
+	(*ToRoboMessage)(nil),           // 21: ToRoboMessage
+	(*RoboHardware)(nil),            // 22: RoboHardware
+	(*Order)(nil),                   // 23: Order
+	(*Inventory_StockItem)(nil),     // 24: Inventory.StockItem
+	(*Telemetry_Error)(nil),         // 25: Telemetry.Error
+	(*Telemetry_Money)(nil),         // 26: Telemetry.Money
+	(*Telemetry_Transaction)(nil),   // 27: Telemetry.Transaction
+	(*Telemetry_Stat)(nil),          // 28: Telemetry.Stat
+	nil,                             // 29: Telemetry.Money.BillsEntry
+	nil,                             // 30: Telemetry.Money.CoinsEntry
+	nil,                             // 31: Telemetry.Stat.BillRejectedEntry
+	nil,                             // 32: Telemetry.Stat.CoinRejectedEntry
+	(*Command_ArgReport)(nil),       // 33: Command.ArgReport
+	(*Command_ArgGetState)(nil),     // 34: Command.ArgGetState
+	(*Command_ArgExec)(nil),         // 35: Command.ArgExec
+	(*Command_ArgSetInventory)(nil), // 36: Command.ArgSetInventory
+	(*Command_ArgSetConfig)(nil),    // 37: Command.ArgSetConfig
+	(*Command_ArgSendStatus)(nil),   // 38: Command.ArgSendStatus
+	(*Command_ArgShowQR)(nil),       // 39: Command.ArgShowQR
+	(*Command_ArgValidateCode)(nil), // 40: Command.ArgValidateCode
+	(*Command_ArgCook)(nil),         // 41: Command.ArgCook
+	(*Stock_StockItem)(nil),         // 42: Stock.StockItem
+	(*Margin_Item)(nil),             // 43: Margin.Item
 }
 var file_tele_proto_depIdxs = []int32{
-	19, // 0: Inventory.stocks:type_name -> Inventory.StockItem
-	20, // 1: Telemetry.error:type_name -> Telemetry.Error
-	8,  // 2: Telemetry.inventory:type_name -> Inventory
-	21, // 3: Telemetry.money_cashbox:type_name -> Telemetry.Money
-	22, // 4: Telemetry.transaction:type_name -> Telemetry.Transaction
-	23, // 5: Telemetry.stat:type_name -> Telemetry.Stat
-	21, // 6: Telemetry.money_save:type_name -> Telemetry.Money
-	21, // 7: Telemetry.money_change:type_name -> Telemetry.Money
-	28, // 8: Command.report:type_name -> Command.ArgReport
-	29, // 9: Command.getState:type_name -> Command.ArgGetState
-	30, // 10: Command.exec:type_name -> Command.ArgExec
-	31, // 11: Command.set_inventory:type_name -> Command.ArgSetInventory
-	32, // 12: Command.set_config:type_name -> Command.ArgSetConfig
-	33, // 13: Command.stop:type_name -> Command.ArgSendStatus
-	34, // 14: Command.show_QR:type_name -> Command.ArgShowQR
-	35, // 15: Command.validate_code:type_name -> Command.ArgValidateCode
-	36, // 16: Command.cook:type_name -> Command.ArgCook
+	24, // 0: Inventory.stocks:type_name -> Inventory.StockItem
+	25, // 1: Telemetry.error:type_name -> Telemetry.Error
+	10, // 2: Telemetry.inventory:type_name -> Inventory
+	26, // 3: Telemetry.money_cashbox:type_name -> Telemetry.Money
+	27, // 4: Telemetry.transaction:type_name -> Telemetry.Transaction
+	28, // 5: Telemetry.stat:type_name -> Telemetry.Stat
+	26, // 6: Telemetry.money_save:type_name -> Telemetry.Money
+	26, // 7: Telemetry.money_change:type_name -> Telemetry.Money
+	33, // 8: Command.report:type_name -> Command.ArgReport
+	34, // 9: Command.getState:type_name -> Command.ArgGetState
+	35, // 10: Command.exec:type_name -> Command.ArgExec
+	36, // 11: Command.set_inventory:type_name -> Command.ArgSetInventory
+	37, // 12: Command.set_config:type_name -> Command.ArgSetConfig
+	38, // 13: Command.stop:type_name -> Command.ArgSendStatus
+	39, // 14: Command.show_QR:type_name -> Command.ArgShowQR
+	40, // 15: Command.validate_code:type_name -> Command.ArgValidateCode
+	41, // 16: Command.cook:type_name -> Command.ArgCook
 	0,  // 17: Response.cmd_replay:type_name -> CmdReplay
 	1,  // 18: Response.cook_replay:type_name -> CookReplay
 	2,  // 19: FromRoboMessage.state:type_name -> State
-	18, // 20: FromRoboMessage.Order:type_name -> Order
-	14, // 21: FromRoboMessage.err:type_name -> Err
-	17, // 22: FromRoboMessage.RoboHardware:type_name -> RoboHardware
-	13, // 23: FromRoboMessage.Stock:type_name -> Stock
-	37, // 24: Stock.stocks:type_name -> Stock.StockItem
-	7,  // 25: ShowQR.qrType:type_name -> ShowQR.QRType
-	6,  // 26: ToRoboMessage.cmd:type_name -> MessageType
-	18, // 27: ToRoboMessage.makeOrder:type_name -> Order
-	15, // 28: ToRoboMessage.showQR:type_name -> ShowQR
-	5,  // 29: Order.orderStatus:type_name -> OrderStatus
-	3,  // 30: Order.paymentMethod:type_name -> PaymentMethod
-	4,  // 31: Order.ownerType:type_name -> OwnerType
-	24, // 32: Telemetry.Money.bills:type_name -> Telemetry.Money.BillsEntry
-	25, // 33: Telemetry.Money.coins:type_name -> Telemetry.Money.CoinsEntry
-	3,  // 34: Telemetry.Transaction.payment_method:type_name -> PaymentMethod
-	8,  // 35: Telemetry.Transaction.spent:type_name -> Inventory
-	26, // 36: Telemetry.Stat.bill_rejected:type_name -> Telemetry.Stat.BillRejectedEntry
-	27, // 37: Telemetry.Stat.coin_rejected:type_name -> Telemetry.Stat.CoinRejectedEntry
-	8,  // 38: Command.ArgSetInventory.new:type_name -> Inventory
-	3,  // 39: Command.ArgCook.payment_method:type_name -> PaymentMethod
-	40, // [40:40] is the sub-list for method output_type
-	40, // [40:40] is the sub-list for method input_type
-	40, // [40:40] is the sub-list for extension type_name
-	40, // [40:40] is the sub-list for extension extendee
-	0,  // [0:40] is the sub-list for field type_name
+	23, // 20: FromRoboMessage.Order:type_name -> Order
+	19, // 21: FromRoboMessage.err:type_name -> Err
+	22, // 22: FromRoboMessage.RoboHardware:type_name -> RoboHardware
+	17, // 23: FromRoboMessage.Stock:type_name -> Stock
+	18, // 24: FromRoboMessage.margin:type_name -> Margin
+	16, // 25: FromRoboMessage.serviceAudit:type_name -> ServiceAudit
+	15, // 26: FromRoboMessage.voucher:type_name -> Voucher
+	7,  // 27: Voucher.kind:type_name -> Voucher.Kind
+	8,  // 28: ServiceAudit.action:type_name -> ServiceAudit.Action
+	42, // 29: Stock.stocks:type_name -> Stock.StockItem
+	43, // 30: Margin.items:type_name -> Margin.Item
+	9,  // 31: ShowQR.qrType:type_name -> ShowQR.QRType
+	6,  // 32: ToRoboMessage.cmd:type_name -> MessageType
+	23, // 33: ToRoboMessage.makeOrder:type_name -> Order
+	20, // 34: ToRoboMessage.showQR:type_name -> ShowQR
+	5,  // 35: Order.orderStatus:type_name -> OrderStatus
+	3,  // 36: Order.paymentMethod:type_name -> PaymentMethod
+	4,  // 37: Order.ownerType:type_name -> OwnerType
+	29, // 38: Telemetry.Money.bills:type_name -> Telemetry.Money.BillsEntry
+	30, // 39: Telemetry.Money.coins:type_name -> Telemetry.Money.CoinsEntry
+	3,  // 40: Telemetry.Transaction.payment_method:type_name -> PaymentMethod
+	10, // 41: Telemetry.Transaction.spent:type_name -> Inventory
+	31, // 42: Telemetry.Stat.bill_rejected:type_name -> Telemetry.Stat.BillRejectedEntry
+	32, // 43: Telemetry.Stat.coin_rejected:type_name -> Telemetry.Stat.CoinRejectedEntry
+	10, // 44: Command.ArgSetInventory.new:type_name -> Inventory
+	3,  // 45: Command.ArgCook.payment_method:type_name -> PaymentMethod
+	46, // [46:46] is the sub-list for method output_type
+	46, // [46:46] is the sub-list for method input_type
+	46, // [46:46] is the sub-list for extension type_name
+	46, // [46:46] is the sub-list for extension extendee
+	0,  // [0:46] is the sub-list for field type_name
 }
 
 func init() { file_tele_proto_init() }
@@ -2711,8 +3249,8 @@ func file_tele_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tele_proto_rawDesc), len(file_tele_proto_rawDesc)),
-			NumEnums:      8,
-			NumMessages:   30,
+			NumEnums:      10,
+			NumMessages:   34,
 			NumExtensions: 0,
 			NumServices:   0,
 		},